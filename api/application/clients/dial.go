@@ -0,0 +1,39 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+
+	"bocchi/api/pkg/auth"
+)
+
+// internalServiceAddr selects direct in-process service calls instead of a network connection
+const internalServiceAddr = "internal"
+
+// dial connects to a remote gRPC service. Connections are plaintext by default since
+// services talk over the private network; opts are applied last and may override that.
+func dial(serviceAddr string, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithChainUnaryInterceptor(forwardAccessToken),
+	}
+	conn, err := grpc.NewClient(serviceAddr, append(dialOpts, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to gRPC service %s: %w", serviceAddr, err)
+	}
+	return conn, nil
+}
+
+// forwardAccessToken passes the caller's bearer token or API key on to the remote service
+func forwardAccessToken(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if token, ok := auth.GetAccessTokenFromContext(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	} else if key, ok := auth.GetAPIKeyFromContext(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", key)
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
@@ -0,0 +1,78 @@
+package clients
+
+import (
+	"context"
+	"database/sql"
+
+	"google.golang.org/grpc"
+
+	ratingv1 "bocchi/api/gen/rating/v1"
+	grpcSvc "bocchi/api/infrastructure/grpc"
+)
+
+// RatingClient wraps gRPC client calls for solo-friendly rating operations
+type RatingClient struct {
+	service *grpcSvc.RatingService
+	remote  ratingv1.RatingServiceClient
+	conn    *grpc.ClientConn
+}
+
+// NewRatingClient creates a new rating client. The "internal" address calls the service
+// in-process; any other address dials a remote gRPC server with the given options.
+func NewRatingClient(serviceAddr string, db *sql.DB, opts ...grpc.DialOption) (*RatingClient, error) {
+	// For internal communication in monolith, we can use direct service calls
+	if serviceAddr == internalServiceAddr {
+		return &RatingClient{
+			service: grpcSvc.NewRatingService(db),
+		}, nil
+	}
+
+	conn, err := dial(serviceAddr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &RatingClient{
+		remote: ratingv1.NewRatingServiceClient(conn),
+		conn:   conn,
+	}, nil
+}
+
+// Close closes the gRPC connection
+func (c *RatingClient) Close() error {
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// CreateSoloRating rates a spot via gRPC
+func (c *RatingClient) CreateSoloRating(ctx context.Context, req *ratingv1.CreateSoloRatingRequest) (*ratingv1.CreateSoloRatingResponse, error) {
+	if c.remote != nil {
+		return c.remote.CreateSoloRating(ctx, req)
+	}
+	return c.service.CreateSoloRating(ctx, req)
+}
+
+// UpdateSoloRating updates a rating via gRPC
+func (c *RatingClient) UpdateSoloRating(ctx context.Context, req *ratingv1.UpdateSoloRatingRequest) (*ratingv1.UpdateSoloRatingResponse, error) {
+	if c.remote != nil {
+		return c.remote.UpdateSoloRating(ctx, req)
+	}
+	return c.service.UpdateSoloRating(ctx, req)
+}
+
+// GetSpotSoloRatings retrieves ratings for a spot via gRPC
+func (c *RatingClient) GetSpotSoloRatings(ctx context.Context, req *ratingv1.GetSpotSoloRatingsRequest) (*ratingv1.GetSpotSoloRatingsResponse, error) {
+	if c.remote != nil {
+		return c.remote.GetSpotSoloRatings(ctx, req)
+	}
+	return c.service.GetSpotSoloRatings(ctx, req)
+}
+
+// GetMySoloRating retrieves the authenticated user's rating via gRPC
+func (c *RatingClient) GetMySoloRating(ctx context.Context, req *ratingv1.GetMySoloRatingRequest) (*ratingv1.GetMySoloRatingResponse, error) {
+	if c.remote != nil {
+		return c.remote.GetMySoloRating(ctx, req)
+	}
+	return c.service.GetMySoloRating(ctx, req)
+}
@@ -0,0 +1,195 @@
+package clients_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"bocchi/api/application/clients"
+	commonv1 "bocchi/api/gen/common/v1"
+	ratingv1 "bocchi/api/gen/rating/v1"
+	spotv1 "bocchi/api/gen/spot/v1"
+	userv1 "bocchi/api/gen/user/v1"
+	grpcSvc "bocchi/api/infrastructure/grpc"
+	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/errors"
+)
+
+const (
+	validToken      = "valid-token"
+	unresolvedToken = "unresolved-token"
+)
+
+// fakeValidator accepts only validToken and cannot resolve the user of unresolvedToken
+type fakeValidator struct{}
+
+func (fakeValidator) ValidateToken(ctx context.Context, token string) (*auth.Claims, error) {
+	if token == unresolvedToken {
+		return nil, errors.New(errors.ErrTypeUnavailable, "user identity lookup is unavailable")
+	}
+	if token != validToken {
+		return nil, errors.Unauthorized("invalid token")
+	}
+	return &auth.Claims{Subject: "auth0|remote-user"}, nil
+}
+
+// echoRatingServer reports the authenticated user back to the caller
+type echoRatingServer struct {
+	ratingv1.UnimplementedRatingServiceServer
+}
+
+func (echoRatingServer) GetMySoloRating(ctx context.Context, req *ratingv1.GetMySoloRatingRequest) (*ratingv1.GetMySoloRatingResponse, error) {
+	return &ratingv1.GetMySoloRatingResponse{
+		Rating: &ratingv1.SoloRating{SpotId: req.GetSpotId(), UserId: errors.GetUserID(ctx)},
+	}, nil
+}
+
+func (echoRatingServer) UpdateSoloRating(ctx context.Context, req *ratingv1.UpdateSoloRatingRequest) (*ratingv1.UpdateSoloRatingResponse, error) {
+	panic("boom")
+}
+
+// serveBufconn serves the given gRPC server in-process and returns the dial options to reach it
+func serveBufconn(t *testing.T, server *grpc.Server) []grpc.DialOption {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	return []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+	}
+}
+
+func newRemoteRatingClient(t *testing.T) *clients.RatingClient {
+	t.Helper()
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		grpcSvc.RecoveryInterceptor(),
+		grpcSvc.AuthInterceptor(fakeValidator{}),
+	))
+	ratingv1.RegisterRatingServiceServer(server, echoRatingServer{})
+
+	client, err := clients.NewRatingClient("passthrough:///bufnet", nil, serveBufconn(t, server)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRemoteClient_ForwardsAccessToken(t *testing.T) {
+	client := newRemoteRatingClient(t)
+	ctx := auth.WithAccessToken(context.Background(), validToken)
+
+	resp, err := client.GetMySoloRating(ctx, &ratingv1.GetMySoloRatingRequest{SpotId: "spot-1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "spot-1", resp.Rating.SpotId)
+	assert.Equal(t, "auth0|remote-user", resp.Rating.UserId)
+}
+
+func TestRemoteClient_AnonymousCall(t *testing.T) {
+	client := newRemoteRatingClient(t)
+
+	resp, err := client.GetMySoloRating(context.Background(), &ratingv1.GetMySoloRatingRequest{SpotId: "spot-1"})
+
+	require.NoError(t, err)
+	assert.Empty(t, resp.Rating.UserId)
+}
+
+func TestRemoteClient_InvalidToken(t *testing.T) {
+	client := newRemoteRatingClient(t)
+	ctx := auth.WithAccessToken(context.Background(), "forged-token")
+
+	_, err := client.GetMySoloRating(ctx, &ratingv1.GetMySoloRatingRequest{SpotId: "spot-1"})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestRemoteClient_UnresolvedUserIsUnavailable(t *testing.T) {
+	client := newRemoteRatingClient(t)
+	ctx := auth.WithAccessToken(context.Background(), unresolvedToken)
+
+	_, err := client.GetMySoloRating(ctx, &ratingv1.GetMySoloRatingRequest{SpotId: "spot-1"})
+
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestRemoteClient_HandlerPanicBecomesInternal(t *testing.T) {
+	client := newRemoteRatingClient(t)
+
+	_, err := client.UpdateSoloRating(context.Background(), &ratingv1.UpdateSoloRatingRequest{SpotId: "spot-1"})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
+
+func TestNewServer_RegistersSpotService(t *testing.T) {
+	server := grpcSvc.NewServer(nil, fakeValidator{})
+	client, err := clients.NewSpotClient("passthrough:///bufnet", nil, serveBufconn(t, server)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	// Validation runs before any database access, so no database is needed
+	_, err = client.ListSpots(context.Background(), &spotv1.ListSpotsRequest{
+		Center: &commonv1.Coordinates{Latitude: 35.68, Longitude: 139.76},
+		Bbox:   &commonv1.BoundingBox{MinLatitude: 35, MinLongitude: 139, MaxLatitude: 36, MaxLongitude: 140},
+	})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestNewServer_LogsAuthenticatedUserID(t *testing.T) {
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = previous })
+
+	server := grpcSvc.NewServer(nil, fakeValidator{})
+	client, err := clients.NewSpotClient("passthrough:///bufnet", nil, serveBufconn(t, server)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	ctx := auth.WithAccessToken(context.Background(), validToken)
+	_, err = client.ListSpots(ctx, &spotv1.ListSpotsRequest{
+		Center: &commonv1.Coordinates{Latitude: 35.68, Longitude: 139.76},
+		Bbox:   &commonv1.BoundingBox{MinLatitude: 35, MinLongitude: 139, MaxLatitude: 36, MaxLongitude: 140},
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "gRPC call completed", entry["message"])
+	assert.Equal(t, spotv1.SpotService_ListSpots_FullMethodName, entry["method"])
+	assert.Equal(t, "auth0|remote-user", entry["user_id"])
+}
+
+func TestNewServer_LinkIdentityValidatesIdentityToken(t *testing.T) {
+	server := grpcSvc.NewServer(nil, fakeValidator{})
+	client, err := clients.NewUserClient("passthrough:///bufnet", nil, serveBufconn(t, server)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	ctx := auth.WithAccessToken(context.Background(), validToken)
+
+	// The identity is never taken from the caller; a token the server cannot validate is rejected
+	// before any database access, so no database is needed
+	_, err = client.LinkIdentity(ctx, &userv1.LinkIdentityRequest{
+		UserId:        "auth0|remote-user",
+		IdentityToken: "forged-token",
+	})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -3,7 +3,6 @@ package clients
 import (
 	"context"
 	"database/sql"
-	"fmt"
 
 	"google.golang.org/grpc"
 
@@ -14,22 +13,28 @@ import (
 // ReviewClient wraps gRPC client calls for review operations
 type ReviewClient struct {
 	service *grpcSvc.ReviewService
+	remote  reviewv1.ReviewServiceClient
 	conn    *grpc.ClientConn
 }
 
-// NewReviewClient creates a new review client
-func NewReviewClient(serviceAddr string, db *sql.DB) (*ReviewClient, error) {
+// NewReviewClient creates a new review client. The "internal" address calls the service
+// in-process; any other address dials a remote gRPC server with the given options.
+func NewReviewClient(serviceAddr string, db *sql.DB, opts ...grpc.DialOption) (*ReviewClient, error) {
 	// For internal communication in monolith, we can use direct service calls
-	// In a true microservice setup, this would connect to remote gRPC service
-	if serviceAddr == "internal" {
+	if serviceAddr == internalServiceAddr {
 		return &ReviewClient{
 			service: grpcSvc.NewReviewService(db),
 		}, nil
 	}
 
-	// TODO: Implement external gRPC service connection when protobuf client is ready
-	// For now, return error for external services to avoid silent failures
-	return nil, fmt.Errorf("external gRPC service not implemented yet: %s", serviceAddr)
+	conn, err := dial(serviceAddr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &ReviewClient{
+		remote: reviewv1.NewReviewServiceClient(conn),
+		conn:   conn,
+	}, nil
 }
 
 // Close closes the gRPC connection
@@ -42,15 +47,40 @@ func (c *ReviewClient) Close() error {
 
 // CreateReview creates a new review via gRPC
 func (c *ReviewClient) CreateReview(ctx context.Context, req *reviewv1.CreateReviewRequest) (*reviewv1.CreateReviewResponse, error) {
+	if c.remote != nil {
+		return c.remote.CreateReview(ctx, req)
+	}
 	return c.service.CreateReview(ctx, req)
 }
 
 // GetSpotReviews retrieves reviews for a spot via gRPC
 func (c *ReviewClient) GetSpotReviews(ctx context.Context, req *reviewv1.GetSpotReviewsRequest) (*reviewv1.GetSpotReviewsResponse, error) {
+	if c.remote != nil {
+		return c.remote.GetSpotReviews(ctx, req)
+	}
 	return c.service.GetSpotReviews(ctx, req)
 }
 
 // GetUserReviews retrieves reviews by user via gRPC
 func (c *ReviewClient) GetUserReviews(ctx context.Context, req *reviewv1.GetUserReviewsRequest) (*reviewv1.GetUserReviewsResponse, error) {
+	if c.remote != nil {
+		return c.remote.GetUserReviews(ctx, req)
+	}
 	return c.service.GetUserReviews(ctx, req)
+}
+
+// UpdateReview updates a review via gRPC
+func (c *ReviewClient) UpdateReview(ctx context.Context, req *reviewv1.UpdateReviewRequest) (*reviewv1.UpdateReviewResponse, error) {
+	if c.remote != nil {
+		return c.remote.UpdateReview(ctx, req)
+	}
+	return c.service.UpdateReview(ctx, req)
+}
+
+// DeleteReview deletes a review via gRPC
+func (c *ReviewClient) DeleteReview(ctx context.Context, req *reviewv1.DeleteReviewRequest) (*reviewv1.DeleteReviewResponse, error) {
+	if c.remote != nil {
+		return c.remote.DeleteReview(ctx, req)
+	}
+	return c.service.DeleteReview(ctx, req)
 }
\ No newline at end of file
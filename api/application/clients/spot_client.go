@@ -3,32 +3,38 @@ package clients
 import (
 	"context"
 	"database/sql"
-	"fmt"
 
 	"google.golang.org/grpc"
 
+	spotv1 "bocchi/api/gen/spot/v1"
 	grpcSvc "bocchi/api/infrastructure/grpc"
 )
 
 // SpotClient wraps gRPC client calls for spot operations
 type SpotClient struct {
 	service *grpcSvc.SpotService
+	remote  spotv1.SpotServiceClient
 	conn    *grpc.ClientConn
 }
 
-// NewSpotClient creates a new spot client
-func NewSpotClient(serviceAddr string, db *sql.DB) (*SpotClient, error) {
+// NewSpotClient creates a new spot client. The "internal" address calls the service
+// in-process; any other address dials a remote gRPC server with the given options.
+func NewSpotClient(serviceAddr string, db *sql.DB, opts ...grpc.DialOption) (*SpotClient, error) {
 	// For internal communication in monolith, we can use direct service calls
-	// In a true microservice setup, this would connect to remote gRPC service
-	if serviceAddr == "internal" {
+	if serviceAddr == internalServiceAddr {
 		return &SpotClient{
 			service: grpcSvc.NewSpotService(db),
 		}, nil
 	}
 
-	// TODO: Implement external gRPC service connection when protobuf client is ready
-	// For now, return error for external services to avoid silent failures
-	return nil, fmt.Errorf("external gRPC service not implemented yet: %s", serviceAddr)
+	conn, err := dial(serviceAddr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &SpotClient{
+		remote: spotv1.NewSpotServiceClient(conn),
+		conn:   conn,
+	}, nil
 }
 
 // Close closes the gRPC connection
@@ -41,20 +47,56 @@ func (c *SpotClient) Close() error {
 
 // CreateSpot creates a new spot via gRPC
 func (c *SpotClient) CreateSpot(ctx context.Context, req *grpcSvc.CreateSpotRequest) (*grpcSvc.CreateSpotResponse, error) {
+	if c.remote != nil {
+		return c.remote.CreateSpot(ctx, req)
+	}
 	return c.service.CreateSpot(ctx, req)
 }
 
 // GetSpot retrieves a spot by ID via gRPC
 func (c *SpotClient) GetSpot(ctx context.Context, req *grpcSvc.GetSpotRequest) (*grpcSvc.GetSpotResponse, error) {
+	if c.remote != nil {
+		return c.remote.GetSpot(ctx, req)
+	}
 	return c.service.GetSpot(ctx, req)
 }
 
+// UpdateSpot updates a spot via gRPC
+func (c *SpotClient) UpdateSpot(ctx context.Context, req *grpcSvc.UpdateSpotRequest) (*grpcSvc.UpdateSpotResponse, error) {
+	if c.remote != nil {
+		return c.remote.UpdateSpot(ctx, req)
+	}
+	return c.service.UpdateSpot(ctx, req)
+}
+
+// DeleteSpot deletes a spot via gRPC
+func (c *SpotClient) DeleteSpot(ctx context.Context, req *grpcSvc.DeleteSpotRequest) (*grpcSvc.DeleteSpotResponse, error) {
+	if c.remote != nil {
+		return c.remote.DeleteSpot(ctx, req)
+	}
+	return c.service.DeleteSpot(ctx, req)
+}
+
 // ListSpots lists spots with filters via gRPC
 func (c *SpotClient) ListSpots(ctx context.Context, req *grpcSvc.ListSpotsRequest) (*grpcSvc.ListSpotsResponse, error) {
+	if c.remote != nil {
+		return c.remote.ListSpots(ctx, req)
+	}
 	return c.service.ListSpots(ctx, req)
 }
 
 // SearchSpots searches spots via gRPC
 func (c *SpotClient) SearchSpots(ctx context.Context, req *grpcSvc.SearchSpotsRequest) (*grpcSvc.SearchSpotsResponse, error) {
+	if c.remote != nil {
+		return c.remote.SearchSpots(ctx, req)
+	}
 	return c.service.SearchSpots(ctx, req)
-}
\ No newline at end of file
+}
+
+// ClusterSpots aggregates spots into map clusters via gRPC
+func (c *SpotClient) ClusterSpots(ctx context.Context, req *grpcSvc.ClusterSpotsRequest) (*grpcSvc.ClusterSpotsResponse, error) {
+	if c.remote != nil {
+		return c.remote.ClusterSpots(ctx, req)
+	}
+	return c.service.ClusterSpots(ctx, req)
+}
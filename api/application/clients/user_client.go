@@ -3,32 +3,46 @@ package clients
 import (
 	"context"
 	"database/sql"
-	"fmt"
 
 	"google.golang.org/grpc"
 
+	userv1 "bocchi/api/gen/user/v1"
 	grpcSvc "bocchi/api/infrastructure/grpc"
 )
 
 // UserClient wraps gRPC client calls for user operations
 type UserClient struct {
 	service *grpcSvc.UserService
+	remote  userv1.UserServiceClient
 	conn    *grpc.ClientConn
 }
 
-// NewUserClient creates a new user client
-func NewUserClient(serviceAddr string, db *sql.DB) (*UserClient, error) {
+// NewUserClient creates a new user client. The "internal" address calls the service
+// in-process; any other address dials a remote gRPC server with the given options.
+func NewUserClient(serviceAddr string, db *sql.DB, opts ...grpc.DialOption) (*UserClient, error) {
 	// For internal communication in monolith, we can use direct service calls
-	// In a true microservice setup, this would connect to remote gRPC service
-	if serviceAddr == "internal" {
+	if serviceAddr == internalServiceAddr {
 		return &UserClient{
 			service: grpcSvc.NewUserService(db),
 		}, nil
 	}
 
-	// TODO: Implement external gRPC service connection when protobuf client is ready
-	// For now, return error for external services to avoid silent failures
-	return nil, fmt.Errorf("external gRPC service not implemented yet: %s", serviceAddr)
+	conn, err := dial(serviceAddr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &UserClient{
+		remote: userv1.NewUserServiceClient(conn),
+		conn:   conn,
+	}, nil
+}
+
+// SetTokenValidator sets the validator the in-process service checks the tokens of
+// identities to link with. A remote service uses the validator of its own server.
+func (c *UserClient) SetTokenValidator(validator grpcSvc.TokenValidator) {
+	if c.service != nil {
+		c.service.SetTokenValidator(validator)
+	}
 }
 
 // Close closes the gRPC connection
@@ -41,25 +55,80 @@ func (c *UserClient) Close() error {
 
 // GetUser retrieves a user by ID via gRPC
 func (c *UserClient) GetUser(ctx context.Context, req *grpcSvc.GetUserRequest) (*grpcSvc.GetUserResponse, error) {
+	if c.remote != nil {
+		return c.remote.GetUser(ctx, req)
+	}
 	return c.service.GetUser(ctx, req)
 }
 
 // GetUserByEmail retrieves a user by email via gRPC
 func (c *UserClient) GetUserByEmail(ctx context.Context, req *grpcSvc.GetUserByEmailRequest) (*grpcSvc.GetUserByEmailResponse, error) {
+	if c.remote != nil {
+		return c.remote.GetUserByEmail(ctx, req)
+	}
 	return c.service.GetUserByEmail(ctx, req)
 }
 
 // CreateUser creates a new user via gRPC
 func (c *UserClient) CreateUser(ctx context.Context, req *grpcSvc.CreateUserRequest) (*grpcSvc.CreateUserResponse, error) {
+	if c.remote != nil {
+		return c.remote.CreateUser(ctx, req)
+	}
 	return c.service.CreateUser(ctx, req)
 }
 
 // UpdateUser updates a user via gRPC
 func (c *UserClient) UpdateUser(ctx context.Context, req *grpcSvc.UpdateUserRequest) (*grpcSvc.UpdateUserResponse, error) {
+	if c.remote != nil {
+		return c.remote.UpdateUser(ctx, req)
+	}
 	return c.service.UpdateUser(ctx, req)
 }
 
+// UpdateUserPreferences merges changes into a user's preferences via gRPC
+func (c *UserClient) UpdateUserPreferences(ctx context.Context, req *grpcSvc.UpdateUserPreferencesRequest) (*grpcSvc.UpdateUserPreferencesResponse, error) {
+	if c.remote != nil {
+		return c.remote.UpdateUserPreferences(ctx, req)
+	}
+	return c.service.UpdateUserPreferences(ctx, req)
+}
+
 // DeleteUser deletes a user via gRPC
 func (c *UserClient) DeleteUser(ctx context.Context, req *grpcSvc.DeleteUserRequest) (*grpcSvc.DeleteUserResponse, error) {
+	if c.remote != nil {
+		return c.remote.DeleteUser(ctx, req)
+	}
 	return c.service.DeleteUser(ctx, req)
-}
\ No newline at end of file
+}
+
+// RestoreUser cancels the deletion of a user via gRPC
+func (c *UserClient) RestoreUser(ctx context.Context, req *grpcSvc.RestoreUserRequest) (*grpcSvc.RestoreUserResponse, error) {
+	if c.remote != nil {
+		return c.remote.RestoreUser(ctx, req)
+	}
+	return c.service.RestoreUser(ctx, req)
+}
+
+// ListIdentities lists the sign-in identities of a user via gRPC
+func (c *UserClient) ListIdentities(ctx context.Context, req *grpcSvc.ListIdentitiesRequest) (*grpcSvc.ListIdentitiesResponse, error) {
+	if c.remote != nil {
+		return c.remote.ListIdentities(ctx, req)
+	}
+	return c.service.ListIdentities(ctx, req)
+}
+
+// LinkIdentity links a sign-in identity to a user via gRPC
+func (c *UserClient) LinkIdentity(ctx context.Context, req *grpcSvc.LinkIdentityRequest) (*grpcSvc.LinkIdentityResponse, error) {
+	if c.remote != nil {
+		return c.remote.LinkIdentity(ctx, req)
+	}
+	return c.service.LinkIdentity(ctx, req)
+}
+
+// UnlinkIdentity unlinks a sign-in identity from a user via gRPC
+func (c *UserClient) UnlinkIdentity(ctx context.Context, req *grpcSvc.UnlinkIdentityRequest) (*grpcSvc.UnlinkIdentityResponse, error) {
+	if c.remote != nil {
+		return c.remote.UnlinkIdentity(ctx, req)
+	}
+	return c.service.UnlinkIdentity(ctx, req)
+}
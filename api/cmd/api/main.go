@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/danielgtaylor/huma/v2/humacli"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+	"google.golang.org/grpc"
+
+	"bocchi/api/application/clients"
+	"bocchi/api/infrastructure/database"
+	grpcSvc "bocchi/api/infrastructure/grpc"
+	"bocchi/api/interfaces/http/handlers"
+	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/clientip"
+	"bocchi/api/pkg/config"
+	"bocchi/api/pkg/export"
+	"bocchi/api/pkg/jobs"
+	"bocchi/api/pkg/logger"
+	"bocchi/api/pkg/monitoring"
+)
+
+// Options for the CLI
+type Options struct {
+	Port     string `help:"HTTP port to listen on" default:"8080"`
+	GRPCPort string `help:"gRPC port to listen on" default:"9090"`
+}
+
+// HealthCheckOutput represents the health check response
+type HealthCheckOutput struct {
+	Body struct {
+		Status  string `json:"status" example:"ok" doc:"Health status"`
+		Version string `json:"version" example:"1.0.0" doc:"API version"`
+	}
+}
+
+func main() {
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load configuration", err)
+	}
+
+	// Initialize logger
+	logger.Init(logger.Level(cfg.App.LogLevel))
+	logger.Info("Starting Bocchi The Map API")
+
+	// Initialize monitoring services
+	if err := monitoring.InitMonitoring(
+		cfg.Monitoring.NewRelicLicenseKey,
+		cfg.Monitoring.SentryDSN,
+		"bocchi-the-map-api",
+		cfg.App.Environment,
+		cfg.App.Version,
+	); err != nil {
+		logger.Error("Failed to initialize monitoring", err)
+		// Don't exit - monitoring is not critical for basic functionality
+	}
+
+	// Create CLI
+	cli := humacli.New(func(hooks humacli.Hooks, options *Options) {
+		// Initialize database connection
+		db, err := sql.Open("mysql", cfg.Database.GetDSN())
+		if err != nil {
+			logger.Fatal("Failed to connect to database", err)
+		}
+		// Note: Database connection will be closed when the application shuts down
+
+		// Configure connection pool
+		db.SetMaxOpenConns(25)
+		db.SetMaxIdleConns(25)
+		db.SetConnMaxLifetime(5 * time.Minute)
+
+		// Test database connection
+		if err := db.Ping(); err != nil {
+			logger.Fatal("Failed to ping database", err)
+		}
+		logger.Info("Database connection established")
+
+		// Create database queries instance
+		queries := database.New(db)
+
+		// Initialize gRPC clients (in-process for the monolith unless a service address is configured)
+		spotClient, err := clients.NewSpotClient(cfg.Services.SpotAddr, db)
+		if err != nil {
+			logger.Fatal("Failed to create spot client", err)
+		}
+
+		userClient, err := clients.NewUserClient(cfg.Services.UserAddr, db)
+		if err != nil {
+			spotClient.Close()
+			logger.Fatal("Failed to create user client", err)
+		}
+
+		reviewClient, err := clients.NewReviewClient(cfg.Services.ReviewAddr, db)
+		if err != nil {
+			spotClient.Close()
+			userClient.Close()
+			logger.Fatal("Failed to create review client", err)
+		}
+
+		ratingClient, err := clients.NewRatingClient(cfg.Services.RatingAddr, db)
+		if err != nil {
+			spotClient.Close()
+			userClient.Close()
+			reviewClient.Close()
+			logger.Fatal("Failed to create rating client", err)
+		}
+
+		// humacli keeps only the last OnStop hook, so collect cleanups and run them in reverse order
+		var stopFuncs []func()
+		onStop := func(fn func()) {
+			stopFuncs = append(stopFuncs, fn)
+		}
+		hooks.OnStop(func() {
+			for i := len(stopFuncs) - 1; i >= 0; i-- {
+				stopFuncs[i]()
+			}
+		})
+
+		// Ensure proper cleanup on shutdown
+		onStop(func() {
+			logger.Info("Shutting down application...")
+			
+			// Shutdown monitoring services
+			monitoring.ShutdownMonitoring()
+			
+			// Close gRPC clients
+			spotClient.Close()
+			userClient.Close()
+			reviewClient.Close()
+			ratingClient.Close()
+			
+			// Close database connection
+			logger.Info("Closing database connection")
+			if err := db.Close(); err != nil {
+				logger.Error("Failed to close database connection", err)
+			}
+			
+			logger.Info("Application shutdown complete")
+		})
+
+		// Create chi router
+		router := chi.NewRouter()
+
+		// Add middleware
+		router.Use(middleware.RequestID)
+		// Resolve client IPs behind trusted proxies only, and reject denylisted clients early
+		ipResolver, err := clientip.NewResolver(cfg.Network)
+		if err != nil {
+			logger.Fatal("Failed to configure client IP resolution", err)
+		}
+		router.Use(ipResolver.Middleware())
+		router.Use(middleware.Logger)
+		router.Use(middleware.Recoverer)
+		router.Use(middleware.Compress(5))
+		
+		// Add CORS middleware for frontend integration
+		router.Use(cors.Handler(cors.Options{
+			AllowedOrigins:   []string{"http://localhost:3000", "https://bocchi-the-map.vercel.app"}, // Next.js dev and production
+			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
+			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-Request-ID"},
+			ExposedHeaders:   []string{"Link", "X-Request-ID"},
+			AllowCredentials: true,
+			MaxAge:           300, // Maximum value not ignored by any of major browsers
+		}))
+		
+		// Add monitoring middleware
+		router.Use(monitoring.RequestIDMiddleware())
+		router.Use(monitoring.MonitoringMiddleware())
+		router.Use(monitoring.PerformanceMiddleware())
+
+		// Initialize authentication service with full Auth0 configuration
+		// The user service provisions users of first-seen sign-in identities in-process
+		authService, err := auth.NewServiceFromConfig(cfg, queries, grpcSvc.NewUserService(db))
+		if err != nil {
+			logger.Fatal("Failed to initialize authentication service", err)
+		}
+		
+		// Get components from auth service
+		authMiddleware := authService.GetMiddleware()
+		// The user service validates the tokens of identities to link in-process
+		userClient.SetTokenValidator(authMiddleware)
+		rateLimiter := authService.GetRateLimiter()
+		
+		// Ensure proper cleanup of auth service on shutdown; this stops the API key usage
+		// flushing after the servers registered later have stopped
+		onStop(func() {
+			authService.Stop()
+		})
+
+		// Create Huma API with security definitions
+		config := huma.DefaultConfig("Bocchi The Map API", cfg.App.Version)
+		
+		// Add security scheme for Bearer token authentication
+		config.Components.SecuritySchemes = map[string]*huma.SecurityScheme{
+			"bearerAuth": {
+				Type:         "http",
+				Scheme:       "bearer",
+				BearerFormat: "JWT",
+				Description:  "Auth0 JWT token authentication",
+			},
+			"apiKeyAuth": {
+				Type:        "apiKey",
+				In:          "header",
+				Name:        auth.APIKeyHeader,
+				Description: "API key for machine-to-machine clients",
+			},
+		}
+		
+		api := humachi.New(router, config)
+
+		// Add Huma v2 middleware for authentication on protected routes
+		api.UseMiddleware(authMiddleware.HumaMiddleware())
+
+		// Apply rate limit policies to every operation; runs after authentication for per-user keys
+		api.UseMiddleware(authService.GetLimiter().HumaMiddleware(api, auth.RateLimitIdentity))
+
+		// Background jobs; a MySQL named lock elects one replica per run and the
+		// job_runs ledger skips runs another replica made within the interval
+		scheduler := jobs.NewScheduler(jobs.NewMySQLLocker(db), jobs.NewMySQLRunLedger(queries))
+		if err := scheduler.Register(jobs.NewTokenBlacklistCleanupJob(queries)); err != nil {
+			logger.Fatal("Failed to register token blacklist cleanup job", err)
+		}
+		exports := export.NewService(db, export.DefaultDownloadTTL)
+		if err := scheduler.Register(jobs.NewUserExportJob(exports)); err != nil {
+			logger.Fatal("Failed to register user export job", err)
+		}
+		gracePeriod := time.Duration(cfg.Users.DeletionGraceDays) * 24 * time.Hour
+		if err := scheduler.Register(jobs.NewUserPurgeJob(grpcSvc.NewUserService(db), gracePeriod)); err != nil {
+			logger.Fatal("Failed to register user purge job", err)
+		}
+		onStop(scheduler.Stop)
+
+		// Register routes with gRPC clients and database queries
+		registerRoutes(api, spotClient, userClient, reviewClient, ratingClient, queries, cfg, authMiddleware, rateLimiter)
+		registerJobRoutes(api, scheduler, authMiddleware)
+		registerAPIKeyRoutes(api, authMiddleware)
+		registerExportRoutes(api, exports, authMiddleware)
+
+		// Start gRPC server in a goroutine
+		grpcServer := grpcSvc.NewServer(db, authMiddleware)
+		onStop(func() {
+			grpcServer.GracefulStop()
+		})
+
+		errChan := make(chan error, 1)
+		go func() {
+			if err := startGRPCServer(grpcServer, options.GRPCPort); err != nil {
+				errChan <- fmt.Errorf("gRPC server failed: %w", err)
+			}
+		}()
+
+		// Check for immediate startup errors
+		select {
+		case err := <-errChan:
+			logger.Fatal("Server startup failed", err)
+		case <-time.After(100 * time.Millisecond):
+			// Continue if no immediate errors
+		}
+
+		// Start HTTP server with graceful shutdown
+		hooks.OnStart(func() {
+			scheduler.Start()
+
+			logger.Info(fmt.Sprintf("HTTP server starting on port %s", options.Port))
+			logger.Info(fmt.Sprintf("gRPC server starting on port %s", options.GRPCPort))
+			
+			// Create HTTP server
+			server := &http.Server{
+				Addr:    ":" + options.Port,
+				Handler: router,
+				ReadTimeout:  15 * time.Second,
+				WriteTimeout: 15 * time.Second,
+				IdleTimeout:  60 * time.Second,
+			}
+			
+			// Channel to listen for interrupt signal
+			quit := make(chan os.Signal, 1)
+			signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+			
+			// Start server in a goroutine
+			go func() {
+				logger.Info("Server is ready to handle requests")
+				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					logger.Fatal("HTTP server failed to start", err)
+				}
+			}()
+			
+			// Wait for interrupt signal
+			<-quit
+			logger.Info("Shutting down server...")
+			
+			// Create context with timeout for graceful shutdown
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			
+			// Shutdown server gracefully
+			if err := server.Shutdown(ctx); err != nil {
+				logger.Error("Server forced to shutdown", err)
+			}
+		})
+	})
+
+	// Run CLI
+	cli.Run()
+}
+
+// startGRPCServer serves the registered gRPC services on the given port
+func startGRPCServer(grpcServer *grpc.Server, port string) error {
+	lis, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %s: %w", port, err)
+	}
+
+	logger.Info(fmt.Sprintf("gRPC server listening on port %s", port))
+	return grpcServer.Serve(lis)
+}
+
+// registerRoutes registers all API routes
+func registerRoutes(api huma.API, spotClient *clients.SpotClient, userClient *clients.UserClient, reviewClient *clients.ReviewClient, ratingClient *clients.RatingClient, queries *database.Queries, cfg *config.Config, authMiddleware *auth.AuthMiddleware, rateLimiter *auth.RateLimiter) {
+	// Health check endpoint
+	huma.Register(api, huma.Operation{
+		OperationID: "health-check",
+		Method:      http.MethodGet,
+		Path:        "/health",
+		Summary:     "Health Check",
+		Description: "Check if the API is healthy",
+		Tags:        []string{"System"},
+	}, func(ctx context.Context, input *struct{}) (*HealthCheckOutput, error) {
+		resp := &HealthCheckOutput{}
+		resp.Body.Status = "ok"
+		resp.Body.Version = cfg.App.Version
+		return resp, nil
+	})
+
+	// Spot routes
+	registerSpotRoutes(api, spotClient, authMiddleware)
+
+	// Review routes
+	registerReviewRoutes(api, reviewClient, authMiddleware)
+
+	// Solo-friendly rating routes
+	registerRatingRoutes(api, ratingClient, authMiddleware)
+
+	// User routes
+	registerUserRoutes(api, userClient, queries, authMiddleware)
+	
+	// Authentication routes
+	registerAuthRoutes(api, authMiddleware, userClient, rateLimiter)
+}
+
+// registerSpotRoutes registers spot-related routes
+func registerSpotRoutes(api huma.API, spotClient *clients.SpotClient, authMiddleware *auth.AuthMiddleware) {
+	spotHandler := handlers.NewSpotHandler(spotClient)
+	spotHandler.RegisterRoutesWithAuth(api, authMiddleware)
+	logger.Info("Spot routes registered with authentication")
+}
+
+func registerReviewRoutes(api huma.API, reviewClient *clients.ReviewClient, authMiddleware *auth.AuthMiddleware) {
+	reviewHandler := handlers.NewReviewHandler(reviewClient)
+	
+	// Register routes with authentication support
+	reviewHandler.RegisterRoutesWithAuth(api, authMiddleware)
+	logger.Info("Review routes registered with authentication")
+}
+
+func registerRatingRoutes(api huma.API, ratingClient *clients.RatingClient, authMiddleware *auth.AuthMiddleware) {
+	ratingHandler := handlers.NewRatingHandler(ratingClient)
+	ratingHandler.RegisterRoutesWithAuth(api, authMiddleware)
+	logger.Info("Rating routes registered with authentication")
+}
+
+func registerUserRoutes(api huma.API, userClient *clients.UserClient, queries *database.Queries, authMiddleware *auth.AuthMiddleware) {
+	userHandler := handlers.NewUserHandler(userClient)
+	
+	// Register standard API routes (under /api/v1/users) with authentication middleware
+	userHandler.RegisterRoutesWithAuth(api, authMiddleware)
+	logger.Info("User routes registered with authentication")
+}
+
+// registerAuthRoutes registers authentication-related routes
+func registerAuthRoutes(api huma.API, authMiddleware *auth.AuthMiddleware, userClient *clients.UserClient, rateLimiter *auth.RateLimiter) {
+	authHandler := handlers.NewAuthHandler(authMiddleware, userClient)
+	
+	// Register authentication routes with rate limiting
+	authHandler.RegisterRoutesWithRateLimit(api, rateLimiter)
+	logger.Info("Authentication routes registered with rate limiting")
+}
+
+// registerJobRoutes registers the admin routes for background jobs
+func registerJobRoutes(api huma.API, scheduler *jobs.Scheduler, authMiddleware *auth.AuthMiddleware) {
+	jobsHandler := handlers.NewJobsHandler(scheduler)
+	jobsHandler.RegisterRoutesWithAuth(api, authMiddleware)
+	logger.Info("Job routes registered with admin authorization")
+}
+
+// registerAPIKeyRoutes registers the admin routes for API keys
+func registerAPIKeyRoutes(api huma.API, authMiddleware *auth.AuthMiddleware) {
+	apiKeyHandler := handlers.NewAPIKeyHandler(authMiddleware.GetAPIKeyManager())
+	apiKeyHandler.RegisterRoutesWithAuth(api, authMiddleware)
+	logger.Info("API key routes registered with admin authorization")
+}
+
+// registerExportRoutes registers the personal data export routes
+func registerExportRoutes(api huma.API, exports *export.Service, authMiddleware *auth.AuthMiddleware) {
+	exportHandler := handlers.NewExportHandler(exports)
+	exportHandler.RegisterRoutesWithAuth(api, authMiddleware)
+	logger.Info("Export routes registered with authentication")
+}
@@ -0,0 +1,50 @@
+// Command reconcile-ratings recomputes every spot's rating aggregates from the reviews table.
+// Review writes keep the aggregates up to date incrementally; this repairs any drift.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+
+	"bocchi/api/infrastructure/database"
+	"bocchi/api/pkg/config"
+	"bocchi/api/pkg/logger"
+)
+
+func main() {
+	timeout := flag.Duration("timeout", 10*time.Minute, "maximum time to spend reconciling")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("Failed to load configuration", err)
+	}
+	logger.Init(logger.Level(cfg.App.LogLevel))
+
+	db, err := sql.Open("mysql", cfg.Database.GetDSN())
+	if err != nil {
+		logger.Fatal("Failed to connect to database", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		logger.Fatal("Failed to ping database", err)
+	}
+
+	updated, err := database.New(db).ReconcileSpotRatings(ctx)
+	if err != nil {
+		logger.Fatal("Failed to reconcile spot ratings", err)
+	}
+
+	logger.InfoWithFields("Spot rating reconciliation completed", map[string]interface{}{
+		"spots_updated": updated,
+	})
+}
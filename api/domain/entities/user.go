@@ -14,11 +14,33 @@ const (
 	AuthProviderAuth0   AuthProvider = "auth0"
 )
 
-// UserPreferences represents user-specific preferences
+// IsValid reports whether the provider is one users can sign in with
+func (p AuthProvider) IsValid() bool {
+	switch p {
+	case AuthProviderGoogle, AuthProviderTwitter, AuthProviderX, AuthProviderAuth0:
+		return true
+	}
+	return false
+}
+
+// UserPreferences represents user-specific preferences. The stored document is
+// versioned; see package preferences for its schema, defaults and migrations.
 type UserPreferences struct {
-	Language string `json:"language"`
-	DarkMode bool   `json:"dark_mode"`
-	Timezone string `json:"timezone"`
+	Version        int                     `json:"version"`
+	Language       string                  `json:"language"`
+	DarkMode       bool                    `json:"dark_mode"`
+	Timezone       string                  `json:"timezone"`
+	SearchRadiusKm float64                 `json:"search_radius_km"`
+	SoloCategories []string                `json:"solo_categories"`
+	DistanceUnit   string                  `json:"distance_unit"`
+	Notifications  NotificationPreferences `json:"notifications"`
+}
+
+// NotificationPreferences represents which notifications a user receives
+type NotificationPreferences struct {
+	Email       bool `json:"email"`
+	Push        bool `json:"push"`
+	NearbySpots bool `json:"nearby_spots"`
 }
 
 // User represents a user in the domain
@@ -54,6 +54,33 @@ func (ns NullTokenBlacklistTokenType) Value() (driver.Value, error) {
 	return string(ns.TokenBlacklistTokenType), nil
 }
 
+type ApiKey struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Prefix      string          `json:"prefix"`
+	KeyHash     string          `json:"key_hash"`
+	Permissions json.RawMessage `json:"permissions"`
+	CreatedBy   string          `json:"created_by"`
+	UsageCount  int64           `json:"usage_count"`
+	LastUsedAt  sql.NullTime    `json:"last_used_at"`
+	ExpiresAt   sql.NullTime    `json:"expires_at"`
+	RevokedAt   sql.NullTime    `json:"revoked_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+type EmailVerificationOverride struct {
+	UserID    string         `json:"user_id"`
+	GrantedBy string         `json:"granted_by"`
+	Reason    sql.NullString `json:"reason"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+type JobRun struct {
+	Name      string    `json:"name"`
+	LastRunAt time.Time `json:"last_run_at"`
+}
+
 type Review struct {
 	ID            string          `json:"id"`
 	SpotID        string          `json:"spot_id"`
@@ -66,20 +93,54 @@ type Review struct {
 	UserID        sql.NullString  `json:"user_id"`
 }
 
+type Role struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RolePermission struct {
+	RoleID     string `json:"role_id"`
+	Permission string `json:"permission"`
+}
+
+type SoloRating struct {
+	ID                 string          `json:"id"`
+	SpotID             string          `json:"spot_id"`
+	UserID             string          `json:"user_id"`
+	SoloFriendlyRating int32           `json:"solo_friendly_rating"`
+	Categories         json.RawMessage `json:"categories"`
+	Comment            sql.NullString  `json:"comment"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
 type Spot struct {
-	ID            string          `json:"id"`
-	Name          string          `json:"name"`
-	NameI18n      json.RawMessage `json:"name_i18n"`
-	Latitude      string          `json:"latitude"`
-	Longitude     string          `json:"longitude"`
-	Category      string          `json:"category"`
-	Address       string          `json:"address"`
-	AddressI18n   json.RawMessage `json:"address_i18n"`
-	CountryCode   string          `json:"country_code"`
-	AverageRating string          `json:"average_rating"`
-	ReviewCount   int32           `json:"review_count"`
-	CreatedAt     time.Time       `json:"created_at"`
-	UpdatedAt     time.Time       `json:"updated_at"`
+	ID                 string          `json:"id"`
+	Name               string          `json:"name"`
+	NameI18n           json.RawMessage `json:"name_i18n"`
+	Latitude           string          `json:"latitude"`
+	Longitude          string          `json:"longitude"`
+	Category           string          `json:"category"`
+	Address            string          `json:"address"`
+	AddressI18n        json.RawMessage `json:"address_i18n"`
+	CountryCode        string          `json:"country_code"`
+	AverageRating      string          `json:"average_rating"`
+	ReviewCount        int32           `json:"review_count"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+	NameI18nText       sql.NullString  `json:"name_i18n_text"`
+	AddressI18nText    sql.NullString  `json:"address_i18n_text"`
+	CreatedBy          sql.NullString  `json:"created_by"`
+	RatingSum          int32           `json:"rating_sum"`
+	SoloFriendlyRating string          `json:"solo_friendly_rating"`
+	SoloRatingCount    int32           `json:"solo_rating_count"`
+}
+
+type SpotSoloCategory struct {
+	SpotID        string `json:"spot_id"`
+	Category      string `json:"category"`
+	Confirmations int32  `json:"confirmations"`
 }
 
 type TokenBlacklist struct {
@@ -102,4 +163,42 @@ type User struct {
 	Preferences   json.RawMessage `json:"preferences"`
 	CreatedAt     time.Time       `json:"created_at"`
 	UpdatedAt     time.Time       `json:"updated_at"`
+	DeletedAt     sql.NullTime    `json:"deleted_at"`
+}
+
+type UserExport struct {
+	ID                string         `json:"id"`
+	UserID            string         `json:"user_id"`
+	Format            string         `json:"format"`
+	Status            string         `json:"status"`
+	DownloadTokenHash string         `json:"download_token_hash"`
+	Archive           []byte         `json:"archive"`
+	SizeBytes         int64          `json:"size_bytes"`
+	Error             sql.NullString `json:"error"`
+	CreatedAt         time.Time      `json:"created_at"`
+	CompletedAt       sql.NullTime   `json:"completed_at"`
+	ExpiresAt         sql.NullTime   `json:"expires_at"`
+	ClaimedAt         sql.NullTime   `json:"claimed_at"`
+}
+
+type UserIdentity struct {
+	Provider      string         `json:"provider"`
+	ProviderID    string         `json:"provider_id"`
+	UserID        string         `json:"user_id"`
+	Email         sql.NullString `json:"email"`
+	EmailVerified bool           `json:"email_verified"`
+	LastUsedAt    sql.NullTime   `json:"last_used_at"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+type UserRole struct {
+	UserID    string    `json:"user_id"`
+	RoleID    string    `json:"role_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type UserTokenRevocation struct {
+	UserID        string    `json:"user_id"`
+	RevokedBefore time.Time `json:"revoked_before"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
@@ -7,36 +7,158 @@ package database
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
 )
 
 type Querier interface {
+	// Adds usage counted in memory since the last flush
+	AddAPIKeyUsage(ctx context.Context, arg AddAPIKeyUsageParams) error
 	// Token blacklist queries for logout and security
 	AddToBlacklist(ctx context.Context, arg AddToBlacklistParams) error
+	// ApplySpotRatingDelta adjusts the rating aggregates incrementally. MySQL evaluates
+	// single-table SET assignments left to right, so average_rating is computed first
+	// from the sum and count as they were before this update.
+	ApplySpotRatingDelta(ctx context.Context, arg ApplySpotRatingDeltaParams) error
+	// Detaches a purged user's reviews; the rating and comment stay with the spot
+	AnonymizeUserReviews(ctx context.Context, userID sql.NullString) (int64, error)
 	BlacklistAccessToken(ctx context.Context, arg BlacklistAccessTokenParams) error
 	BlacklistRefreshToken(ctx context.Context, arg BlacklistRefreshTokenParams) error
 	CleanupExpiredTokens(ctx context.Context) error
+	// Claims a pending or stale export for one run; no row is affected when another run
+	// claimed it first
+	ClaimUserExport(ctx context.Context, arg ClaimUserExportParams) (int64, error)
+	// ClusterSpotsInBounds groups spots into a fixed degree grid. The bounding box
+	// filter is served by idx_location; cells are numbered from the south-west corner.
+	// A viewport crossing the antimeridian is passed as two longitude ranges.
+	ClusterSpotsInBounds(ctx context.Context, arg ClusterSpotsInBoundsParams) ([]ClusterSpotsInBoundsRow, error)
+	// Stores the archive of an export; no row is affected when the claim was taken over
+	// by another run
+	CompleteUserExport(ctx context.Context, arg CompleteUserExportParams) (int64, error)
+	CountActiveUserExports(ctx context.Context, userID string) (int64, error)
 	CountReviewsBySpot(ctx context.Context, spotID string) (int64, error)
 	CountReviewsByUser(ctx context.Context, userID sql.NullString) (int64, error)
+	CountSearchSpots(ctx context.Context, arg CountSearchSpotsParams) (int64, error)
+	CountSpots(ctx context.Context, arg CountSpotsParams) (int64, error)
+	CountSpotsByLocation(ctx context.Context, arg CountSpotsByLocationParams) (int64, error)
+	CountSpotsInViewport(ctx context.Context, arg CountSpotsInViewportParams) (int64, error)
 	CountTopRatedSpots(ctx context.Context, id string) (int64, error)
+	CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) error
 	CreateReview(ctx context.Context, arg CreateReviewParams) error
+	CreateSoloRating(ctx context.Context, arg CreateSoloRatingParams) error
+	CreateSpot(ctx context.Context, arg CreateSpotParams) error
+	CreateSpotSoloCategory(ctx context.Context, arg CreateSpotSoloCategoryParams) error
 	CreateUser(ctx context.Context, arg CreateUserParams) error
+	CreateUserExport(ctx context.Context, arg CreateUserExportParams) error
+	CreateUserIdentity(ctx context.Context, arg CreateUserIdentityParams) error
+	DeleteEmailVerificationOverride(ctx context.Context, userID string) (int64, error)
+	// Expiry is compared against the service clock that set expires_at
+	DeleteExpiredUserExports(ctx context.Context, expiresAt sql.NullTime) (int64, error)
 	DeleteReview(ctx context.Context, id string) error
+	DeleteSpot(ctx context.Context, id string) error
+	DeleteSoloRatingsByUser(ctx context.Context, userID string) (int64, error)
+	DeleteSpotSoloCategories(ctx context.Context, spotID string) error
 	DeleteUser(ctx context.Context, id string) error
+	DeleteUserIdentity(ctx context.Context, arg DeleteUserIdentityParams) (int64, error)
+	// Removes the roles of a purged user
+	DeleteUserRoles(ctx context.Context, userID string) error
+	// Removes the revocation cutoff of a purged user
+	DeleteUserTokenRevocation(ctx context.Context, userID string) error
+	// Records why an export failed; no row is affected when the claim was taken over by
+	// another run
+	FailUserExport(ctx context.Context, arg FailUserExportParams) (int64, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error)
+	GetAPIKeyByID(ctx context.Context, id string) (ApiKey, error)
 	GetReviewByID(ctx context.Context, id string) (Review, error)
+	GetReviewByIDForUpdate(ctx context.Context, id string) (Review, error)
 	GetReviewByUserAndSpot(ctx context.Context, arg GetReviewByUserAndSpotParams) (Review, error)
+	GetSoloRatingBySpotAndUser(ctx context.Context, arg GetSoloRatingBySpotAndUserParams) (SoloRating, error)
 	GetSpotRatingStats(ctx context.Context, spotID string) (GetSpotRatingStatsRow, error)
+	GetSpotByID(ctx context.Context, id string) (Spot, error)
+	// Deleted accounts waiting to be purged are not found by email
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	// User management queries for Bocchi The Map API
 	// These queries support Auth0 integration and user profile management
 	GetUserByID(ctx context.Context, id string) (User, error)
 	GetUserByProviderID(ctx context.Context, arg GetUserByProviderIDParams) (User, error)
+	// Verification state of a user for the verified email policy
+	GetUserEmailVerification(ctx context.Context, id string) (GetUserEmailVerificationRow, error)
+	GetUserExport(ctx context.Context, id string) (UserExport, error)
+	GetUserIdentity(ctx context.Context, arg GetUserIdentityParams) (UserIdentity, error)
+	// Locks the user's row so concurrent preference patches are merged one after the other
+	GetUserPreferencesForUpdate(ctx context.Context, id string) (json.RawMessage, error)
+	GetUserTokensRevokedBefore(ctx context.Context, userID string) (time.Time, error)
+	IsEmailOfDeletedUser(ctx context.Context, email string) (bool, error)
+	// Reports whether any replica started the job within the interval, by the database clock
+	IsJobRunRecent(ctx context.Context, arg IsJobRunRecentParams) (bool, error)
 	IsTokenBlacklisted(ctx context.Context, jti string) (bool, error)
+	IsUserDeleted(ctx context.Context, id string) (bool, error)
+	ListAPIKeys(ctx context.Context) ([]ApiKey, error)
+	// Last run of every job that any replica has run
+	ListJobRuns(ctx context.Context) ([]JobRun, error)
+	// Permissions granted to a user through their roles
+	ListPermissionsByUserID(ctx context.Context, userID string) ([]string, error)
+	// Exports waiting for their archive, including exports whose claim went stale because
+	// the run building them was interrupted
+	ListPendingUserExports(ctx context.Context, arg ListPendingUserExportsParams) ([]ListPendingUserExportsRow, error)
+	ListReviewedSpotIDsByUser(ctx context.Context, userID sql.NullString) ([]string, error)
+	// Reviews by deleted accounts are listed without their author's name and avatar
 	ListReviewsBySpot(ctx context.Context, arg ListReviewsBySpotParams) ([]ListReviewsBySpotRow, error)
 	ListReviewsByUser(ctx context.Context, arg ListReviewsByUserParams) ([]ListReviewsByUserRow, error)
+	ListRolesByUserID(ctx context.Context, userID string) ([]string, error)
+	ListSoloRatingsBySpot(ctx context.Context, spotID string) ([]SoloRating, error)
+	ListSoloRatingsByUser(ctx context.Context, userID string) ([]SoloRating, error)
+	ListSpotSoloCategories(ctx context.Context, spotIds []string) ([]SpotSoloCategory, error)
+	ListSpots(ctx context.Context, arg ListSpotsParams) ([]Spot, error)
+	// ListSpotsByLocation pre-filters on a bounding box so idx_location can be used,
+	// then applies the exact great-circle distance. LEAST guards acos against
+	// floating point values slightly above 1 for points at the center.
+	ListSpotsByLocation(ctx context.Context, arg ListSpotsByLocationParams) ([]ListSpotsByLocationRow, error)
+	// ListSpotsInViewport returns spots inside a map viewport, compared directly against
+	// the DECIMAL coordinate columns so idx_location applies. A viewport crossing the
+	// antimeridian is passed as two longitude ranges; otherwise both ranges are the same.
+	ListSpotsInViewport(ctx context.Context, arg ListSpotsInViewportParams) ([]Spot, error)
 	ListTopRatedSpots(ctx context.Context, arg ListTopRatedSpotsParams) ([]ListTopRatedSpotsRow, error)
+	ListUserIdentities(ctx context.Context, userID string) ([]UserIdentity, error)
+	// Locks the user's identities so concurrent unlinks cannot remove the last one
+	ListUserIdentitiesForUpdate(ctx context.Context, userID string) ([]UserIdentity, error)
+	ListUsersDeletedBefore(ctx context.Context, arg ListUsersDeletedBeforeParams) ([]string, error)
+	// LockSpotForUpdate takes the spot row lock that serializes concurrent review writes for a spot
+	LockSpotForUpdate(ctx context.Context, id string) (string, error)
+	// Locks the user's row so concurrent requests for the user are applied one after the other
+	LockUserForUpdate(ctx context.Context, id string) (string, error)
+	// ReconcileSpotRatings recomputes every spot's rating aggregates from reviews and
+	// only touches spots whose stored values have drifted.
+	ReconcileSpotRatings(ctx context.Context) (int64, error)
+	// RecomputeSpotRating recomputes one spot's rating aggregates from its reviews
+	RecomputeSpotRating(ctx context.Context, id string) error
+	// Records a run of the job starting now
+	RecordJobRun(ctx context.Context, name string) error
+	// Profile fields refreshed from the identity provider on sign-in
+	RefreshUserProfile(ctx context.Context, arg RefreshUserProfileParams) error
+	RestoreUser(ctx context.Context, id string) (int64, error)
+	RevokeAPIKey(ctx context.Context, id string) (int64, error)
+	// Per-user revocation cutoff for logout-everywhere; the cutoff never moves backwards
+	RevokeUserTokensBefore(ctx context.Context, arg RevokeUserTokensBeforeParams) error
+	// Replaces the secret of an active key; the old secret stops working immediately
+	RotateAPIKey(ctx context.Context, arg RotateAPIKeyParams) (int64, error)
+	// SearchSpots ranks spots by full-text relevance over the ft_spots_search index.
+	// Spots whose name in the preferred language contains the query are boosted.
+	// Sorting by solo_friendly_rating ranks the best solo-friendly spots first, then by relevance.
+	SearchSpots(ctx context.Context, arg SearchSpotsParams) ([]SearchSpotsRow, error)
+	// Moves the identity the account was created with to another linked identity
+	SetUserPrimaryIdentity(ctx context.Context, arg SetUserPrimaryIdentityParams) error
+	// Soft delete: the account is purged once the grace period has passed
+	SoftDeleteUser(ctx context.Context, id string) (int64, error)
+	// Records a sign-in with the identity and the email the provider reported for it
+	TouchUserIdentity(ctx context.Context, arg TouchUserIdentityParams) error
 	UpdateReview(ctx context.Context, arg UpdateReviewParams) error
+	UpdateSoloRating(ctx context.Context, arg UpdateSoloRatingParams) error
+	UpdateSpot(ctx context.Context, arg UpdateSpotParams) error
+	UpdateSpotSoloStats(ctx context.Context, arg UpdateSpotSoloStatsParams) error
 	UpdateUserAvatar(ctx context.Context, arg UpdateUserAvatarParams) error
 	UpdateUserPreferences(ctx context.Context, arg UpdateUserPreferencesParams) error
+	UpsertEmailVerificationOverride(ctx context.Context, arg UpsertEmailVerificationOverrideParams) error
 	UpsertUser(ctx context.Context, arg UpsertUserParams) error
 }
 
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	stdErrors "errors"
+
+	"github.com/go-sql-driver/mysql"
+
+	"bocchi/api/internal/domain/rating"
+	"bocchi/api/pkg/errors"
+)
+
+// RatingRepository stores solo-friendly ratings in the solo_ratings table
+type RatingRepository struct {
+	queries *Queries
+}
+
+// mysqlErrDuplicateEntry is the MySQL error number of ER_DUP_ENTRY
+const mysqlErrDuplicateEntry = 1062
+
+// NewRatingRepository creates a new RatingRepository instance on a database or a transaction
+func NewRatingRepository(db DBTX) *RatingRepository {
+	return &RatingRepository{
+		queries: New(db),
+	}
+}
+
+// Create inserts a new solo-friendly rating
+func (r *RatingRepository) Create(ctx context.Context, rt *rating.Rating) error {
+	categories, err := json.Marshal(rt.Categories)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeInternal, "failed to marshal rating categories")
+	}
+
+	err = r.queries.CreateSoloRating(ctx, CreateSoloRatingParams{
+		ID:                 rt.ID,
+		SpotID:             rt.SpotID,
+		UserID:             rt.UserID,
+		SoloFriendlyRating: int32(rt.SoloFriendlyRating),
+		Categories:         categories,
+		Comment:            toNullString(rt.Comment),
+	})
+	if err != nil {
+		// The (spot_id, user_id) unique key rejects a second rating of the spot by the user
+		var mysqlErr *mysql.MySQLError
+		if stdErrors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry {
+			return errors.Conflict("rating", "user has already rated this spot")
+		}
+		return errors.Database("create solo rating", err)
+	}
+	return nil
+}
+
+// Update saves the rating value, categories and comment of an existing rating
+func (r *RatingRepository) Update(ctx context.Context, rt *rating.Rating) error {
+	categories, err := json.Marshal(rt.Categories)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeInternal, "failed to marshal rating categories")
+	}
+
+	err = r.queries.UpdateSoloRating(ctx, UpdateSoloRatingParams{
+		ID:                 rt.ID,
+		SoloFriendlyRating: int32(rt.SoloFriendlyRating),
+		Categories:         categories,
+		Comment:            toNullString(rt.Comment),
+	})
+	if err != nil {
+		return errors.Database("update solo rating", err)
+	}
+	return nil
+}
+
+// GetBySpotAndUser retrieves a user's rating for a spot
+func (r *RatingRepository) GetBySpotAndUser(ctx context.Context, spotID, userID string) (*rating.Rating, error) {
+	row, err := r.queries.GetSoloRatingBySpotAndUser(ctx, GetSoloRatingBySpotAndUserParams{
+		SpotID: spotID,
+		UserID: userID,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFound("rating", spotID)
+		}
+		return nil, errors.Database("get solo rating", err)
+	}
+	return toDomainRating(row), nil
+}
+
+// GetBySpot retrieves all ratings for a spot, most recently updated first
+func (r *RatingRepository) GetBySpot(ctx context.Context, spotID string) ([]*rating.Rating, error) {
+	rows, err := r.queries.ListSoloRatingsBySpot(ctx, spotID)
+	if err != nil {
+		return nil, errors.Database("list solo ratings", err)
+	}
+
+	ratings := make([]*rating.Rating, len(rows))
+	for i, row := range rows {
+		ratings[i] = toDomainRating(row)
+	}
+	return ratings, nil
+}
+
+// toDomainRating converts a solo_ratings row to the domain Rating
+func toDomainRating(row SoloRating) *rating.Rating {
+	categories := []string{}
+	if len(row.Categories) > 0 {
+		// Rows are written by Create/Update, so malformed JSON leaves the categories empty
+		_ = json.Unmarshal(row.Categories, &categories)
+	}
+
+	return &rating.Rating{
+		ID:                 row.ID,
+		SpotID:             row.SpotID,
+		UserID:             row.UserID,
+		SoloFriendlyRating: int(row.SoloFriendlyRating),
+		Categories:         categories,
+		Comment:            row.Comment.String,
+		CreatedAt:          row.CreatedAt,
+		UpdatedAt:          row.UpdatedAt,
+	}
+}
+
+// toNullString converts an empty string to SQL NULL
+func toNullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
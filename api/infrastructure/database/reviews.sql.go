@@ -12,6 +12,20 @@ import (
 	"time"
 )
 
+const anonymizeUserReviews = `-- name: AnonymizeUserReviews :execrows
+UPDATE reviews SET user_id = NULL, reviewer_name = ''
+WHERE user_id = ?
+`
+
+// Detaches a purged user's reviews; the rating and comment stay with the spot
+func (q *Queries) AnonymizeUserReviews(ctx context.Context, userID sql.NullString) (int64, error) {
+	result, err := q.db.ExecContext(ctx, anonymizeUserReviews, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const countReviewsBySpot = `-- name: CountReviewsBySpot :one
 SELECT COUNT(*) FROM reviews 
 WHERE spot_id = ?
@@ -113,6 +127,29 @@ func (q *Queries) GetReviewByID(ctx context.Context, id string) (Review, error)
 	return i, err
 }
 
+const getReviewByIDForUpdate = `-- name: GetReviewByIDForUpdate :one
+SELECT id, spot_id, reviewer_name, rating, comment, rating_aspects, created_at, updated_at, user_id FROM reviews 
+WHERE id = ?
+FOR UPDATE
+`
+
+func (q *Queries) GetReviewByIDForUpdate(ctx context.Context, id string) (Review, error) {
+	row := q.db.QueryRowContext(ctx, getReviewByIDForUpdate, id)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.SpotID,
+		&i.ReviewerName,
+		&i.Rating,
+		&i.Comment,
+		&i.RatingAspects,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+	)
+	return i, err
+}
+
 const getReviewByUserAndSpot = `-- name: GetReviewByUserAndSpot :one
 SELECT id, spot_id, reviewer_name, rating, comment, rating_aspects, created_at, updated_at, user_id FROM reviews 
 WHERE user_id = ? AND spot_id = ?
@@ -178,6 +215,34 @@ func (q *Queries) GetSpotRatingStats(ctx context.Context, spotID string) (GetSpo
 	return i, err
 }
 
+const listReviewedSpotIDsByUser = `-- name: ListReviewedSpotIDsByUser :many
+SELECT DISTINCT spot_id FROM reviews
+WHERE user_id = ?
+`
+
+func (q *Queries) ListReviewedSpotIDsByUser(ctx context.Context, userID sql.NullString) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listReviewedSpotIDsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var spot_id string
+		if err := rows.Scan(&spot_id); err != nil {
+			return nil, err
+		}
+		items = append(items, spot_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listReviewsBySpot = `-- name: ListReviewsBySpot :many
 SELECT
   r.id,
@@ -191,7 +256,7 @@ SELECT
   u.name          AS user_name,
   u.picture       AS user_avatar
 FROM reviews r
-JOIN users u ON r.user_id = u.id
+LEFT JOIN users u ON r.user_id = u.id AND u.deleted_at IS NULL
 WHERE r.spot_id = ?
 ORDER BY r.created_at DESC
 LIMIT ? OFFSET ?
@@ -216,6 +281,7 @@ type ListReviewsBySpotRow struct {
 	UserAvatar    sql.NullString  `json:"user_avatar"`
 }
 
+// Reviews by deleted accounts are listed without their author's name and avatar
 func (q *Queries) ListReviewsBySpot(ctx context.Context, arg ListReviewsBySpotParams) ([]ListReviewsBySpotRow, error) {
 	rows, err := q.db.QueryContext(ctx, listReviewsBySpot, arg.SpotID, arg.Limit, arg.Offset)
 	if err != nil {
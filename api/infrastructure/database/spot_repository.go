@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+
+	"bocchi/api/domain/entities"
+	"bocchi/api/pkg/errors"
+)
+
+// SpotRepository provides the spot data access needed by the rating service
+type SpotRepository struct {
+	db      DBTX
+	queries *Queries
+}
+
+// NewSpotRepository creates a new SpotRepository instance on a database or a transaction
+func NewSpotRepository(db DBTX) *SpotRepository {
+	return &SpotRepository{
+		db:      db,
+		queries: New(db),
+	}
+}
+
+// GetByID retrieves a spot by its ID
+func (r *SpotRepository) GetByID(ctx context.Context, id string) (*entities.Spot, error) {
+	row, err := r.queries.GetSpotByID(ctx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NotFound("spot", id)
+		}
+		return nil, errors.Database("get spot", err)
+	}
+
+	spot := &entities.Spot{
+		ID:          row.ID,
+		Name:        row.Name,
+		Category:    row.Category,
+		Address:     row.Address,
+		CountryCode: row.CountryCode,
+		ReviewCount: int(row.ReviewCount),
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+	// DECIMAL columns are scanned as strings
+	spot.Latitude, _ = strconv.ParseFloat(row.Latitude, 64)
+	spot.Longitude, _ = strconv.ParseFloat(row.Longitude, 64)
+	spot.AverageRating, _ = strconv.ParseFloat(row.AverageRating, 64)
+	if len(row.NameI18n) > 0 {
+		_ = json.Unmarshal(row.NameI18n, &spot.NameI18n)
+	}
+	if len(row.AddressI18n) > 0 {
+		_ = json.Unmarshal(row.AddressI18n, &spot.AddressI18n)
+	}
+	return spot, nil
+}
+
+// LockForUpdate locks the spot row until the end of the repository's transaction
+func (r *SpotRepository) LockForUpdate(ctx context.Context, id string) error {
+	if _, err := r.queries.LockSpotForUpdate(ctx, id); err != nil {
+		if err == sql.ErrNoRows {
+			return errors.NotFound("spot", id)
+		}
+		return errors.Database("lock spot", err)
+	}
+	return nil
+}
+
+// UpdateSoloFriendlyStats stores a spot's solo-friendly average rating and rating count
+func (r *SpotRepository) UpdateSoloFriendlyStats(ctx context.Context, spotID string, avgRating float64, totalRatings int) error {
+	err := r.queries.UpdateSpotSoloStats(ctx, UpdateSpotSoloStatsParams{
+		ID:                 spotID,
+		SoloFriendlyRating: strconv.FormatFloat(avgRating, 'f', 1, 64),
+		SoloRatingCount:    int32(totalRatings),
+	})
+	if err != nil {
+		return errors.Database("update spot solo stats", err)
+	}
+	return nil
+}
+
+// UpdateSoloCategoryCounts replaces a spot's solo-friendly category confirmation counts.
+// Outside a transaction the replacement runs in one of its own.
+func (r *SpotRepository) UpdateSoloCategoryCounts(ctx context.Context, spotID string, counts map[string]int) error {
+	db, ok := r.db.(*sql.DB)
+	if !ok {
+		return r.replaceSoloCategoryCounts(ctx, spotID, counts)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Database("begin solo category update", err)
+	}
+	defer tx.Rollback()
+
+	if err := NewSpotRepository(tx).replaceSoloCategoryCounts(ctx, spotID, counts); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Database("commit solo category update", err)
+	}
+	return nil
+}
+
+// replaceSoloCategoryCounts deletes a spot's category counts and inserts the given ones
+func (r *SpotRepository) replaceSoloCategoryCounts(ctx context.Context, spotID string, counts map[string]int) error {
+	if err := r.queries.DeleteSpotSoloCategories(ctx, spotID); err != nil {
+		return errors.Database("delete spot solo categories", err)
+	}
+	for category, count := range counts {
+		err := r.queries.CreateSpotSoloCategory(ctx, CreateSpotSoloCategoryParams{
+			SpotID:        spotID,
+			Category:      category,
+			Confirmations: int32(count),
+		})
+		if err != nil {
+			return errors.Database("create spot solo category", err)
+		}
+	}
+	return nil
+}
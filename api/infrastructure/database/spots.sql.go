@@ -7,14 +7,135 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"strings"
 )
 
+const applySpotRatingDelta = `-- name: ApplySpotRatingDelta :exec
+UPDATE spots
+SET average_rating = IF(review_count + ? > 0,
+        ROUND((rating_sum + ?) / (review_count + ?), 1),
+        0.0),
+    rating_sum = rating_sum + ?,
+    review_count = review_count + ?,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?
+`
+
+type ApplySpotRatingDeltaParams struct {
+	CountDelta int32  `json:"count_delta"`
+	SumDelta   int32  `json:"sum_delta"`
+	ID         string `json:"id"`
+}
+
+// ApplySpotRatingDelta adjusts the rating aggregates incrementally. MySQL evaluates
+// single-table SET assignments left to right, so average_rating is computed first
+// from the sum and count as they were before this update.
+func (q *Queries) ApplySpotRatingDelta(ctx context.Context, arg ApplySpotRatingDeltaParams) error {
+	_, err := q.db.ExecContext(ctx, applySpotRatingDelta,
+		arg.CountDelta,
+		arg.SumDelta,
+		arg.CountDelta,
+		arg.SumDelta,
+		arg.CountDelta,
+		arg.ID,
+	)
+	return err
+}
+
+const clusterSpotsInBounds = `-- name: ClusterSpotsInBounds :many
+SELECT CAST(FLOOR((latitude + 90) / ?) AS SIGNED) AS cell_row,
+       CAST(FLOOR((longitude + 180) / ?) AS SIGNED) AS cell_col,
+       COUNT(*) AS spot_count,
+       AVG(latitude) AS centroid_lat,
+       AVG(longitude) AS centroid_lng,
+       AVG(CASE WHEN review_count > 0 THEN average_rating END) AS average_rating
+FROM spots
+WHERE latitude BETWEEN ? AND ?
+  AND (longitude BETWEEN ? AND ?
+       OR longitude BETWEEN ? AND ?)
+  AND (? = '' OR category = ?)
+  AND (? = '' OR country_code = ?)
+GROUP BY cell_row, cell_col
+ORDER BY spot_count DESC, cell_row, cell_col
+LIMIT ?
+`
+
+type ClusterSpotsInBoundsParams struct {
+	CellSize    float64 `json:"cell_size"`
+	MinLat      string  `json:"min_lat"`
+	MaxLat      string  `json:"max_lat"`
+	MinLng      string  `json:"min_lng"`
+	MaxLng      string  `json:"max_lng"`
+	WrapMinLng  string  `json:"wrap_min_lng"`
+	WrapMaxLng  string  `json:"wrap_max_lng"`
+	Category    string  `json:"category"`
+	CountryCode string  `json:"country_code"`
+	Limit       int32   `json:"limit"`
+}
+
+type ClusterSpotsInBoundsRow struct {
+	CellRow       int64           `json:"cell_row"`
+	CellCol       int64           `json:"cell_col"`
+	SpotCount     int64           `json:"spot_count"`
+	CentroidLat   float64         `json:"centroid_lat"`
+	CentroidLng   float64         `json:"centroid_lng"`
+	AverageRating sql.NullFloat64 `json:"average_rating"`
+}
+
+// ClusterSpotsInBounds groups spots into a fixed degree grid. The bounding box
+// filter is served by idx_location; cells are numbered from the south-west corner.
+// A viewport crossing the antimeridian is passed as two longitude ranges.
+func (q *Queries) ClusterSpotsInBounds(ctx context.Context, arg ClusterSpotsInBoundsParams) ([]ClusterSpotsInBoundsRow, error) {
+	rows, err := q.db.QueryContext(ctx, clusterSpotsInBounds,
+		arg.CellSize,
+		arg.CellSize,
+		arg.MinLat,
+		arg.MaxLat,
+		arg.MinLng,
+		arg.MaxLng,
+		arg.WrapMinLng,
+		arg.WrapMaxLng,
+		arg.Category,
+		arg.Category,
+		arg.CountryCode,
+		arg.CountryCode,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ClusterSpotsInBoundsRow{}
+	for rows.Next() {
+		var i ClusterSpotsInBoundsRow
+		if err := rows.Scan(
+			&i.CellRow,
+			&i.CellCol,
+			&i.SpotCount,
+			&i.CentroidLat,
+			&i.CentroidLng,
+			&i.AverageRating,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createSpot = `-- name: CreateSpot :exec
 INSERT INTO spots (
-    id, name, name_i18n, latitude, longitude, category, address, address_i18n, country_code
+    id, name, name_i18n, latitude, longitude, category, address, address_i18n, country_code, created_by
 ) VALUES (
-    ?, ?, ?, ?, ?, ?, ?, ?, ?
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 )
 `
 
@@ -28,6 +149,7 @@ type CreateSpotParams struct {
 	Address     string          `json:"address"`
 	AddressI18n json.RawMessage `json:"address_i18n"`
 	CountryCode string          `json:"country_code"`
+	CreatedBy   sql.NullString  `json:"created_by"`
 }
 
 func (q *Queries) CreateSpot(ctx context.Context, arg CreateSpotParams) error {
@@ -41,6 +163,7 @@ func (q *Queries) CreateSpot(ctx context.Context, arg CreateSpotParams) error {
 		arg.Address,
 		arg.AddressI18n,
 		arg.CountryCode,
+		arg.CreatedBy,
 	)
 	return err
 }
@@ -56,7 +179,7 @@ func (q *Queries) DeleteSpot(ctx context.Context, id string) error {
 }
 
 const getSpotByID = `-- name: GetSpotByID :one
-SELECT id, name, name_i18n, latitude, longitude, category, address, address_i18n, country_code, average_rating, review_count, created_at, updated_at FROM spots 
+SELECT id, name, name_i18n, latitude, longitude, category, address, address_i18n, country_code, average_rating, review_count, created_at, updated_at, name_i18n_text, address_i18n_text, created_by, rating_sum, solo_friendly_rating, solo_rating_count FROM spots 
 WHERE id = ?
 `
 
@@ -77,49 +200,77 @@ func (q *Queries) GetSpotByID(ctx context.Context, id string) (Spot, error) {
 		&i.ReviewCount,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.NameI18nText,
+		&i.AddressI18nText,
+		&i.CreatedBy,
+		&i.RatingSum,
+		&i.SoloFriendlyRating,
+		&i.SoloRatingCount,
 	)
 	return i, err
 }
 
-const listSpotsByLocation = `-- name: ListSpotsByLocation :many
-SELECT id, name, name_i18n, latitude, longitude, category, address, address_i18n, country_code, average_rating, review_count, created_at, updated_at FROM spots 
-WHERE (6371 * acos(
-    cos(radians(?)) * cos(radians(latitude)) * 
-    cos(radians(longitude) - radians(?)) + 
-    sin(radians(?)) * sin(radians(latitude))
-)) <= ?
-ORDER BY (6371 * acos(
-    cos(radians(?)) * cos(radians(latitude)) * 
-    cos(radians(longitude) - radians(?)) + 
-    sin(radians(?)) * sin(radians(latitude))
-))
+const lockSpotForUpdate = `-- name: LockSpotForUpdate :one
+SELECT id FROM spots
+WHERE id = ?
+FOR UPDATE
+`
+
+// LockSpotForUpdate takes the spot row lock that serializes concurrent review writes for a spot
+func (q *Queries) LockSpotForUpdate(ctx context.Context, id string) (string, error) {
+	row := q.db.QueryRowContext(ctx, lockSpotForUpdate, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const listSpots = `-- name: ListSpots :many
+SELECT id, name, name_i18n, latitude, longitude, category, address, address_i18n, country_code, average_rating, review_count, created_at, updated_at, name_i18n_text, address_i18n_text, created_by, rating_sum, solo_friendly_rating, solo_rating_count FROM spots
+WHERE (? = '' OR category = ?)
+  AND (? = '' OR country_code = ?)
+  AND solo_friendly_rating >= ?
+  AND (? = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (/*SLICE:solo_categories*/?)
+        AND confirmations >= ?
+      GROUP BY spot_id
+      HAVING COUNT(*) = ?))
+ORDER BY created_at DESC, id
 LIMIT ? OFFSET ?
 `
 
-type ListSpotsByLocationParams struct {
-	Latitude   string `json:"latitude"`
-	Longitude  string `json:"longitude"`
-	Latitude_2 string `json:"latitude_2"`
-	RadiusKm   string `json:"radius_km"`
-	Latitude_3 string `json:"latitude_3"`
-	Longitude_2 string `json:"longitude_2"`
-	Latitude_4 string `json:"latitude_4"`
-	Limit      int32  `json:"limit"`
-	Offset     int32  `json:"offset"`
-}
-
-func (q *Queries) ListSpotsByLocation(ctx context.Context, arg ListSpotsByLocationParams) ([]Spot, error) {
-	rows, err := q.db.QueryContext(ctx, listSpotsByLocation,
-		arg.Latitude,
-		arg.Longitude,
-		arg.Latitude_2,
-		arg.RadiusKm,
-		arg.Latitude_3,
-		arg.Longitude_2,
-		arg.Latitude_4,
-		arg.Limit,
-		arg.Offset,
-	)
+type ListSpotsParams struct {
+	Category          string   `json:"category"`
+	CountryCode       string   `json:"country_code"`
+	MinSoloScore      string   `json:"min_solo_score"`
+	SoloCategoryCount int32    `json:"solo_category_count"`
+	SoloCategories    []string `json:"solo_categories"`
+	MinConfirmations  int32    `json:"min_confirmations"`
+	Limit             int32    `json:"limit"`
+	Offset            int32    `json:"offset"`
+}
+
+func (q *Queries) ListSpots(ctx context.Context, arg ListSpotsParams) ([]Spot, error) {
+	query := listSpots
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.MinSoloScore)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	if len(arg.SoloCategories) > 0 {
+		for _, v := range arg.SoloCategories {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", strings.Repeat(",?", len(arg.SoloCategories))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.MinConfirmations)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	queryParams = append(queryParams, arg.Limit)
+	queryParams = append(queryParams, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
@@ -141,6 +292,134 @@ func (q *Queries) ListSpotsByLocation(ctx context.Context, arg ListSpotsByLocati
 			&i.ReviewCount,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.NameI18nText,
+			&i.AddressI18nText,
+			&i.CreatedBy,
+			&i.RatingSum,
+			&i.SoloFriendlyRating,
+			&i.SoloRatingCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listSpotsByLocation = `-- name: ListSpotsByLocation :many
+SELECT spots.id, spots.name, spots.name_i18n, spots.latitude, spots.longitude, spots.category, spots.address, spots.address_i18n, spots.country_code, spots.average_rating, spots.review_count, spots.created_at, spots.updated_at, spots.name_i18n_text, spots.address_i18n_text, spots.created_by, spots.rating_sum, spots.solo_friendly_rating, spots.solo_rating_count,
+       (6371 * acos(LEAST(1.0,
+           cos(radians(?)) * cos(radians(latitude)) *
+           cos(radians(longitude) - radians(?)) +
+           sin(radians(?)) * sin(radians(latitude))
+       ))) AS distance_km
+FROM spots
+WHERE latitude BETWEEN ? AND ?
+  AND longitude BETWEEN ? AND ?
+  AND (? = '' OR category = ?)
+  AND (? = '' OR country_code = ?)
+  AND solo_friendly_rating >= ?
+  AND (? = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (/*SLICE:solo_categories*/?)
+        AND confirmations >= ?
+      GROUP BY spot_id
+      HAVING COUNT(*) = ?))
+HAVING distance_km <= ?
+ORDER BY distance_km, id
+LIMIT ? OFFSET ?
+`
+
+type ListSpotsByLocationParams struct {
+	CenterLat         float64  `json:"center_lat"`
+	CenterLng         float64  `json:"center_lng"`
+	MinLat            string   `json:"min_lat"`
+	MaxLat            string   `json:"max_lat"`
+	MinLng            string   `json:"min_lng"`
+	MaxLng            string   `json:"max_lng"`
+	Category          string   `json:"category"`
+	CountryCode       string   `json:"country_code"`
+	MinSoloScore      string   `json:"min_solo_score"`
+	SoloCategoryCount int32    `json:"solo_category_count"`
+	SoloCategories    []string `json:"solo_categories"`
+	MinConfirmations  int32    `json:"min_confirmations"`
+	RadiusKm          float64  `json:"radius_km"`
+	Limit             int32    `json:"limit"`
+	Offset            int32    `json:"offset"`
+}
+
+type ListSpotsByLocationRow struct {
+	Spot       Spot    `json:"spot"`
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// ListSpotsByLocation pre-filters on a bounding box so idx_location can be used,
+// then applies the exact great-circle distance. LEAST guards acos against
+// floating point values slightly above 1 for points at the center.
+func (q *Queries) ListSpotsByLocation(ctx context.Context, arg ListSpotsByLocationParams) ([]ListSpotsByLocationRow, error) {
+	query := listSpotsByLocation
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.CenterLat)
+	queryParams = append(queryParams, arg.CenterLng)
+	queryParams = append(queryParams, arg.CenterLat)
+	queryParams = append(queryParams, arg.MinLat)
+	queryParams = append(queryParams, arg.MaxLat)
+	queryParams = append(queryParams, arg.MinLng)
+	queryParams = append(queryParams, arg.MaxLng)
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.MinSoloScore)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	if len(arg.SoloCategories) > 0 {
+		for _, v := range arg.SoloCategories {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", strings.Repeat(",?", len(arg.SoloCategories))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.MinConfirmations)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	queryParams = append(queryParams, arg.RadiusKm)
+	queryParams = append(queryParams, arg.Limit)
+	queryParams = append(queryParams, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListSpotsByLocationRow{}
+	for rows.Next() {
+		var i ListSpotsByLocationRow
+		if err := rows.Scan(
+			&i.Spot.ID,
+			&i.Spot.Name,
+			&i.Spot.NameI18n,
+			&i.Spot.Latitude,
+			&i.Spot.Longitude,
+			&i.Spot.Category,
+			&i.Spot.Address,
+			&i.Spot.AddressI18n,
+			&i.Spot.CountryCode,
+			&i.Spot.AverageRating,
+			&i.Spot.ReviewCount,
+			&i.Spot.CreatedAt,
+			&i.Spot.UpdatedAt,
+			&i.Spot.NameI18nText,
+			&i.Spot.AddressI18nText,
+			&i.Spot.CreatedBy,
+			&i.Spot.RatingSum,
+			&i.Spot.SoloFriendlyRating,
+			&i.Spot.SoloRatingCount,
+			&i.DistanceKm,
 		); err != nil {
 			return nil, err
 		}
@@ -155,85 +434,194 @@ func (q *Queries) ListSpotsByLocation(ctx context.Context, arg ListSpotsByLocati
 	return items, nil
 }
 
+const countSpots = `-- name: CountSpots :one
+SELECT COUNT(*) FROM spots
+WHERE (? = '' OR category = ?)
+  AND (? = '' OR country_code = ?)
+  AND solo_friendly_rating >= ?
+  AND (? = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (/*SLICE:solo_categories*/?)
+        AND confirmations >= ?
+      GROUP BY spot_id
+      HAVING COUNT(*) = ?))
+`
+
+type CountSpotsParams struct {
+	Category          string   `json:"category"`
+	CountryCode       string   `json:"country_code"`
+	MinSoloScore      string   `json:"min_solo_score"`
+	SoloCategoryCount int32    `json:"solo_category_count"`
+	SoloCategories    []string `json:"solo_categories"`
+	MinConfirmations  int32    `json:"min_confirmations"`
+}
+
+func (q *Queries) CountSpots(ctx context.Context, arg CountSpotsParams) (int64, error) {
+	query := countSpots
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.MinSoloScore)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	if len(arg.SoloCategories) > 0 {
+		for _, v := range arg.SoloCategories {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", strings.Repeat(",?", len(arg.SoloCategories))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.MinConfirmations)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	row := q.db.QueryRowContext(ctx, query, queryParams...)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countSpotsByLocation = `-- name: CountSpotsByLocation :one
-SELECT COUNT(*) FROM spots 
-WHERE (6371 * acos(
-    cos(radians(?)) * cos(radians(latitude)) * 
-    cos(radians(longitude) - radians(?)) + 
-    sin(radians(?)) * sin(radians(latitude))
-)) <= ?
+SELECT COUNT(*) FROM spots
+WHERE latitude BETWEEN ? AND ?
+  AND longitude BETWEEN ? AND ?
+  AND (? = '' OR category = ?)
+  AND (? = '' OR country_code = ?)
+  AND solo_friendly_rating >= ?
+  AND (? = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (/*SLICE:solo_categories*/?)
+        AND confirmations >= ?
+      GROUP BY spot_id
+      HAVING COUNT(*) = ?))
+  AND (6371 * acos(LEAST(1.0,
+      cos(radians(?)) * cos(radians(latitude)) *
+      cos(radians(longitude) - radians(?)) +
+      sin(radians(?)) * sin(radians(latitude))
+  ))) <= ?
 `
 
 type CountSpotsByLocationParams struct {
-	Latitude   string `json:"latitude"`
-	Longitude  string `json:"longitude"`
-	Latitude_2 string `json:"latitude_2"`
-	RadiusKm   string `json:"radius_km"`
+	MinLat            string   `json:"min_lat"`
+	MaxLat            string   `json:"max_lat"`
+	MinLng            string   `json:"min_lng"`
+	MaxLng            string   `json:"max_lng"`
+	Category          string   `json:"category"`
+	CountryCode       string   `json:"country_code"`
+	MinSoloScore      string   `json:"min_solo_score"`
+	SoloCategoryCount int32    `json:"solo_category_count"`
+	SoloCategories    []string `json:"solo_categories"`
+	MinConfirmations  int32    `json:"min_confirmations"`
+	CenterLat         float64  `json:"center_lat"`
+	CenterLng         float64  `json:"center_lng"`
+	RadiusKm          float64  `json:"radius_km"`
 }
 
 func (q *Queries) CountSpotsByLocation(ctx context.Context, arg CountSpotsByLocationParams) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countSpotsByLocation,
-		arg.Latitude,
-		arg.Longitude,
-		arg.Latitude_2,
-		arg.RadiusKm,
-	)
+	query := countSpotsByLocation
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.MinLat)
+	queryParams = append(queryParams, arg.MaxLat)
+	queryParams = append(queryParams, arg.MinLng)
+	queryParams = append(queryParams, arg.MaxLng)
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.MinSoloScore)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	if len(arg.SoloCategories) > 0 {
+		for _, v := range arg.SoloCategories {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", strings.Repeat(",?", len(arg.SoloCategories))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.MinConfirmations)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	queryParams = append(queryParams, arg.CenterLat)
+	queryParams = append(queryParams, arg.CenterLng)
+	queryParams = append(queryParams, arg.CenterLat)
+	queryParams = append(queryParams, arg.RadiusKm)
+	row := q.db.QueryRowContext(ctx, query, queryParams...)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
 }
 
-const searchSpots = `-- name: SearchSpots :many
-SELECT id, name, name_i18n, latitude, longitude, category, address, address_i18n, country_code, average_rating, review_count, created_at, updated_at FROM spots 
-WHERE (name LIKE ? OR address LIKE ?)
+const listSpotsInViewport = `-- name: ListSpotsInViewport :many
+SELECT id, name, name_i18n, latitude, longitude, category, address, address_i18n, country_code, average_rating, review_count, created_at, updated_at, name_i18n_text, address_i18n_text, created_by, rating_sum, solo_friendly_rating, solo_rating_count FROM spots
+WHERE latitude BETWEEN ? AND ?
+  AND (longitude BETWEEN ? AND ?
+       OR longitude BETWEEN ? AND ?)
   AND (? = '' OR category = ?)
   AND (? = '' OR country_code = ?)
-  AND (? = 0 OR (6371 * acos(
-      cos(radians(?)) * cos(radians(latitude)) * 
-      cos(radians(longitude) - radians(?)) + 
-      sin(radians(?)) * sin(radians(latitude))
-  )) <= ?)
-ORDER BY 
-  CASE WHEN name LIKE ? THEN 1 ELSE 2 END,
+  AND solo_friendly_rating >= ?
+  AND (? = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (/*SLICE:solo_categories*/?)
+        AND confirmations >= ?
+      GROUP BY spot_id
+      HAVING COUNT(*) = ?))
+ORDER BY
+  CASE WHEN ? = 'review_count' THEN review_count ELSE 0 END DESC,
   average_rating DESC,
-  review_count DESC
+  review_count DESC,
+  id
 LIMIT ? OFFSET ?
 `
 
-type SearchSpotsParams struct {
-	Name        string `json:"name"`
-	Address     string `json:"address"`
-	Category    string `json:"category"`
-	Category_2  string `json:"category_2"`
-	CountryCode string `json:"country_code"`
-	CountryCode_2 string `json:"country_code_2"`
-	RadiusKm    string `json:"radius_km"`
-	Latitude    string `json:"latitude"`
-	Longitude   string `json:"longitude"`
-	Latitude_2  string `json:"latitude_2"`
-	RadiusKm_2  string `json:"radius_km_2"`
-	Name_2      string `json:"name_2"`
-	Limit       int32  `json:"limit"`
-	Offset      int32  `json:"offset"`
-}
-
-func (q *Queries) SearchSpots(ctx context.Context, arg SearchSpotsParams) ([]Spot, error) {
-	rows, err := q.db.QueryContext(ctx, searchSpots,
-		arg.Name,
-		arg.Address,
-		arg.Category,
-		arg.Category_2,
-		arg.CountryCode,
-		arg.CountryCode_2,
-		arg.RadiusKm,
-		arg.Latitude,
-		arg.Longitude,
-		arg.Latitude_2,
-		arg.RadiusKm_2,
-		arg.Name_2,
-		arg.Limit,
-		arg.Offset,
-	)
+type ListSpotsInViewportParams struct {
+	MinLat            string   `json:"min_lat"`
+	MaxLat            string   `json:"max_lat"`
+	MinLng            string   `json:"min_lng"`
+	MaxLng            string   `json:"max_lng"`
+	WrapMinLng        string   `json:"wrap_min_lng"`
+	WrapMaxLng        string   `json:"wrap_max_lng"`
+	Category          string   `json:"category"`
+	CountryCode       string   `json:"country_code"`
+	MinSoloScore      string   `json:"min_solo_score"`
+	SoloCategoryCount int32    `json:"solo_category_count"`
+	SoloCategories    []string `json:"solo_categories"`
+	MinConfirmations  int32    `json:"min_confirmations"`
+	SortBy            string   `json:"sort_by"`
+	Limit             int32    `json:"limit"`
+	Offset            int32    `json:"offset"`
+}
+
+// ListSpotsInViewport returns spots inside a map viewport, compared directly against
+// the DECIMAL coordinate columns so idx_location applies. A viewport crossing the
+// antimeridian is passed as two longitude ranges; otherwise both ranges are the same.
+func (q *Queries) ListSpotsInViewport(ctx context.Context, arg ListSpotsInViewportParams) ([]Spot, error) {
+	query := listSpotsInViewport
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.MinLat)
+	queryParams = append(queryParams, arg.MaxLat)
+	queryParams = append(queryParams, arg.MinLng)
+	queryParams = append(queryParams, arg.MaxLng)
+	queryParams = append(queryParams, arg.WrapMinLng)
+	queryParams = append(queryParams, arg.WrapMaxLng)
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.MinSoloScore)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	if len(arg.SoloCategories) > 0 {
+		for _, v := range arg.SoloCategories {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", strings.Repeat(",?", len(arg.SoloCategories))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.MinConfirmations)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	queryParams = append(queryParams, arg.SortBy)
+	queryParams = append(queryParams, arg.Limit)
+	queryParams = append(queryParams, arg.Offset)
+	rows, err := q.db.QueryContext(ctx, query, queryParams...)
 	if err != nil {
 		return nil, err
 	}
@@ -255,6 +643,238 @@ func (q *Queries) SearchSpots(ctx context.Context, arg SearchSpotsParams) ([]Spo
 			&i.ReviewCount,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.NameI18nText,
+			&i.AddressI18nText,
+			&i.CreatedBy,
+			&i.RatingSum,
+			&i.SoloFriendlyRating,
+			&i.SoloRatingCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countSpotsInViewport = `-- name: CountSpotsInViewport :one
+SELECT COUNT(*) FROM spots
+WHERE latitude BETWEEN ? AND ?
+  AND (longitude BETWEEN ? AND ?
+       OR longitude BETWEEN ? AND ?)
+  AND (? = '' OR category = ?)
+  AND (? = '' OR country_code = ?)
+  AND solo_friendly_rating >= ?
+  AND (? = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (/*SLICE:solo_categories*/?)
+        AND confirmations >= ?
+      GROUP BY spot_id
+      HAVING COUNT(*) = ?))
+`
+
+type CountSpotsInViewportParams struct {
+	MinLat            string   `json:"min_lat"`
+	MaxLat            string   `json:"max_lat"`
+	MinLng            string   `json:"min_lng"`
+	MaxLng            string   `json:"max_lng"`
+	WrapMinLng        string   `json:"wrap_min_lng"`
+	WrapMaxLng        string   `json:"wrap_max_lng"`
+	Category          string   `json:"category"`
+	CountryCode       string   `json:"country_code"`
+	MinSoloScore      string   `json:"min_solo_score"`
+	SoloCategoryCount int32    `json:"solo_category_count"`
+	SoloCategories    []string `json:"solo_categories"`
+	MinConfirmations  int32    `json:"min_confirmations"`
+}
+
+func (q *Queries) CountSpotsInViewport(ctx context.Context, arg CountSpotsInViewportParams) (int64, error) {
+	query := countSpotsInViewport
+	var queryParams []interface{}
+	queryParams = append(queryParams, arg.MinLat)
+	queryParams = append(queryParams, arg.MaxLat)
+	queryParams = append(queryParams, arg.MinLng)
+	queryParams = append(queryParams, arg.MaxLng)
+	queryParams = append(queryParams, arg.WrapMinLng)
+	queryParams = append(queryParams, arg.WrapMaxLng)
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.Category)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.CountryCode)
+	queryParams = append(queryParams, arg.MinSoloScore)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	if len(arg.SoloCategories) > 0 {
+		for _, v := range arg.SoloCategories {
+			queryParams = append(queryParams, v)
+		}
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", strings.Repeat(",?", len(arg.SoloCategories))[1:], 1)
+	} else {
+		query = strings.Replace(query, "/*SLICE:solo_categories*/?", "NULL", 1)
+	}
+	queryParams = append(queryParams, arg.MinConfirmations)
+	queryParams = append(queryParams, arg.SoloCategoryCount)
+	row := q.db.QueryRowContext(ctx, query, queryParams...)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const reconcileSpotRatings = `-- name: ReconcileSpotRatings :execrows
+UPDATE spots s
+LEFT JOIN (
+    SELECT spot_id, COUNT(*) AS review_count, SUM(rating) AS rating_sum
+    FROM reviews
+    GROUP BY spot_id
+) r ON r.spot_id = s.id
+SET s.review_count = COALESCE(r.review_count, 0),
+    s.rating_sum = COALESCE(r.rating_sum, 0),
+    s.average_rating = COALESCE(ROUND(r.rating_sum / r.review_count, 1), 0.0)
+WHERE s.review_count <> COALESCE(r.review_count, 0)
+   OR s.rating_sum <> COALESCE(r.rating_sum, 0)
+   OR s.average_rating <> COALESCE(ROUND(r.rating_sum / r.review_count, 1), 0.0)
+`
+
+// ReconcileSpotRatings recomputes every spot's rating aggregates from reviews and
+// only touches spots whose stored values have drifted.
+func (q *Queries) ReconcileSpotRatings(ctx context.Context) (int64, error) {
+	result, err := q.db.ExecContext(ctx, reconcileSpotRatings)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const recomputeSpotRating = `-- name: RecomputeSpotRating :exec
+UPDATE spots s
+LEFT JOIN (
+    SELECT spot_id, COUNT(*) AS review_count, SUM(rating) AS rating_sum
+    FROM reviews
+    WHERE spot_id = ?
+    GROUP BY spot_id
+) r ON r.spot_id = s.id
+SET s.review_count = COALESCE(r.review_count, 0),
+    s.rating_sum = COALESCE(r.rating_sum, 0),
+    s.average_rating = COALESCE(ROUND(r.rating_sum / r.review_count, 1), 0.0),
+    s.updated_at = CURRENT_TIMESTAMP
+WHERE s.id = ?
+`
+
+// RecomputeSpotRating recomputes one spot's rating aggregates from its reviews
+func (q *Queries) RecomputeSpotRating(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, recomputeSpotRating, id, id)
+	return err
+}
+
+const searchSpots = `-- name: SearchSpots :many
+SELECT spots.id, spots.name, spots.name_i18n, spots.latitude, spots.longitude, spots.category, spots.address, spots.address_i18n, spots.country_code, spots.average_rating, spots.review_count, spots.created_at, spots.updated_at, spots.name_i18n_text, spots.address_i18n_text, spots.created_by, spots.rating_sum, spots.solo_friendly_rating, spots.solo_rating_count,
+       (MATCH(name, address, name_i18n_text, address_i18n_text) AGAINST (? IN NATURAL LANGUAGE MODE)
+        * IF(? <> ''
+             AND JSON_UNQUOTE(JSON_EXTRACT(name_i18n, CONCAT('$."', ?, '"'))) LIKE ?,
+             1.5, 1.0)) AS relevance
+FROM spots
+WHERE MATCH(name, address, name_i18n_text, address_i18n_text) AGAINST (? IN NATURAL LANGUAGE MODE)
+  AND latitude BETWEEN ? AND ?
+  AND longitude BETWEEN ? AND ?
+  AND (? = 0 OR (6371 * acos(LEAST(1.0,
+      cos(radians(?)) * cos(radians(latitude)) *
+      cos(radians(longitude) - radians(?)) +
+      sin(radians(?)) * sin(radians(latitude))
+  ))) <= ?)
+  AND (? = '' OR category = ?)
+  AND (? = '' OR country_code = ?)
+ORDER BY
+  CASE WHEN ? = 'solo_friendly_rating' THEN solo_friendly_rating ELSE 0 END DESC,
+  relevance DESC,
+  average_rating DESC,
+  review_count DESC,
+  id
+LIMIT ? OFFSET ?
+`
+
+type SearchSpotsParams struct {
+	Query       string  `json:"query"`
+	Lang        string  `json:"lang"`
+	NamePattern string  `json:"name_pattern"`
+	MinLat      string  `json:"min_lat"`
+	MaxLat      string  `json:"max_lat"`
+	MinLng      string  `json:"min_lng"`
+	MaxLng      string  `json:"max_lng"`
+	RadiusKm    float64 `json:"radius_km"`
+	CenterLat   float64 `json:"center_lat"`
+	CenterLng   float64 `json:"center_lng"`
+	Category    string  `json:"category"`
+	CountryCode string  `json:"country_code"`
+	SortBy      string  `json:"sort_by"`
+	Limit       int32   `json:"limit"`
+	Offset      int32   `json:"offset"`
+}
+
+type SearchSpotsRow struct {
+	Spot      Spot    `json:"spot"`
+	Relevance float64 `json:"relevance"`
+}
+
+// SearchSpots ranks spots by full-text relevance over the ft_spots_search index.
+// Spots whose name in the preferred language contains the query are boosted.
+// Sorting by solo_friendly_rating ranks the best solo-friendly spots first, then by relevance.
+func (q *Queries) SearchSpots(ctx context.Context, arg SearchSpotsParams) ([]SearchSpotsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchSpots,
+		arg.Query,
+		arg.Lang,
+		arg.Lang,
+		arg.NamePattern,
+		arg.Query,
+		arg.MinLat,
+		arg.MaxLat,
+		arg.MinLng,
+		arg.MaxLng,
+		arg.RadiusKm,
+		arg.CenterLat,
+		arg.CenterLng,
+		arg.CenterLat,
+		arg.RadiusKm,
+		arg.Category,
+		arg.Category,
+		arg.CountryCode,
+		arg.CountryCode,
+		arg.SortBy,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchSpotsRow{}
+	for rows.Next() {
+		var i SearchSpotsRow
+		if err := rows.Scan(
+			&i.Spot.ID,
+			&i.Spot.Name,
+			&i.Spot.NameI18n,
+			&i.Spot.Latitude,
+			&i.Spot.Longitude,
+			&i.Spot.Category,
+			&i.Spot.Address,
+			&i.Spot.AddressI18n,
+			&i.Spot.CountryCode,
+			&i.Spot.AverageRating,
+			&i.Spot.ReviewCount,
+			&i.Spot.CreatedAt,
+			&i.Spot.UpdatedAt,
+			&i.Spot.NameI18nText,
+			&i.Spot.AddressI18nText,
+			&i.Spot.CreatedBy,
+			&i.Spot.RatingSum,
+			&i.Spot.SoloFriendlyRating,
+			&i.Spot.SoloRatingCount,
+			&i.Relevance,
 		); err != nil {
 			return nil, err
 		}
@@ -269,6 +889,55 @@ func (q *Queries) SearchSpots(ctx context.Context, arg SearchSpotsParams) ([]Spo
 	return items, nil
 }
 
+const countSearchSpots = `-- name: CountSearchSpots :one
+SELECT COUNT(*) FROM spots
+WHERE MATCH(name, address, name_i18n_text, address_i18n_text) AGAINST (? IN NATURAL LANGUAGE MODE)
+  AND latitude BETWEEN ? AND ?
+  AND longitude BETWEEN ? AND ?
+  AND (? = 0 OR (6371 * acos(LEAST(1.0,
+      cos(radians(?)) * cos(radians(latitude)) *
+      cos(radians(longitude) - radians(?)) +
+      sin(radians(?)) * sin(radians(latitude))
+  ))) <= ?)
+  AND (? = '' OR category = ?)
+  AND (? = '' OR country_code = ?)
+`
+
+type CountSearchSpotsParams struct {
+	Query       string  `json:"query"`
+	MinLat      string  `json:"min_lat"`
+	MaxLat      string  `json:"max_lat"`
+	MinLng      string  `json:"min_lng"`
+	MaxLng      string  `json:"max_lng"`
+	RadiusKm    float64 `json:"radius_km"`
+	CenterLat   float64 `json:"center_lat"`
+	CenterLng   float64 `json:"center_lng"`
+	Category    string  `json:"category"`
+	CountryCode string  `json:"country_code"`
+}
+
+func (q *Queries) CountSearchSpots(ctx context.Context, arg CountSearchSpotsParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countSearchSpots,
+		arg.Query,
+		arg.MinLat,
+		arg.MaxLat,
+		arg.MinLng,
+		arg.MaxLng,
+		arg.RadiusKm,
+		arg.CenterLat,
+		arg.CenterLng,
+		arg.CenterLat,
+		arg.RadiusKm,
+		arg.Category,
+		arg.Category,
+		arg.CountryCode,
+		arg.CountryCode,
+	)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const updateSpot = `-- name: UpdateSpot :exec
 UPDATE spots 
 SET name = ?, name_i18n = ?, latitude = ?, longitude = ?, category = ?, 
@@ -303,23 +972,19 @@ func (q *Queries) UpdateSpot(ctx context.Context, arg UpdateSpotParams) error {
 	return err
 }
 
-const updateSpotRating = `-- name: UpdateSpotRating :exec
-UPDATE spots 
-SET average_rating = ?, review_count = ?, updated_at = CURRENT_TIMESTAMP
+const updateSpotSoloStats = `-- name: UpdateSpotSoloStats :exec
+UPDATE spots
+SET solo_friendly_rating = ?, solo_rating_count = ?, updated_at = CURRENT_TIMESTAMP
 WHERE id = ?
 `
 
-type UpdateSpotRatingParams struct {
-	AverageRating string `json:"average_rating"`
-	ReviewCount   int32  `json:"review_count"`
-	ID            string `json:"id"`
+type UpdateSpotSoloStatsParams struct {
+	SoloFriendlyRating string `json:"solo_friendly_rating"`
+	SoloRatingCount    int32  `json:"solo_rating_count"`
+	ID                 string `json:"id"`
 }
 
-func (q *Queries) UpdateSpotRating(ctx context.Context, arg UpdateSpotRatingParams) error {
-	_, err := q.db.ExecContext(ctx, updateSpotRating,
-		arg.AverageRating,
-		arg.ReviewCount,
-		arg.ID,
-	)
+func (q *Queries) UpdateSpotSoloStats(ctx context.Context, arg UpdateSpotSoloStatsParams) error {
+	_, err := q.db.ExecContext(ctx, updateSpotSoloStats, arg.SoloFriendlyRating, arg.SoloRatingCount, arg.ID)
 	return err
-}
\ No newline at end of file
+}
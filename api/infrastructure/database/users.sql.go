@@ -113,10 +113,21 @@ func (q *Queries) DeleteUser(ctx context.Context, id string) error {
 	return err
 }
 
+const deleteUserTokenRevocation = `-- name: DeleteUserTokenRevocation :exec
+DELETE FROM user_token_revocations WHERE user_id = ?
+`
+
+// Removes the revocation cutoff of a purged user
+func (q *Queries) DeleteUserTokenRevocation(ctx context.Context, userID string) error {
+	_, err := q.db.ExecContext(ctx, deleteUserTokenRevocation, userID)
+	return err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, name, nickname, picture, provider, provider_id, email_verified, preferences, created_at, updated_at FROM users WHERE email = ? LIMIT 1
+SELECT id, email, name, nickname, picture, provider, provider_id, email_verified, preferences, created_at, updated_at, deleted_at FROM users WHERE email = ? AND deleted_at IS NULL LIMIT 1
 `
 
+// Deleted accounts waiting to be purged are not found by email
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
 	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
 	var i User
@@ -132,13 +143,14 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Preferences,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
 
-SELECT id, email, name, nickname, picture, provider, provider_id, email_verified, preferences, created_at, updated_at FROM users WHERE id = ? LIMIT 1
+SELECT id, email, name, nickname, picture, provider, provider_id, email_verified, preferences, created_at, updated_at, deleted_at FROM users WHERE id = ? LIMIT 1
 `
 
 // User management queries for Bocchi The Map API
@@ -158,12 +170,13 @@ func (q *Queries) GetUserByID(ctx context.Context, id string) (User, error) {
 		&i.Preferences,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
 const getUserByProviderID = `-- name: GetUserByProviderID :one
-SELECT id, email, name, nickname, picture, provider, provider_id, email_verified, preferences, created_at, updated_at FROM users WHERE provider = ? AND provider_id = ? LIMIT 1
+SELECT id, email, name, nickname, picture, provider, provider_id, email_verified, preferences, created_at, updated_at, deleted_at FROM users WHERE provider = ? AND provider_id = ? LIMIT 1
 `
 
 type GetUserByProviderIDParams struct {
@@ -186,10 +199,45 @@ func (q *Queries) GetUserByProviderID(ctx context.Context, arg GetUserByProvider
 		&i.Preferences,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeletedAt,
 	)
 	return i, err
 }
 
+const getUserPreferencesForUpdate = `-- name: GetUserPreferencesForUpdate :one
+SELECT preferences FROM users WHERE id = ? FOR UPDATE
+`
+
+// Locks the user's row so concurrent preference patches are merged one after the other
+func (q *Queries) GetUserPreferencesForUpdate(ctx context.Context, id string) (json.RawMessage, error) {
+	row := q.db.QueryRowContext(ctx, getUserPreferencesForUpdate, id)
+	var preferences json.RawMessage
+	err := row.Scan(&preferences)
+	return preferences, err
+}
+
+const getUserTokensRevokedBefore = `-- name: GetUserTokensRevokedBefore :one
+SELECT revoked_before FROM user_token_revocations WHERE user_id = ? LIMIT 1
+`
+
+func (q *Queries) GetUserTokensRevokedBefore(ctx context.Context, userID string) (time.Time, error) {
+	row := q.db.QueryRowContext(ctx, getUserTokensRevokedBefore, userID)
+	var revoked_before time.Time
+	err := row.Scan(&revoked_before)
+	return revoked_before, err
+}
+
+const isEmailOfDeletedUser = `-- name: IsEmailOfDeletedUser :one
+SELECT EXISTS(SELECT 1 FROM users WHERE email = ? AND deleted_at IS NOT NULL)
+`
+
+func (q *Queries) IsEmailOfDeletedUser(ctx context.Context, email string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isEmailOfDeletedUser, email)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
 const isTokenBlacklisted = `-- name: IsTokenBlacklisted :one
 SELECT EXISTS(
     SELECT 1 FROM token_blacklist 
@@ -204,6 +252,105 @@ func (q *Queries) IsTokenBlacklisted(ctx context.Context, jti string) (bool, err
 	return is_blacklisted, err
 }
 
+const isUserDeleted = `-- name: IsUserDeleted :one
+SELECT EXISTS(SELECT 1 FROM users WHERE id = ? AND deleted_at IS NOT NULL)
+`
+
+func (q *Queries) IsUserDeleted(ctx context.Context, id string) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isUserDeleted, id)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listUsersDeletedBefore = `-- name: ListUsersDeletedBefore :many
+SELECT id FROM users
+WHERE deleted_at IS NOT NULL AND deleted_at <= ?
+ORDER BY deleted_at, id
+LIMIT ?
+`
+
+type ListUsersDeletedBeforeParams struct {
+	DeletedAt sql.NullTime `json:"deleted_at"`
+	Limit     int32        `json:"limit"`
+}
+
+func (q *Queries) ListUsersDeletedBefore(ctx context.Context, arg ListUsersDeletedBeforeParams) ([]string, error) {
+	rows, err := q.db.QueryContext(ctx, listUsersDeletedBefore, arg.DeletedAt, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUserForUpdate = `-- name: LockUserForUpdate :one
+SELECT id FROM users WHERE id = ? FOR UPDATE
+`
+
+// Locks the user's row so concurrent requests for the user are applied one after the other
+func (q *Queries) LockUserForUpdate(ctx context.Context, id string) (string, error) {
+	row := q.db.QueryRowContext(ctx, lockUserForUpdate, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const restoreUser = `-- name: RestoreUser :execrows
+UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL
+`
+
+func (q *Queries) RestoreUser(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserTokensBefore = `-- name: RevokeUserTokensBefore :exec
+INSERT INTO user_token_revocations (user_id, revoked_before)
+VALUES (?, ?)
+ON DUPLICATE KEY UPDATE revoked_before = GREATEST(revoked_before, VALUES(revoked_before))
+`
+
+type RevokeUserTokensBeforeParams struct {
+	UserID        string    `json:"user_id"`
+	RevokedBefore time.Time `json:"revoked_before"`
+}
+
+// Per-user revocation cutoff for logout-everywhere; the cutoff never moves backwards
+func (q *Queries) RevokeUserTokensBefore(ctx context.Context, arg RevokeUserTokensBeforeParams) error {
+	_, err := q.db.ExecContext(ctx, revokeUserTokensBefore, arg.UserID, arg.RevokedBefore)
+	return err
+}
+
+const softDeleteUser = `-- name: SoftDeleteUser :execrows
+UPDATE users SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL
+`
+
+// Soft delete: the account is purged once the grace period has passed
+func (q *Queries) SoftDeleteUser(ctx context.Context, id string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, softDeleteUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateUserAvatar = `-- name: UpdateUserAvatar :exec
 UPDATE users SET picture = ?, updated_at = NOW() WHERE id = ?
 `
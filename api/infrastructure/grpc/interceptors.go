@@ -0,0 +1,164 @@
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	googlegrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	ratingv1 "bocchi/api/gen/rating/v1"
+	reviewv1 "bocchi/api/gen/review/v1"
+	spotv1 "bocchi/api/gen/spot/v1"
+	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/logger"
+)
+
+// TokenValidator validates bearer tokens presented to the gRPC server
+type TokenValidator interface {
+	ValidateToken(ctx context.Context, token string) (*auth.Claims, error)
+}
+
+// APIKeyAuthenticator is implemented by validators that also accept API keys
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*auth.Claims, error)
+}
+
+// EmailVerificationChecker is implemented by validators that enforce the verified email
+// policy on writes
+type EmailVerificationChecker interface {
+	CheckEmailVerified(ctx context.Context, userID string, tokenEmailVerified bool, action string) error
+}
+
+// verifiedEmailMethods are the calls subject to the verified email policy, with the action
+// named in the error
+var verifiedEmailMethods = map[string]string{
+	spotv1.SpotService_CreateSpot_FullMethodName:           "create spots",
+	reviewv1.ReviewService_CreateReview_FullMethodName:     "write reviews",
+	ratingv1.RatingService_CreateSoloRating_FullMethodName: "rate spots",
+}
+
+// RecoveryInterceptor turns handler panics into Internal errors instead of crashing the server
+func RecoveryInterceptor() googlegrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *googlegrpc.UnaryServerInfo, handler googlegrpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.ErrorWithContextAndFields(ctx, "gRPC handler panicked", fmt.Errorf("%v", r), map[string]interface{}{
+					"method": info.FullMethod,
+					"stack":  string(debug.Stack()),
+				})
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// LoggingInterceptor logs the method, status code and duration of every call
+func LoggingInterceptor() googlegrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *googlegrpc.UnaryServerInfo, handler googlegrpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		logger.InfoWithFields("gRPC call completed", map[string]interface{}{
+			"method":      info.FullMethod,
+			"code":        status.Code(err).String(),
+			"duration_ms": time.Since(start).Milliseconds(),
+			"user_id":     errors.GetUserID(ctx),
+		})
+		return resp, err
+	}
+}
+
+// AuthInterceptor authenticates calls that carry an "authorization: Bearer <token>" header,
+// or an "x-api-key" header when the validator implements APIKeyAuthenticator.
+// Calls without credentials pass through anonymously; each service decides whether it requires a user.
+// Bearer token calls to verifiedEmailMethods are also checked against the verified email policy
+// when the validator implements EmailVerificationChecker.
+func AuthInterceptor(validator TokenValidator) googlegrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *googlegrpc.UnaryServerInfo, handler googlegrpc.UnaryHandler) (interface{}, error) {
+		token, ok := bearerTokenFromMetadata(ctx)
+		if !ok {
+			if key, found := metadataValue(ctx, "x-api-key"); found {
+				return authenticateAPIKey(ctx, req, handler, validator, key)
+			}
+			return handler(ctx, req)
+		}
+		if validator == nil {
+			return nil, status.Error(codes.Unauthenticated, "authentication is not configured")
+		}
+
+		claims, err := validator.ValidateToken(ctx, token)
+		if errors.Is(err, errors.ErrTypeUnavailable) {
+			return nil, status.Error(codes.Unavailable, "authentication is temporarily unavailable")
+		}
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+		}
+
+		if checker, ok := validator.(EmailVerificationChecker); ok {
+			if action, found := verifiedEmailMethods[info.FullMethod]; found {
+				if err := checker.CheckEmailVerified(ctx, claims.GetUserID(), claims.EmailVerified, action); err != nil {
+					return nil, errors.ToGRPCError(ctx, err, "check_email_verified")
+				}
+			}
+		}
+
+		ctx = errors.WithUserID(ctx, claims.GetUserID())
+		ctx = errors.WithPermissions(ctx, claims.Permissions)
+		ctx = auth.WithAccessToken(ctx, token)
+		return handler(ctx, req)
+	}
+}
+
+// authenticateAPIKey authenticates a call made with an API key
+func authenticateAPIKey(ctx context.Context, req interface{}, handler googlegrpc.UnaryHandler, validator TokenValidator, key string) (interface{}, error) {
+	authenticator, ok := validator.(APIKeyAuthenticator)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "API keys are not accepted")
+	}
+
+	claims, err := authenticator.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid API key")
+	}
+
+	ctx = errors.WithUserID(ctx, claims.GetUserID())
+	ctx = errors.WithPermissions(ctx, claims.Permissions)
+	ctx = auth.WithAPIKey(ctx, key)
+	return handler(ctx, req)
+}
+
+// metadataValue returns the first non-empty value of an incoming metadata key
+func metadataValue(ctx context.Context, key string) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, value := range md.Get(key) {
+		if value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// bearerTokenFromMetadata extracts the bearer token from the incoming call metadata
+func bearerTokenFromMetadata(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+	for _, value := range md.Get("authorization") {
+		if token, found := strings.CutPrefix(value, "Bearer "); found && token != "" {
+			return token, true
+		}
+	}
+	return "", false
+}
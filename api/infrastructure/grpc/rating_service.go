@@ -0,0 +1,195 @@
+package grpc
+
+import (
+	"context"
+	"database/sql"
+	stderrors "errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	ratingv1 "bocchi/api/gen/rating/v1"
+	"bocchi/api/infrastructure/database"
+	"bocchi/api/internal/application"
+	"bocchi/api/internal/domain/rating"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/logger"
+)
+
+// RatingService implements the gRPC RatingService for solo-friendly ratings
+type RatingService struct {
+	ratingv1.UnimplementedRatingServiceServer
+
+	queries *database.Queries
+	ratings *application.RatingService
+}
+
+// NewRatingService creates a new RatingService instance
+func NewRatingService(db *sql.DB) *RatingService {
+	return &RatingService{
+		queries: database.New(db),
+		ratings: newApplicationRatingService(db),
+	}
+}
+
+// newApplicationRatingService creates the application rating service on db, running
+// rating writes and their spot statistics updates in one transaction
+func newApplicationRatingService(db *sql.DB) *application.RatingService {
+	return application.NewRatingService(
+		database.NewRatingRepository(db),
+		database.NewSpotRepository(db),
+		ratingTx{db: db},
+	)
+}
+
+// ratingTx runs rating writes in a MySQL transaction
+type ratingTx struct {
+	db *sql.DB
+}
+
+// RunInTx runs fn with repositories bound to a new transaction, committing only if fn succeeds
+func (t ratingTx) RunInTx(ctx context.Context, fn func(ratingRepo application.RatingRepository, spotRepo application.SpotRepository) error) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Database("begin rating transaction", err)
+	}
+
+	if err := fn(database.NewRatingRepository(tx), database.NewSpotRepository(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logger.ErrorWithContext(ctx, "Failed to roll back rating transaction", rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Database("commit rating transaction", err)
+	}
+	return nil
+}
+
+// CreateSoloRating rates a spot for the authenticated user
+func (s *RatingService) CreateSoloRating(ctx context.Context, req *ratingv1.CreateSoloRatingRequest) (*ratingv1.CreateSoloRatingResponse, error) {
+	if req.GetSpotId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "spot_id is required")
+	}
+
+	// Extract user ID from authentication context
+	userID := errors.GetUserID(ctx)
+	if userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "user not authenticated")
+	}
+	if err := rejectDeletedCaller(ctx, s.queries); err != nil {
+		return nil, err
+	}
+
+	created, err := s.ratings.CreateRating(ctx, req.GetSpotId(), userID, int(req.GetSoloFriendlyRating()), req.GetCategories(), req.GetComment())
+	if err != nil {
+		return nil, ratingStatusError(ctx, err, "failed to create rating")
+	}
+
+	return &ratingv1.CreateSoloRatingResponse{Rating: convertRatingToGRPC(created)}, nil
+}
+
+// UpdateSoloRating updates the authenticated user's rating of a spot
+func (s *RatingService) UpdateSoloRating(ctx context.Context, req *ratingv1.UpdateSoloRatingRequest) (*ratingv1.UpdateSoloRatingResponse, error) {
+	if req.GetSpotId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "spot_id is required")
+	}
+
+	// Extract user ID from authentication context
+	userID := errors.GetUserID(ctx)
+	if userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "user not authenticated")
+	}
+	if err := rejectDeletedCaller(ctx, s.queries); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.ratings.UpdateRating(ctx, req.GetSpotId(), userID, int(req.GetSoloFriendlyRating()), req.GetCategories(), req.GetComment())
+	if err != nil {
+		return nil, ratingStatusError(ctx, err, "failed to update rating")
+	}
+
+	return &ratingv1.UpdateSoloRatingResponse{Rating: convertRatingToGRPC(updated)}, nil
+}
+
+// GetSpotSoloRatings retrieves all ratings and the solo-friendly statistics for a spot
+func (s *RatingService) GetSpotSoloRatings(ctx context.Context, req *ratingv1.GetSpotSoloRatingsRequest) (*ratingv1.GetSpotSoloRatingsResponse, error) {
+	if req.GetSpotId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "spot_id is required")
+	}
+
+	spotRatings, err := s.ratings.GetSpotRatings(ctx, req.GetSpotId())
+	if err != nil {
+		return nil, ratingStatusError(ctx, err, "failed to get spot ratings")
+	}
+
+	ratings := make([]*ratingv1.SoloRating, len(spotRatings))
+	for i, r := range spotRatings {
+		ratings[i] = convertRatingToGRPC(r)
+	}
+
+	avgRating, totalCount := s.ratings.CalculateSpotStatistics(spotRatings)
+	categoryCounts := make(map[string]int32)
+	for category, count := range s.ratings.CalculateCategoryCounts(spotRatings) {
+		categoryCounts[category] = int32(count)
+	}
+
+	return &ratingv1.GetSpotSoloRatingsResponse{
+		Ratings: ratings,
+		Statistics: &ratingv1.SoloRatingStatistics{
+			AverageRating:  avgRating,
+			TotalCount:     int32(totalCount),
+			CategoryCounts: categoryCounts,
+		},
+	}, nil
+}
+
+// GetMySoloRating retrieves the authenticated user's rating of a spot
+func (s *RatingService) GetMySoloRating(ctx context.Context, req *ratingv1.GetMySoloRatingRequest) (*ratingv1.GetMySoloRatingResponse, error) {
+	if req.GetSpotId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "spot_id is required")
+	}
+
+	// Extract user ID from authentication context
+	userID := errors.GetUserID(ctx)
+	if userID == "" {
+		return nil, status.Error(codes.Unauthenticated, "user not authenticated")
+	}
+
+	r, err := s.ratings.GetUserRating(ctx, req.GetSpotId(), userID)
+	if err != nil {
+		return nil, ratingStatusError(ctx, err, "failed to get rating")
+	}
+
+	return &ratingv1.GetMySoloRatingResponse{Rating: convertRatingToGRPC(r)}, nil
+}
+
+// ratingStatusError converts client-facing domain errors to gRPC status errors and hides everything else
+func ratingStatusError(ctx context.Context, err error, message string) error {
+	var domainErr *errors.DomainError
+	if stderrors.As(err, &domainErr) {
+		switch domainErr.Type {
+		case errors.ErrTypeNotFound, errors.ErrTypeInvalidInput, errors.ErrTypeConflict:
+			return domainErr.ToGRPCError()
+		}
+	}
+
+	logger.ErrorWithContext(ctx, message, err)
+	return status.Error(codes.Internal, message)
+}
+
+// convertRatingToGRPC converts a domain rating to the gRPC SoloRating message
+func convertRatingToGRPC(r *rating.Rating) *ratingv1.SoloRating {
+	return &ratingv1.SoloRating{
+		Id:                 r.ID,
+		SpotId:             r.SpotID,
+		UserId:             r.UserID,
+		SoloFriendlyRating: int32(r.SoloFriendlyRating),
+		Categories:         r.Categories,
+		Comment:            r.Comment,
+		CreatedAt:          timestamppb.New(r.CreatedAt),
+		UpdatedAt:          timestamppb.New(r.UpdatedAt),
+	}
+}
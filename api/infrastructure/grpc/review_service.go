@@ -4,10 +4,6 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"log"
-	"math/rand"
-	"strconv"
-	"time"
 
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
@@ -24,12 +20,16 @@ import (
 
 // ReviewService implements the gRPC ReviewService
 type ReviewService struct {
+	reviewv1.UnimplementedReviewServiceServer
+
+	db      *sql.DB
 	queries *database.Queries
 }
 
 // NewReviewService creates a new ReviewService instance
 func NewReviewService(db *sql.DB) *ReviewService {
 	return &ReviewService{
+		db:      db,
 		queries: database.New(db),
 	}
 }
@@ -50,17 +50,8 @@ func (s *ReviewService) CreateReview(ctx context.Context, req *reviewv1.CreateRe
 	if userID == "" {
 		return nil, status.Error(codes.Unauthenticated, "user not authenticated")
 	}
-
-	// Check if user already reviewed this spot
-	_, err := s.queries.GetReviewByUserAndSpot(ctx, database.GetReviewByUserAndSpotParams{
-		UserID: sql.NullString{String: userID, Valid: true},
-		SpotID: req.GetSpotId(),
-	})
-	if err == nil {
-		// User already reviewed this spot, return error
-		return nil, status.Error(codes.AlreadyExists, "user has already reviewed this spot")
-	} else if err != sql.ErrNoRows {
-		return nil, status.Error(codes.Internal, "failed to check existing review")
+	if err := rejectDeletedCaller(ctx, s.queries); err != nil {
+		return nil, err
 	}
 
 	// Generate UUID for new review
@@ -81,63 +72,50 @@ func (s *ReviewService) CreateReview(ctx context.Context, req *reviewv1.CreateRe
 		comment = sql.NullString{String: req.GetComment(), Valid: true}
 	}
 
-	// Create review in database
-	err = s.queries.CreateReview(ctx, database.CreateReviewParams{
-		ID:            reviewID,
-		SpotID:        req.GetSpotId(),
-		UserID:        sql.NullString{String: userID, Valid: true},
-		Rating:        req.GetRating(),
-		Comment:       comment,
-		RatingAspects: ratingAspectsJSON,
-	})
-	if err != nil {
-		return nil, status.Error(codes.Internal, "failed to create review")
-	}
-
-	// Update spot rating statistics asynchronously with retry logic
-	go func(parentCtx context.Context) {
-		const maxRetries = 3
-		const baseDelay = time.Second
-		
-		for attempt := 0; attempt < maxRetries; attempt++ {
-			// Create timeout context for each attempt
-			ctx, cancel := context.WithTimeout(parentCtx, 30*time.Second)
-			
-			err := s.updateSpotRating(ctx, req.GetSpotId())
-			cancel()
-			
-			if err == nil {
-				// Success - exit retry loop
-				break
-			}
-			
-			// Check if parent context was cancelled
-			if parentCtx.Err() != nil {
-				log.Printf("spot rating update cancelled for spot %s: %v", req.GetSpotId(), parentCtx.Err())
-				return
-			}
-			
-			// Log the error
-			log.Printf("failed to update spot rating for spot %s (attempt %d/%d): %v", req.GetSpotId(), attempt+1, maxRetries, err)
-			
-			// If this was the last attempt, we're done
-			if attempt == maxRetries-1 {
-				log.Printf("exhausted all retry attempts for spot rating update for spot %s", req.GetSpotId())
-				return
-			}
-			
-			// Exponential backoff with jitter
-			delay := baseDelay * time.Duration(1<<attempt)
-			jitter := time.Duration(rand.Int63n(int64(delay / 2)))
-			select {
-			case <-time.After(delay + jitter):
-				// Continue to next retry
-			case <-parentCtx.Done():
-				log.Printf("spot rating update cancelled during backoff for spot %s: %v", req.GetSpotId(), parentCtx.Err())
-				return
+	// Insert the review and update the spot statistics atomically
+	err = s.withTx(ctx, func(q *database.Queries) error {
+		// Lock the spot so concurrent reviews for it are serialized
+		if _, err := q.LockSpotForUpdate(ctx, req.GetSpotId()); err != nil {
+			if err == sql.ErrNoRows {
+				return status.Error(codes.NotFound, "spot not found")
 			}
+			return err
+		}
+
+		// Check if user already reviewed this spot
+		_, err := q.GetReviewByUserAndSpot(ctx, database.GetReviewByUserAndSpotParams{
+			UserID: sql.NullString{String: userID, Valid: true},
+			SpotID: req.GetSpotId(),
+		})
+		if err == nil {
+			// User already reviewed this spot, return error
+			return status.Error(codes.AlreadyExists, "user has already reviewed this spot")
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+
+		// Create review in database
+		err = q.CreateReview(ctx, database.CreateReviewParams{
+			ID:            reviewID,
+			SpotID:        req.GetSpotId(),
+			UserID:        sql.NullString{String: userID, Valid: true},
+			Rating:        req.GetRating(),
+			Comment:       comment,
+			RatingAspects: ratingAspectsJSON,
+		})
+		if err != nil {
+			return err
 		}
-	}(ctx)
+
+		return q.ApplySpotRatingDelta(ctx, database.ApplySpotRatingDeltaParams{
+			ID:         req.GetSpotId(),
+			SumDelta:   req.GetRating(),
+			CountDelta: 1,
+		})
+	})
+	if err != nil {
+		return nil, txError(ctx, err, "failed to create review")
+	}
 
 	// Retrieve the created review to get accurate timestamps
 	dbReview, err := s.queries.GetReviewByID(ctx, reviewID)
@@ -150,6 +128,168 @@ func (s *ReviewService) CreateReview(ctx context.Context, req *reviewv1.CreateRe
 	return &reviewv1.CreateReviewResponse{Review: review}, nil
 }
 
+// UpdateReview updates a review. Only the review's author may update it.
+func (s *ReviewService) UpdateReview(ctx context.Context, req *reviewv1.UpdateReviewRequest) (*reviewv1.UpdateReviewResponse, error) {
+	// Validate request
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+	if req.GetRating() < 1 || req.GetRating() > 5 {
+		return nil, status.Error(codes.InvalidArgument, "rating must be between 1 and 5")
+	}
+
+	// Convert rating aspects to JSON
+	ratingAspectsJSON := []byte("{}")
+	if req.GetRatingAspects() != nil {
+		var err error
+		ratingAspectsJSON, err = json.Marshal(req.GetRatingAspects())
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to marshal rating aspects")
+		}
+	}
+
+	// Convert comment to nullable string
+	var comment sql.NullString
+	if req.GetComment() != "" {
+		comment = sql.NullString{String: req.GetComment(), Valid: true}
+	}
+
+	// Update the review and apply the rating change to the spot statistics atomically
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		dbReview, err := s.lockOwnedReview(ctx, q, req.GetId())
+		if err != nil {
+			return err
+		}
+
+		err = q.UpdateReview(ctx, database.UpdateReviewParams{
+			ID:            dbReview.ID,
+			Rating:        req.GetRating(),
+			Comment:       comment,
+			RatingAspects: ratingAspectsJSON,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Only a rating change affects the spot statistics
+		if req.GetRating() == dbReview.Rating {
+			return nil
+		}
+		return q.ApplySpotRatingDelta(ctx, database.ApplySpotRatingDeltaParams{
+			ID:       dbReview.SpotID,
+			SumDelta: req.GetRating() - dbReview.Rating,
+		})
+	})
+	if err != nil {
+		return nil, txError(ctx, err, "failed to update review")
+	}
+
+	// Retrieve the updated review to get accurate timestamps
+	updated, err := s.queries.GetReviewByID(ctx, req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to retrieve updated review")
+	}
+
+	return &reviewv1.UpdateReviewResponse{Review: s.convertDatabaseReviewToGRPC(updated)}, nil
+}
+
+// DeleteReview deletes a review. Only the review's author may delete it.
+func (s *ReviewService) DeleteReview(ctx context.Context, req *reviewv1.DeleteReviewRequest) (*reviewv1.DeleteReviewResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	// Delete the review and remove its rating from the spot statistics atomically
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		dbReview, err := s.lockOwnedReview(ctx, q, req.GetId())
+		if err != nil {
+			return err
+		}
+
+		if err := q.DeleteReview(ctx, dbReview.ID); err != nil {
+			return err
+		}
+
+		return q.ApplySpotRatingDelta(ctx, database.ApplySpotRatingDeltaParams{
+			ID:         dbReview.SpotID,
+			SumDelta:   -dbReview.Rating,
+			CountDelta: -1,
+		})
+	})
+	if err != nil {
+		return nil, txError(ctx, err, "failed to delete review")
+	}
+
+	return &reviewv1.DeleteReviewResponse{Success: true}, nil
+}
+
+// lockOwnedReview locks a review within a transaction and checks that it belongs to the authenticated user.
+// The spot row is locked before the review so every review write takes its locks in the same order.
+func (s *ReviewService) lockOwnedReview(ctx context.Context, q *database.Queries, reviewID string) (database.Review, error) {
+	// Extract user ID from authentication context
+	userID := errors.GetUserID(ctx)
+	if userID == "" {
+		return database.Review{}, status.Error(codes.Unauthenticated, "user not authenticated")
+	}
+	if err := rejectDeletedCaller(ctx, q); err != nil {
+		return database.Review{}, err
+	}
+
+	dbReview, err := q.GetReviewByID(ctx, reviewID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return database.Review{}, status.Error(codes.NotFound, "review not found")
+		}
+		return database.Review{}, err
+	}
+
+	if _, err := q.LockSpotForUpdate(ctx, dbReview.SpotID); err != nil {
+		return database.Review{}, err
+	}
+
+	// Re-read under lock in case the review changed before the spot lock was taken
+	dbReview, err = q.GetReviewByIDForUpdate(ctx, reviewID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return database.Review{}, status.Error(codes.NotFound, "review not found")
+		}
+		return database.Review{}, err
+	}
+
+	if !dbReview.UserID.Valid || dbReview.UserID.String != userID {
+		return database.Review{}, status.Error(codes.PermissionDenied, "only the review author can modify this review")
+	}
+
+	return dbReview, nil
+}
+
+// withTx runs fn with queries bound to a transaction, committing only if fn succeeds
+func (s *ReviewService) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(s.queries.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logger.ErrorWithContext(ctx, "Failed to roll back review transaction", rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// txError passes gRPC status errors from a transaction through and reports anything else as internal
+func txError(ctx context.Context, err error, message string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	logger.ErrorWithContext(ctx, message, err)
+	monitoring.CaptureError(ctx, err)
+	return status.Error(codes.Internal, message)
+}
+
 // GetSpotReviews retrieves reviews for a specific spot
 func (s *ReviewService) GetSpotReviews(ctx context.Context, req *reviewv1.GetSpotReviewsRequest) (*reviewv1.GetSpotReviewsResponse, error) {
 	if req.GetSpotId() == "" {
@@ -371,36 +511,4 @@ func (s *ReviewService) getSpotStatistics(ctx context.Context, spotID string) (*
 			5: convertToInt32(stats.FiveStarCount),
 		},
 	}, nil
-}
-
-// updateSpotRating updates the spot's average rating and review count
-func (s *ReviewService) updateSpotRating(ctx context.Context, spotID string) error {
-	stats, err := s.queries.GetSpotRatingStats(ctx, spotID)
-	if err != nil {
-		logger.ErrorWithContextAndFields(ctx, "Failed to get spot rating stats", err, map[string]interface{}{
-			"spot_id": spotID,
-		})
-		monitoring.CaptureError(ctx, err)
-		return err
-	}
-
-	averageRating := "0.0"
-	if avgRating, ok := stats.AverageRating.(float64); ok {
-		averageRating = strconv.FormatFloat(avgRating, 'f', 1, 64)
-	}
-
-	// Update spot table with new rating statistics
-	err = s.queries.UpdateSpotRating(ctx, database.UpdateSpotRatingParams{
-		ID:            spotID,
-		AverageRating: averageRating,
-		ReviewCount:   int32(stats.ReviewCount),
-	})
-	if err != nil {
-		logger.ErrorWithContextAndFields(ctx, "Failed to update spot rating", err, map[string]interface{}{
-			"spot_id": spotID,
-		})
-		monitoring.CaptureError(ctx, err)
-		return err
-	}
-	return nil
 }
\ No newline at end of file
@@ -0,0 +1,35 @@
+package grpc
+
+import (
+	"database/sql"
+
+	googlegrpc "google.golang.org/grpc"
+
+	ratingv1 "bocchi/api/gen/rating/v1"
+	reviewv1 "bocchi/api/gen/review/v1"
+	spotv1 "bocchi/api/gen/spot/v1"
+	userv1 "bocchi/api/gen/user/v1"
+)
+
+// NewServer creates a gRPC server with every service registered behind the
+// recovery, auth and logging interceptors. Auth runs before logging so calls are
+// logged with the authenticated user. Extra options are applied after the defaults.
+func NewServer(db *sql.DB, validator TokenValidator, opts ...googlegrpc.ServerOption) *googlegrpc.Server {
+	serverOpts := []googlegrpc.ServerOption{
+		googlegrpc.ChainUnaryInterceptor(
+			RecoveryInterceptor(),
+			AuthInterceptor(validator),
+			LoggingInterceptor(),
+		),
+	}
+	server := googlegrpc.NewServer(append(serverOpts, opts...)...)
+
+	spotv1.RegisterSpotServiceServer(server, NewSpotService(db))
+	reviewv1.RegisterReviewServiceServer(server, NewReviewService(db))
+	users := NewUserService(db)
+	users.SetTokenValidator(validator)
+	userv1.RegisterUserServiceServer(server, users)
+	ratingv1.RegisterRatingServiceServer(server, NewRatingService(db))
+
+	return server
+}
@@ -6,7 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
-	"time"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
@@ -16,11 +17,17 @@ import (
 	commonv1 "bocchi/api/gen/common/v1"
 	spotv1 "bocchi/api/gen/spot/v1"
 	"bocchi/api/infrastructure/database"
+	"bocchi/api/internal/domain/rating"
+	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/geo"
 	"bocchi/api/pkg/logger"
 )
 
 // SpotService implements the gRPC SpotService
 type SpotService struct {
+	spotv1.UnimplementedSpotServiceServer
+
 	queries *database.Queries
 }
 
@@ -31,20 +38,51 @@ func NewSpotService(db *sql.DB) *SpotService {
 	}
 }
 
+const (
+	// defaultSearchRadiusKm is used when a center is given without a radius
+	defaultSearchRadiusKm = 5.0
+	// maxSearchRadiusKm bounds location queries to keep them index-friendly
+	maxSearchRadiusKm = 50.0
+	// maxSpotPageSize caps the number of spots returned per page
+	maxSpotPageSize = int32(100)
+	// minSearchQueryLength matches the ngram token size of the full-text index
+	minSearchQueryLength = 2
+	// maxClusterCells caps the number of clusters returned for one viewport
+	maxClusterCells = int32(1000)
+	// maxViewportSpots caps the number of spots reachable for one viewport, across all pages
+	maxViewportSpots = int32(500)
+	// maxSoloScore is the highest solo-friendly rating a spot can have
+	maxSoloScore = 5.0
+
+	viewportSortByRating  = "average_rating"
+	viewportSortByReviews = "review_count"
+
+	searchSortByRelevance = "relevance"
+	searchSortBySolo      = "solo_friendly_rating"
+)
+
 // Use Protocol Buffers generated types
 type (
-	Coordinates        = commonv1.Coordinates
-	PaginationRequest  = commonv1.PaginationRequest
-	PaginationResponse = commonv1.PaginationResponse
-	Spot               = spotv1.Spot
-	CreateSpotRequest  = spotv1.CreateSpotRequest
-	CreateSpotResponse = spotv1.CreateSpotResponse
-	GetSpotRequest     = spotv1.GetSpotRequest
-	GetSpotResponse    = spotv1.GetSpotResponse
-	ListSpotsRequest   = spotv1.ListSpotsRequest
-	ListSpotsResponse  = spotv1.ListSpotsResponse
-	SearchSpotsRequest = spotv1.SearchSpotsRequest
-	SearchSpotsResponse = spotv1.SearchSpotsResponse
+	Coordinates          = commonv1.Coordinates
+	PaginationRequest    = commonv1.PaginationRequest
+	PaginationResponse   = commonv1.PaginationResponse
+	Spot                 = spotv1.Spot
+	CreateSpotRequest    = spotv1.CreateSpotRequest
+	CreateSpotResponse   = spotv1.CreateSpotResponse
+	GetSpotRequest       = spotv1.GetSpotRequest
+	GetSpotResponse      = spotv1.GetSpotResponse
+	ListSpotsRequest     = spotv1.ListSpotsRequest
+	ListSpotsResponse    = spotv1.ListSpotsResponse
+	SearchSpotsRequest   = spotv1.SearchSpotsRequest
+	SearchSpotsResponse  = spotv1.SearchSpotsResponse
+	BoundingBox          = commonv1.BoundingBox
+	SpotCluster          = spotv1.SpotCluster
+	ClusterSpotsRequest  = spotv1.ClusterSpotsRequest
+	ClusterSpotsResponse = spotv1.ClusterSpotsResponse
+	UpdateSpotRequest    = spotv1.UpdateSpotRequest
+	UpdateSpotResponse   = spotv1.UpdateSpotResponse
+	DeleteSpotRequest    = spotv1.DeleteSpotRequest
+	DeleteSpotResponse   = spotv1.DeleteSpotResponse
 )
 
 // CreateSpot creates a new spot
@@ -65,6 +103,9 @@ func (s *SpotService) CreateSpot(ctx context.Context, req *CreateSpotRequest) (*
 	if req.CountryCode == "" {
 		return nil, status.Error(codes.InvalidArgument, "country code is required")
 	}
+	if err := rejectDeletedCaller(ctx, s.queries); err != nil {
+		return nil, err
+	}
 
 	// Generate UUID for new spot
 	spotID := uuid.New().String()
@@ -96,6 +137,9 @@ func (s *SpotService) CreateSpot(ctx context.Context, req *CreateSpotRequest) (*
 		}
 	}
 
+	// Record the authenticated user as the creator
+	creatorID := errors.GetUserID(ctx)
+
 	// Create spot in database
 	err := s.queries.CreateSpot(ctx, database.CreateSpotParams{
 		ID:          spotID,
@@ -107,6 +151,7 @@ func (s *SpotService) CreateSpot(ctx context.Context, req *CreateSpotRequest) (*
 		Address:     req.Address,
 		AddressI18n: addressI18nJSON,
 		CountryCode: req.CountryCode,
+		CreatedBy:   sql.NullString{String: creatorID, Valid: creatorID != ""},
 	})
 	if err != nil {
 		return nil, status.Error(codes.Internal, "failed to create spot")
@@ -143,74 +188,666 @@ func (s *SpotService) GetSpot(ctx context.Context, req *GetSpotRequest) (*GetSpo
 	return &GetSpotResponse{Spot: spot}, nil
 }
 
-// ListSpots lists spots with optional filters
+// UpdateSpot updates a spot. Only the creator or an admin may update it.
+func (s *SpotService) UpdateSpot(ctx context.Context, req *UpdateSpotRequest) (*UpdateSpotResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	dbSpot, err := s.getModifiableSpot(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge provided fields into the current spot
+	params := database.UpdateSpotParams{
+		ID:          dbSpot.ID,
+		Name:        dbSpot.Name,
+		NameI18n:    dbSpot.NameI18n,
+		Latitude:    dbSpot.Latitude,
+		Longitude:   dbSpot.Longitude,
+		Category:    dbSpot.Category,
+		Address:     dbSpot.Address,
+		AddressI18n: dbSpot.AddressI18n,
+		CountryCode: dbSpot.CountryCode,
+	}
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, status.Error(codes.InvalidArgument, "name cannot be empty")
+		}
+		params.Name = *req.Name
+	}
+	if req.Category != nil {
+		if *req.Category == "" {
+			return nil, status.Error(codes.InvalidArgument, "category cannot be empty")
+		}
+		params.Category = *req.Category
+	}
+	if req.Address != nil {
+		if *req.Address == "" {
+			return nil, status.Error(codes.InvalidArgument, "address cannot be empty")
+		}
+		params.Address = *req.Address
+	}
+	if len(req.NameI18N) > 0 {
+		params.NameI18n, err = json.Marshal(req.NameI18N)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to marshal name i18n")
+		}
+	}
+	if len(req.AddressI18N) > 0 {
+		params.AddressI18n, err = json.Marshal(req.AddressI18N)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "failed to marshal address i18n")
+		}
+	}
+
+	if err := s.queries.UpdateSpot(ctx, params); err != nil {
+		logger.ErrorWithContext(ctx, "Failed to update spot", err)
+		return nil, status.Error(codes.Internal, "failed to update spot")
+	}
+
+	// Retrieve the updated spot to get accurate timestamps and data
+	updated, err := s.queries.GetSpotByID(ctx, req.Id)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to retrieve updated spot")
+	}
+
+	return &UpdateSpotResponse{Spot: s.convertDatabaseSpotToGRPC(updated)}, nil
+}
+
+// DeleteSpot deletes a spot and, through the foreign key cascade, its reviews.
+// Only the creator or an admin may delete it.
+func (s *SpotService) DeleteSpot(ctx context.Context, req *DeleteSpotRequest) (*DeleteSpotResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "id is required")
+	}
+
+	if _, err := s.getModifiableSpot(ctx, req.Id); err != nil {
+		return nil, err
+	}
+
+	if err := s.queries.DeleteSpot(ctx, req.Id); err != nil {
+		logger.ErrorWithContext(ctx, "Failed to delete spot", err)
+		return nil, status.Error(codes.Internal, "failed to delete spot")
+	}
+
+	logger.InfoWithFields("Spot deleted successfully", map[string]interface{}{
+		"spot_id":      req.Id,
+		"auth_user_id": errors.GetUserID(ctx),
+	})
+
+	return &DeleteSpotResponse{Success: true}, nil
+}
+
+// getModifiableSpot loads a spot and checks that the authenticated user may modify it.
+// Spots without a recorded creator can only be modified by admins.
+func (s *SpotService) getModifiableSpot(ctx context.Context, spotID string) (database.Spot, error) {
+	authUserID := errors.GetUserID(ctx)
+	if authUserID == "" {
+		return database.Spot{}, status.Error(codes.Unauthenticated, "user not authenticated")
+	}
+	if err := rejectDeletedCaller(ctx, s.queries); err != nil {
+		return database.Spot{}, err
+	}
+
+	dbSpot, err := s.queries.GetSpotByID(ctx, spotID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return database.Spot{}, status.Error(codes.NotFound, "spot not found")
+		}
+		logger.ErrorWithContext(ctx, "Failed to get spot for modification", err)
+		return database.Spot{}, status.Error(codes.Internal, "failed to get spot")
+	}
+
+	isCreator := dbSpot.CreatedBy.Valid && dbSpot.CreatedBy.String == authUserID
+	if !isCreator && !errors.HasPermission(ctx, auth.PermissionAdminSpots) {
+		return database.Spot{}, status.Error(codes.PermissionDenied, "only the spot creator or an admin can modify this spot")
+	}
+
+	return dbSpot, nil
+}
+
+// ListSpots lists spots with optional filters.
+// When a center is given, spots are limited to the search radius and ordered by distance.
+// When a bounding box is given, spots inside the viewport are ordered by rating or review count.
 func (s *SpotService) ListSpots(ctx context.Context, req *ListSpotsRequest) (*ListSpotsResponse, error) {
-	// TODO: Implement actual listing logic
-	// For now, return dummy data
-	spot := &Spot{
-		Id:            "spot_1",
-		Name:          "Sample Cafe",
-		Coordinates:   &Coordinates{Latitude: 35.6762, Longitude: 139.6503},
-		Category:      "cafe",
-		AverageRating: 4.5,
-		ReviewCount:   10,
-		CreatedAt:     timestamppb.New(time.Now().Add(-24 * time.Hour)),
-		UpdatedAt:     timestamppb.New(time.Now()),
-	}
-
-	pagination := &PaginationResponse{
-		TotalCount: 1,
-		Page:       1,
-		PageSize:   20,
-		TotalPages: 1,
+	if req.Bbox != nil && req.Center != nil {
+		return nil, status.Error(codes.InvalidArgument, "center and bbox cannot be combined")
 	}
+
+	// Set default pagination
+	pageSize := int32(20)
+	page := int32(1)
 	if req.Pagination != nil {
-		pagination.Page = req.Pagination.Page
-		pagination.PageSize = req.Pagination.PageSize
+		if req.Pagination.PageSize > 0 {
+			pageSize = req.Pagination.PageSize
+		}
+		if req.Pagination.Page > 0 {
+			page = req.Pagination.Page
+		}
 	}
 
+	// Viewport loading may return more spots per request than regular listing
+	maxPageSize := maxSpotPageSize
+	if req.Bbox != nil {
+		maxPageSize = maxViewportSpots
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	offset := (page - 1) * pageSize
+
+	solo, err := soloFilterFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var spots []*Spot
+	var totalCount int64
+
+	switch {
+	case req.Bbox != nil:
+		spots, totalCount, err = s.listSpotsInViewport(ctx, req, solo, pageSize, offset)
+	case req.Center != nil:
+		spots, totalCount, err = s.listSpotsByLocation(ctx, req, solo, pageSize, offset)
+	default:
+		spots, totalCount, err = s.listSpotsByFilter(ctx, req, solo, pageSize, offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachSoloCategoryCounts(ctx, spots); err != nil {
+		return nil, err
+	}
+
+	// Calculate pagination
+	totalPages := (int32(totalCount) + pageSize - 1) / pageSize
+
 	return &ListSpotsResponse{
-		Spots:      []*Spot{spot},
-		Pagination: pagination,
+		Spots: spots,
+		Pagination: &PaginationResponse{
+			TotalCount: int32(totalCount),
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// listSpotsByLocation lists spots within a radius of the request center, nearest first
+func (s *SpotService) listSpotsByLocation(ctx context.Context, req *ListSpotsRequest, solo soloFilter, limit, offset int32) ([]*Spot, int64, error) {
+	if err := geo.ValidateCoordinates(req.Center.Latitude, req.Center.Longitude); err != nil {
+		return nil, 0, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	radiusKm := req.RadiusKm
+	if radiusKm <= 0 {
+		radiusKm = defaultSearchRadiusKm
+	}
+	if radiusKm > maxSearchRadiusKm {
+		return nil, 0, status.Errorf(codes.InvalidArgument, "radius must not exceed %.0f km", maxSearchRadiusKm)
+	}
+
+	box := geo.BoundingBoxAround(req.Center.Latitude, req.Center.Longitude, radiusKm)
+	minLat, maxLat := formatCoordinate(box.MinLat), formatCoordinate(box.MaxLat)
+	minLng, maxLng := formatCoordinate(box.MinLng), formatCoordinate(box.MaxLng)
+
+	rows, err := s.queries.ListSpotsByLocation(ctx, database.ListSpotsByLocationParams{
+		CenterLat:         req.Center.Latitude,
+		CenterLng:         req.Center.Longitude,
+		MinLat:            minLat,
+		MaxLat:            maxLat,
+		MinLng:            minLng,
+		MaxLng:            maxLng,
+		Category:          req.Category,
+		CountryCode:       req.CountryCode,
+		MinSoloScore:      solo.minScore,
+		SoloCategoryCount: int32(len(solo.categories)),
+		SoloCategories:    solo.categories,
+		MinConfirmations:  solo.minConfirmations,
+		RadiusKm:          radiusKm,
+		Limit:             limit,
+		Offset:            offset,
+	})
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to list spots by location", err)
+		return nil, 0, status.Error(codes.Internal, "failed to list spots")
+	}
+
+	totalCount, err := s.queries.CountSpotsByLocation(ctx, database.CountSpotsByLocationParams{
+		MinLat:            minLat,
+		MaxLat:            maxLat,
+		MinLng:            minLng,
+		MaxLng:            maxLng,
+		Category:          req.Category,
+		CountryCode:       req.CountryCode,
+		MinSoloScore:      solo.minScore,
+		SoloCategoryCount: int32(len(solo.categories)),
+		SoloCategories:    solo.categories,
+		MinConfirmations:  solo.minConfirmations,
+		CenterLat:         req.Center.Latitude,
+		CenterLng:         req.Center.Longitude,
+		RadiusKm:          radiusKm,
+	})
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to count spots by location", err)
+		return nil, 0, status.Error(codes.Internal, "failed to count spots")
+	}
+
+	spots := make([]*Spot, len(rows))
+	for i, row := range rows {
+		spots[i] = s.convertDatabaseSpotToGRPC(row.Spot)
+		spots[i].DistanceKm = row.DistanceKm
+	}
+	return spots, totalCount, nil
+}
+
+// listSpotsInViewport lists spots inside the request bounding box, best rated first
+func (s *SpotService) listSpotsInViewport(ctx context.Context, req *ListSpotsRequest, solo soloFilter, limit, offset int32) ([]*Spot, int64, error) {
+	box, err := boundingBoxFromProto(req.Bbox)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sortBy := req.SortBy
+	switch sortBy {
+	case "":
+		sortBy = viewportSortByRating
+	case viewportSortByRating, viewportSortByReviews:
+	default:
+		return nil, 0, status.Errorf(codes.InvalidArgument, "sort_by must be %s or %s", viewportSortByRating, viewportSortByReviews)
+	}
+
+	// The result cap covers the whole viewport, so paging cannot reach past it
+	switch {
+	case offset >= maxViewportSpots:
+		limit = 0
+	case offset+limit > maxViewportSpots:
+		limit = maxViewportSpots - offset
+	}
+
+	minLng, maxLng, wrapMinLng, wrapMaxLng := longitudeRangeParams(box)
+	var dbSpots []database.Spot
+	if limit > 0 {
+		dbSpots, err = s.queries.ListSpotsInViewport(ctx, database.ListSpotsInViewportParams{
+			MinLat:            formatCoordinate(box.MinLat),
+			MaxLat:            formatCoordinate(box.MaxLat),
+			MinLng:            minLng,
+			MaxLng:            maxLng,
+			WrapMinLng:        wrapMinLng,
+			WrapMaxLng:        wrapMaxLng,
+			Category:          req.Category,
+			CountryCode:       req.CountryCode,
+			MinSoloScore:      solo.minScore,
+			SoloCategoryCount: int32(len(solo.categories)),
+			SoloCategories:    solo.categories,
+			MinConfirmations:  solo.minConfirmations,
+			SortBy:            sortBy,
+			Limit:             limit,
+			Offset:            offset,
+		})
+		if err != nil {
+			logger.ErrorWithContext(ctx, "Failed to list spots in viewport", err)
+			return nil, 0, status.Error(codes.Internal, "failed to list spots")
+		}
+	}
+
+	totalCount, err := s.queries.CountSpotsInViewport(ctx, database.CountSpotsInViewportParams{
+		MinLat:            formatCoordinate(box.MinLat),
+		MaxLat:            formatCoordinate(box.MaxLat),
+		MinLng:            minLng,
+		MaxLng:            maxLng,
+		WrapMinLng:        wrapMinLng,
+		WrapMaxLng:        wrapMaxLng,
+		Category:          req.Category,
+		CountryCode:       req.CountryCode,
+		MinSoloScore:      solo.minScore,
+		SoloCategoryCount: int32(len(solo.categories)),
+		SoloCategories:    solo.categories,
+		MinConfirmations:  solo.minConfirmations,
+	})
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to count spots in viewport", err)
+		return nil, 0, status.Error(codes.Internal, "failed to count spots")
+	}
+	if totalCount > int64(maxViewportSpots) {
+		totalCount = int64(maxViewportSpots)
+	}
+
+	spots := make([]*Spot, len(dbSpots))
+	for i, dbSpot := range dbSpots {
+		spots[i] = s.convertDatabaseSpotToGRPC(dbSpot)
+	}
+	return spots, totalCount, nil
+}
+
+// listSpotsByFilter lists spots by category and country, newest first
+func (s *SpotService) listSpotsByFilter(ctx context.Context, req *ListSpotsRequest, solo soloFilter, limit, offset int32) ([]*Spot, int64, error) {
+	dbSpots, err := s.queries.ListSpots(ctx, database.ListSpotsParams{
+		Category:          req.Category,
+		CountryCode:       req.CountryCode,
+		MinSoloScore:      solo.minScore,
+		SoloCategoryCount: int32(len(solo.categories)),
+		SoloCategories:    solo.categories,
+		MinConfirmations:  solo.minConfirmations,
+		Limit:             limit,
+		Offset:            offset,
+	})
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to list spots", err)
+		return nil, 0, status.Error(codes.Internal, "failed to list spots")
+	}
+
+	totalCount, err := s.queries.CountSpots(ctx, database.CountSpotsParams{
+		Category:          req.Category,
+		CountryCode:       req.CountryCode,
+		MinSoloScore:      solo.minScore,
+		SoloCategoryCount: int32(len(solo.categories)),
+		SoloCategories:    solo.categories,
+		MinConfirmations:  solo.minConfirmations,
+	})
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to count spots", err)
+		return nil, 0, status.Error(codes.Internal, "failed to count spots")
+	}
+
+	spots := make([]*Spot, len(dbSpots))
+	for i, dbSpot := range dbSpots {
+		spots[i] = s.convertDatabaseSpotToGRPC(dbSpot)
+	}
+	return spots, totalCount, nil
+}
+
+// soloFilter holds the validated solo-friendly filters shared by every listing mode
+type soloFilter struct {
+	minScore         string
+	categories       []string
+	minConfirmations int32
+}
+
+// soloFilterFromRequest validates the solo-friendly filters of a list request.
+// Categories are deduplicated because the query requires a match for each one.
+func soloFilterFromRequest(req *ListSpotsRequest) (soloFilter, error) {
+	if req.MinSoloScore < 0 || req.MinSoloScore > maxSoloScore {
+		return soloFilter{}, status.Errorf(codes.InvalidArgument, "min_solo_score must be between 0 and %.0f", maxSoloScore)
+	}
+	if req.MinConfirmations < 0 {
+		return soloFilter{}, status.Error(codes.InvalidArgument, "min_confirmations must not be negative")
+	}
+
+	seen := make(map[string]bool, len(req.SoloCategories))
+	categories := make([]string, 0, len(req.SoloCategories))
+	for _, category := range req.SoloCategories {
+		if !rating.ValidCategories[category] {
+			return soloFilter{}, status.Errorf(codes.InvalidArgument, "invalid solo category: %s", category)
+		}
+		if !seen[category] {
+			seen[category] = true
+			categories = append(categories, category)
+		}
+	}
+
+	minConfirmations := req.MinConfirmations
+	if minConfirmations == 0 {
+		minConfirmations = 1
+	}
+
+	return soloFilter{
+		minScore:         strconv.FormatFloat(req.MinSoloScore, 'f', 1, 64),
+		categories:       categories,
+		minConfirmations: minConfirmations,
 	}, nil
 }
 
-// SearchSpots searches spots by query
+// attachSoloCategoryCounts fills in the per-category confirmation counts of listed spots
+func (s *SpotService) attachSoloCategoryCounts(ctx context.Context, spots []*Spot) error {
+	if len(spots) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(spots))
+	byID := make(map[string]*Spot, len(spots))
+	for i, spot := range spots {
+		ids[i] = spot.Id
+		byID[spot.Id] = spot
+	}
+
+	rows, err := s.queries.ListSpotSoloCategories(ctx, ids)
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to list spot solo categories", err)
+		return status.Error(codes.Internal, "failed to list spots")
+	}
+	for _, row := range rows {
+		spot, ok := byID[row.SpotID]
+		if !ok {
+			continue
+		}
+		if spot.SoloCategoryCounts == nil {
+			spot.SoloCategoryCounts = make(map[string]int32)
+		}
+		spot.SoloCategoryCounts[row.Category] = row.Confirmations
+	}
+	return nil
+}
+
+// SearchSpots searches spots by full-text query across names and addresses in every language.
+// Results are ordered by relevance, boosting spots whose name matches in the requested language,
+// or by solo-friendly rating first when requested.
 func (s *SpotService) SearchSpots(ctx context.Context, req *SearchSpotsRequest) (*SearchSpotsResponse, error) {
-	if req.Query == "" {
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
 		return nil, status.Error(codes.InvalidArgument, "query is required")
 	}
-
-	// TODO: Implement actual search logic
-	// For now, return dummy data similar to ListSpots
-	spot := &Spot{
-		Id:            "spot_search_1",
-		Name:          fmt.Sprintf("Search Result for: %s", req.Query),
-		Coordinates:   &Coordinates{Latitude: 35.6762, Longitude: 139.6503},
-		Category:      "cafe",
-		AverageRating: 4.5,
-		ReviewCount:   10,
-		CreatedAt:     timestamppb.New(time.Now().Add(-24 * time.Hour)),
-		UpdatedAt:     timestamppb.New(time.Now()),
+	// The ngram parser indexes two-character tokens, so shorter queries never match
+	if utf8.RuneCountInString(query) < minSearchQueryLength {
+		return nil, status.Errorf(codes.InvalidArgument, "query must be at least %d characters", minSearchQueryLength)
 	}
 
-	pagination := &PaginationResponse{
-		TotalCount: 1,
-		Page:       1,
-		PageSize:   20,
-		TotalPages: 1,
+	sortBy := req.SortBy
+	switch sortBy {
+	case "":
+		sortBy = searchSortByRelevance
+	case searchSortByRelevance, searchSortBySolo:
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "sort_by must be %s or %s", searchSortByRelevance, searchSortBySolo)
 	}
+
+	// Set default pagination
+	pageSize := int32(20)
+	page := int32(1)
 	if req.Pagination != nil {
-		pagination.Page = req.Pagination.Page
-		pagination.PageSize = req.Pagination.PageSize
+		if req.Pagination.PageSize > 0 {
+			pageSize = req.Pagination.PageSize
+		}
+		if req.Pagination.Page > 0 {
+			page = req.Pagination.Page
+		}
+	}
+	if pageSize > maxSpotPageSize {
+		pageSize = maxSpotPageSize
 	}
+	offset := (page - 1) * pageSize
+
+	// Without a center (or radius) the whole globe is searched
+	box := geo.BoundingBox{MinLat: -90, MinLng: -180, MaxLat: 90, MaxLng: 180}
+	var centerLat, centerLng, radiusKm float64
+	if req.Center != nil {
+		if err := geo.ValidateCoordinates(req.Center.Latitude, req.Center.Longitude); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if req.RadiusKm < 0 || req.RadiusKm > maxSearchRadiusKm {
+			return nil, status.Errorf(codes.InvalidArgument, "radius must be between 0 and %.0f km", maxSearchRadiusKm)
+		}
+		centerLat, centerLng, radiusKm = req.Center.Latitude, req.Center.Longitude, req.RadiusKm
+		if radiusKm > 0 {
+			box = geo.BoundingBoxAround(centerLat, centerLng, radiusKm)
+		}
+	}
+	minLat, maxLat := formatCoordinate(box.MinLat), formatCoordinate(box.MaxLat)
+	minLng, maxLng := formatCoordinate(box.MinLng), formatCoordinate(box.MaxLng)
+
+	rows, err := s.queries.SearchSpots(ctx, database.SearchSpotsParams{
+		Query:       query,
+		Lang:        languageCode(req.Language),
+		NamePattern: "%" + escapeLikePattern(query) + "%",
+		MinLat:      minLat,
+		MaxLat:      maxLat,
+		MinLng:      minLng,
+		MaxLng:      maxLng,
+		RadiusKm:    radiusKm,
+		CenterLat:   centerLat,
+		CenterLng:   centerLng,
+		Category:    req.Category,
+		CountryCode: req.CountryCode,
+		SortBy:      sortBy,
+		Limit:       pageSize,
+		Offset:      offset,
+	})
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to search spots", err)
+		return nil, status.Error(codes.Internal, "failed to search spots")
+	}
+
+	totalCount, err := s.queries.CountSearchSpots(ctx, database.CountSearchSpotsParams{
+		Query:       query,
+		MinLat:      minLat,
+		MaxLat:      maxLat,
+		MinLng:      minLng,
+		MaxLng:      maxLng,
+		RadiusKm:    radiusKm,
+		CenterLat:   centerLat,
+		CenterLng:   centerLng,
+		Category:    req.Category,
+		CountryCode: req.CountryCode,
+	})
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to count search results", err)
+		return nil, status.Error(codes.Internal, "failed to count search results")
+	}
+
+	spots := make([]*Spot, len(rows))
+	for i, row := range rows {
+		spots[i] = s.convertDatabaseSpotToGRPC(row.Spot)
+		spots[i].Relevance = row.Relevance
+		if req.Center != nil {
+			spots[i].DistanceKm = geo.DistanceKm(centerLat, centerLng,
+				spots[i].Coordinates.Latitude, spots[i].Coordinates.Longitude)
+		}
+	}
+
+	// Calculate pagination
+	totalPages := (int32(totalCount) + pageSize - 1) / pageSize
 
 	return &SearchSpotsResponse{
-		Spots:      []*Spot{spot},
-		Pagination: pagination,
+		Spots: spots,
+		Pagination: &PaginationResponse{
+			TotalCount: int32(totalCount),
+			Page:       page,
+			PageSize:   pageSize,
+			TotalPages: totalPages,
+		},
+	}, nil
+}
+
+// ClusterSpots aggregates spots inside a bounding box into grid cells sized for the zoom level
+func (s *SpotService) ClusterSpots(ctx context.Context, req *ClusterSpotsRequest) (*ClusterSpotsResponse, error) {
+	if req.Bbox == nil {
+		return nil, status.Error(codes.InvalidArgument, "bbox is required")
+	}
+	if req.Zoom < 0 || req.Zoom > geo.MaxZoom {
+		return nil, status.Errorf(codes.InvalidArgument, "zoom must be between 0 and %d", geo.MaxZoom)
+	}
+
+	box, err := boundingBoxFromProto(req.Bbox)
+	if err != nil {
+		return nil, err
+	}
+
+	cellSize := geo.GridCellSize(int(req.Zoom))
+	minLng, maxLng, wrapMinLng, wrapMaxLng := longitudeRangeParams(box)
+	rows, err := s.queries.ClusterSpotsInBounds(ctx, database.ClusterSpotsInBoundsParams{
+		CellSize:    cellSize,
+		MinLat:      formatCoordinate(box.MinLat),
+		MaxLat:      formatCoordinate(box.MaxLat),
+		MinLng:      minLng,
+		MaxLng:      maxLng,
+		WrapMinLng:  wrapMinLng,
+		WrapMaxLng:  wrapMaxLng,
+		Category:    req.Category,
+		CountryCode: req.CountryCode,
+		Limit:       maxClusterCells,
+	})
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to cluster spots", err)
+		return nil, status.Error(codes.Internal, "failed to cluster spots")
+	}
+
+	clusters := make([]*SpotCluster, len(rows))
+	for i, row := range rows {
+		clusters[i] = &SpotCluster{
+			Id:       fmt.Sprintf("%d/%d/%d", req.Zoom, row.CellRow, row.CellCol),
+			Centroid: &Coordinates{Latitude: row.CentroidLat, Longitude: row.CentroidLng},
+			Count:    int32(row.SpotCount),
+		}
+		if row.AverageRating.Valid {
+			clusters[i].AverageRating = row.AverageRating.Float64
+		}
+	}
+
+	return &ClusterSpotsResponse{
+		Clusters:        clusters,
+		CellSizeDegrees: cellSize,
 	}, nil
 }
 
+// languageCode converts the protobuf language enum to the key used in i18n JSON columns
+func languageCode(lang commonv1.Language) string {
+	switch lang {
+	case commonv1.Language_LANGUAGE_JA:
+		return "ja"
+	case commonv1.Language_LANGUAGE_EN:
+		return "en"
+	default:
+		return ""
+	}
+}
+
+// escapeLikePattern escapes LIKE wildcards so user input is matched literally
+func escapeLikePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+	return replacer.Replace(value)
+}
+
+// boundingBoxFromProto converts and validates a protobuf bounding box
+func boundingBoxFromProto(bbox *BoundingBox) (geo.BoundingBox, error) {
+	box := geo.BoundingBox{
+		MinLat: bbox.MinLatitude,
+		MinLng: bbox.MinLongitude,
+		MaxLat: bbox.MaxLatitude,
+		MaxLng: bbox.MaxLongitude,
+	}
+	if err := box.Validate(); err != nil {
+		return box, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return box, nil
+}
+
+// longitudeRangeParams returns the two longitude ranges expected by viewport queries.
+// Boxes that do not cross the antimeridian repeat their single range.
+func longitudeRangeParams(box geo.BoundingBox) (minLng, maxLng, wrapMinLng, wrapMaxLng string) {
+	ranges := box.LongitudeRanges()
+	wrap := ranges[len(ranges)-1]
+	return formatCoordinate(ranges[0][0]), formatCoordinate(ranges[0][1]),
+		formatCoordinate(wrap[0]), formatCoordinate(wrap[1])
+}
+
+// formatCoordinate formats a coordinate for comparison with the DECIMAL coordinate columns
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', 8, 64)
+}
+
 // convertDatabaseSpotToGRPC converts database spot model to gRPC spot struct
 func (s *SpotService) convertDatabaseSpotToGRPC(dbSpot database.Spot) *Spot {
 	// Parse coordinates from strings with error handling
@@ -241,6 +878,15 @@ func (s *SpotService) convertDatabaseSpotToGRPC(dbSpot database.Spot) *Spot {
 		averageRating = 0.0 // Use default value for invalid rating
 	}
 
+	soloFriendlyRating, err := strconv.ParseFloat(dbSpot.SoloFriendlyRating, 64)
+	if err != nil {
+		logger.ErrorWithFields("Failed to parse solo-friendly rating", err, map[string]interface{}{
+			"spot_id":      dbSpot.ID,
+			"rating_value": dbSpot.SoloFriendlyRating,
+		})
+		soloFriendlyRating = 0.0 // Use default value for invalid rating
+	}
+
 	// Parse i18n JSON fields with error handling
 	var nameI18n map[string]string
 	if len(dbSpot.NameI18n) > 0 {
@@ -278,7 +924,11 @@ func (s *SpotService) convertDatabaseSpotToGRPC(dbSpot database.Spot) *Spot {
 		CountryCode:   dbSpot.CountryCode,
 		AverageRating: averageRating,
 		ReviewCount:   dbSpot.ReviewCount,
+		CreatedBy:     dbSpot.CreatedBy.String,
 		CreatedAt:     timestamppb.New(dbSpot.CreatedAt),
 		UpdatedAt:     timestamppb.New(dbSpot.UpdatedAt),
+
+		SoloFriendlyRating: soloFriendlyRating,
+		SoloRatingCount:    dbSpot.SoloRatingCount,
 	}
-}
\ No newline at end of file
+}
@@ -3,44 +3,73 @@ package grpc
 import (
 	"context"
 	"database/sql"
-	"encoding/json"
+	stdErrors "errors"
+	"sort"
+	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
+	"bocchi/api/domain/entities"
 	"bocchi/api/gen/user/v1"
 	"bocchi/api/infrastructure/database"
+	"bocchi/api/internal/application"
+	"bocchi/api/pkg/auth"
 	"bocchi/api/pkg/errors"
 	"bocchi/api/pkg/logger"
+	"bocchi/api/pkg/preferences"
 )
 
 // UserService implements the gRPC UserService
 type UserService struct {
+	userv1.UnimplementedUserServiceServer
+
+	db      *sql.DB
 	queries *database.Queries
+	tokens  TokenValidator // validates the tokens of identities to link
 }
 
 // NewUserService creates a new UserService instance
 func NewUserService(db *sql.DB) *UserService {
 	return &UserService{
+		db:      db,
 		queries: database.New(db),
 	}
 }
 
+// SetTokenValidator sets the validator for identity tokens presented to LinkIdentity.
+// Without one, identities cannot be linked.
+func (s *UserService) SetTokenValidator(validator TokenValidator) {
+	s.tokens = validator
+}
+
 // Use Protocol Buffers generated types
 type (
-	User                   = userv1.User
-	GetUserRequest         = userv1.GetUserRequest
-	GetUserResponse        = userv1.GetUserResponse
-	GetUserByEmailRequest  = userv1.GetUserByEmailRequest
-	GetUserByEmailResponse = userv1.GetUserByEmailResponse
-	CreateUserRequest      = userv1.CreateUserRequest
-	CreateUserResponse     = userv1.CreateUserResponse
-	UpdateUserRequest      = userv1.UpdateUserRequest
-	UpdateUserResponse     = userv1.UpdateUserResponse
-	DeleteUserRequest      = userv1.DeleteUserRequest
-	DeleteUserResponse     = userv1.DeleteUserResponse
+	User                          = userv1.User
+	GetUserRequest                = userv1.GetUserRequest
+	GetUserResponse               = userv1.GetUserResponse
+	GetUserByEmailRequest         = userv1.GetUserByEmailRequest
+	GetUserByEmailResponse        = userv1.GetUserByEmailResponse
+	CreateUserRequest             = userv1.CreateUserRequest
+	CreateUserResponse            = userv1.CreateUserResponse
+	UpdateUserRequest             = userv1.UpdateUserRequest
+	UpdateUserResponse            = userv1.UpdateUserResponse
+	UpdateUserPreferencesRequest  = userv1.UpdateUserPreferencesRequest
+	UpdateUserPreferencesResponse = userv1.UpdateUserPreferencesResponse
+	DeleteUserRequest             = userv1.DeleteUserRequest
+	DeleteUserResponse            = userv1.DeleteUserResponse
+	RestoreUserRequest            = userv1.RestoreUserRequest
+	RestoreUserResponse           = userv1.RestoreUserResponse
+	Identity                      = userv1.Identity
+	ListIdentitiesRequest         = userv1.ListIdentitiesRequest
+	ListIdentitiesResponse        = userv1.ListIdentitiesResponse
+	LinkIdentityRequest           = userv1.LinkIdentityRequest
+	LinkIdentityResponse          = userv1.LinkIdentityResponse
+	UnlinkIdentityRequest         = userv1.UnlinkIdentityRequest
+	UnlinkIdentityResponse        = userv1.UnlinkIdentityResponse
 )
 
 // GetUser retrieves a user by ID
@@ -59,6 +88,11 @@ func (s *UserService) GetUser(ctx context.Context, req *GetUserRequest) (*GetUse
 		return nil, status.Error(codes.Internal, "failed to get user")
 	}
 
+	// Deleted accounts are only visible to their owner and admins until they are purged
+	if dbUser.DeletedAt.Valid && errors.GetUserID(ctx) != dbUser.ID && !errors.HasPermission(ctx, auth.PermissionAdminUsers) {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
 	// Convert database user to gRPC response
 	user := s.convertDatabaseUserToGRPC(dbUser)
 	return &GetUserResponse{User: user}, nil
@@ -113,17 +147,15 @@ func (s *UserService) CreateUser(ctx context.Context, req *CreateUserRequest) (*
 	// Generate UUID for new user
 	userID := uuid.New().String()
 
-	// Convert preferences to JSON (for proto string field)
-	var preferencesJSON []byte
-	if req.GetPreferences() != "" {
-		// Validate that it's valid JSON
-		var temp interface{}
-		if err := json.Unmarshal([]byte(req.GetPreferences()), &temp); err != nil {
-			return nil, status.Error(codes.InvalidArgument, "preferences must be valid JSON")
-		}
-		preferencesJSON = []byte(req.GetPreferences())
-	} else {
-		preferencesJSON = []byte("{}")
+	// Validate preferences against the preferences schema; missing fields take their defaults
+	prefs, err := preferences.Parse([]byte(req.GetPreferences()))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	preferencesJSON, err := preferences.Encode(nil, prefs)
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to encode user preferences", err)
+		return nil, status.Error(codes.Internal, "failed to create user")
 	}
 
 	// Convert avatar URL to nullable string
@@ -158,6 +190,10 @@ func (s *UserService) CreateUser(ctx context.Context, req *CreateUserRequest) (*
 		Preferences:   preferencesJSON,
 	})
 	if err != nil {
+		// The email can still belong to a deleted account waiting to be purged
+		if isDuplicateKey(err) {
+			return nil, status.Error(codes.AlreadyExists, "user with this email already exists")
+		}
 		logger.ErrorWithContext(ctx, "Failed to create user", err)
 		return nil, status.Error(codes.Internal, "failed to create user")
 	}
@@ -180,8 +216,12 @@ func (s *UserService) UpdateUser(ctx context.Context, req *UpdateUserRequest) (*
 		return nil, status.Error(codes.InvalidArgument, "user ID is required")
 	}
 
+	if err := rejectDeletedCaller(ctx, s.queries); err != nil {
+		return nil, err
+	}
+
 	// Get current user to verify it exists
-	_, err := s.queries.GetUserByID(ctx, req.GetId())
+	currentUser, err := s.queries.GetUserByID(ctx, req.GetId())
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, status.Error(codes.NotFound, "user not found")
@@ -196,7 +236,7 @@ func (s *UserService) UpdateUser(ctx context.Context, req *UpdateUserRequest) (*
 		if req.GetAvatarUrl() != "" {
 			avatarUrl = sql.NullString{String: req.GetAvatarUrl(), Valid: true}
 		}
-		
+
 		err = s.queries.UpdateUserAvatar(ctx, database.UpdateUserAvatarParams{
 			ID:      req.GetId(),
 			Picture: avatarUrl,
@@ -207,17 +247,21 @@ func (s *UserService) UpdateUser(ctx context.Context, req *UpdateUserRequest) (*
 		}
 	}
 
-	// Update preferences if provided
+	// Replace preferences if provided; keys the stored document has from a newer release are kept
 	if req.GetPreferences() != "" {
-		// Validate that it's valid JSON
-		var temp interface{}
-		if err := json.Unmarshal([]byte(req.GetPreferences()), &temp); err != nil {
-			return nil, status.Error(codes.InvalidArgument, "preferences must be valid JSON")
+		prefs, err := preferences.Parse([]byte(req.GetPreferences()))
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		preferencesJSON, err := preferences.Encode(currentUser.Preferences, prefs)
+		if err != nil {
+			logger.ErrorWithContext(ctx, "Failed to encode user preferences", err)
+			return nil, status.Error(codes.Internal, "failed to update user preferences")
 		}
 
 		err = s.queries.UpdateUserPreferences(ctx, database.UpdateUserPreferencesParams{
 			ID:          req.GetId(),
-			Preferences: []byte(req.GetPreferences()),
+			Preferences: preferencesJSON,
 		})
 		if err != nil {
 			logger.ErrorWithContext(ctx, "Failed to update user preferences", err)
@@ -237,7 +281,63 @@ func (s *UserService) UpdateUser(ctx context.Context, req *UpdateUserRequest) (*
 	return &UpdateUserResponse{User: user}, nil
 }
 
-// DeleteUser deletes a user (hard deletion)
+// UpdateUserPreferences merges a patch into a user's preferences. Fields missing from
+// the patch keep their values.
+func (s *UserService) UpdateUserPreferences(ctx context.Context, req *UpdateUserPreferencesRequest) (*UpdateUserPreferencesResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user ID is required")
+	}
+
+	// Extract authenticated user ID from context
+	authUserID := errors.GetUserID(ctx)
+	if authUserID == "" {
+		return nil, status.Error(codes.Unauthenticated, "user not authenticated")
+	}
+
+	// Check if user is updating themselves or has admin permissions
+	if authUserID != req.GetId() && !errors.HasPermission(ctx, auth.PermissionAdminUsers) {
+		return nil, status.Error(codes.PermissionDenied, "insufficient permissions to update user preferences")
+	}
+	if err := rejectDeletedCaller(ctx, s.queries); err != nil {
+		return nil, err
+	}
+
+	var dbUser database.User
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		stored, err := q.GetUserPreferencesForUpdate(ctx, req.GetId())
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return status.Error(codes.NotFound, "user not found")
+			}
+			return err
+		}
+
+		_, merged, err := preferences.Merge(stored, []byte(req.GetPatch()))
+		if err != nil {
+			if errors.Is(err, errors.ErrTypeInvalidInput) {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+			return err
+		}
+		if err := q.UpdateUserPreferences(ctx, database.UpdateUserPreferencesParams{
+			ID:          req.GetId(),
+			Preferences: merged,
+		}); err != nil {
+			return err
+		}
+
+		dbUser, err = q.GetUserByID(ctx, req.GetId())
+		return err
+	})
+	if err != nil {
+		return nil, txError(ctx, err, "failed to update user preferences")
+	}
+
+	return &UpdateUserPreferencesResponse{User: s.convertDatabaseUserToGRPC(dbUser)}, nil
+}
+
+// DeleteUser deletes a user and revokes the user's tokens; the account is kept until the
+// deletion grace period ends and cannot make changes until it is restored
 func (s *UserService) DeleteUser(ctx context.Context, req *DeleteUserRequest) (*DeleteUserResponse, error) {
 	if req.GetId() == "" {
 		return nil, status.Error(codes.InvalidArgument, "user ID is required")
@@ -250,8 +350,7 @@ func (s *UserService) DeleteUser(ctx context.Context, req *DeleteUserRequest) (*
 	}
 
 	// Check if user is trying to delete themselves or has admin permissions
-	if authUserID != req.GetId() {
-		// TODO: Add admin permission check here
+	if authUserID != req.GetId() && !errors.HasPermission(ctx, auth.PermissionAdminUsers) {
 		return nil, status.Error(codes.PermissionDenied, "insufficient permissions to delete user")
 	}
 
@@ -265,8 +364,19 @@ func (s *UserService) DeleteUser(ctx context.Context, req *DeleteUserRequest) (*
 		return nil, status.Error(codes.Internal, "failed to get user")
 	}
 
-	// Delete user (CASCADE will handle related reviews)
-	err = s.queries.DeleteUser(ctx, req.GetId())
+	// Soft delete; the purge job removes the account once the grace period has passed.
+	// Deleting an account that is already deleted keeps the original deletion time.
+	// Every token issued so far is revoked, as by logout-all; the cutoff is compared
+	// with iat, which has one-second resolution.
+	err = s.withTx(ctx, func(q *database.Queries) error {
+		if _, err := q.SoftDeleteUser(ctx, req.GetId()); err != nil {
+			return err
+		}
+		return q.RevokeUserTokensBefore(ctx, database.RevokeUserTokensBeforeParams{
+			UserID:        req.GetId(),
+			RevokedBefore: time.Now().Truncate(time.Second),
+		})
+	})
 	if err != nil {
 		logger.ErrorWithContext(ctx, "Failed to delete user", err)
 		return nil, status.Error(codes.Internal, "failed to delete user")
@@ -280,24 +390,169 @@ func (s *UserService) DeleteUser(ctx context.Context, req *DeleteUserRequest) (*
 	return &DeleteUserResponse{Success: true}, nil
 }
 
+// RestoreUser cancels the deletion of a user that has not been purged yet
+func (s *UserService) RestoreUser(ctx context.Context, req *RestoreUserRequest) (*RestoreUserResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user ID is required")
+	}
+
+	// Extract authenticated user ID from context
+	authUserID := errors.GetUserID(ctx)
+	if authUserID == "" {
+		return nil, status.Error(codes.Unauthenticated, "user not authenticated")
+	}
+
+	// Check if user is trying to restore themselves or has admin permissions
+	if authUserID != req.GetId() && !errors.HasPermission(ctx, auth.PermissionAdminUsers) {
+		return nil, status.Error(codes.PermissionDenied, "insufficient permissions to restore user")
+	}
+
+	restored, err := s.queries.RestoreUser(ctx, req.GetId())
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to restore user", err)
+		return nil, status.Error(codes.Internal, "failed to restore user")
+	}
+
+	dbUser, err := s.queries.GetUserByID(ctx, req.GetId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Error(codes.NotFound, "user not found")
+		}
+		logger.ErrorWithContext(ctx, "Failed to get restored user", err)
+		return nil, status.Error(codes.Internal, "failed to get user")
+	}
+	if restored == 0 {
+		return nil, status.Error(codes.FailedPrecondition, "user is not deleted")
+	}
+
+	logger.InfoWithFields("User restored successfully", map[string]interface{}{
+		"user_id":      req.GetId(),
+		"auth_user_id": authUserID,
+	})
+
+	return &RestoreUserResponse{User: s.convertDatabaseUserToGRPC(dbUser)}, nil
+}
+
+// PurgeDeletedUsers permanently removes up to limit users deleted before deletedBefore
+// and returns how many were purged. Their reviews are anonymized rather than deleted,
+// their solo ratings are removed, and the statistics of the affected spots are
+// recomputed.
+func (s *UserService) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	userIDs, err := s.queries.ListUsersDeletedBefore(ctx, database.ListUsersDeletedBeforeParams{
+		DeletedAt: sql.NullTime{Time: deletedBefore, Valid: true},
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		return 0, errors.Database("list deleted users", err)
+	}
+
+	purged := 0
+	for _, userID := range userIDs {
+		if err := ctx.Err(); err != nil {
+			return purged, err
+		}
+		if err := s.purgeUser(ctx, userID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeUser anonymizes the reviews of a deleted user, removes the user with their solo
+// ratings, roles and revocation cutoff, and recomputes the statistics of every spot they
+// reviewed or rated. All of it happens in one transaction, so a failed recompute keeps
+// the user for the next run. The affected spots are locked before their reviews and
+// ratings, in the order review and rating writes take the locks.
+func (s *UserService) purgeUser(ctx context.Context, userID string) error {
+	var reviewedSpots, ratedSpots []string
+	err := s.withSQLTx(ctx, func(tx *sql.Tx) error {
+		q := s.queries.WithTx(tx)
+		// Solo-friendly statistics are derived by the rating service, on the same transaction
+		ratingService := application.NewRatingService(database.NewRatingRepository(tx), database.NewSpotRepository(tx), nil)
+
+		var err error
+		reviewer := sql.NullString{String: userID, Valid: true}
+		if reviewedSpots, err = q.ListReviewedSpotIDsByUser(ctx, reviewer); err != nil {
+			return errors.Database("list reviewed spots", err)
+		}
+		ratings, err := q.ListSoloRatingsByUser(ctx, userID)
+		if err != nil {
+			return errors.Database("list solo ratings", err)
+		}
+		for _, rating := range ratings {
+			ratedSpots = append(ratedSpots, rating.SpotID)
+		}
+
+		for _, spotID := range lockOrder(reviewedSpots, ratedSpots) {
+			if _, err := q.LockSpotForUpdate(ctx, spotID); err != nil && err != sql.ErrNoRows {
+				return errors.Database("lock spot", err)
+			}
+		}
+
+		if _, err := q.AnonymizeUserReviews(ctx, reviewer); err != nil {
+			return errors.Database("anonymize reviews", err)
+		}
+		if _, err := q.DeleteSoloRatingsByUser(ctx, userID); err != nil {
+			return errors.Database("delete solo ratings", err)
+		}
+		if err := q.DeleteUserRoles(ctx, userID); err != nil {
+			return errors.Database("delete user roles", err)
+		}
+		if err := q.DeleteUserTokenRevocation(ctx, userID); err != nil {
+			return errors.Database("delete user token revocation", err)
+		}
+		if err := q.DeleteUser(ctx, userID); err != nil {
+			return errors.Database("delete user", err)
+		}
+
+		for _, spotID := range reviewedSpots {
+			if err := q.RecomputeSpotRating(ctx, spotID); err != nil {
+				return errors.Database("recompute spot rating", err)
+			}
+		}
+		for _, spotID := range ratedSpots {
+			if err := ratingService.RecomputeSpotStatistics(ctx, spotID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.InfoWithFields("Deleted user purged", map[string]interface{}{
+		"user_id":        userID,
+		"reviewed_spots": len(reviewedSpots),
+		"rated_spots":    len(ratedSpots),
+	})
+	return nil
+}
+
+// lockOrder returns the distinct spot IDs of the given lists in sorted order, so
+// transactions locking several spots take the locks in the same order
+func lockOrder(spotIDLists ...[]string) []string {
+	seen := make(map[string]bool)
+	var spotIDs []string
+	for _, list := range spotIDLists {
+		for _, spotID := range list {
+			if !seen[spotID] {
+				seen[spotID] = true
+				spotIDs = append(spotIDs, spotID)
+			}
+		}
+	}
+	sort.Strings(spotIDs)
+	return spotIDs
+}
+
 // convertDatabaseUserToGRPC converts database user model to gRPC user struct
 func (s *UserService) convertDatabaseUserToGRPC(dbUser database.User) *User {
-	// Convert preferences to JSON string for proto
-	var preferencesStr string
-	if len(dbUser.Preferences) > 0 {
-		// Validate JSON and pretty-print if needed
-		var temp interface{}
-		if err := json.Unmarshal(dbUser.Preferences, &temp); err != nil {
-			logger.Error("Failed to unmarshal user preferences", err)
-			preferencesStr = "{}"
-		} else {
-			preferencesStr = string(dbUser.Preferences)
-		}
-	} else {
-		preferencesStr = "{}"
-	}
-
-	return &User{
+	// Convert preferences to JSON string for proto, migrated to the current schema
+	preferencesStr := string(preferences.Normalize(dbUser.Preferences))
+
+	user := &User{
 		Id:             dbUser.ID,
 		Email:          dbUser.Email,
 		DisplayName:    dbUser.Name.String,
@@ -308,58 +563,74 @@ func (s *UserService) convertDatabaseUserToGRPC(dbUser database.User) *User {
 		CreatedAt:      timestamppb.New(dbUser.CreatedAt),
 		UpdatedAt:      timestamppb.New(dbUser.UpdatedAt),
 	}
+	if dbUser.DeletedAt.Valid {
+		user.DeletedAt = timestamppb.New(dbUser.DeletedAt.Time)
+	}
+	return user
 }
 
-// UpsertUserFromAuth creates or updates a user from authentication provider
-func (s *UserService) UpsertUserFromAuth(ctx context.Context, email, displayName, avatarUrl, authProvider, authProviderID string) (*User, error) {
-	// Generate UUID for new user (will be ignored if user exists)
-	userID := uuid.New().String()
+// AuthProfile is the profile an identity provider reports for a sign-in
+type AuthProfile struct {
+	Provider      string // google, twitter, x or auth0
+	ProviderID    string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Nickname      string
+	Picture       string
+}
 
-	// Convert preferences to JSON
-	defaultPreferences := map[string]interface{}{
-		"language": "en",
-		"theme":    "auto",
+// ProvisionUser implements auth.UserProvisioner, so the auth middleware can create or
+// refresh the user of a first-seen sign-in identity from its token claims
+func (s *UserService) ProvisionUser(ctx context.Context, claims *auth.Claims) (string, error) {
+	provider, providerID, ok := auth.IdentityFromSubject(claims.Subject)
+	if !ok {
+		return "", status.Error(codes.InvalidArgument, "token subject is not a sign-in identity")
 	}
-	preferencesJSON, _ := json.Marshal(defaultPreferences)
 
-	// Convert avatar URL to nullable string
-	var avatarUrlNullable sql.NullString
-	if avatarUrl != "" {
-		avatarUrlNullable = sql.NullString{String: avatarUrl, Valid: true}
+	user, err := s.UpsertUserFromAuth(ctx, AuthProfile{
+		Provider:      string(provider),
+		ProviderID:    providerID,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+		Nickname:      claims.Nickname,
+		Picture:       claims.Picture,
+	})
+	if err != nil {
+		return "", err
 	}
+	return user.Id, nil
+}
 
-	// Validate auth provider
-	var dbAuthProvider string
-	switch authProvider {
-	case "google":
-		dbAuthProvider = "google"
-	case "twitter":
-		dbAuthProvider = "twitter"
-	case "x":
-		dbAuthProvider = "x"
-	default:
+// UpsertUserFromAuth returns the user signed in with the given identity, creating the
+// user or linking the identity on first sign-in. A new identity is linked to the existing
+// account with the same email only when both the provider and the account have verified
+// that email; otherwise the email conflict is reported so the user can sign in with the
+// existing identity and link the new one explicitly.
+func (s *UserService) UpsertUserFromAuth(ctx context.Context, profile AuthProfile) (*User, error) {
+	if !entities.AuthProvider(profile.Provider).IsValid() {
 		return nil, status.Error(codes.InvalidArgument, "invalid auth provider")
 	}
+	if profile.ProviderID == "" {
+		return nil, status.Error(codes.InvalidArgument, "auth provider ID is required")
+	}
+	if profile.Email == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
 
-	// Upsert user in database
-	err := s.queries.UpsertUser(ctx, database.UpsertUserParams{
-		ID:            userID,
-		Email:         email,
-		Name:          sql.NullString{String: displayName, Valid: true},
-		Nickname:      sql.NullString{String: displayName, Valid: true},
-		Picture:       avatarUrlNullable,
-		Provider:      dbAuthProvider,
-		ProviderID:    authProviderID,
-		EmailVerified: true,
-		Preferences:   preferencesJSON,
-	})
+	userID, err := s.upsertUserFromAuth(ctx, profile)
+	if isDuplicateKey(err) {
+		// A concurrent first sign-in, e.g. on another replica, created the user or linked
+		// the identity first; the retry finds it
+		userID, err = s.upsertUserFromAuth(ctx, profile)
+	}
 	if err != nil {
-		logger.ErrorWithContext(ctx, "Failed to upsert user", err)
-		return nil, status.Error(codes.Internal, "failed to upsert user")
+		return nil, txError(ctx, err, "failed to upsert user")
 	}
 
 	// Retrieve the upserted user
-	dbUser, err := s.queries.GetUserByEmail(ctx, email)
+	dbUser, err := s.queries.GetUserByID(ctx, userID)
 	if err != nil {
 		logger.ErrorWithContext(ctx, "Failed to retrieve upserted user", err)
 		return nil, status.Error(codes.Internal, "failed to retrieve upserted user")
@@ -367,4 +638,392 @@ func (s *UserService) UpsertUserFromAuth(ctx context.Context, email, displayName
 
 	// Convert database user to gRPC response
 	return s.convertDatabaseUserToGRPC(dbUser), nil
-}
\ No newline at end of file
+}
+
+// upsertUserFromAuth resolves, links or creates the user of the identity in a transaction
+func (s *UserService) upsertUserFromAuth(ctx context.Context, profile AuthProfile) (string, error) {
+	email := sql.NullString{String: profile.Email, Valid: true}
+
+	var userID string
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		// Returning sign-in: record it and refresh the profile from the provider
+		existing, err := q.GetUserIdentity(ctx, database.GetUserIdentityParams{
+			Provider:   profile.Provider,
+			ProviderID: profile.ProviderID,
+		})
+		if err == nil {
+			userID = existing.UserID
+			// A deleted account keeps resolving so its owner can restore it, but sign-ins
+			// do not update it
+			deleted, err := q.IsUserDeleted(ctx, userID)
+			if err != nil || deleted {
+				return err
+			}
+			if err := q.TouchUserIdentity(ctx, database.TouchUserIdentityParams{
+				Email:         email,
+				EmailVerified: profile.EmailVerified,
+				Provider:      profile.Provider,
+				ProviderID:    profile.ProviderID,
+			}); err != nil {
+				return err
+			}
+			return refreshProfile(ctx, q, userID, profile)
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+
+		identity := database.CreateUserIdentityParams{
+			Provider:      profile.Provider,
+			ProviderID:    profile.ProviderID,
+			Email:         email,
+			EmailVerified: profile.EmailVerified,
+		}
+
+		// First sign-in with this identity: link it to the account with the same verified email
+		dbUser, err := q.GetUserByEmail(ctx, profile.Email)
+		if err == nil {
+			if !profile.EmailVerified || !dbUser.EmailVerified {
+				return status.Error(codes.AlreadyExists, "an account with this email already exists; sign in with it to link this identity")
+			}
+			userID = dbUser.ID
+			identity.UserID = userID
+			return q.CreateUserIdentity(ctx, identity)
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+
+		// A new identity is never attached to a deleted account
+		deleted, err := q.IsEmailOfDeletedUser(ctx, profile.Email)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			return status.Error(codes.FailedPrecondition, "the account with this email is deleted; sign in with its identity to restore it")
+		}
+
+		// Otherwise create the user with this identity
+		userID = uuid.New().String()
+		prefs := preferences.Defaults()
+		prefs.Language = "en"
+		preferencesJSON, err := preferences.Encode(nil, prefs)
+		if err != nil {
+			return err
+		}
+		if err := q.CreateUser(ctx, database.CreateUserParams{
+			ID:            userID,
+			Email:         profile.Email,
+			Name:          nullString(profile.Name),
+			Nickname:      nullString(firstNonEmpty(profile.Nickname, profile.Name)),
+			Picture:       nullString(profile.Picture),
+			Provider:      profile.Provider,
+			ProviderID:    profile.ProviderID,
+			EmailVerified: profile.EmailVerified,
+			Preferences:   preferencesJSON,
+		}); err != nil {
+			return err
+		}
+		identity.UserID = userID
+		return q.CreateUserIdentity(ctx, identity)
+	})
+	return userID, err
+}
+
+// refreshProfile updates the user's profile with the fields the provider reported. Empty
+// fields keep the stored values, and the account's email only becomes verified when the
+// identity verified that same email.
+func refreshProfile(ctx context.Context, q *database.Queries, userID string, profile AuthProfile) error {
+	dbUser, err := q.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	params := database.RefreshUserProfileParams{
+		ID:            userID,
+		Name:          dbUser.Name,
+		Nickname:      dbUser.Nickname,
+		Picture:       dbUser.Picture,
+		EmailVerified: dbUser.EmailVerified || (profile.EmailVerified && dbUser.Email == profile.Email),
+	}
+	if profile.Name != "" {
+		params.Name = nullString(profile.Name)
+	}
+	if profile.Nickname != "" {
+		params.Nickname = nullString(profile.Nickname)
+	}
+	if profile.Picture != "" {
+		params.Picture = nullString(profile.Picture)
+	}
+	return q.RefreshUserProfile(ctx, params)
+}
+
+// nullString returns a NULL for the empty string
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// mysqlErrDuplicateEntry is the MySQL error number of ER_DUP_ENTRY
+const mysqlErrDuplicateEntry = 1062
+
+// isDuplicateKey reports whether err is a MySQL duplicate entry error
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return stdErrors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry
+}
+
+// ListIdentities lists the sign-in identities linked to a user (self or admin only)
+func (s *UserService) ListIdentities(ctx context.Context, req *ListIdentitiesRequest) (*ListIdentitiesResponse, error) {
+	if err := authorizeIdentityAccess(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+
+	identities, err := s.queries.ListUserIdentities(ctx, req.GetUserId())
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to list user identities", err)
+		return nil, status.Error(codes.Internal, "failed to list identities")
+	}
+
+	resp := &ListIdentitiesResponse{Identities: make([]*Identity, 0, len(identities))}
+	for _, identity := range identities {
+		resp.Identities = append(resp.Identities, convertIdentityToGRPC(identity))
+	}
+	return resp, nil
+}
+
+// LinkIdentity links the sign-in identity of a token to a user (self or admin only). Holding
+// a valid token for the identity proves the caller owns it, so the identity and its email
+// verification are taken from the token's claims. Linking an identity the user already has
+// is a no-op; an identity linked to another user is rejected.
+func (s *UserService) LinkIdentity(ctx context.Context, req *LinkIdentityRequest) (*LinkIdentityResponse, error) {
+	if err := authorizeIdentityAccess(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+	if req.GetIdentityToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "identity token is required")
+	}
+	if s.tokens == nil {
+		return nil, status.Error(codes.Unimplemented, "identity linking is not configured")
+	}
+
+	claims, err := s.tokens.ValidateToken(ctx, req.GetIdentityToken())
+	if errors.Is(err, errors.ErrTypeUnavailable) {
+		return nil, status.Error(codes.Unavailable, "the identity to link cannot be resolved right now")
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "the token of the identity to link is invalid or expired")
+	}
+	provider, providerID, ok := auth.IdentityFromSubject(claims.Subject)
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "the token does not belong to a sign-in identity that can be linked")
+	}
+	if err := rejectDeletedCaller(ctx, s.queries); err != nil {
+		return nil, err
+	}
+
+	key := database.GetUserIdentityParams{
+		Provider:   string(provider),
+		ProviderID: providerID,
+	}
+	var identity database.UserIdentity
+	err = s.withTx(ctx, func(q *database.Queries) error {
+		if _, err := q.GetUserByID(ctx, req.GetUserId()); err != nil {
+			if err == sql.ErrNoRows {
+				return status.Error(codes.NotFound, "user not found")
+			}
+			return err
+		}
+
+		existing, err := q.GetUserIdentity(ctx, key)
+		if err == nil {
+			if existing.UserID != req.GetUserId() {
+				return status.Error(codes.AlreadyExists, "identity is already linked to another account")
+			}
+			identity = existing
+			return nil
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+
+		var email sql.NullString
+		if claims.Email != "" {
+			email = sql.NullString{String: claims.Email, Valid: true}
+		}
+		if err := q.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+			Provider:      string(provider),
+			ProviderID:    providerID,
+			UserID:        req.GetUserId(),
+			Email:         email,
+			EmailVerified: claims.EmailVerified,
+		}); err != nil {
+			return err
+		}
+		identity, err = q.GetUserIdentity(ctx, key)
+		return err
+	})
+	if err != nil {
+		return nil, txError(ctx, err, "failed to link identity")
+	}
+
+	logger.InfoWithFields("Identity linked", map[string]interface{}{
+		"user_id":  req.GetUserId(),
+		"provider": string(provider),
+	})
+
+	return &LinkIdentityResponse{Identity: convertIdentityToGRPC(identity)}, nil
+}
+
+// UnlinkIdentity unlinks a sign-in identity from a user (self or admin only). The last
+// identity of a user cannot be unlinked, since the user could no longer sign in.
+func (s *UserService) UnlinkIdentity(ctx context.Context, req *UnlinkIdentityRequest) (*UnlinkIdentityResponse, error) {
+	if err := authorizeIdentityAccess(ctx, req.GetUserId()); err != nil {
+		return nil, err
+	}
+	if err := rejectDeletedCaller(ctx, s.queries); err != nil {
+		return nil, err
+	}
+	if req.GetProvider() == "" || req.GetProviderId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "provider and provider ID are required")
+	}
+
+	err := s.withTx(ctx, func(q *database.Queries) error {
+		// Lock the user's identities so concurrent unlinks see each other
+		identities, err := q.ListUserIdentitiesForUpdate(ctx, req.GetUserId())
+		if err != nil {
+			return err
+		}
+
+		var remaining []database.UserIdentity
+		found := false
+		for _, identity := range identities {
+			if identity.Provider == req.GetProvider() && identity.ProviderID == req.GetProviderId() {
+				found = true
+				continue
+			}
+			remaining = append(remaining, identity)
+		}
+		if !found {
+			return status.Error(codes.NotFound, "identity not found")
+		}
+		if len(remaining) == 0 {
+			return status.Error(codes.FailedPrecondition, "cannot unlink the last identity of an account")
+		}
+
+		if _, err := q.DeleteUserIdentity(ctx, database.DeleteUserIdentityParams{
+			UserID:     req.GetUserId(),
+			Provider:   req.GetProvider(),
+			ProviderID: req.GetProviderId(),
+		}); err != nil {
+			return err
+		}
+
+		// Keep users.provider and users.provider_id pointing at a linked identity, so the
+		// unlinked one can create a new account later without a duplicate key
+		dbUser, err := q.GetUserByID(ctx, req.GetUserId())
+		if err != nil {
+			return err
+		}
+		if dbUser.Provider != req.GetProvider() || dbUser.ProviderID != req.GetProviderId() {
+			return nil
+		}
+		return q.SetUserPrimaryIdentity(ctx, database.SetUserPrimaryIdentityParams{
+			ID:         req.GetUserId(),
+			Provider:   remaining[0].Provider,
+			ProviderID: remaining[0].ProviderID,
+		})
+	})
+	if err != nil {
+		return nil, txError(ctx, err, "failed to unlink identity")
+	}
+
+	logger.InfoWithFields("Identity unlinked", map[string]interface{}{
+		"user_id":  req.GetUserId(),
+		"provider": req.GetProvider(),
+	})
+
+	return &UnlinkIdentityResponse{Success: true}, nil
+}
+
+// authorizeIdentityAccess allows the user themselves and holders of admin:users to manage
+// a user's identities
+func authorizeIdentityAccess(ctx context.Context, userID string) error {
+	if userID == "" {
+		return status.Error(codes.InvalidArgument, "user ID is required")
+	}
+
+	authUserID := errors.GetUserID(ctx)
+	if authUserID == "" {
+		return status.Error(codes.Unauthenticated, "user not authenticated")
+	}
+	if authUserID != userID && !errors.HasPermission(ctx, auth.PermissionAdminUsers) {
+		return status.Error(codes.PermissionDenied, "insufficient permissions to manage this user's identities")
+	}
+	return nil
+}
+
+// rejectDeletedCaller fails a write made by an account that is deleted and waiting to be
+// purged; the account has to be restored first. Callers that are not users, such as
+// unlinked subjects and API keys, pass.
+func rejectDeletedCaller(ctx context.Context, q *database.Queries) error {
+	userID := errors.GetUserID(ctx)
+	if userID == "" {
+		return nil
+	}
+
+	deleted, err := q.IsUserDeleted(ctx, userID)
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to check whether the account is deleted", err)
+		return status.Error(codes.Internal, "failed to check account status")
+	}
+	if deleted {
+		return status.Error(codes.PermissionDenied, "the account is deleted; restore it to make changes")
+	}
+	return nil
+}
+
+// convertIdentityToGRPC converts a database identity to the gRPC identity
+func convertIdentityToGRPC(identity database.UserIdentity) *Identity {
+	result := &Identity{
+		Provider:      identity.Provider,
+		ProviderId:    identity.ProviderID,
+		Email:         identity.Email.String,
+		EmailVerified: identity.EmailVerified,
+		CreatedAt:     timestamppb.New(identity.CreatedAt),
+	}
+	if identity.LastUsedAt.Valid {
+		result.LastUsedAt = timestamppb.New(identity.LastUsedAt.Time)
+	}
+	return result
+}
+
+// withTx runs fn in a transaction, committing if it returns nil and rolling back otherwise
+func (s *UserService) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	return s.withSQLTx(ctx, func(tx *sql.Tx) error {
+		return fn(s.queries.WithTx(tx))
+	})
+}
+
+// withSQLTx is withTx for callers that build repositories on the transaction itself
+func (s *UserService) withSQLTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logger.ErrorWithContext(ctx, "Failed to roll back user transaction", rbErr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
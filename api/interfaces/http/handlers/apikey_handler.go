@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/errors"
+)
+
+// APIKeyHandler manages API keys for machine-to-machine clients
+type APIKeyHandler struct {
+	manager *auth.APIKeyManager
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(manager *auth.APIKeyManager) *APIKeyHandler {
+	return &APIKeyHandler{
+		manager: manager,
+	}
+}
+
+// APIKeyInfo represents an API key without its secret
+type APIKeyInfo struct {
+	ID          string     `json:"id" doc:"API key ID"`
+	Name        string     `json:"name" doc:"Human-readable name of the client"`
+	Prefix      string     `json:"prefix" doc:"Non-secret start of the key, for identifying it"`
+	Permissions []string   `json:"permissions" doc:"Permissions granted to the key"`
+	CreatedBy   string     `json:"created_by" doc:"User who created the key"`
+	UsageCount  int64      `json:"usage_count" doc:"Number of requests authenticated with the key"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty" doc:"Time of the last request made with the key"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" doc:"Expiry time; keys without one do not expire"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" doc:"Revocation time"`
+	Active      bool       `json:"active" doc:"Whether the key currently authenticates requests"`
+	CreatedAt   time.Time  `json:"created_at" doc:"Creation time"`
+}
+
+// CreateAPIKeyInput represents the request to create an API key
+type CreateAPIKeyInput struct {
+	Body struct {
+		Name        string     `json:"name" minLength:"1" maxLength:"100" doc:"Human-readable name of the client"`
+		Permissions []string   `json:"permissions,omitempty" doc:"Permissions to grant; each must be held by the caller"`
+		ExpiresAt   *time.Time `json:"expires_at,omitempty" doc:"Optional expiry time"`
+	}
+}
+
+// APIKeySecretOutput represents a key along with its secret, which is only shown once
+type APIKeySecretOutput struct {
+	Body struct {
+		APIKey APIKeyInfo `json:"api_key" doc:"The API key"`
+		Key    string     `json:"key" doc:"The API key to send in the X-API-Key header; it cannot be retrieved again"`
+	}
+}
+
+// ListAPIKeysInput represents the request to list API keys
+type ListAPIKeysInput struct{}
+
+// ListAPIKeysOutput represents the response for listing API keys
+type ListAPIKeysOutput struct {
+	Body struct {
+		APIKeys []APIKeyInfo `json:"api_keys" doc:"API keys, newest first"`
+	}
+}
+
+// APIKeyIDInput identifies an API key
+type APIKeyIDInput struct {
+	ID string `path:"id" doc:"API key ID"`
+}
+
+// RegisterRoutesWithAuth registers the admin API key routes
+func (h *APIKeyHandler) RegisterRoutesWithAuth(api huma.API, authMiddleware *auth.AuthMiddleware) {
+	huma.Register(api, authMiddleware.RequirePermissions(api, huma.Operation{
+		OperationID:   "create-api-key",
+		Method:        http.MethodPost,
+		Path:          "/api/v1/admin/api-keys",
+		Summary:       "Create an API key",
+		Description:   "Create an API key scoped to a subset of the caller's permissions. The key is returned only once (admin only)",
+		Tags:          []string{"Admin"},
+		DefaultStatus: http.StatusCreated,
+	}, auth.PermissionAdminAPIKeys), h.CreateAPIKey)
+
+	huma.Register(api, authMiddleware.RequirePermissions(api, huma.Operation{
+		OperationID: "list-api-keys",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/admin/api-keys",
+		Summary:     "List API keys",
+		Description: "List every API key with its usage (admin only)",
+		Tags:        []string{"Admin"},
+	}, auth.PermissionAdminAPIKeys), h.ListAPIKeys)
+
+	huma.Register(api, authMiddleware.RequirePermissions(api, huma.Operation{
+		OperationID: "rotate-api-key",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/admin/api-keys/{id}/rotate",
+		Summary:     "Rotate an API key",
+		Description: "Replace the key's secret; the previous secret stops working immediately (admin only)",
+		Tags:        []string{"Admin"},
+	}, auth.PermissionAdminAPIKeys), h.RotateAPIKey)
+
+	huma.Register(api, authMiddleware.RequirePermissions(api, huma.Operation{
+		OperationID:   "revoke-api-key",
+		Method:        http.MethodDelete,
+		Path:          "/api/v1/admin/api-keys/{id}",
+		Summary:       "Revoke an API key",
+		Description:   "Permanently disable an API key (admin only)",
+		Tags:          []string{"Admin"},
+		DefaultStatus: http.StatusNoContent,
+	}, auth.PermissionAdminAPIKeys), h.RevokeAPIKey)
+}
+
+// CreateAPIKey creates a new API key
+func (h *APIKeyHandler) CreateAPIKey(ctx context.Context, input *CreateAPIKeyInput) (*APIKeySecretOutput, error) {
+	userID, _ := auth.GetUserIDFromContext(ctx)
+	params := auth.APIKeyParams{
+		Name:        input.Body.Name,
+		Permissions: input.Body.Permissions,
+		CreatedBy:   userID,
+	}
+	if input.Body.ExpiresAt != nil {
+		params.ExpiresAt = *input.Body.ExpiresAt
+	}
+
+	key, secret, err := h.manager.Create(ctx, params)
+	if err != nil {
+		return nil, errors.HandleHTTPError(ctx, err, "create_api_key", "failed to create API key")
+	}
+
+	resp := &APIKeySecretOutput{}
+	resp.Body.APIKey = toAPIKeyInfo(key)
+	resp.Body.Key = secret
+	return resp, nil
+}
+
+// ListAPIKeys lists every API key
+func (h *APIKeyHandler) ListAPIKeys(ctx context.Context, input *ListAPIKeysInput) (*ListAPIKeysOutput, error) {
+	keys, err := h.manager.List(ctx)
+	if err != nil {
+		return nil, errors.HandleHTTPError(ctx, err, "list_api_keys", "failed to list API keys")
+	}
+
+	resp := &ListAPIKeysOutput{}
+	resp.Body.APIKeys = make([]APIKeyInfo, 0, len(keys))
+	for i := range keys {
+		resp.Body.APIKeys = append(resp.Body.APIKeys, toAPIKeyInfo(&keys[i]))
+	}
+	return resp, nil
+}
+
+// RotateAPIKey replaces the secret of an API key
+func (h *APIKeyHandler) RotateAPIKey(ctx context.Context, input *APIKeyIDInput) (*APIKeySecretOutput, error) {
+	key, secret, err := h.manager.Rotate(ctx, input.ID)
+	if err != nil {
+		return nil, errors.HandleHTTPError(ctx, err, "rotate_api_key", "failed to rotate API key")
+	}
+
+	resp := &APIKeySecretOutput{}
+	resp.Body.APIKey = toAPIKeyInfo(key)
+	resp.Body.Key = secret
+	return resp, nil
+}
+
+// RevokeAPIKey permanently disables an API key
+func (h *APIKeyHandler) RevokeAPIKey(ctx context.Context, input *APIKeyIDInput) (*struct{}, error) {
+	if err := h.manager.Revoke(ctx, input.ID); err != nil {
+		return nil, errors.HandleHTTPError(ctx, err, "revoke_api_key", "failed to revoke API key")
+	}
+	return nil, nil
+}
+
+func toAPIKeyInfo(key *auth.APIKey) APIKeyInfo {
+	info := APIKeyInfo{
+		ID:          key.ID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		Permissions: key.Permissions,
+		CreatedBy:   key.CreatedBy,
+		UsageCount:  key.UsageCount,
+		LastUsedAt:  optionalTime(key.LastUsedAt),
+		ExpiresAt:   optionalTime(key.ExpiresAt),
+		RevokedAt:   optionalTime(key.RevokedAt),
+		Active:      key.Active(time.Now()),
+		CreatedAt:   key.CreatedAt,
+	}
+	if info.Permissions == nil {
+		info.Permissions = []string{}
+	}
+	return info
+}
+
+// optionalTime returns nil for the zero time
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -97,6 +97,24 @@ type LogoutOutput struct {
 	}
 }
 
+// LogoutAllInput represents the request to revoke every session of the current user
+type LogoutAllInput struct{}
+
+// RevokeUserSessionsInput represents the request to revoke every session of a user
+type RevokeUserSessionsInput struct {
+	ID string `path:"id" doc:"ID of the user whose sessions are revoked; for a subject not linked to a user, the token subject"`
+}
+
+// LogoutAllOutput represents the response for revoking every session of a user
+type LogoutAllOutput struct {
+	Body struct {
+		Success       bool      `json:"success" doc:"Whether the sessions were revoked"`
+		Message       string    `json:"message" doc:"Logout message"`
+		RevokedBefore time.Time `json:"revoked_before" doc:"Tokens issued at or before this time are rejected"`
+		Timestamp     time.Time `json:"timestamp" doc:"Response timestamp"`
+	}
+}
+
 // AuthStatsInput represents the request to get auth statistics
 type AuthStatsInput struct{}
 
@@ -150,18 +168,38 @@ func (h *AuthHandler) RegisterRoutesWithRateLimit(api huma.API, rateLimiter *aut
 		},
 	}, h.Logout)
 
-	// Auth statistics endpoint (admin only)
+	// Logout-everywhere endpoint (requires authentication)
 	huma.Register(api, huma.Operation{
+		OperationID: "logout-all",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/auth/logout-all",
+		Summary:     "Logout user everywhere",
+		Description: "Revoke every token issued to the current user, including the one used for this request",
+		Tags:        []string{"Authentication"},
+		Security: []map[string][]string{
+			{"bearerAuth": {}},
+		},
+	}, h.LogoutAll)
+
+	// Revoke another user's sessions (admin only)
+	huma.Register(api, h.authMiddleware.RequirePermissions(api, huma.Operation{
+		OperationID: "revoke-user-sessions",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/admin/users/{id}/revoke-sessions",
+		Summary:     "Revoke user sessions",
+		Description: "Revoke every token issued to the given user so far (admin only)",
+		Tags:        []string{"Admin"},
+	}, auth.PermissionAdminUsers), h.RevokeUserSessions)
+
+	// Auth statistics endpoint (admin only)
+	huma.Register(api, h.authMiddleware.RequirePermissions(api, huma.Operation{
 		OperationID: "auth-stats",
 		Method:      http.MethodGet,
 		Path:        "/api/v1/auth/stats",
 		Summary:     "Get authentication statistics",
 		Description: "Get authentication service statistics (admin only)",
 		Tags:        []string{"Authentication"},
-		Security: []map[string][]string{
-			{"bearerAuth": {}},
-		},
-	}, h.GetAuthStats)
+	}, auth.PermissionAdminAuth), h.GetAuthStats)
 }
 
 // GetAuthStatus checks the authentication status of the current request
@@ -221,8 +259,8 @@ func (h *AuthHandler) GetAuthStatus(ctx context.Context, input *AuthStatusInput)
 		resp.Body.TokenInfo = tokenInfo
 	}
 
-	// Extract permissions (placeholder for future implementation)
-	resp.Body.Permissions = []string{} // TODO: Implement role-based permissions
+	// Permissions from the token claim merged with those granted through roles
+	resp.Body.Permissions = auth.GetPermissionsFromContext(ctx)
 
 	resp.Body.User = authUserInfo
 
@@ -266,7 +304,9 @@ func (h *AuthHandler) ValidateToken(ctx context.Context, input *ValidateTokenInp
 		Issuer:    claims.Issuer,
 		Audience:  claims.Audience,
 		IssuedAt:  time.Unix(claims.IssuedAt, 0),
-		ExpiresAt: time.Unix(claims.ExpiresAt, 0),
+	}
+	if claims.ExpiresAt != nil {
+		resp.Body.Claims.ExpiresAt = claims.ExpiresAt.Time
 	}
 
 	// Extract scopes if available
@@ -338,6 +378,53 @@ func (h *AuthHandler) Logout(ctx context.Context, input *LogoutInput) (*LogoutOu
 	return resp, nil
 }
 
+// LogoutAll revokes every token issued to the current user
+func (h *AuthHandler) LogoutAll(ctx context.Context, input *LogoutAllInput) (*LogoutAllOutput, error) {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required")
+	}
+
+	cutoff, err := h.authMiddleware.LogoutAll(ctx, userID)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("failed to revoke sessions")
+	}
+
+	resp := &LogoutAllOutput{}
+	resp.Body.Success = true
+	resp.Body.Message = "Logout successful. All tokens issued so far have been invalidated."
+	resp.Body.RevokedBefore = cutoff
+	resp.Body.Timestamp = time.Now()
+
+	monitoring.AddUserContext(ctx, userID, "")
+
+	return resp, nil
+}
+
+// RevokeUserSessions revokes every token issued to another user
+func (h *AuthHandler) RevokeUserSessions(ctx context.Context, input *RevokeUserSessionsInput) (*LogoutAllOutput, error) {
+	// Admin permission is enforced by the operation's permission middleware
+	adminID, _ := auth.GetUserIDFromContext(ctx)
+
+	cutoff, err := h.authMiddleware.LogoutAll(ctx, input.ID)
+	if err != nil {
+		return nil, huma.Error500InternalServerError("failed to revoke sessions")
+	}
+
+	logger.InfoWithFields("User sessions revoked by admin", map[string]interface{}{
+		"admin_id": adminID,
+		"user_id":  input.ID,
+	})
+
+	resp := &LogoutAllOutput{}
+	resp.Body.Success = true
+	resp.Body.Message = "All tokens issued to the user so far have been invalidated."
+	resp.Body.RevokedBefore = cutoff
+	resp.Body.Timestamp = time.Now()
+
+	return resp, nil
+}
+
 // GetAuthStats returns authentication service statistics
 func (h *AuthHandler) GetAuthStats(ctx context.Context, input *AuthStatsInput) (*AuthStatsOutput, error) {
 	// Extract user ID from authentication context
@@ -346,8 +433,7 @@ func (h *AuthHandler) GetAuthStats(ctx context.Context, input *AuthStatsInput) (
 		return nil, huma.Error401Unauthorized("authentication required")
 	}
 
-	// TODO: Check if user has admin permissions
-	// For now, we'll allow any authenticated user to see basic stats
+	// Admin permission is enforced by the operation's permission middleware
 
 	resp := &AuthStatsOutput{}
 	resp.Body.Timestamp = time.Now()
@@ -364,6 +450,9 @@ func (h *AuthHandler) GetAuthStats(ctx context.Context, input *AuthStatsInput) (
 			"stats":  "JWT validator statistics would go here",
 		},
 	}
+	if blacklistStats := h.authMiddleware.GetBlacklistStats(); blacklistStats != nil {
+		resp.Body.ServiceStats["token_blacklist_cache"] = blacklistStats
+	}
 
 	resp.Body.HealthStatus = "healthy"
 
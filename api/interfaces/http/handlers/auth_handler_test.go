@@ -5,6 +5,8 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -50,9 +52,8 @@ func verifyTokenInfo(tokenInfo map[string]interface{}, expectedUserID string) {
 }
 
 func createMockAuthMiddleware() *auth.AuthMiddleware {
-	// Create a mock auth middleware for testing
-	// In a real test environment, this would be configured with test keys
-	return &auth.AuthMiddleware{} // Simplified for testing
+	// Accepts the local HS256 tokens minted by helpers.AuthHelper
+	return auth.NewAuthMiddleware(helpers.TestJWTSecret, nil)
 }
 
 var _ = Describe("AuthHandler BDD Tests", func() {
@@ -393,6 +394,45 @@ var _ = Describe("AuthHandler BDD Tests", func() {
 		})
 	})
 
+	Describe("User Logout Everywhere", func() {
+		Context("Given an unauthenticated user", func() {
+			Context("When requesting logout-all", func() {
+				It("Then authentication error should be returned", func() {
+					unauthCtx := testSuite.AuthHelper.CreateUnauthenticatedContext(context.Background())
+
+					_, err := authHandler.LogoutAll(unauthCtx, &LogoutAllInput{})
+
+					Expect(err).To(HaveOccurred(), "Logout-all should require authentication")
+					Expect(err.Error()).To(ContainSubstring("401"), "Should return 401 Unauthorized")
+				})
+			})
+		})
+
+		Context("Given admin session revocation", func() {
+			var adminRouter *chi.Mux
+
+			BeforeEach(func() {
+				adminRouter = chi.NewRouter()
+				adminAPI := humachi.New(adminRouter, huma.DefaultConfig("Admin Test API", "1.0.0"))
+				adminAPI.UseMiddleware(authMiddleware.HumaMiddleware())
+				authHandler.RegisterRoutesWithRateLimit(adminAPI, nil)
+			})
+
+			Context("When a user without the admin permission revokes another user's sessions", func() {
+				It("Then access should be forbidden", func() {
+					req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/other-user/revoke-sessions", nil)
+					token := testSuite.AuthHelper.MintToken(authData.ValidUserID, authData.TestUser.Email, auth.PermissionAdminAuth)
+					req.Header.Set("Authorization", "Bearer "+token)
+
+					resp := httptest.NewRecorder()
+					adminRouter.ServeHTTP(resp, req)
+
+					Expect(resp.Code).To(Equal(http.StatusForbidden))
+				})
+			})
+		})
+	})
+
 	Describe("Authentication Statistics", func() {
 		Context("Given an authenticated user", func() {
 			Context("When requesting auth statistics", func() {
@@ -453,24 +493,41 @@ var _ = Describe("AuthHandler BDD Tests", func() {
 		})
 
 		Context("Given admin user access control", func() {
-			Context("When implementing role-based access", func() {
-				It("Then future admin verification should be considered", func() {
-					By("Documenting future admin access control requirement")
-					// Currently, any authenticated user can access stats
-					// In future implementation, this should check for admin role
+			var adminAPI huma.API
+			var adminRouter *chi.Mux
+
+			BeforeEach(func() {
+				By("Registering auth routes behind the local token middleware")
+				adminRouter = chi.NewRouter()
+				adminAPI = humachi.New(adminRouter, huma.DefaultConfig("Admin Test API", "1.0.0"))
+				adminAPI.UseMiddleware(authMiddleware.HumaMiddleware())
+				authHandler.RegisterRoutesWithRateLimit(adminAPI, nil)
+			})
 
-					baseCtx := context.Background()
-					authCtx := context.WithValue(baseCtx, "user_id", authData.ValidUserID)
+			Context("When a user without the admin permission requests stats", func() {
+				It("Then access should be forbidden", func() {
+					req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/stats", nil)
+					token := testSuite.AuthHelper.MintToken(authData.ValidUserID, authData.TestUser.Email, auth.PermissionAdminSpots)
+					req.Header.Set("Authorization", "Bearer "+token)
 
-					input := &AuthStatsInput{}
-					output, err := authHandler.GetAuthStats(authCtx, input)
+					resp := httptest.NewRecorder()
+					adminRouter.ServeHTTP(resp, req)
+
+					Expect(resp.Code).To(Equal(http.StatusForbidden))
+				})
+			})
+
+			Context("When an admin requests stats", func() {
+				It("Then the stats should be returned", func() {
+					req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/stats", nil)
+					token := testSuite.AuthHelper.MintToken(authData.ValidUserID, authData.TestUser.Email, auth.PermissionAdminAuth)
+					req.Header.Set("Authorization", "Bearer "+token)
 
-					Expect(err).NotTo(HaveOccurred(), "Currently allows any authenticated user")
-					Expect(output).NotTo(BeNil(), "Should return stats for now")
+					resp := httptest.NewRecorder()
+					adminRouter.ServeHTTP(resp, req)
 
-					// TODO: Implement admin role checking
-					// Future test should verify that only admin users can access auth stats
-					By("Noting that admin role verification should be implemented")
+					Expect(resp.Code).To(Equal(http.StatusOK))
+					Expect(resp.Body.String()).To(ContainSubstring("service_stats"))
 				})
 			})
 		})
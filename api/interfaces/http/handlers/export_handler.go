@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/export"
+)
+
+// ExportHandler handles personal data export requests
+type ExportHandler struct {
+	exports *export.Service
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(exports *export.Service) *ExportHandler {
+	return &ExportHandler{
+		exports: exports,
+	}
+}
+
+// ExportInfo represents a personal data export without its archive
+type ExportInfo struct {
+	ID          string     `json:"id" doc:"Export ID"`
+	Format      string     `json:"format" enum:"json,zip" doc:"Archive format"`
+	Status      string     `json:"status" enum:"pending,processing,ready,failed" doc:"Export status"`
+	SizeBytes   int64      `json:"size_bytes,omitempty" doc:"Archive size in bytes once ready"`
+	Error       string     `json:"error,omitempty" doc:"Reason the export failed"`
+	CreatedAt   time.Time  `json:"created_at" doc:"Request time"`
+	CompletedAt *time.Time `json:"completed_at,omitempty" doc:"Time the archive was built or the export failed"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" doc:"Time the download link stops working"`
+}
+
+// RequestExportInput represents the request to export the current user's data; the
+// body is optional
+type RequestExportInput struct {
+	Body *struct {
+		Format string `json:"format,omitempty" enum:"json,zip" default:"json" doc:"Archive format"`
+	}
+}
+
+// RequestExportOutput represents an accepted export along with its download link
+type RequestExportOutput struct {
+	Body struct {
+		Export      ExportInfo `json:"export" doc:"The export"`
+		DownloadURL string     `json:"download_url" doc:"Link to download the archive once ready; it cannot be retrieved again"`
+	}
+}
+
+// GetExportInput represents the request to get one of the current user's exports
+type GetExportInput struct {
+	ID string `path:"id" doc:"Export ID"`
+}
+
+// GetExportOutput represents the response for getting an export
+type GetExportOutput struct {
+	Body ExportInfo
+}
+
+// DownloadExportInput represents the request to download an export archive
+type DownloadExportInput struct {
+	ID    string `path:"id" doc:"Export ID"`
+	Token string `query:"token" required:"true" doc:"Download token from the download link"`
+}
+
+// DownloadExportOutput is the raw export archive
+type DownloadExportOutput struct {
+	ContentType        string `header:"Content-Type"`
+	ContentDisposition string `header:"Content-Disposition"`
+	CacheControl       string `header:"Cache-Control"`
+	Body               []byte
+}
+
+// RegisterRoutesWithAuth registers the personal data export routes
+func (h *ExportHandler) RegisterRoutesWithAuth(api huma.API, authMiddleware *auth.AuthMiddleware) {
+	huma.Register(api, authMiddleware.CreateProtectedOperation(huma.Operation{
+		OperationID:   "request-user-export",
+		Method:        http.MethodPost,
+		Path:          "/api/v1/users/me/export",
+		Summary:       "Export my data",
+		Description:   "Request an archive of the current user's data. The archive is built in the background and can be downloaded from the returned link, which is shown only once",
+		Tags:          []string{"Users"},
+		DefaultStatus: http.StatusAccepted,
+	}), h.RequestExport)
+
+	huma.Register(api, authMiddleware.CreateProtectedOperation(huma.Operation{
+		OperationID: "get-user-export",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/me/exports/{id}",
+		Summary:     "Get an export",
+		Description: "Get the status of one of the current user's data exports",
+		Tags:        []string{"Users"},
+	}), h.GetExport)
+
+	huma.Register(api, huma.Operation{
+		OperationID: "download-user-export",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/exports/{id}/download",
+		Summary:     "Download an export",
+		Description: "Download a finished data export archive. The download token authorizes the request, so the link works until the export expires",
+		Tags:        []string{"Users"},
+	}, h.DownloadExport)
+}
+
+// RequestExport queues an export of the current user's data
+func (h *ExportHandler) RequestExport(ctx context.Context, input *RequestExportInput) (*RequestExportOutput, error) {
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required")
+	}
+	ctx = errors.WithUserID(ctx, userID)
+
+	format := export.FormatJSON
+	if input.Body != nil && input.Body.Format != "" {
+		format = input.Body.Format
+	}
+
+	exp, token, err := h.exports.Request(ctx, userID, format)
+	if err != nil {
+		return nil, errors.HandleHTTPError(ctx, err, "request_user_export", "failed to request export")
+	}
+
+	resp := &RequestExportOutput{}
+	resp.Body.Export = toExportInfo(exp)
+	resp.Body.DownloadURL = "/api/v1/exports/" + url.PathEscape(exp.ID) + "/download?token=" + url.QueryEscape(token)
+	return resp, nil
+}
+
+// GetExport returns one of the current user's exports
+func (h *ExportHandler) GetExport(ctx context.Context, input *GetExportInput) (*GetExportOutput, error) {
+	userID, ok := auth.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required")
+	}
+
+	exp, err := h.exports.Get(ctx, userID, input.ID)
+	if err != nil {
+		return nil, errors.HandleHTTPError(ctx, err, "get_user_export", "failed to get export")
+	}
+	return &GetExportOutput{Body: toExportInfo(exp)}, nil
+}
+
+// DownloadExport returns the archive of a finished export
+func (h *ExportHandler) DownloadExport(ctx context.Context, input *DownloadExportInput) (*DownloadExportOutput, error) {
+	archive, err := h.exports.Download(ctx, input.ID, input.Token)
+	if err != nil {
+		return nil, errors.HandleHTTPError(ctx, err, "download_user_export", "failed to download export")
+	}
+
+	return &DownloadExportOutput{
+		ContentType:        archive.ContentType,
+		ContentDisposition: `attachment; filename="` + archive.Filename + `"`,
+		CacheControl:       "no-store",
+		Body:               archive.Data,
+	}, nil
+}
+
+func toExportInfo(exp *export.Export) ExportInfo {
+	return ExportInfo{
+		ID:          exp.ID,
+		Format:      exp.Format,
+		Status:      exp.Status,
+		SizeBytes:   exp.SizeBytes,
+		Error:       exp.Error,
+		CreatedAt:   exp.CreatedAt,
+		CompletedAt: optionalTime(exp.CompletedAt),
+		ExpiresAt:   optionalTime(exp.ExpiresAt),
+	}
+}
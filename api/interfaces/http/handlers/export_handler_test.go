@@ -0,0 +1,159 @@
+//go:build integration
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/export"
+	"bocchi/api/tests/helpers"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ExportHandler BDD Tests", func() {
+	var (
+		router   *chi.Mux
+		exports  *export.Service
+		authData *helpers.AuthTestData
+	)
+
+	BeforeEach(func() {
+		By("Setting up ExportHandler test environment")
+		router = chi.NewRouter()
+		api := humachi.New(router, huma.DefaultConfig("Export Test API", "1.0.0"))
+		authMiddleware := auth.NewAuthMiddleware(helpers.TestJWTSecret, testSuite.TestDB.Queries)
+		DeferCleanup(authMiddleware.Stop)
+		api.UseMiddleware(authMiddleware.HumaMiddleware())
+
+		exports = export.NewService(testSuite.TestDB.DB, 0)
+		NewExportHandler(exports).RegisterRoutesWithAuth(api, authMiddleware)
+
+		By("Creating a user with a review")
+		authData = testSuite.AuthHelper.NewAuthTestData()
+		ctx := context.Background()
+		testSuite.FixtureManager.CreateUserFixture(ctx, helpers.UserFixture{
+			ID:             authData.ValidUserID,
+			Email:          authData.TestUser.Email,
+			DisplayName:    authData.TestUser.DisplayName,
+			AuthProvider:   string(authData.TestUser.AuthProvider),
+			AuthProviderID: authData.TestUser.AuthProviderID,
+			Preferences:    authData.TestUser.Preferences,
+		})
+		spot := testSuite.FixtureManager.CreateSpotFixture(ctx, testSuite.FixtureManager.DefaultSpotFixtures()[0])
+		testSuite.FixtureManager.CreateReviewFixture(ctx, helpers.ReviewFixture{
+			ID:      "review-export-1",
+			SpotID:  spot.ID,
+			UserID:  authData.ValidUserID,
+			Rating:  5,
+			Comment: "Quiet corner seats",
+		})
+	})
+
+	serve := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+		return resp
+	}
+
+	Context("Given a user requesting an export of their data", func() {
+		It("Then the archive should be downloadable once the job has built it", func() {
+			By("Requesting the export")
+			resp := serve(http.MethodPost, "/api/v1/users/me/export", authData.ValidToken, `{"format":"json"}`)
+			Expect(resp.Code).To(Equal(http.StatusAccepted), resp.Body.String())
+
+			var requested struct {
+				Export      ExportInfo `json:"export"`
+				DownloadURL string     `json:"download_url"`
+			}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &requested)).To(Succeed())
+			Expect(requested.Export.Status).To(Equal(export.StatusPending))
+			Expect(requested.DownloadURL).To(ContainSubstring("token="))
+
+			By("Rejecting a second export while the first is in progress")
+			resp = serve(http.MethodPost, "/api/v1/users/me/export", authData.ValidToken, `{}`)
+			Expect(resp.Code).To(Equal(http.StatusConflict))
+
+			By("Not serving the archive before it is built")
+			resp = serve(http.MethodGet, requested.DownloadURL, "", "")
+			Expect(resp.Code).To(Equal(http.StatusConflict))
+
+			By("Running the export job")
+			Expect(exports.ProcessPending(context.Background())).To(Succeed())
+			resp = serve(http.MethodGet, "/api/v1/users/me/exports/"+requested.Export.ID, authData.ValidToken, "")
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Body.String()).To(ContainSubstring(`"status":"ready"`))
+
+			By("Downloading the archive")
+			resp = serve(http.MethodGet, requested.DownloadURL, "", "")
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Header().Get("Content-Disposition")).To(ContainSubstring("attachment"))
+
+			var doc export.Document
+			Expect(json.Unmarshal(resp.Body.Bytes(), &doc)).To(Succeed())
+			Expect(doc.FormatVersion).To(Equal(export.FormatVersion))
+			Expect(doc.User.ID).To(Equal(authData.ValidUserID))
+			Expect(doc.Reviews).To(HaveLen(1))
+			Expect(doc.Reviews[0].Comment).To(Equal("Quiet corner seats"))
+
+			By("Rejecting a wrong download token")
+			resp = serve(http.MethodGet, "/api/v1/exports/"+requested.Export.ID+"/download?token=wrong", "", "")
+			Expect(resp.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("Then an export claimed by another run should only be taken over once the claim is stale", func() {
+			resp := serve(http.MethodPost, "/api/v1/users/me/export", authData.ValidToken, `{}`)
+			Expect(resp.Code).To(Equal(http.StatusAccepted))
+
+			var requested struct {
+				Export ExportInfo `json:"export"`
+			}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &requested)).To(Succeed())
+			path := "/api/v1/users/me/exports/" + requested.Export.ID
+
+			By("Leaving an export another run has just claimed alone")
+			ctx := context.Background()
+			_, err := testSuite.TestDB.DB.ExecContext(ctx,
+				"UPDATE user_exports SET status = 'processing', claimed_at = NOW() WHERE id = ?", requested.Export.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exports.ProcessPending(ctx)).To(Succeed())
+			resp = serve(http.MethodGet, path, authData.ValidToken, "")
+			Expect(resp.Body.String()).To(ContainSubstring(`"status":"processing"`))
+
+			By("Taking over the export once its claim is stale")
+			_, err = testSuite.TestDB.DB.ExecContext(ctx,
+				"UPDATE user_exports SET claimed_at = NOW() - INTERVAL 1 HOUR WHERE id = ?", requested.Export.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(exports.ProcessPending(ctx)).To(Succeed())
+			resp = serve(http.MethodGet, path, authData.ValidToken, "")
+			Expect(resp.Body.String()).To(ContainSubstring(`"status":"ready"`))
+		})
+
+		It("Then other users should not see the export", func() {
+			resp := serve(http.MethodPost, "/api/v1/users/me/export", authData.ValidToken, `{"format":"zip"}`)
+			Expect(resp.Code).To(Equal(http.StatusAccepted))
+
+			var requested struct {
+				Export ExportInfo `json:"export"`
+			}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &requested)).To(Succeed())
+
+			otherToken := testSuite.AuthHelper.MintToken("other-user-123", "otheruser@example.com")
+			resp = serve(http.MethodGet, "/api/v1/users/me/exports/"+requested.Export.ID, otherToken, "")
+			Expect(resp.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/jobs"
+	"bocchi/api/pkg/logger"
+)
+
+// JobsHandler exposes the status of background jobs to administrators
+type JobsHandler struct {
+	scheduler *jobs.Scheduler
+}
+
+// NewJobsHandler creates a new jobs handler
+func NewJobsHandler(scheduler *jobs.Scheduler) *JobsHandler {
+	return &JobsHandler{
+		scheduler: scheduler,
+	}
+}
+
+// ListJobsInput represents the request to list background jobs
+type ListJobsInput struct{}
+
+// JobStatus represents the last run of a background job
+type JobStatus struct {
+	Name            string     `json:"name" doc:"Job name"`
+	IntervalSeconds int64      `json:"interval_seconds" doc:"Nominal interval between runs in seconds (before jitter)"`
+	Runs            int64      `json:"runs" doc:"Number of runs performed by this replica"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty" doc:"Start time of the last run by any replica"`
+	LastAttemptAt   *time.Time `json:"last_attempt_at,omitempty" doc:"Start time of this replica's last run attempt"`
+	LastDurationMs  int64      `json:"last_duration_ms" doc:"Duration of the last run attempt in milliseconds"`
+	LastResult      string     `json:"last_result,omitempty" enum:"succeeded,failed,skipped" doc:"Outcome of the last run attempt; skipped means another replica held the job lock or ran the job within its interval"`
+	LastError       string     `json:"last_error,omitempty" doc:"Error of the last failed run"`
+	NextRunAt       time.Time  `json:"next_run_at" doc:"Scheduled time of the next run attempt"`
+}
+
+// ListJobsOutput represents the response for listing background jobs
+type ListJobsOutput struct {
+	Body struct {
+		Jobs      []JobStatus `json:"jobs" doc:"Background jobs on this replica"`
+		Timestamp time.Time   `json:"timestamp" doc:"Response timestamp"`
+	}
+}
+
+// RegisterRoutesWithAuth registers the admin job routes
+func (h *JobsHandler) RegisterRoutesWithAuth(api huma.API, authMiddleware *auth.AuthMiddleware) {
+	huma.Register(api, authMiddleware.RequirePermissions(api, huma.Operation{
+		OperationID: "list-jobs",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/admin/jobs",
+		Summary:     "List background jobs",
+		Description: "Get the last-run status of every background job on this replica (admin only)",
+		Tags:        []string{"Admin"},
+	}, auth.PermissionAdminSystem), h.ListJobs)
+}
+
+// ListJobs returns the status of every background job
+func (h *JobsHandler) ListJobs(ctx context.Context, input *ListJobsInput) (*ListJobsOutput, error) {
+	resp := &ListJobsOutput{}
+	resp.Body.Timestamp = time.Now()
+	resp.Body.Jobs = make([]JobStatus, 0)
+
+	lastRuns, err := h.scheduler.LastRuns(ctx)
+	if err != nil {
+		logger.ErrorWithContext(ctx, "Failed to read job runs", err)
+		return nil, huma.Error500InternalServerError("failed to read job runs")
+	}
+
+	for _, status := range h.scheduler.Statuses() {
+		job := JobStatus{
+			Name:            status.Name,
+			IntervalSeconds: int64(status.Interval.Seconds()),
+			Runs:            status.Runs,
+			LastDurationMs:  status.LastDuration.Milliseconds(),
+			LastResult:      status.LastResult,
+			LastError:       status.LastError,
+			NextRunAt:       status.NextRunAt,
+		}
+		if lastRunAt, ok := lastRuns[status.Name]; ok {
+			job.LastRunAt = &lastRunAt
+		}
+		if !status.LastAttemptAt.IsZero() {
+			lastAttemptAt := status.LastAttemptAt
+			job.LastAttemptAt = &lastAttemptAt
+		}
+		resp.Body.Jobs = append(resp.Body.Jobs, job)
+	}
+
+	return resp, nil
+}
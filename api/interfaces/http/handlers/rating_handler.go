@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"bocchi/api/application/clients"
+	ratingv1 "bocchi/api/gen/rating/v1"
+	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/errors"
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// RatingHandler handles solo-friendly rating HTTP requests
+type RatingHandler struct {
+	ratingClient *clients.RatingClient
+}
+
+// NewRatingHandler creates a new rating handler
+func NewRatingHandler(ratingClient *clients.RatingClient) *RatingHandler {
+	if ratingClient == nil {
+		panic("ratingClient cannot be nil")
+	}
+	return &RatingHandler{
+		ratingClient: ratingClient,
+	}
+}
+
+// SoloRatingInput represents the request to create or update a solo-friendly rating
+type SoloRatingInput struct {
+	SpotID string `path:"spot_id" maxLength:"36" doc:"Spot ID"`
+	Body   struct {
+		SoloFriendlyRating int32    `json:"solo_friendly_rating" minimum:"1" maximum:"5" doc:"How comfortable the spot is for a solo visit, from 1 to 5"`
+		Categories         []string `json:"categories,omitempty" maxItems:"10" doc:"Solo-friendly features such as quiet_atmosphere, wifi_available or power_outlets"`
+		Comment            string   `json:"comment,omitempty" maxLength:"1000" doc:"Optional comment"`
+	}
+}
+
+// SoloRatingOutput represents a single solo-friendly rating response (using protobuf SoloRating type)
+type SoloRatingOutput struct {
+	Body *ratingv1.SoloRating `json:"rating" doc:"Solo-friendly rating data"`
+}
+
+// SpotSoloRatingInput identifies a spot for rating lookups
+type SpotSoloRatingInput struct {
+	SpotID string `path:"spot_id" maxLength:"36" doc:"Spot ID"`
+}
+
+// GetSpotSoloRatingsOutput represents the response for getting a spot's solo-friendly ratings
+type GetSpotSoloRatingsOutput struct {
+	Body struct {
+		Ratings    []*ratingv1.SoloRating         `json:"ratings" doc:"List of solo-friendly ratings"`
+		Statistics *ratingv1.SoloRatingStatistics `json:"statistics" doc:"Solo-friendly statistics"`
+	}
+}
+
+// RegisterRoutes registers rating routes
+func (h *RatingHandler) RegisterRoutes(api huma.API) {
+	// Get solo-friendly ratings for a spot (public)
+	huma.Register(api, huma.Operation{
+		OperationID: "get-spot-solo-ratings",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/spots/{spot_id}/solo-ratings",
+		Summary:     "Get solo-friendly ratings for a spot",
+		Description: "Get all solo-friendly ratings for a spot with statistics",
+		Tags:        []string{"Ratings"},
+	}, h.GetSpotSoloRatings)
+}
+
+// RegisterRoutesWithAuth registers rating routes with authentication middleware
+func (h *RatingHandler) RegisterRoutesWithAuth(api huma.API, authMiddleware *auth.AuthMiddleware) {
+	// Register public routes first
+	h.RegisterRoutes(api)
+
+	// Create rating (protected - requires authentication and a verified email)
+	huma.Register(api, authMiddleware.RequireVerifiedEmail(api, huma.Operation{
+		OperationID: "create-solo-rating",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/spots/{spot_id}/solo-ratings",
+		Summary:     "Rate a spot",
+		Description: "Rate how solo-friendly a spot is (requires authentication and, when the policy is enabled, a verified email; one rating per spot)",
+		Tags:        []string{"Ratings"},
+	}, "rate spots"), h.CreateSoloRating)
+
+	// Get own rating (protected - requires authentication)
+	huma.Register(api, authMiddleware.CreateProtectedOperation(huma.Operation{
+		OperationID: "get-my-solo-rating",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/spots/{spot_id}/solo-ratings/me",
+		Summary:     "Get my rating of a spot",
+		Description: "Get the authenticated user's solo-friendly rating of a spot",
+		Tags:        []string{"Ratings"},
+	}), h.GetMySoloRating)
+
+	// Update own rating (protected - requires authentication)
+	huma.Register(api, authMiddleware.CreateProtectedOperation(huma.Operation{
+		OperationID: "update-solo-rating",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/spots/{spot_id}/solo-ratings/me",
+		Summary:     "Update my rating of a spot",
+		Description: "Update the authenticated user's solo-friendly rating of a spot",
+		Tags:        []string{"Ratings"},
+	}), h.UpdateSoloRating)
+}
+
+// CreateSoloRating rates a spot for the authenticated user
+func (h *RatingHandler) CreateSoloRating(ctx context.Context, input *SoloRatingInput) (*SoloRatingOutput, error) {
+	// Extract user ID from Huma v2 authentication context
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required to rate spot")
+	}
+
+	// Add user ID to context for gRPC service access
+	ctx = errors.WithUserID(ctx, userID)
+
+	resp, err := h.ratingClient.CreateSoloRating(ctx, &ratingv1.CreateSoloRatingRequest{
+		SpotId:             input.SpotID,
+		SoloFriendlyRating: input.Body.SoloFriendlyRating,
+		Categories:         input.Body.Categories,
+		Comment:            input.Body.Comment,
+	})
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to create rating")
+	}
+
+	return &SoloRatingOutput{
+		Body: resp.Rating,
+	}, nil
+}
+
+// UpdateSoloRating updates the authenticated user's rating of a spot
+func (h *RatingHandler) UpdateSoloRating(ctx context.Context, input *SoloRatingInput) (*SoloRatingOutput, error) {
+	// Extract user ID from Huma v2 authentication context
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required to update rating")
+	}
+
+	// Add user ID to context for gRPC service access
+	ctx = errors.WithUserID(ctx, userID)
+
+	resp, err := h.ratingClient.UpdateSoloRating(ctx, &ratingv1.UpdateSoloRatingRequest{
+		SpotId:             input.SpotID,
+		SoloFriendlyRating: input.Body.SoloFriendlyRating,
+		Categories:         input.Body.Categories,
+		Comment:            input.Body.Comment,
+	})
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to update rating")
+	}
+
+	return &SoloRatingOutput{
+		Body: resp.Rating,
+	}, nil
+}
+
+// GetMySoloRating gets the authenticated user's rating of a spot
+func (h *RatingHandler) GetMySoloRating(ctx context.Context, input *SpotSoloRatingInput) (*SoloRatingOutput, error) {
+	// Extract user ID from Huma v2 authentication context
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required to get rating")
+	}
+
+	// Add user ID to context for gRPC service access
+	ctx = errors.WithUserID(ctx, userID)
+
+	resp, err := h.ratingClient.GetMySoloRating(ctx, &ratingv1.GetMySoloRatingRequest{
+		SpotId: input.SpotID,
+	})
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to get rating")
+	}
+
+	return &SoloRatingOutput{
+		Body: resp.Rating,
+	}, nil
+}
+
+// GetSpotSoloRatings gets all solo-friendly ratings for a spot
+func (h *RatingHandler) GetSpotSoloRatings(ctx context.Context, input *SpotSoloRatingInput) (*GetSpotSoloRatingsOutput, error) {
+	resp, err := h.ratingClient.GetSpotSoloRatings(ctx, &ratingv1.GetSpotSoloRatingsRequest{
+		SpotId: input.SpotID,
+	})
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to get spot ratings")
+	}
+
+	output := &GetSpotSoloRatingsOutput{}
+	output.Body.Ratings = resp.Ratings
+	output.Body.Statistics = resp.Statistics
+	return output, nil
+}
@@ -0,0 +1,104 @@
+//go:build integration
+
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"bocchi/api/application/clients"
+	"bocchi/api/infrastructure/database"
+	"bocchi/api/internal/domain/rating"
+	"bocchi/api/tests/helpers"
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/go-chi/chi/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RatingHandler BDD Tests", func() {
+	var (
+		testServer  *httptest.Server
+		spotFixture *helpers.SpotFixture
+	)
+
+	BeforeEach(func() {
+		By("Setting up RatingHandler test environment")
+
+		ratingClient, err := clients.NewRatingClient("internal", testSuite.TestDB.DB)
+		Expect(err).NotTo(HaveOccurred())
+
+		// Setup test API with chi router
+		router := chi.NewRouter()
+		api := humachi.New(router, huma.DefaultConfig("Test API", "1.0.0"))
+		testServer = httptest.NewServer(router)
+		NewRatingHandler(ratingClient).RegisterRoutes(api)
+
+		spotFixture = &helpers.SpotFixture{
+			ID:          "test-spot-solo-rating",
+			Name:        "Quiet Library Cafe",
+			Latitude:    35.6762,
+			Longitude:   139.6503,
+			Category:    "cafe",
+			Address:     "Test Address",
+			CountryCode: "JP",
+		}
+		testSuite.FixtureManager.CreateSpotFixture(context.Background(), *spotFixture)
+	})
+
+	AfterEach(func() {
+		testServer.Close()
+	})
+
+	Describe("Getting solo-friendly ratings for a spot", func() {
+		Context("Given a spot without ratings", func() {
+			It("Then empty statistics should be returned", func() {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/spots/"+spotFixture.ID+"/solo-ratings", nil)
+				resp := httptest.NewRecorder()
+				testServer.Config.Handler.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				responseBody := verifyResponseBody(resp)
+				Expect(responseBody["ratings"]).To(BeEmpty())
+				statsMap := verifyStatisticsExists(responseBody)
+				Expect(statsMap["average_rating"]).To(BeNil(), "Zero average is omitted from the protobuf JSON")
+			})
+		})
+
+		Context("Given a spot with stored ratings", func() {
+			It("Then the ratings and category counts should be returned", func() {
+				By("Storing ratings through the repository")
+				repo := database.NewRatingRepository(testSuite.TestDB.DB)
+				for i, score := range []int{5, 3} {
+					r, err := rating.NewRating(spotFixture.ID, []string{"user-a", "user-b"}[i], score, []string{"wifi_available"}, "")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(repo.Create(context.Background(), r)).To(Succeed())
+				}
+
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/spots/"+spotFixture.ID+"/solo-ratings", nil)
+				resp := httptest.NewRecorder()
+				testServer.Config.Handler.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				responseBody := verifyResponseBody(resp)
+				Expect(responseBody["ratings"]).To(HaveLen(2))
+				statsMap := verifyStatisticsExists(responseBody)
+				Expect(statsMap["average_rating"]).To(Equal(float64(4)))
+				Expect(statsMap["total_count"]).To(Equal(float64(2)))
+				Expect(statsMap["category_counts"]).To(HaveKeyWithValue("wifi_available", float64(2)))
+			})
+		})
+
+		Context("Given a spot that does not exist", func() {
+			It("Then 404 should be returned", func() {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/spots/missing-spot/solo-ratings", nil)
+				resp := httptest.NewRecorder()
+				testServer.Config.Handler.ServeHTTP(resp, req)
+
+				Expect(resp.Code).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+})
@@ -43,6 +43,29 @@ type CreateReviewOutput struct {
 	Body *reviewv1.Review `json:"review" doc:"Created review data"`
 }
 
+// UpdateReviewInput represents the review update request
+type UpdateReviewInput struct {
+	ID   string `path:"id" maxLength:"36" doc:"Review ID"`
+	Body struct {
+		Rating        int32            `json:"rating" minimum:"1" maximum:"5" doc:"Rating from 1 to 5"`
+		Comment       string           `json:"comment,omitempty" maxLength:"1000" doc:"Optional review comment"`
+		RatingAspects map[string]int32 `json:"rating_aspects,omitempty" doc:"Optional aspect ratings"`
+	}
+}
+
+// UpdateReviewOutput represents the response for review update (using protobuf Review type)
+type UpdateReviewOutput struct {
+	Body *reviewv1.Review `json:"review" doc:"Updated review data"`
+}
+
+// DeleteReviewInput represents the review deletion request
+type DeleteReviewInput struct {
+	ID string `path:"id" maxLength:"36" doc:"Review ID"`
+}
+
+// DeleteReviewOutput represents the response for review deletion
+type DeleteReviewOutput struct{} // Empty response body for 204 No Content
+
 // GetSpotReviewsInput represents the request to get reviews for a spot
 type GetSpotReviewsInput struct {
 	SpotID string `path:"spot_id" maxLength:"36" doc:"Spot ID"`
@@ -103,15 +126,36 @@ func (h *ReviewHandler) RegisterRoutesWithAuth(api huma.API, authMiddleware *aut
 	// Register public routes first
 	h.RegisterRoutes(api)
 
-	// Create review (protected - requires authentication)
-	huma.Register(api, authMiddleware.CreateProtectedOperation(huma.Operation{
+	// Create review (protected - requires authentication and a verified email)
+	huma.Register(api, authMiddleware.RequireVerifiedEmail(api, huma.Operation{
 		OperationID: "create-review",
 		Method:      http.MethodPost,
 		Path:        "/api/v1/reviews",
 		Summary:     "Create a review",
-		Description: "Create a new review for a spot (requires authentication)",
+		Description: "Create a new review for a spot (requires authentication and, when the policy is enabled, a verified email)",
 		Tags:        []string{"Reviews"},
-	}), h.CreateReview)
+	}, "write reviews"), h.CreateReview)
+
+	// Update review (protected - requires authentication and authorship)
+	huma.Register(api, authMiddleware.CreateProtectedOperation(huma.Operation{
+		OperationID: "update-review",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/reviews/{id}",
+		Summary:     "Update a review",
+		Description: "Update your own review (requires authentication)",
+		Tags:        []string{"Reviews"},
+	}), h.UpdateReview)
+
+	// Delete review (protected - requires authentication and authorship)
+	huma.Register(api, authMiddleware.CreateProtectedOperation(huma.Operation{
+		OperationID:   "delete-review",
+		Method:        http.MethodDelete,
+		Path:          "/api/v1/reviews/{id}",
+		Summary:       "Delete a review",
+		Description:   "Delete your own review (requires authentication)",
+		Tags:          []string{"Reviews"},
+		DefaultStatus: http.StatusNoContent,
+	}), h.DeleteReview)
 }
 
 // CreateReview creates a new review
@@ -142,6 +186,50 @@ func (h *ReviewHandler) CreateReview(ctx context.Context, input *CreateReviewInp
 	}, nil
 }
 
+// UpdateReview updates the authenticated user's review
+func (h *ReviewHandler) UpdateReview(ctx context.Context, input *UpdateReviewInput) (*UpdateReviewOutput, error) {
+	// Extract user ID from Huma v2 authentication context
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required to update review")
+	}
+
+	// Add user ID to context for gRPC service access
+	ctx = errors.WithUserID(ctx, userID)
+
+	resp, err := h.reviewClient.UpdateReview(ctx, &reviewv1.UpdateReviewRequest{
+		Id:            input.ID,
+		Rating:        input.Body.Rating,
+		Comment:       input.Body.Comment,
+		RatingAspects: input.Body.RatingAspects,
+	})
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to update review")
+	}
+
+	return &UpdateReviewOutput{
+		Body: resp.Review,
+	}, nil
+}
+
+// DeleteReview deletes the authenticated user's review
+func (h *ReviewHandler) DeleteReview(ctx context.Context, input *DeleteReviewInput) (*DeleteReviewOutput, error) {
+	// Extract user ID from Huma v2 authentication context
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required to delete review")
+	}
+
+	// Add user ID to context for gRPC service access
+	ctx = errors.WithUserID(ctx, userID)
+
+	if _, err := h.reviewClient.DeleteReview(ctx, &reviewv1.DeleteReviewRequest{Id: input.ID}); err != nil {
+		return nil, grpcToHTTPError(err, "failed to delete review")
+	}
+
+	return &DeleteReviewOutput{}, nil
+}
+
 // GetSpotReviews gets reviews for a specific spot
 func (h *ReviewHandler) GetSpotReviews(ctx context.Context, input *GetSpotReviewsInput) (*GetSpotReviewsOutput, error) {
 	// Call gRPC service via client
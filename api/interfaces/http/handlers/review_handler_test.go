@@ -14,6 +14,7 @@ import (
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
 	"github.com/go-chi/chi/v5"
 	"bocchi/api/application/clients"
+	"bocchi/api/pkg/auth"
 	"bocchi/api/tests/helpers"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -667,4 +668,136 @@ var _ = Describe("ReviewHandler BDD Tests", func() {
 			})
 		})
 	})
-})
\ No newline at end of file
+	Describe("Review Update and Deletion", func() {
+		var (
+			authServer *httptest.Server
+			reviewID   string
+			otherToken string
+		)
+
+		sendRequest := func(method, path, token string, body map[string]interface{}) *httptest.ResponseRecorder {
+			reader := bytes.NewReader(nil)
+			if body != nil {
+				bodyBytes, err := json.Marshal(body)
+				Expect(err).NotTo(HaveOccurred())
+				reader = bytes.NewReader(bodyBytes)
+			}
+
+			req := httptest.NewRequest(method, path, reader)
+			req.Header.Set("Content-Type", "application/json")
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			resp := httptest.NewRecorder()
+			authServer.Config.Handler.ServeHTTP(resp, req)
+			return resp
+		}
+
+		reviewPath := func(id string) string {
+			return fmt.Sprintf("/api/v1/reviews/%s", id)
+		}
+
+		expectSpotStatistics := func(reviewCount int32, ratingSum int32) {
+			spot, err := testSuite.TestDB.Queries.GetSpotByID(context.Background(), spotFixture.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(spot.ReviewCount).To(Equal(reviewCount))
+			Expect(spot.RatingSum).To(Equal(ratingSum))
+		}
+
+		BeforeEach(func() {
+			By("Registering the protected review routes behind authentication")
+			router := chi.NewRouter()
+			authAPI := humachi.New(router, huma.DefaultConfig("Test API", "1.0.0"))
+			authMiddleware := auth.NewAuthMiddleware(helpers.TestJWTSecret, testSuite.TestDB.Queries)
+			DeferCleanup(authMiddleware.Stop)
+			reviewHandler.RegisterRoutesWithAuth(authAPI, authMiddleware)
+			authServer = httptest.NewServer(router)
+			DeferCleanup(authServer.Close)
+
+			By("Creating a review through the API so the spot statistics include it")
+			resp := sendRequest(http.MethodPost, "/api/v1/reviews", authData.ValidToken, map[string]interface{}{
+				"spot_id": spotFixture.ID,
+				"rating":  2,
+				"comment": "Too crowded at lunch time.",
+			})
+			Expect(resp.Code).To(Equal(http.StatusCreated))
+			reviewID = verifyResponseBody(resp)["id"].(string)
+			expectSpotStatistics(1, 2)
+
+			otherToken = testSuite.AuthHelper.MintToken("other-review-user", "other-review@example.com", "write:reviews")
+		})
+
+		Context("Given the review author", func() {
+			It("Then the review and the spot statistics should be updated", func() {
+				resp := sendRequest(http.MethodPut, reviewPath(reviewID), authData.ValidToken, map[string]interface{}{
+					"rating":  5,
+					"comment": "Quiet in the evening.",
+				})
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				responseBody := verifyResponseBody(resp)
+				Expect(responseBody["rating"]).To(Equal(float64(5)))
+				Expect(responseBody["comment"]).To(Equal("Quiet in the evening."))
+				expectSpotStatistics(1, 5)
+			})
+
+			It("Then a rating outside 1-5 should be rejected", func() {
+				resp := sendRequest(http.MethodPut, reviewPath(reviewID), authData.ValidToken, map[string]interface{}{
+					"rating": 6,
+				})
+				Expect(resp.Code).To(Equal(http.StatusUnprocessableEntity))
+				expectSpotStatistics(1, 2)
+			})
+
+			It("Then the review should be deleted and removed from the spot statistics", func() {
+				resp := sendRequest(http.MethodDelete, reviewPath(reviewID), authData.ValidToken, nil)
+				Expect(resp.Code).To(Equal(http.StatusNoContent))
+
+				_, err := testSuite.TestDB.Queries.GetReviewByID(context.Background(), reviewID)
+				Expect(err).To(HaveOccurred(), "Deleted review should not be found")
+				expectSpotStatistics(0, 0)
+			})
+		})
+
+		Context("Given another user", func() {
+			It("Then updating the review should be forbidden", func() {
+				resp := sendRequest(http.MethodPut, reviewPath(reviewID), otherToken, map[string]interface{}{
+					"rating": 1,
+				})
+				Expect(resp.Code).To(Equal(http.StatusForbidden))
+				expectSpotStatistics(1, 2)
+			})
+
+			It("Then deleting the review should be forbidden", func() {
+				resp := sendRequest(http.MethodDelete, reviewPath(reviewID), otherToken, nil)
+				Expect(resp.Code).To(Equal(http.StatusForbidden))
+				expectSpotStatistics(1, 2)
+			})
+		})
+
+		Context("Given an unauthenticated request", func() {
+			It("Then updating and deleting should require authentication", func() {
+				resp := sendRequest(http.MethodPut, reviewPath(reviewID), "", map[string]interface{}{
+					"rating": 1,
+				})
+				Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+
+				resp = sendRequest(http.MethodDelete, reviewPath(reviewID), "", nil)
+				Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			})
+		})
+
+		Context("Given a non-existent review", func() {
+			It("Then updating and deleting should return not found", func() {
+				resp := sendRequest(http.MethodPut, reviewPath("non-existent-review"), authData.ValidToken, map[string]interface{}{
+					"rating": 3,
+				})
+				Expect(resp.Code).To(Equal(http.StatusNotFound))
+
+				resp = sendRequest(http.MethodDelete, reviewPath("non-existent-review"), authData.ValidToken, nil)
+				Expect(resp.Code).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+})
@@ -3,10 +3,13 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"bocchi/api/application/clients"
 	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/geo"
 	spotv1 "bocchi/api/gen/spot/v1"
 	commonv1 "bocchi/api/gen/common/v1"
 )
@@ -55,6 +58,32 @@ type GetSpotOutput struct {
 
 // ListSpotsInput represents the request to list spots
 type ListSpotsInput struct {
+	Page             int      `query:"page" default:"1" minimum:"1" doc:"Page number"`
+	PageSize         int      `query:"page_size" default:"20" minimum:"1" maximum:"500" doc:"Items per page (at most 100, or 500 in bbox mode)"`
+	Latitude         float64  `query:"lat,omitempty" minimum:"-90" maximum:"90" doc:"Center latitude"`
+	Longitude        float64  `query:"lng,omitempty" minimum:"-180" maximum:"180" doc:"Center longitude"`
+	RadiusKm         float64  `query:"radius_km,omitempty" minimum:"0.1" maximum:"50" doc:"Search radius in km"`
+	BBox             string   `query:"bbox,omitempty" doc:"Viewport as minLng,minLat,maxLng,maxLat; minLng > maxLng crosses the antimeridian"`
+	SortBy           string   `query:"sort_by,omitempty" enum:"average_rating,review_count" doc:"Sort order in bbox mode"`
+	Category         string   `query:"category,omitempty" doc:"Filter by category"`
+	CountryCode      string   `query:"country_code,omitempty" doc:"Filter by country code"`
+	SoloCategories   []string `query:"solo_categories,omitempty" doc:"Only spots confirmed for every listed solo-friendly category (comma-separated)"`
+	MinConfirmations int      `query:"min_confirmations,omitempty" minimum:"0" doc:"Confirmations required per solo category (default 1)"`
+	MinSoloScore     float64  `query:"min_solo_score,omitempty" minimum:"0" maximum:"5" doc:"Minimum solo-friendly rating"`
+}
+
+// ListSpotsOutput represents the response for listing spots (using protobuf types)
+type ListSpotsOutput struct {
+	Body struct {
+		Spots      []*spotv1.Spot             `json:"spots" doc:"List of spots"`
+		Pagination *commonv1.PaginationResponse `json:"pagination" doc:"Pagination metadata"`
+	}
+}
+
+// SearchSpotsInput represents the request to search spots
+type SearchSpotsInput struct {
+	Query       string  `query:"q" minLength:"2" maxLength:"100" doc:"Search text matched against names and addresses in all languages"`
+	Lang        string  `query:"lang,omitempty" enum:"ja,en" doc:"Preferred language; matches in this language rank higher"`
 	Page        int     `query:"page" default:"1" minimum:"1" doc:"Page number"`
 	PageSize    int     `query:"page_size" default:"20" minimum:"1" maximum:"100" doc:"Items per page"`
 	Latitude    float64 `query:"lat,omitempty" minimum:"-90" maximum:"90" doc:"Center latitude"`
@@ -62,16 +91,33 @@ type ListSpotsInput struct {
 	RadiusKm    float64 `query:"radius_km,omitempty" minimum:"0.1" maximum:"50" doc:"Search radius in km"`
 	Category    string  `query:"category,omitempty" doc:"Filter by category"`
 	CountryCode string  `query:"country_code,omitempty" doc:"Filter by country code"`
+	SortBy      string  `query:"sort_by,omitempty" enum:"relevance,solo_friendly_rating" doc:"Sort order; solo_friendly_rating ranks by solo-friendly rating, then relevance"`
 }
 
-// ListSpotsOutput represents the response for listing spots (using protobuf types)
-type ListSpotsOutput struct {
+// SearchSpotsOutput represents the response for searching spots (using protobuf types)
+type SearchSpotsOutput struct {
 	Body struct {
-		Spots      []*spotv1.Spot             `json:"spots" doc:"List of spots"`
+		Spots      []*spotv1.Spot              `json:"spots" doc:"Matching spots ordered by relevance"`
 		Pagination *commonv1.PaginationResponse `json:"pagination" doc:"Pagination metadata"`
 	}
 }
 
+// ClusterSpotsInput represents the request to cluster spots for a map viewport
+type ClusterSpotsInput struct {
+	BBox        string `query:"bbox" doc:"Viewport as minLng,minLat,maxLng,maxLat"`
+	Zoom        int    `query:"zoom" minimum:"0" maximum:"22" doc:"Web map zoom level"`
+	Category    string `query:"category,omitempty" doc:"Filter by category"`
+	CountryCode string `query:"country_code,omitempty" doc:"Filter by country code"`
+}
+
+// ClusterSpotsOutput represents the response for spot clustering (using protobuf types)
+type ClusterSpotsOutput struct {
+	Body struct {
+		Clusters        []*spotv1.SpotCluster `json:"clusters" doc:"Spot clusters inside the viewport"`
+		CellSizeDegrees float64               `json:"cell_size_degrees" doc:"Grid cell size used for this zoom level"`
+	}
+}
+
 // UpdateSpotInput represents the request to update a spot
 type UpdateSpotInput struct {
 	ID string `path:"id" doc:"Spot ID"`
@@ -100,6 +146,14 @@ type UpdateSpotOutput struct {
 	}
 }
 
+// DeleteSpotInput represents the request to delete a spot
+type DeleteSpotInput struct {
+	ID string `path:"id" doc:"Spot ID"`
+}
+
+// DeleteSpotOutput represents the response for deleting a spot
+type DeleteSpotOutput struct{} // Empty response body for 204 No Content
+
 // RegisterRoutes registers spot routes
 func (h *SpotHandler) RegisterRoutes(api huma.API) {
 	// Get spot (public)
@@ -118,9 +172,29 @@ func (h *SpotHandler) RegisterRoutes(api huma.API) {
 		Method:      http.MethodGet,
 		Path:        "/api/v1/spots",
 		Summary:     "List spots",
-		Description: "List spots with optional filters",
+		Description: "List spots with optional filters. When lat/lng are given, spots within radius_km are returned ordered by distance. When bbox is given, spots inside the viewport are returned ordered by sort_by",
 		Tags:        []string{"Spots"},
 	}, h.ListSpots)
+
+	// Search spots (public)
+	huma.Register(api, huma.Operation{
+		OperationID: "search-spots",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/spots/search",
+		Summary:     "Search spots",
+		Description: "Full-text search over spot names and addresses, including localized values",
+		Tags:        []string{"Spots"},
+	}, h.SearchSpots)
+
+	// Cluster spots for map display (public)
+	huma.Register(api, huma.Operation{
+		OperationID: "cluster-spots",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/spots/clusters",
+		Summary:     "Cluster spots",
+		Description: "Aggregate spots inside a viewport into grid cells with count, centroid and average rating",
+		Tags:        []string{"Spots"},
+	}, h.ClusterSpots)
 }
 
 // RegisterRoutesWithAuth registers spot routes with authentication middleware
@@ -128,18 +202,36 @@ func (h *SpotHandler) RegisterRoutesWithAuth(api huma.API, authMiddleware *auth.
 	// Register public routes first
 	h.RegisterRoutes(api)
 
-	// Create spot (protected - requires authentication)
-	huma.Register(api, authMiddleware.CreateProtectedOperation(huma.Operation{
+	// Create spot (protected - requires authentication and a verified email)
+	huma.Register(api, authMiddleware.RequireVerifiedEmail(api, huma.Operation{
 		OperationID: "create-spot",
 		Method:      http.MethodPost,
 		Path:        "/api/v1/spots",
 		Summary:     "Create a new spot",
-		Description: "Create a new reviewable spot on the map (requires authentication)",
+		Description: "Create a new reviewable spot on the map (requires authentication and, when the policy is enabled, a verified email)",
+		Tags:        []string{"Spots"},
+	}, "create spots"), h.CreateSpot)
+
+	// Update spot (protected - requires authentication and ownership or admin permission)
+	huma.Register(api, authMiddleware.CreateProtectedOperation(huma.Operation{
+		OperationID: "update-spot",
+		Method:      http.MethodPut,
+		Path:        "/api/v1/spots/{id}",
+		Summary:     "Update a spot",
+		Description: "Update a spot (requires authentication; only the creator or an admin may update)",
 		Tags:        []string{"Spots"},
-	}), h.CreateSpot)
+	}), h.UpdateSpot)
 
-	// TODO: Update spot (protected - requires authentication and ownership or admin permission)
-	// Will be implemented when UpdateSpotRequest is available in gRPC service
+	// Delete spot (protected - requires authentication and ownership or admin permission)
+	huma.Register(api, authMiddleware.CreateProtectedOperation(huma.Operation{
+		OperationID:   "delete-spot",
+		Method:        http.MethodDelete,
+		Path:          "/api/v1/spots/{id}",
+		Summary:       "Delete a spot",
+		Description:   "Delete a spot and its reviews (requires authentication; only the creator or an admin may delete)",
+		Tags:          []string{"Spots"},
+		DefaultStatus: http.StatusNoContent,
+	}), h.DeleteSpot)
 }
 
 // CreateSpot creates a new spot
@@ -150,6 +242,9 @@ func (h *SpotHandler) CreateSpot(ctx context.Context, input *CreateSpotInput) (*
 		return nil, huma.Error401Unauthorized("authentication required to create spot")
 	}
 
+	// Add user ID to context for creator tracking in the gRPC service
+	ctx = errors.WithUserID(ctx, userID)
+
 	// Convert HTTP request to gRPC request
 	grpcReq := &spotv1.CreateSpotRequest{
 		Name:        input.Body.Name,
@@ -162,7 +257,6 @@ func (h *SpotHandler) CreateSpot(ctx context.Context, input *CreateSpotInput) (*
 		Address:     input.Body.Address,
 		AddressI18N: input.Body.AddressI18n,
 		CountryCode: input.Body.CountryCode,
-		// Note: Creator tracking will be handled by auth middleware
 	}
 
 	// Call gRPC service
@@ -204,8 +298,26 @@ func (h *SpotHandler) ListSpots(ctx context.Context, input *ListSpotsInput) (*Li
 			Page:     int32(input.Page),
 			PageSize: int32(input.PageSize),
 		},
-		Category:    input.Category,
-		CountryCode: input.CountryCode,
+		Category:         input.Category,
+		CountryCode:      input.CountryCode,
+		SortBy:           input.SortBy,
+		SoloCategories:   input.SoloCategories,
+		MinConfirmations: int32(input.MinConfirmations),
+		MinSoloScore:     input.MinSoloScore,
+	}
+
+	// Add viewport if provided
+	if input.BBox != "" {
+		box, err := geo.ParseBoundingBox(input.BBox)
+		if err != nil {
+			return nil, huma.Error400BadRequest(err.Error())
+		}
+		grpcReq.Bbox = &commonv1.BoundingBox{
+			MinLatitude:  box.MinLat,
+			MinLongitude: box.MinLng,
+			MaxLatitude:  box.MaxLat,
+			MaxLongitude: box.MaxLng,
+		}
 	}
 
 	// Add coordinates if provided (check for non-zero or explicit flag)
@@ -220,7 +332,7 @@ func (h *SpotHandler) ListSpots(ctx context.Context, input *ListSpotsInput) (*Li
 	// Call gRPC service
 	grpcResp, err := h.spotClient.ListSpots(ctx, grpcReq)
 	if err != nil {
-		return nil, err
+		return nil, grpcToHTTPError(err, "failed to list spots")
 	}
 
 	// Convert gRPC response to HTTP response
@@ -235,4 +347,154 @@ func (h *SpotHandler) ListSpots(ctx context.Context, input *ListSpotsInput) (*Li
 	}, nil
 }
 
-// TODO: UpdateSpot - will be implemented when UpdateSpotRequest is available in gRPC service
\ No newline at end of file
+// SearchSpots searches spots by text
+func (h *SpotHandler) SearchSpots(ctx context.Context, input *SearchSpotsInput) (*SearchSpotsOutput, error) {
+	// Convert HTTP request to gRPC request
+	grpcReq := &spotv1.SearchSpotsRequest{
+		Query: input.Query,
+		Pagination: &commonv1.PaginationRequest{
+			Page:     int32(input.Page),
+			PageSize: int32(input.PageSize),
+		},
+		Category:    input.Category,
+		CountryCode: input.CountryCode,
+		SortBy:      input.SortBy,
+	}
+
+	switch input.Lang {
+	case "ja":
+		grpcReq.Language = commonv1.Language_LANGUAGE_JA
+	case "en":
+		grpcReq.Language = commonv1.Language_LANGUAGE_EN
+	}
+
+	// Add coordinates if provided
+	if input.Latitude != 0 || input.Longitude != 0 {
+		grpcReq.Center = &commonv1.Coordinates{
+			Latitude:  input.Latitude,
+			Longitude: input.Longitude,
+		}
+		grpcReq.RadiusKm = input.RadiusKm
+	}
+
+	// Call gRPC service
+	grpcResp, err := h.spotClient.SearchSpots(ctx, grpcReq)
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to search spots")
+	}
+
+	// Convert gRPC response to HTTP response
+	return &SearchSpotsOutput{
+		Body: struct {
+			Spots      []*spotv1.Spot              `json:"spots" doc:"Matching spots ordered by relevance"`
+			Pagination *commonv1.PaginationResponse `json:"pagination" doc:"Pagination metadata"`
+		}{
+			Spots:      grpcResp.Spots,
+			Pagination: grpcResp.Pagination,
+		},
+	}, nil
+}
+
+// ClusterSpots aggregates spots in a viewport into clusters
+func (h *SpotHandler) ClusterSpots(ctx context.Context, input *ClusterSpotsInput) (*ClusterSpotsOutput, error) {
+	box, err := geo.ParseBoundingBox(input.BBox)
+	if err != nil {
+		return nil, huma.Error400BadRequest(err.Error())
+	}
+
+	// Call gRPC service
+	grpcResp, err := h.spotClient.ClusterSpots(ctx, &spotv1.ClusterSpotsRequest{
+		Bbox: &commonv1.BoundingBox{
+			MinLatitude:  box.MinLat,
+			MinLongitude: box.MinLng,
+			MaxLatitude:  box.MaxLat,
+			MaxLongitude: box.MaxLng,
+		},
+		Zoom:        int32(input.Zoom),
+		Category:    input.Category,
+		CountryCode: input.CountryCode,
+	})
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to cluster spots")
+	}
+
+	// Convert gRPC response to HTTP response
+	return &ClusterSpotsOutput{
+		Body: struct {
+			Clusters        []*spotv1.SpotCluster `json:"clusters" doc:"Spot clusters inside the viewport"`
+			CellSizeDegrees float64               `json:"cell_size_degrees" doc:"Grid cell size used for this zoom level"`
+		}{
+			Clusters:        grpcResp.Clusters,
+			CellSizeDegrees: grpcResp.CellSizeDegrees,
+		},
+	}, nil
+}
+
+// UpdateSpot updates a spot owned by the authenticated user
+func (h *SpotHandler) UpdateSpot(ctx context.Context, input *UpdateSpotInput) (*UpdateSpotOutput, error) {
+	// Extract user ID from authentication context
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required to update spot")
+	}
+
+	// Add user identity to context for ownership checks in the gRPC service
+	ctx = errors.WithUserID(ctx, userID)
+	ctx = errors.WithPermissions(ctx, auth.GetPermissionsFromContext(ctx))
+
+	// Convert HTTP request to gRPC request
+	grpcReq := &spotv1.UpdateSpotRequest{
+		Id:       input.ID,
+		Name:     input.Body.Name,
+		Category: input.Body.Category,
+		Address:  input.Body.Address,
+	}
+	if input.Body.NameI18n != nil {
+		grpcReq.NameI18N = *input.Body.NameI18n
+	}
+	if input.Body.AddressI18n != nil {
+		grpcReq.AddressI18N = *input.Body.AddressI18n
+	}
+
+	// Call gRPC service
+	grpcResp, err := h.spotClient.UpdateSpot(ctx, grpcReq)
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to update spot")
+	}
+
+	// Convert gRPC response to HTTP response
+	spot := grpcResp.Spot
+	output := &UpdateSpotOutput{}
+	output.Body.ID = spot.Id
+	output.Body.Name = spot.Name
+	output.Body.NameI18n = spot.NameI18N
+	output.Body.Latitude = spot.Coordinates.GetLatitude()
+	output.Body.Longitude = spot.Coordinates.GetLongitude()
+	output.Body.Category = spot.Category
+	output.Body.Address = spot.Address
+	output.Body.AddressI18n = spot.AddressI18N
+	output.Body.CountryCode = spot.CountryCode
+	output.Body.UpdatedAt = spot.UpdatedAt.AsTime().Format(time.RFC3339)
+
+	return output, nil
+}
+
+// DeleteSpot deletes a spot owned by the authenticated user
+func (h *SpotHandler) DeleteSpot(ctx context.Context, input *DeleteSpotInput) (*DeleteSpotOutput, error) {
+	// Extract user ID from authentication context
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required to delete spot")
+	}
+
+	// Add user identity to context for ownership checks in the gRPC service
+	ctx = errors.WithUserID(ctx, userID)
+	ctx = errors.WithPermissions(ctx, auth.GetPermissionsFromContext(ctx))
+
+	// Call gRPC service
+	if _, err := h.spotClient.DeleteSpot(ctx, &spotv1.DeleteSpotRequest{Id: input.ID}); err != nil {
+		return nil, grpcToHTTPError(err, "failed to delete spot")
+	}
+
+	return &DeleteSpotOutput{}, nil
+}
\ No newline at end of file
@@ -10,11 +10,13 @@ import (
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
 	"github.com/go-chi/chi/v5"
 	"bocchi/api/application/clients"
+	"bocchi/api/pkg/auth"
 	"bocchi/api/tests/helpers"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -573,6 +575,74 @@ var _ = Describe("SpotHandler BDD Tests", func() {
 			})
 		})
 
+		Context("Given a viewport containing spots", func() {
+			Context("When a user pages past the viewport result cap", func() {
+				It("Then no spots should be returned", func() {
+					req := httptest.NewRequest(http.MethodGet, "/api/v1/spots?bbox=129.0,30.0,146.0,46.0&page=2&page_size=500", nil)
+
+					resp := httptest.NewRecorder()
+					testServer.Config.Handler.ServeHTTP(resp, req)
+					Expect(resp.Code).To(Equal(http.StatusOK))
+
+					var responseBody map[string]interface{}
+					Expect(json.Unmarshal(resp.Body.Bytes(), &responseBody)).To(Succeed())
+					Expect(responseBody["spots"]).To(BeEmpty())
+				})
+			})
+		})
+
+		Context("Given spots with confirmed solo-friendly categories", func() {
+			BeforeEach(func() {
+				By("Confirming the Tokyo cafe as quiet and having single seating")
+				_, err := testSuite.TestDB.DB.ExecContext(context.Background(),
+					"INSERT INTO spot_solo_categories (spot_id, category, confirmations) VALUES (?, ?, ?), (?, ?, ?)",
+					"spot-cafe-tokyo", "quiet_atmosphere", 3,
+					"spot-cafe-tokyo", "single_seating", 1)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Context("When a user filters by solo categories", func() {
+				It("Then only spots confirmed for every category should be returned", func() {
+					req := httptest.NewRequest(http.MethodGet, "/api/v1/spots?solo_categories=quiet_atmosphere,single_seating", nil)
+
+					resp := httptest.NewRecorder()
+					testServer.Config.Handler.ServeHTTP(resp, req)
+					Expect(resp.Code).To(Equal(http.StatusOK))
+
+					var responseBody map[string]interface{}
+					Expect(json.Unmarshal(resp.Body.Bytes(), &responseBody)).To(Succeed())
+
+					spotsArray, ok := responseBody["spots"].([]interface{})
+					Expect(ok).To(BeTrue(), "Spots should be an array")
+					Expect(spotsArray).To(HaveLen(1))
+
+					spot := spotsArray[0].(map[string]interface{})
+					Expect(spot["id"]).To(Equal("spot-cafe-tokyo"))
+					Expect(spot["solo_category_counts"]).To(HaveKeyWithValue("quiet_atmosphere", float64(3)))
+				})
+
+				It("Then spots below the required confirmations should be excluded", func() {
+					req := httptest.NewRequest(http.MethodGet, "/api/v1/spots?solo_categories=single_seating&min_confirmations=2", nil)
+
+					resp := httptest.NewRecorder()
+					testServer.Config.Handler.ServeHTTP(resp, req)
+					Expect(resp.Code).To(Equal(http.StatusOK))
+
+					var responseBody map[string]interface{}
+					Expect(json.Unmarshal(resp.Body.Bytes(), &responseBody)).To(Succeed())
+					Expect(responseBody["spots"]).To(BeEmpty())
+				})
+
+				It("Then an unknown category should be rejected", func() {
+					req := httptest.NewRequest(http.MethodGet, "/api/v1/spots?solo_categories=rooftop", nil)
+
+					resp := httptest.NewRecorder()
+					testServer.Config.Handler.ServeHTTP(resp, req)
+					Expect(resp.Code).To(Equal(http.StatusBadRequest))
+				})
+			})
+		})
+
 		Context("Given no spots in the database", func() {
 			BeforeEach(func() {
 				By("Ensuring database is clean")
@@ -606,4 +676,428 @@ var _ = Describe("SpotHandler BDD Tests", func() {
 			})
 		})
 	})
-})
\ No newline at end of file
+	Describe("Searching spots", func() {
+		BeforeEach(func() {
+			By("Creating multiple test spots")
+			testSuite.FixtureManager.SetupStandardFixtures(context.Background())
+		})
+
+		searchSpots := func(query string) (*httptest.ResponseRecorder, map[string]interface{}) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/spots/search?"+query, nil)
+
+			resp := httptest.NewRecorder()
+			testServer.Config.Handler.ServeHTTP(resp, req)
+
+			var responseBody map[string]interface{}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &responseBody)).To(Succeed())
+			return resp, responseBody
+		}
+
+		Context("Given a query matching a spot name", func() {
+			It("Then the matching spot should be ranked first", func() {
+				resp, responseBody := searchSpots("q=Library")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				// ngram tokens can also match other spots partially, with a lower relevance
+				spotsArray, ok := responseBody["spots"].([]interface{})
+				Expect(ok).To(BeTrue(), "Spots should be an array")
+				Expect(spotsArray).NotTo(BeEmpty())
+
+				spot := spotsArray[0].(map[string]interface{})
+				Expect(spot["id"]).To(Equal("spot-library-osaka"))
+				Expect(spot["relevance"]).To(BeNumerically(">", 0))
+			})
+
+			It("Then localized names should be matched too", func() {
+				resp, responseBody := searchSpots("q=" + url.QueryEscape("東京") + "&lang=ja")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				spotsArray, ok := responseBody["spots"].([]interface{})
+				Expect(ok).To(BeTrue(), "Spots should be an array")
+				Expect(spotsArray).To(HaveLen(1))
+				Expect(spotsArray[0].(map[string]interface{})["id"]).To(Equal("spot-cafe-tokyo"))
+			})
+		})
+
+		Context("Given spots with different solo-friendly ratings", func() {
+			setSoloRating := func(spotID string, rating float64) {
+				_, err := testSuite.TestDB.DB.ExecContext(context.Background(),
+					"UPDATE spots SET solo_friendly_rating = ?, solo_rating_count = 1 WHERE id = ?", rating, spotID)
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			searchIDs := func(query string) []string {
+				resp, responseBody := searchSpots(query)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				spotsArray, ok := responseBody["spots"].([]interface{})
+				Expect(ok).To(BeTrue(), "Spots should be an array")
+				ids := make([]string, len(spotsArray))
+				for i, spot := range spotsArray {
+					ids[i] = spot.(map[string]interface{})["id"].(string)
+				}
+				return ids
+			}
+
+			It("Then sorting by solo-friendly rating should rank the best rated spot first", func() {
+				query := "q=" + url.QueryEscape("Cafe Library") + "&sort_by=solo_friendly_rating"
+
+				setSoloRating("spot-cafe-tokyo", 4.5)
+				setSoloRating("spot-library-osaka", 3.0)
+				Expect(searchIDs(query)).To(Equal([]string{"spot-cafe-tokyo", "spot-library-osaka"}))
+
+				setSoloRating("spot-cafe-tokyo", 2.0)
+				Expect(searchIDs(query)).To(Equal([]string{"spot-library-osaka", "spot-cafe-tokyo"}))
+			})
+
+			It("Then an unknown sort order should be rejected", func() {
+				resp, _ := searchSpots("q=Cafe&sort_by=distance")
+				Expect(resp.Code).To(Equal(http.StatusUnprocessableEntity))
+			})
+		})
+
+		Context("Given a category filter", func() {
+			It("Then only matching spots of that category should be returned", func() {
+				resp, responseBody := searchSpots("q=" + url.QueryEscape("Cafe Library") + "&category=library")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				spotsArray, ok := responseBody["spots"].([]interface{})
+				Expect(ok).To(BeTrue(), "Spots should be an array")
+				Expect(spotsArray).To(HaveLen(1))
+				Expect(spotsArray[0].(map[string]interface{})["id"]).To(Equal("spot-library-osaka"))
+
+				pagination := responseBody["pagination"].(map[string]interface{})
+				Expect(pagination["total_count"]).To(Equal(float64(1)))
+			})
+		})
+
+		Context("Given a country filter", func() {
+			It("Then spots from other countries should be excluded", func() {
+				resp, responseBody := searchSpots("q=" + url.QueryEscape("Cafe Library") + "&country_code=US")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(responseBody["spots"]).To(BeEmpty())
+			})
+		})
+
+		Context("Given an empty query", func() {
+			It("Then a missing query should be rejected", func() {
+				resp, _ := searchSpots("")
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Then a blank query should be rejected", func() {
+				resp, _ := searchSpots("q=%20%20%20")
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("Given pagination parameters", func() {
+			It("Then results should be split into pages", func() {
+				resp, responseBody := searchSpots("q=" + url.QueryEscape("Cafe Library") + "&page=2&page_size=1")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				pagination, ok := responseBody["pagination"].(map[string]interface{})
+				Expect(ok).To(BeTrue(), "Pagination should be an object")
+				Expect(pagination["total_count"]).To(Equal(float64(2)))
+				Expect(pagination["total_pages"]).To(Equal(float64(2)))
+				Expect(pagination["page"]).To(Equal(float64(2)))
+				Expect(pagination["page_size"]).To(Equal(float64(1)))
+				Expect(responseBody["spots"]).To(HaveLen(1))
+			})
+
+			It("Then a page size above the maximum should be rejected", func() {
+				resp, responseBody := searchSpots("q=Cafe&page_size=101")
+				Expect(resp.Code).To(Equal(http.StatusUnprocessableEntity))
+
+				errors, ok := responseBody["errors"].([]interface{})
+				Expect(ok).To(BeTrue(), "Response should contain errors array")
+				Expect(errors[0].(map[string]interface{})["location"]).To(Equal("query.page_size"))
+			})
+
+			It("Then a page below one should be rejected", func() {
+				resp, responseBody := searchSpots("q=Cafe&page=0")
+				Expect(resp.Code).To(Equal(http.StatusUnprocessableEntity))
+
+				errors, ok := responseBody["errors"].([]interface{})
+				Expect(ok).To(BeTrue(), "Response should contain errors array")
+				Expect(errors[0].(map[string]interface{})["location"]).To(Equal("query.page"))
+			})
+		})
+
+		Context("Given invalid coordinates", func() {
+			It("Then an out of range latitude should be rejected", func() {
+				resp, responseBody := searchSpots(fmt.Sprintf("q=Cafe&lat=%v&lng=139.6503", InvalidLatitude))
+				Expect(resp.Code).To(Equal(http.StatusUnprocessableEntity))
+
+				errors, ok := responseBody["errors"].([]interface{})
+				Expect(ok).To(BeTrue(), "Response should contain errors array")
+				firstError := errors[0].(map[string]interface{})
+				Expect(firstError["message"]).To(Equal("expected number <= 90"))
+				Expect(firstError["location"]).To(Equal("query.lat"))
+			})
+
+			It("Then an out of range longitude should be rejected", func() {
+				resp, responseBody := searchSpots(fmt.Sprintf("q=Cafe&lat=35.6762&lng=%v", InvalidLongitude))
+				Expect(resp.Code).To(Equal(http.StatusUnprocessableEntity))
+
+				errors, ok := responseBody["errors"].([]interface{})
+				Expect(ok).To(BeTrue(), "Response should contain errors array")
+				firstError := errors[0].(map[string]interface{})
+				Expect(firstError["message"]).To(Equal("expected number <= 180"))
+				Expect(firstError["location"]).To(Equal("query.lng"))
+			})
+		})
+	})
+	Describe("Clustering spots", func() {
+		BeforeEach(func() {
+			By("Creating multiple test spots")
+			testSuite.FixtureManager.SetupStandardFixtures(context.Background())
+		})
+
+		clusterSpots := func(query string) (*httptest.ResponseRecorder, map[string]interface{}) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/spots/clusters?"+query, nil)
+
+			resp := httptest.NewRecorder()
+			testServer.Config.Handler.ServeHTTP(resp, req)
+
+			var responseBody map[string]interface{}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &responseBody)).To(Succeed())
+			return resp, responseBody
+		}
+
+		// Japan, covering both the Tokyo and Osaka fixtures
+		const japanBBox = "129.0,30.0,146.0,46.0"
+
+		Context("Given a low zoom level", func() {
+			It("Then nearby spots should be merged into one cluster", func() {
+				resp, responseBody := clusterSpots("bbox=" + japanBBox + "&zoom=0")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(responseBody["cell_size_degrees"]).To(Equal(float64(90)))
+
+				clusters, ok := responseBody["clusters"].([]interface{})
+				Expect(ok).To(BeTrue(), "Clusters should be an array")
+				Expect(clusters).To(HaveLen(1))
+
+				cluster := clusters[0].(map[string]interface{})
+				Expect(cluster["id"]).To(HavePrefix("0/"))
+				Expect(cluster["count"]).To(Equal(float64(2)))
+
+				centroid := cluster["centroid"].(map[string]interface{})
+				Expect(centroid["latitude"]).To(BeNumerically("~", (35.6762+34.6937)/2, 0.0001))
+				Expect(centroid["longitude"]).To(BeNumerically("~", (139.6503+135.5023)/2, 0.0001))
+			})
+		})
+
+		Context("Given a high zoom level", func() {
+			It("Then each spot should get its own cluster", func() {
+				resp, responseBody := clusterSpots("bbox=" + japanBBox + "&zoom=12")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				clusters, ok := responseBody["clusters"].([]interface{})
+				Expect(ok).To(BeTrue(), "Clusters should be an array")
+				Expect(clusters).To(HaveLen(2))
+				for _, cluster := range clusters {
+					Expect(cluster.(map[string]interface{})["count"]).To(Equal(float64(1)))
+				}
+			})
+		})
+
+		Context("Given a category filter", func() {
+			It("Then only spots of that category should be clustered", func() {
+				resp, responseBody := clusterSpots("bbox=" + japanBBox + "&zoom=0&category=library")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				clusters, ok := responseBody["clusters"].([]interface{})
+				Expect(ok).To(BeTrue(), "Clusters should be an array")
+				Expect(clusters).To(HaveLen(1))
+				Expect(clusters[0].(map[string]interface{})["count"]).To(Equal(float64(1)))
+			})
+		})
+
+		Context("Given a country filter", func() {
+			It("Then spots from other countries should not be clustered", func() {
+				resp, responseBody := clusterSpots("bbox=" + japanBBox + "&zoom=0&country_code=US")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(responseBody["clusters"]).To(BeEmpty())
+			})
+		})
+
+		Context("Given a viewport without spots", func() {
+			It("Then no clusters should be returned", func() {
+				resp, responseBody := clusterSpots("bbox=-75.0,40.0,-73.0,41.0&zoom=10")
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(responseBody["clusters"]).To(BeEmpty())
+			})
+		})
+
+		Context("Given invalid parameters", func() {
+			It("Then a malformed bbox should be rejected", func() {
+				resp, _ := clusterSpots("bbox=139.0,35.0,140.0&zoom=10")
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Then a bbox with out of range coordinates should be rejected", func() {
+				resp, _ := clusterSpots("bbox=139.0,35.0,140.0,95.0&zoom=10")
+				Expect(resp.Code).To(Equal(http.StatusBadRequest))
+			})
+
+			It("Then a zoom level above the maximum should be rejected", func() {
+				resp, responseBody := clusterSpots("bbox=" + japanBBox + "&zoom=23")
+				Expect(resp.Code).To(Equal(http.StatusUnprocessableEntity))
+
+				errors, ok := responseBody["errors"].([]interface{})
+				Expect(ok).To(BeTrue(), "Response should contain errors array")
+				Expect(errors[0].(map[string]interface{})["location"]).To(Equal("query.zoom"))
+			})
+		})
+	})
+	Describe("Updating and deleting a spot", func() {
+		var (
+			authServer *httptest.Server
+			ownedSpot  helpers.SpotFixture
+			otherToken string
+			adminToken string
+		)
+
+		BeforeEach(func() {
+			By("Registering the protected spot routes behind authentication")
+			router := chi.NewRouter()
+			authAPI := humachi.New(router, huma.DefaultConfig("Test API", "1.0.0"))
+			authMiddleware := auth.NewAuthMiddleware(helpers.TestJWTSecret, testSuite.TestDB.Queries)
+			DeferCleanup(authMiddleware.Stop)
+			spotHandler.RegisterRoutesWithAuth(authAPI, authMiddleware)
+			authServer = httptest.NewServer(router)
+			DeferCleanup(authServer.Close)
+
+			By("Creating a spot owned by the test user")
+			ownedSpot = helpers.SpotFixture{
+				ID:          "test-spot-owned",
+				Name:        "Owned Test Cafe",
+				Latitude:    35.6762,
+				Longitude:   139.6503,
+				Category:    "cafe",
+				Address:     "Owned Address",
+				CountryCode: "JP",
+				CreatedBy:   authData.ValidUserID,
+			}
+			testSuite.FixtureManager.CreateSpotFixture(context.Background(), ownedSpot)
+
+			otherToken = testSuite.AuthHelper.MintToken("other-spot-user", "other-spot@example.com")
+			adminToken = testSuite.AuthHelper.MintToken("admin-spot-user", "admin-spot@example.com", auth.PermissionAdminSpots)
+		})
+
+		sendRequest := func(method, path, token string, body map[string]interface{}) *httptest.ResponseRecorder {
+			var reader *bytes.Reader
+			if body != nil {
+				bodyBytes, err := json.Marshal(body)
+				Expect(err).NotTo(HaveOccurred())
+				reader = bytes.NewReader(bodyBytes)
+			} else {
+				reader = bytes.NewReader(nil)
+			}
+
+			req := httptest.NewRequest(method, path, reader)
+			req.Header.Set("Content-Type", "application/json")
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			resp := httptest.NewRecorder()
+			authServer.Config.Handler.ServeHTTP(resp, req)
+			return resp
+		}
+
+		spotPath := func(id string) string {
+			return fmt.Sprintf("/api/v1/spots/%s", id)
+		}
+
+		Context("Given the spot creator", func() {
+			It("Then the given fields should be updated and the others kept", func() {
+				resp := sendRequest(http.MethodPut, spotPath(ownedSpot.ID), authData.ValidToken, map[string]interface{}{
+					"name": "Renamed Test Cafe",
+				})
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				var responseBody map[string]interface{}
+				Expect(json.Unmarshal(resp.Body.Bytes(), &responseBody)).To(Succeed())
+				Expect(responseBody["name"]).To(Equal("Renamed Test Cafe"))
+				Expect(responseBody["address"]).To(Equal(ownedSpot.Address))
+				Expect(responseBody["category"]).To(Equal(ownedSpot.Category))
+			})
+
+			It("Then an empty name should be rejected", func() {
+				resp := sendRequest(http.MethodPut, spotPath(ownedSpot.ID), authData.ValidToken, map[string]interface{}{
+					"name": "",
+				})
+				Expect(resp.Code).To(Equal(http.StatusUnprocessableEntity))
+			})
+
+			It("Then the spot should be deleted", func() {
+				resp := sendRequest(http.MethodDelete, spotPath(ownedSpot.ID), authData.ValidToken, nil)
+				Expect(resp.Code).To(Equal(http.StatusNoContent))
+
+				resp = sendRequest(http.MethodGet, spotPath(ownedSpot.ID), "", nil)
+				Expect(resp.Code).To(Equal(http.StatusNotFound))
+			})
+		})
+
+		Context("Given another user", func() {
+			It("Then updating the spot should be forbidden", func() {
+				resp := sendRequest(http.MethodPut, spotPath(ownedSpot.ID), otherToken, map[string]interface{}{
+					"name": "Hijacked Cafe",
+				})
+				Expect(resp.Code).To(Equal(http.StatusForbidden))
+			})
+
+			It("Then deleting the spot should be forbidden and leave it in place", func() {
+				resp := sendRequest(http.MethodDelete, spotPath(ownedSpot.ID), otherToken, nil)
+				Expect(resp.Code).To(Equal(http.StatusForbidden))
+
+				resp = sendRequest(http.MethodGet, spotPath(ownedSpot.ID), "", nil)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+			})
+		})
+
+		Context("Given an admin", func() {
+			It("Then any spot should be updatable", func() {
+				resp := sendRequest(http.MethodPut, spotPath(ownedSpot.ID), adminToken, map[string]interface{}{
+					"category": "library",
+				})
+				Expect(resp.Code).To(Equal(http.StatusOK))
+
+				var responseBody map[string]interface{}
+				Expect(json.Unmarshal(resp.Body.Bytes(), &responseBody)).To(Succeed())
+				Expect(responseBody["category"]).To(Equal("library"))
+			})
+
+			It("Then any spot should be deletable", func() {
+				resp := sendRequest(http.MethodDelete, spotPath(ownedSpot.ID), adminToken, nil)
+				Expect(resp.Code).To(Equal(http.StatusNoContent))
+			})
+		})
+
+		Context("Given an unauthenticated request", func() {
+			It("Then updating and deleting should require authentication", func() {
+				resp := sendRequest(http.MethodPut, spotPath(ownedSpot.ID), "", map[string]interface{}{
+					"name": "Anonymous Cafe",
+				})
+				Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+
+				resp = sendRequest(http.MethodDelete, spotPath(ownedSpot.ID), "", nil)
+				Expect(resp.Code).To(Equal(http.StatusUnauthorized))
+			})
+		})
+
+		Context("Given a non-existent spot", func() {
+			It("Then updating and deleting should return not found", func() {
+				resp := sendRequest(http.MethodPut, spotPath("non-existent-id"), authData.ValidToken, map[string]interface{}{
+					"name": "Missing Cafe",
+				})
+				Expect(resp.Code).To(Equal(http.StatusNotFound))
+
+				resp = sendRequest(http.MethodDelete, spotPath("non-existent-id"), authData.ValidToken, nil)
+				Expect(resp.Code).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+})
@@ -2,19 +2,26 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 
 	"github.com/danielgtaylor/huma/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"bocchi/api/application/clients"
+	"bocchi/api/domain/entities"
 	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/preferences"
 	userv1 "bocchi/api/gen/user/v1"
 )
 
 
 // UserHandler handles user-related HTTP requests
 type UserHandler struct {
-	userClient *clients.UserClient
+	userClient     *clients.UserClient
+	authMiddleware *auth.AuthMiddleware // keeps email verification overrides
 }
 
 // NewUserHandler creates a new user handler
@@ -74,12 +81,99 @@ type UpdateCurrentUserOutput struct {
 	Body *userv1.User `json:"user" doc:"User data"`
 }
 
+// UpdateCurrentUserPreferencesInput represents the request to change some of the current
+// user's preferences; omitted fields keep their values
+type UpdateCurrentUserPreferencesInput struct {
+	Body struct {
+		Language       *string                       `json:"language,omitempty" doc:"BCP 47 language tag, e.g. ja or en-US"`
+		DarkMode       *bool                         `json:"dark_mode,omitempty" doc:"Use the dark theme"`
+		Timezone       *string                       `json:"timezone,omitempty" doc:"IANA time zone, e.g. Asia/Tokyo"`
+		SearchRadiusKm *float64                      `json:"search_radius_km,omitempty" minimum:"0.1" maximum:"50" doc:"Default spot search radius in km"`
+		SoloCategories *[]string                     `json:"solo_categories,omitempty" doc:"Preferred solo-friendly categories; replaces the current list"`
+		DistanceUnit   *string                       `json:"distance_unit,omitempty" enum:"metric,imperial" doc:"Unit distances are shown in"`
+		Notifications  *NotificationPreferencesPatch `json:"notifications,omitempty" doc:"Notification settings to change"`
+	}
+}
+
+// NotificationPreferencesPatch represents changes to notification settings; omitted
+// fields keep their values
+type NotificationPreferencesPatch struct {
+	Email       *bool `json:"email,omitempty" doc:"Receive email notifications"`
+	Push        *bool `json:"push,omitempty" doc:"Receive push notifications"`
+	NearbySpots *bool `json:"nearby_spots,omitempty" doc:"Be notified about new spots nearby"`
+}
+
+// UpdateCurrentUserPreferencesOutput represents the current user's merged preferences
+type UpdateCurrentUserPreferencesOutput struct {
+	Body entities.UserPreferences
+}
+
 // DeleteCurrentUserInput represents the request to delete current user
 type DeleteCurrentUserInput struct{}
 
 // DeleteCurrentUserOutput represents the response for deleting current user
 type DeleteCurrentUserOutput struct{}  // Empty response body for 204 No Content
 
+// RestoreCurrentUserInput represents the request to restore current user
+type RestoreCurrentUserInput struct{}
+
+// RestoreCurrentUserOutput represents the response for restoring current user (using protobuf User type)
+type RestoreCurrentUserOutput struct {
+	Body *userv1.User `json:"user" doc:"User data"`
+}
+
+// DeleteUserInput represents the request to delete a user by ID
+type DeleteUserInput struct {
+	ID string `path:"id" doc:"User ID"`
+}
+
+// DeleteUserOutput represents the response for deleting a user
+type DeleteUserOutput struct{}
+
+// ListIdentitiesInput represents the request to list the current user's identities
+type ListIdentitiesInput struct{}
+
+// ListIdentitiesOutput represents the response for listing identities (using protobuf Identity type)
+type ListIdentitiesOutput struct {
+	Body struct {
+		Identities []*userv1.Identity `json:"identities" doc:"Sign-in identities linked to the account"`
+	}
+}
+
+// LinkIdentityInput represents the request to link an identity to the current user
+type LinkIdentityInput struct {
+	Body struct {
+		Token string `json:"token" minLength:"1" doc:"Access token obtained by signing in with the identity to link"`
+	}
+}
+
+// LinkIdentityOutput represents the response for linking an identity (using protobuf Identity type)
+type LinkIdentityOutput struct {
+	Body *userv1.Identity `json:"identity" doc:"Linked identity"`
+}
+
+// UnlinkIdentityInput represents the request to unlink an identity from the current user
+type UnlinkIdentityInput struct {
+	Provider   string `path:"provider" enum:"google,twitter,x,auth0" doc:"Sign-in provider"`
+	ProviderID string `path:"provider_id" doc:"User ID at the provider"`
+}
+
+// UnlinkIdentityOutput represents the response for unlinking an identity
+type UnlinkIdentityOutput struct{}
+
+// SetEmailVerificationOverrideInput represents the request to exempt a user from the verified email policy
+type SetEmailVerificationOverrideInput struct {
+	ID   string `path:"id" doc:"User ID"`
+	Body struct {
+		Reason string `json:"reason,omitempty" maxLength:"500" doc:"Why the user is exempted"`
+	}
+}
+
+// DeleteEmailVerificationOverrideInput represents the request to remove a user's exemption
+type DeleteEmailVerificationOverrideInput struct {
+	ID string `path:"id" doc:"User ID"`
+}
+
 // RegisterRoutes registers user routes (without authentication)
 func (h *UserHandler) RegisterRoutes(api huma.API) {
 	// Get user by ID (public endpoint)
@@ -137,18 +231,117 @@ func (h *UserHandler) RegisterRoutesWithAuth(api huma.API, authMiddleware *auth.
 		},
 	}, h.UpdateCurrentUser)
 
+	// Change some of the current user's preferences (requires authentication)
+	huma.Register(api, huma.Operation{
+		OperationID: "update-current-user-preferences",
+		Method:      http.MethodPatch,
+		Path:        "/api/v1/users/me/preferences",
+		Summary:     "Update current user preferences",
+		Description: "Change some of the current authenticated user's preferences. Omitted fields keep their values, notification settings are merged field by field and solo categories replace the current list",
+		Tags:        []string{"Users"},
+		Security: []map[string][]string{
+			{"bearerAuth": {}},
+		},
+	}, h.UpdateCurrentUserPreferences)
+
 	// Delete current user (requires authentication)
 	huma.Register(api, huma.Operation{
 		OperationID: "delete-current-user",
 		Method:      http.MethodDelete,
 		Path:        "/api/v1/users/me",
 		Summary:     "Delete current user",
-		Description: "Delete current authenticated user account",
+		Description: "Delete current authenticated user account and revoke its tokens. The account can be restored, after signing in again, until the deletion grace period ends; until then it cannot make changes. It is then purged and its reviews are kept anonymously",
 		Tags:        []string{"Users"},
 		Security: []map[string][]string{
 			{"bearerAuth": {}},
 		},
 	}, h.DeleteCurrentUser)
+
+	// Restore current user (requires authentication)
+	huma.Register(api, huma.Operation{
+		OperationID: "restore-current-user",
+		Method:      http.MethodPost,
+		Path:        "/api/v1/users/me/restore",
+		Summary:     "Restore current user",
+		Description: "Cancel the deletion of the current authenticated user account during the deletion grace period",
+		Tags:        []string{"Users"},
+		Security: []map[string][]string{
+			{"bearerAuth": {}},
+		},
+	}, h.RestoreCurrentUser)
+
+	// Sign-in identities of the current user (requires authentication)
+	h.authMiddleware = authMiddleware
+
+	huma.Register(api, huma.Operation{
+		OperationID: "list-identities",
+		Method:      http.MethodGet,
+		Path:        "/api/v1/users/me/identities",
+		Summary:     "List linked identities",
+		Description: "List the sign-in identities (Google, X, Auth0) linked to the current user",
+		Tags:        []string{"Users"},
+		Security: []map[string][]string{
+			{"bearerAuth": {}},
+		},
+	}, h.ListIdentities)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "link-identity",
+		Method:        http.MethodPost,
+		Path:          "/api/v1/users/me/identities",
+		Summary:       "Link an identity",
+		Description:   "Link the identity of another access token to the current user, so either can be used to sign in",
+		Tags:          []string{"Users"},
+		DefaultStatus: http.StatusCreated,
+		Security: []map[string][]string{
+			{"bearerAuth": {}},
+		},
+	}, h.LinkIdentity)
+
+	huma.Register(api, huma.Operation{
+		OperationID:   "unlink-identity",
+		Method:        http.MethodDelete,
+		Path:          "/api/v1/users/me/identities/{provider}/{provider_id}",
+		Summary:       "Unlink an identity",
+		Description:   "Unlink a sign-in identity from the current user. The last identity and the one of the current session cannot be unlinked",
+		Tags:          []string{"Users"},
+		DefaultStatus: http.StatusNoContent,
+		Security: []map[string][]string{
+			{"bearerAuth": {}},
+		},
+	}, h.UnlinkIdentity)
+
+	// Delete user by ID (admin only)
+	huma.Register(api, authMiddleware.RequirePermissions(api, huma.Operation{
+		OperationID:   "delete-user",
+		Method:        http.MethodDelete,
+		Path:          "/api/v1/users/{id}",
+		Summary:       "Delete a user",
+		Description:   "Delete a user account (admin only)",
+		Tags:          []string{"Users"},
+		DefaultStatus: http.StatusNoContent,
+	}, auth.PermissionAdminUsers), h.DeleteUser)
+
+	// Verified email policy overrides (admin only)
+	huma.Register(api, authMiddleware.RequirePermissions(api, huma.Operation{
+		OperationID:   "set-email-verification-override",
+		Method:        http.MethodPut,
+		Path:          "/api/v1/admin/users/{id}/email-verification-override",
+		Summary:       "Exempt a user from email verification",
+		Description:   "Allow a user to create spots, reviews and ratings without a verified email (admin only)",
+		Tags:          []string{"Admin"},
+		DefaultStatus: http.StatusNoContent,
+	}, auth.PermissionAdminUsers), h.SetEmailVerificationOverride)
+
+	huma.Register(api, authMiddleware.RequirePermissions(api, huma.Operation{
+		OperationID:   "delete-email-verification-override",
+		Method:        http.MethodDelete,
+		Path:          "/api/v1/admin/users/{id}/email-verification-override",
+		Summary:       "Remove a user's email verification exemption",
+		Description:   "Subject a user to the verified email policy again (admin only)",
+		Tags:          []string{"Admin"},
+		DefaultStatus: http.StatusNoContent,
+	}, auth.PermissionAdminUsers), h.DeleteEmailVerificationOverride)
 }
 
 // GetUser gets a user by ID (public info only)
@@ -180,6 +373,9 @@ func (h *UserHandler) GetCurrentUser(ctx context.Context, input *GetCurrentUserI
 		return nil, huma.Error401Unauthorized("authentication required")
 	}
 
+	// The user can still see their own account while its deletion is pending
+	ctx = errors.WithUserID(ctx, userID)
+
 	// Call gRPC service
 	grpcResp, err := h.userClient.GetUser(ctx, &userv1.GetUserRequest{
 		Id: userID,
@@ -201,8 +397,7 @@ func (h *UserHandler) UpdateUser(ctx context.Context, input *UpdateUserInput) (*
 	}
 
 	// Check if user is updating themselves or has admin permissions
-	if authUserID != input.ID {
-		// TODO: Add admin permission check here
+	if authUserID != input.ID && !auth.HasPermission(ctx, auth.PermissionAdminUsers) {
 		return nil, huma.Error403Forbidden("insufficient permissions to update this user")
 	}
 
@@ -244,6 +439,33 @@ func (h *UserHandler) UpdateCurrentUser(ctx context.Context, input *UpdateCurren
 	return &UpdateCurrentUserOutput{Body: grpcResp.User}, nil
 }
 
+// UpdateCurrentUserPreferences merges changes into the current user's preferences
+func (h *UserHandler) UpdateCurrentUserPreferences(ctx context.Context, input *UpdateCurrentUserPreferencesInput) (*UpdateCurrentUserPreferencesOutput, error) {
+	// Extract user ID from authentication context
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required")
+	}
+
+	ctx = errors.WithUserID(ctx, userID)
+
+	// Omitted fields are left out of the patch
+	patch, err := json.Marshal(input.Body)
+	if err != nil {
+		return nil, huma.Error400BadRequest("invalid preferences")
+	}
+
+	grpcResp, err := h.userClient.UpdateUserPreferences(ctx, &userv1.UpdateUserPreferencesRequest{
+		Id:    userID,
+		Patch: string(patch),
+	})
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to update preferences")
+	}
+
+	return &UpdateCurrentUserPreferencesOutput{Body: preferences.Decode([]byte(grpcResp.User.Preferences))}, nil
+}
+
 // DeleteCurrentUser deletes the current authenticated user
 func (h *UserHandler) DeleteCurrentUser(ctx context.Context, input *DeleteCurrentUserInput) (*DeleteCurrentUserOutput, error) {
 	// Extract user ID from authentication context
@@ -252,6 +474,8 @@ func (h *UserHandler) DeleteCurrentUser(ctx context.Context, input *DeleteCurren
 		return nil, huma.Error401Unauthorized("authentication required")
 	}
 
+	ctx = errors.WithUserID(ctx, userID)
+
 	// Call gRPC service to delete the user
 	_, err := h.userClient.DeleteUser(ctx, &userv1.DeleteUserRequest{
 		Id: userID,
@@ -262,4 +486,136 @@ func (h *UserHandler) DeleteCurrentUser(ctx context.Context, input *DeleteCurren
 
 	// Return empty response for 204 No Content
 	return &DeleteCurrentUserOutput{}, nil
-}
\ No newline at end of file
+}
+
+// RestoreCurrentUser cancels the deletion of the current authenticated user
+func (h *UserHandler) RestoreCurrentUser(ctx context.Context, input *RestoreCurrentUserInput) (*RestoreCurrentUserOutput, error) {
+	// Extract user ID from authentication context
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required")
+	}
+
+	ctx = errors.WithUserID(ctx, userID)
+
+	grpcResp, err := h.userClient.RestoreUser(ctx, &userv1.RestoreUserRequest{
+		Id: userID,
+	})
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to restore user")
+	}
+
+	return &RestoreCurrentUserOutput{Body: grpcResp.User}, nil
+}
+
+// DeleteUser deletes a user by ID (admin only)
+func (h *UserHandler) DeleteUser(ctx context.Context, input *DeleteUserInput) (*DeleteUserOutput, error) {
+	// The operation's permission middleware has already authenticated the caller
+	authUserID, _ := auth.GetUserIDFromContext(ctx)
+	ctx = errors.WithUserID(ctx, authUserID)
+	ctx = errors.WithPermissions(ctx, auth.GetPermissionsFromContext(ctx))
+
+	_, err := h.userClient.DeleteUser(ctx, &userv1.DeleteUserRequest{
+		Id: input.ID,
+	})
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to delete user")
+	}
+
+	return &DeleteUserOutput{}, nil
+}
+
+// ListIdentities lists the sign-in identities of the current user
+func (h *UserHandler) ListIdentities(ctx context.Context, input *ListIdentitiesInput) (*ListIdentitiesOutput, error) {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required")
+	}
+
+	ctx = errors.WithUserID(ctx, userID)
+
+	grpcResp, err := h.userClient.ListIdentities(ctx, &userv1.ListIdentitiesRequest{
+		UserId: userID,
+	})
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to list identities")
+	}
+
+	resp := &ListIdentitiesOutput{}
+	resp.Body.Identities = grpcResp.Identities
+	if resp.Body.Identities == nil {
+		resp.Body.Identities = []*userv1.Identity{}
+	}
+	return resp, nil
+}
+
+// LinkIdentity links the identity of another access token to the current user. The user
+// service validates the token, since holding a valid token for the identity proves the user owns it.
+func (h *UserHandler) LinkIdentity(ctx context.Context, input *LinkIdentityInput) (*LinkIdentityOutput, error) {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required")
+	}
+
+	ctx = errors.WithUserID(ctx, userID)
+
+	grpcResp, err := h.userClient.LinkIdentity(ctx, &userv1.LinkIdentityRequest{
+		UserId:        userID,
+		IdentityToken: input.Body.Token,
+	})
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to link identity")
+	}
+
+	return &LinkIdentityOutput{Body: grpcResp.Identity}, nil
+}
+
+// UnlinkIdentity unlinks a sign-in identity from the current user
+func (h *UserHandler) UnlinkIdentity(ctx context.Context, input *UnlinkIdentityInput) (*UnlinkIdentityOutput, error) {
+	userID, ok := ctx.Value("user_id").(string)
+	if !ok || userID == "" {
+		return nil, huma.Error401Unauthorized("authentication required")
+	}
+
+	// Unlinking the identity of the current session would turn it into a different user
+	if subject, ok := auth.GetSubjectFromContext(ctx); ok {
+		if provider, providerID, ok := auth.IdentityFromSubject(subject); ok &&
+			string(provider) == input.Provider && providerID == input.ProviderID {
+			return nil, huma.Error409Conflict("sign in with another identity to unlink the one of the current session")
+		}
+	}
+
+	ctx = errors.WithUserID(ctx, userID)
+
+	_, err := h.userClient.UnlinkIdentity(ctx, &userv1.UnlinkIdentityRequest{
+		UserId:     userID,
+		Provider:   input.Provider,
+		ProviderId: input.ProviderID,
+	})
+	if status.Code(err) == codes.FailedPrecondition {
+		// Unlinking the last identity is a conflict, like unlinking the session's identity
+		return nil, huma.Error409Conflict(status.Convert(err).Message())
+	}
+	if err != nil {
+		return nil, grpcToHTTPError(err, "failed to unlink identity")
+	}
+
+	return &UnlinkIdentityOutput{}, nil
+}
+
+// SetEmailVerificationOverride exempts a user from the verified email policy
+func (h *UserHandler) SetEmailVerificationOverride(ctx context.Context, input *SetEmailVerificationOverrideInput) (*struct{}, error) {
+	adminID, _ := auth.GetUserIDFromContext(ctx)
+	if err := h.authMiddleware.SetEmailVerificationOverride(ctx, input.ID, adminID, input.Body.Reason); err != nil {
+		return nil, errors.HandleHTTPError(ctx, err, "set_email_verification_override", "failed to set email verification override")
+	}
+	return nil, nil
+}
+
+// DeleteEmailVerificationOverride removes a user's exemption from the verified email policy
+func (h *UserHandler) DeleteEmailVerificationOverride(ctx context.Context, input *DeleteEmailVerificationOverrideInput) (*struct{}, error) {
+	if err := h.authMiddleware.DeleteEmailVerificationOverride(ctx, input.ID); err != nil {
+		return nil, errors.HandleHTTPError(ctx, err, "delete_email_verification_override", "failed to delete email verification override")
+	}
+	return nil, nil
+}
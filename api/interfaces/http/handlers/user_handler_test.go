@@ -5,17 +5,24 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
 	"github.com/go-chi/chi/v5"
 	"bocchi/api/application/clients"
 	"bocchi/api/domain/entities"
+	ratingv1 "bocchi/api/gen/rating/v1"
+	grpcSvc "bocchi/api/infrastructure/grpc"
 	"bocchi/api/pkg/auth"
+	"bocchi/api/pkg/config"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/preferences"
 	"bocchi/api/tests/helpers"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -83,7 +90,8 @@ var _ = Describe("UserHandler BDD Tests", func() {
 		testServer = httptest.NewServer(router)
 
 		// Create auth middleware for protected endpoints
-		authMiddleware := auth.NewAuthMiddleware("test-jwt-secret", testSuite.TestDB.Queries)
+		authMiddleware := auth.NewAuthMiddleware(helpers.TestJWTSecret, testSuite.TestDB.Queries)
+		DeferCleanup(authMiddleware.Stop)
 
 		// Register user endpoints (both public and authenticated)
 		userHandler.RegisterRoutesWithAuth(api, authMiddleware)
@@ -656,4 +664,406 @@ var _ = Describe("UserHandler BDD Tests", func() {
 			})
 		})
 	})
+
+	Describe("Linked Sign-in Identities", func() {
+		var (
+			identityRouter *chi.Mux
+			googleToken    string
+		)
+
+		BeforeEach(func() {
+			By("Registering user routes behind the local token middleware")
+			identityRouter = chi.NewRouter()
+			identityAPI := humachi.New(identityRouter, huma.DefaultConfig("Identity Test API", "1.0.0"))
+			authMiddleware := auth.NewAuthMiddleware(helpers.TestJWTSecret, testSuite.TestDB.Queries)
+			DeferCleanup(authMiddleware.Stop)
+			identityAPI.UseMiddleware(authMiddleware.HumaMiddleware())
+			userClient.SetTokenValidator(authMiddleware)
+			userHandler.RegisterRoutesWithAuth(identityAPI, authMiddleware)
+
+			// The primary test user was created with the google identity google_test_123
+			googleToken = testSuite.AuthHelper.MintToken("google-oauth2|"+authData.TestUser.AuthProviderID, authData.TestUser.Email)
+		})
+
+		serve := func(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+			var bodyBytes []byte
+			if body != nil {
+				var err error
+				bodyBytes, err = json.Marshal(body)
+				Expect(err).NotTo(HaveOccurred())
+			}
+			req := httptest.NewRequest(method, path, bytes.NewReader(bodyBytes))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			resp := httptest.NewRecorder()
+			identityRouter.ServeHTTP(resp, req)
+			return resp
+		}
+
+		listProviders := func(token string) []string {
+			resp := serve(http.MethodGet, "/api/v1/users/me/identities", token, nil)
+			Expect(resp.Code).To(Equal(http.StatusOK))
+
+			var body struct {
+				Identities []struct {
+					Provider string `json:"provider"`
+				} `json:"identities"`
+			}
+			Expect(json.Unmarshal(resp.Body.Bytes(), &body)).To(Succeed())
+			providers := make([]string, 0, len(body.Identities))
+			for _, identity := range body.Identities {
+				providers = append(providers, identity.Provider)
+			}
+			return providers
+		}
+
+		Context("Given a user signed in with a linked identity", func() {
+			It("Then the token subject should resolve to the user", func() {
+				resp := serve(http.MethodGet, "/api/v1/users/me", googleToken, nil)
+
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(ContainSubstring(authData.ValidUserID))
+				Expect(listProviders(googleToken)).To(Equal([]string{"google"}))
+			})
+		})
+
+		Context("When linking a second identity", func() {
+			It("Then either identity should sign in to the same user", func() {
+				auth0Token := testSuite.AuthHelper.MintToken("auth0|test-user-db", authData.TestUser.Email)
+
+				By("Linking the Auth0 identity")
+				resp := serve(http.MethodPost, "/api/v1/users/me/identities", googleToken, map[string]string{"token": auth0Token})
+				Expect(resp.Code).To(Equal(http.StatusCreated))
+
+				By("Linking it again is a no-op")
+				resp = serve(http.MethodPost, "/api/v1/users/me/identities", googleToken, map[string]string{"token": auth0Token})
+				Expect(resp.Code).To(Equal(http.StatusCreated))
+
+				By("Signing in with the linked identity")
+				Expect(listProviders(auth0Token)).To(ConsistOf("google", "auth0"))
+				resp = serve(http.MethodGet, "/api/v1/users/me", auth0Token, nil)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(ContainSubstring(authData.ValidUserID))
+			})
+
+			It("Then an identity of another user should be rejected", func() {
+				otherToken := testSuite.AuthHelper.MintToken("google-oauth2|"+otherUser.AuthProviderID, otherUser.Email)
+
+				resp := serve(http.MethodPost, "/api/v1/users/me/identities", googleToken, map[string]string{"token": otherToken})
+
+				verifyErrorResponse(resp, http.StatusConflict, "Conflict")
+				Expect(listProviders(googleToken)).To(Equal([]string{"google"}))
+			})
+
+			It("Then a token that is not a sign-in identity should be rejected", func() {
+				localToken := testSuite.AuthHelper.MintToken("local|someone", "someone@example.com")
+
+				resp := serve(http.MethodPost, "/api/v1/users/me/identities", googleToken, map[string]string{"token": localToken})
+
+				verifyErrorResponse(resp, http.StatusBadRequest, "Bad Request")
+			})
+
+			It("Then a token that does not validate should be rejected", func() {
+				resp := serve(http.MethodPost, "/api/v1/users/me/identities", googleToken, map[string]string{"token": "forged.identity.token"})
+
+				verifyErrorResponse(resp, http.StatusBadRequest, "Bad Request")
+				Expect(listProviders(googleToken)).To(Equal([]string{"google"}))
+			})
+		})
+
+		Context("When unlinking identities", func() {
+			It("Then the last identity and the session's identity should be kept", func() {
+				auth0Token := testSuite.AuthHelper.MintToken("auth0|test-user-db", authData.TestUser.Email)
+				resp := serve(http.MethodPost, "/api/v1/users/me/identities", googleToken, map[string]string{"token": auth0Token})
+				Expect(resp.Code).To(Equal(http.StatusCreated))
+
+				By("Refusing to unlink the identity of the current session")
+				resp = serve(http.MethodDelete, "/api/v1/users/me/identities/google/"+authData.TestUser.AuthProviderID, googleToken, nil)
+				Expect(resp.Code).To(Equal(http.StatusConflict))
+
+				By("Unlinking it from a session of the other identity")
+				resp = serve(http.MethodDelete, "/api/v1/users/me/identities/google/"+authData.TestUser.AuthProviderID, auth0Token, nil)
+				Expect(resp.Code).To(Equal(http.StatusNoContent))
+				Expect(listProviders(auth0Token)).To(Equal([]string{"auth0"}))
+
+				By("Refusing to unlink the last identity")
+				resp = serve(http.MethodDelete, "/api/v1/users/me/identities/auth0/test-user-db", authData.ValidToken, nil)
+				Expect(resp.Code).To(Equal(http.StatusConflict))
+				Expect(listProviders(auth0Token)).To(Equal([]string{"auth0"}))
+			})
+
+			It("Then an identity that is not linked should not be found", func() {
+				resp := serve(http.MethodDelete, "/api/v1/users/me/identities/x/unknown", googleToken, nil)
+
+				Expect(resp.Code).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Describe("Email Verification Overrides", func() {
+		var (
+			overrideRouter  *chi.Mux
+			adminToken      string
+			unverifiedToken string
+		)
+
+		BeforeEach(func() {
+			By("Registering user routes and a write gated by the verified email policy")
+			overrideRouter = chi.NewRouter()
+			overrideAPI := humachi.New(overrideRouter, huma.DefaultConfig("Override Test API", "1.0.0"))
+			authMiddleware, err := auth.NewAuthMiddlewareWithConfig(auth.AuthConfig{
+				Mode:                 config.AuthModeLocal,
+				JWTSecret:            helpers.TestJWTSecret,
+				RequireVerifiedEmail: true,
+			}, testSuite.TestDB.Queries)
+			Expect(err).NotTo(HaveOccurred())
+			DeferCleanup(authMiddleware.Stop)
+			overrideAPI.UseMiddleware(authMiddleware.HumaMiddleware())
+			userHandler.RegisterRoutesWithAuth(overrideAPI, authMiddleware)
+			huma.Register(overrideAPI, authMiddleware.RequireVerifiedEmail(overrideAPI, huma.Operation{
+				OperationID: "test-write",
+				Method:      http.MethodPost,
+				Path:        "/test/write",
+			}, "write"), func(ctx context.Context, input *struct{}) (*struct{}, error) {
+				return nil, nil
+			})
+
+			By("Marking the other user's email as unverified")
+			_, err = testSuite.TestDB.DB.Exec("UPDATE users SET email_verified = FALSE WHERE id = ?", otherUser.ID)
+			Expect(err).NotTo(HaveOccurred())
+
+			issuer, err := auth.NewLocalTokenIssuer(helpers.TestJWTSecret, "")
+			Expect(err).NotTo(HaveOccurred())
+			unverifiedToken, err = issuer.Mint(auth.TokenOptions{Subject: otherUser.ID, Email: otherUser.Email})
+			Expect(err).NotTo(HaveOccurred())
+			adminToken = testSuite.AuthHelper.MintToken("admin-user-123", "admin@example.com", auth.PermissionAdminUsers)
+		})
+
+		serve := func(method, path, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, bytes.NewReader([]byte(`{"reason":"verified by support"}`)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			resp := httptest.NewRecorder()
+			overrideRouter.ServeHTTP(resp, req)
+			return resp
+		}
+		overridePath := func(userID string) string {
+			return "/api/v1/admin/users/" + userID + "/email-verification-override"
+		}
+
+		Context("Given a user with an unverified email", func() {
+			It("Then writes should be rejected until an admin grants an override", func() {
+				By("Rejecting the write with a specific error type")
+				resp := serve(http.MethodPost, "/test/write", unverifiedToken)
+				Expect(resp.Code).To(Equal(http.StatusForbidden))
+				Expect(resp.Body.String()).To(ContainSubstring("EMAIL_NOT_VERIFIED"))
+
+				By("Granting an override")
+				resp = serve(http.MethodPut, overridePath(otherUser.ID), adminToken)
+				Expect(resp.Code).To(Equal(http.StatusNoContent))
+				Expect(serve(http.MethodPost, "/test/write", unverifiedToken).Code).To(Equal(http.StatusNoContent))
+
+				By("Removing the override")
+				resp = serve(http.MethodDelete, overridePath(otherUser.ID), adminToken)
+				Expect(resp.Code).To(Equal(http.StatusNoContent))
+				Expect(serve(http.MethodPost, "/test/write", unverifiedToken).Code).To(Equal(http.StatusForbidden))
+			})
+
+			It("Then users with a verified email should not be affected", func() {
+				resp := serve(http.MethodPost, "/test/write", authData.ValidToken)
+
+				Expect(resp.Code).To(Equal(http.StatusNoContent))
+			})
+		})
+
+		Context("When managing overrides", func() {
+			It("Then only admins should be allowed", func() {
+				resp := serve(http.MethodPut, overridePath(otherUser.ID), unverifiedToken)
+
+				Expect(resp.Code).To(Equal(http.StatusForbidden))
+			})
+
+			It("Then unknown users and missing overrides should not be found", func() {
+				Expect(serve(http.MethodPut, overridePath("missing-user"), adminToken).Code).To(Equal(http.StatusNotFound))
+				Expect(serve(http.MethodDelete, overridePath(otherUser.ID), adminToken).Code).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
+
+	Describe("Preference Updates", func() {
+		var preferencesRouter *chi.Mux
+
+		BeforeEach(func() {
+			By("Registering user routes behind the local token middleware")
+			preferencesRouter = chi.NewRouter()
+			preferencesAPI := humachi.New(preferencesRouter, huma.DefaultConfig("Preferences Test API", "1.0.0"))
+			authMiddleware := auth.NewAuthMiddleware(helpers.TestJWTSecret, testSuite.TestDB.Queries)
+			DeferCleanup(authMiddleware.Stop)
+			preferencesAPI.UseMiddleware(authMiddleware.HumaMiddleware())
+			userHandler.RegisterRoutesWithAuth(preferencesAPI, authMiddleware)
+		})
+
+		patch := func(body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/me/preferences", bytes.NewReader([]byte(body)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+authData.ValidToken)
+			resp := httptest.NewRecorder()
+			preferencesRouter.ServeHTTP(resp, req)
+			return resp
+		}
+
+		Context("Given a user changing some of their preferences", func() {
+			It("Then only the given fields should change", func() {
+				By("Changing the timezone and one notification setting")
+				resp := patch(`{"timezone":"Europe/London","notifications":{"push":true}}`)
+				Expect(resp.Code).To(Equal(http.StatusOK), resp.Body.String())
+
+				var prefs entities.UserPreferences
+				Expect(json.Unmarshal(resp.Body.Bytes(), &prefs)).To(Succeed())
+				Expect(prefs.Version).To(Equal(preferences.Version))
+				Expect(prefs.Timezone).To(Equal("Europe/London"))
+				Expect(prefs.Language).To(Equal(authData.TestUser.Preferences.Language))
+				Expect(prefs.Notifications.Push).To(BeTrue())
+				Expect(prefs.Notifications.Email).To(BeTrue(), "Notification settings missing from the patch keep their values")
+
+				By("Replacing the solo categories in a second patch")
+				resp = patch(`{"solo_categories":["quiet_atmosphere","wifi_available"],"distance_unit":"imperial"}`)
+				Expect(resp.Code).To(Equal(http.StatusOK), resp.Body.String())
+				Expect(json.Unmarshal(resp.Body.Bytes(), &prefs)).To(Succeed())
+				Expect(prefs.Timezone).To(Equal("Europe/London"))
+				Expect(prefs.SoloCategories).To(Equal([]string{"quiet_atmosphere", "wifi_available"}))
+				Expect(prefs.DistanceUnit).To(Equal(preferences.UnitImperial))
+			})
+
+			It("Then invalid values should be rejected", func() {
+				Expect(patch(`{"timezone":"Asia/Nowhere"}`).Code).To(Equal(http.StatusBadRequest))
+				Expect(patch(`{"language":"not a tag"}`).Code).To(Equal(http.StatusBadRequest))
+				Expect(patch(`{"solo_categories":["karaoke"]}`).Code).To(Equal(http.StatusBadRequest))
+				Expect(patch(`{"search_radius_km":100}`).Code).To(Equal(http.StatusUnprocessableEntity))
+			})
+		})
+	})
+
+	Describe("Account Deletion", func() {
+		var deletionRouter *chi.Mux
+
+		BeforeEach(func() {
+			By("Registering user routes behind the local token middleware")
+			deletionRouter = chi.NewRouter()
+			deletionAPI := humachi.New(deletionRouter, huma.DefaultConfig("Deletion Test API", "1.0.0"))
+			authMiddleware := auth.NewAuthMiddleware(helpers.TestJWTSecret, testSuite.TestDB.Queries)
+			DeferCleanup(authMiddleware.Stop)
+			deletionAPI.UseMiddleware(authMiddleware.HumaMiddleware())
+			userHandler.RegisterRoutesWithAuth(deletionAPI, authMiddleware)
+		})
+
+		serve := func(method, path, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(method, path, nil)
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			resp := httptest.NewRecorder()
+			deletionRouter.ServeHTTP(resp, req)
+			return resp
+		}
+
+		// signInAgain mints a token issued after the revocation cutoff of the deletion,
+		// which is compared with iat at one-second resolution
+		signInAgain := func() string {
+			time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+			return testSuite.AuthHelper.MintToken(authData.ValidUserID, authData.TestUser.Email, authData.TestUser.Permissions...)
+		}
+
+		Context("Given a user who deletes their account", func() {
+			It("Then the account should be hidden but restorable during the grace period", func() {
+				By("Deleting the account")
+				Expect(serve(http.MethodDelete, "/api/v1/users/me", authData.ValidToken).Code).To(Equal(http.StatusNoContent))
+
+				By("Revoking the tokens issued before the deletion")
+				Expect(serve(http.MethodGet, "/api/v1/users/me", authData.ValidToken).Code).To(Equal(http.StatusUnauthorized))
+				token := signInAgain()
+
+				By("Hiding it from other users")
+				Expect(serve(http.MethodGet, "/api/v1/users/"+authData.ValidUserID, "").Code).To(Equal(http.StatusNotFound))
+				_, err := testSuite.TestDB.Queries.GetUserByEmail(context.Background(), authData.TestUser.Email)
+				Expect(err).To(MatchError(sql.ErrNoRows))
+
+				By("Showing the deletion to the user")
+				resp := serve(http.MethodGet, "/api/v1/users/me", token)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).To(ContainSubstring("deleted_at"))
+
+				By("Rejecting changes until the account is restored")
+				req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/me/preferences", bytes.NewReader([]byte(`{"timezone":"Europe/London"}`)))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Authorization", "Bearer "+token)
+				resp = httptest.NewRecorder()
+				deletionRouter.ServeHTTP(resp, req)
+				Expect(resp.Code).To(Equal(http.StatusForbidden), resp.Body.String())
+
+				By("Restoring the account")
+				resp = serve(http.MethodPost, "/api/v1/users/me/restore", token)
+				Expect(resp.Code).To(Equal(http.StatusOK))
+				Expect(resp.Body.String()).NotTo(ContainSubstring("deleted_at"))
+				Expect(serve(http.MethodGet, "/api/v1/users/"+authData.ValidUserID, "").Code).To(Equal(http.StatusOK))
+
+				By("Refusing to restore an account that is not deleted")
+				Expect(serve(http.MethodPost, "/api/v1/users/me/restore", token).Code).To(Equal(http.StatusPreconditionFailed))
+			})
+
+			It("Then purging it should keep its reviews anonymously", func() {
+				ctx := context.Background()
+				spot := testSuite.FixtureManager.CreateSpotFixture(ctx, testSuite.FixtureManager.DefaultSpotFixtures()[0])
+				testSuite.FixtureManager.CreateReviewFixture(ctx, helpers.ReviewFixture{
+					ID:      "review-purge-1",
+					SpotID:  spot.ID,
+					UserID:  authData.ValidUserID,
+					Rating:  4,
+					Comment: "Counter seats by the window",
+				})
+				_, err := grpcSvc.NewRatingService(testSuite.TestDB.DB).CreateSoloRating(errors.WithUserID(ctx, authData.ValidUserID), &ratingv1.CreateSoloRatingRequest{
+					SpotId:             spot.ID,
+					SoloFriendlyRating: 5,
+					Categories:         []string{"quiet_atmosphere"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				_, err = testSuite.TestDB.DB.ExecContext(ctx,
+					"INSERT INTO user_roles (user_id, role_id) VALUES (?, 'moderator')", authData.ValidUserID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(serve(http.MethodDelete, "/api/v1/users/me", authData.ValidToken).Code).To(Equal(http.StatusNoContent))
+
+				By("Leaving accounts within the grace period alone")
+				users := grpcSvc.NewUserService(testSuite.TestDB.DB)
+				purged, err := users.PurgeDeletedUsers(ctx, time.Now().Add(-time.Hour), 10)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(purged).To(Equal(0))
+
+				By("Purging accounts past the grace period")
+				purged, err = users.PurgeDeletedUsers(ctx, time.Now().Add(time.Hour), 10)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(purged).To(Equal(1))
+
+				review, err := testSuite.TestDB.Queries.GetReviewByID(ctx, "review-purge-1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(review.UserID.Valid).To(BeFalse())
+				Expect(review.Comment.String).To(Equal("Counter seats by the window"))
+
+				dbSpot, err := testSuite.TestDB.Queries.GetSpotByID(ctx, spot.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dbSpot.ReviewCount).To(Equal(int32(1)))
+				Expect(dbSpot.SoloRatingCount).To(BeZero(), "Solo statistics are recomputed without the purged rating")
+
+				roles, err := testSuite.TestDB.Queries.ListRolesByUserID(ctx, authData.ValidUserID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(roles).To(BeEmpty())
+				_, err = testSuite.TestDB.Queries.GetUserTokensRevokedBefore(ctx, authData.ValidUserID)
+				Expect(err).To(MatchError(sql.ErrNoRows), "The revocation cutoff is removed with the user")
+
+				By("No longer restoring the purged account")
+				Expect(serve(http.MethodPost, "/api/v1/users/me/restore", signInAgain()).Code).To(Equal(http.StatusNotFound))
+			})
+		})
+	})
 })
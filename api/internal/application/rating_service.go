@@ -6,6 +6,7 @@ import (
 
 	"bocchi/api/domain/entities"
 	"bocchi/api/internal/domain/rating"
+	"bocchi/api/pkg/errors"
 )
 
 // RatingRepository defines the interface for rating data access
@@ -19,23 +20,123 @@ type RatingRepository interface {
 // SpotRepository defines the interface for spot data access
 type SpotRepository interface {
 	GetByID(ctx context.Context, id string) (*entities.Spot, error)
+	// LockForUpdate locks the spot row until the end of the transaction, serializing
+	// rating writes for the spot
+	LockForUpdate(ctx context.Context, id string) error
 	UpdateSoloFriendlyStats(ctx context.Context, spotID string, avgRating float64, totalRatings int) error
+	UpdateSoloCategoryCounts(ctx context.Context, spotID string, counts map[string]int) error
+}
+
+// TxRunner runs fn in a transaction with repositories bound to it, committing only if fn succeeds
+type TxRunner interface {
+	RunInTx(ctx context.Context, fn func(ratingRepo RatingRepository, spotRepo SpotRepository) error) error
 }
 
 // RatingService handles business logic for solo-friendly ratings
 type RatingService struct {
 	ratingRepo RatingRepository
 	spotRepo   SpotRepository
+	tx         TxRunner
 }
 
-// NewRatingService creates a new RatingService instance
-func NewRatingService(ratingRepo RatingRepository, spotRepo SpotRepository) *RatingService {
+// NewRatingService creates a new RatingService instance. Rating writes and the statistics
+// they change run in one transaction of tx; a nil tx runs them directly on the
+// repositories, for repositories already bound to the caller's transaction.
+func NewRatingService(ratingRepo RatingRepository, spotRepo SpotRepository, tx TxRunner) *RatingService {
 	return &RatingService{
 		ratingRepo: ratingRepo,
 		spotRepo:   spotRepo,
+		tx:         tx,
 	}
 }
 
+// CreateRating records a user's first solo-friendly rating for a spot
+func (s *RatingService) CreateRating(ctx context.Context, spotID, userID string, soloRating int, categories []string, comment string) (*rating.Rating, error) {
+	newRating, err := rating.NewRating(spotID, userID, soloRating, categories, comment)
+	if err != nil {
+		return nil, errors.InvalidInput("rating", err.Error())
+	}
+
+	err = s.runInTx(ctx, func(ratingRepo RatingRepository, spotRepo SpotRepository) error {
+		if err := spotRepo.LockForUpdate(ctx, spotID); err != nil {
+			return err
+		}
+
+		// Each user can rate a spot only once; the unique key catches a concurrent insert
+		_, err := ratingRepo.GetBySpotAndUser(ctx, spotID, userID)
+		if err == nil {
+			return errors.Conflict("rating", "user has already rated this spot")
+		}
+		if !isNotFoundError(err) {
+			return err
+		}
+
+		if err := ratingRepo.Create(ctx, newRating); err != nil {
+			return err
+		}
+		return s.updateSpotStatistics(ctx, ratingRepo, spotRepo, spotID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return newRating, nil
+}
+
+// UpdateRating changes a user's existing solo-friendly rating for a spot
+func (s *RatingService) UpdateRating(ctx context.Context, spotID, userID string, soloRating int, categories []string, comment string) (*rating.Rating, error) {
+	var existing *rating.Rating
+	err := s.runInTx(ctx, func(ratingRepo RatingRepository, spotRepo SpotRepository) error {
+		if err := spotRepo.LockForUpdate(ctx, spotID); err != nil {
+			return err
+		}
+
+		var err error
+		existing, err = ratingRepo.GetBySpotAndUser(ctx, spotID, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := existing.UpdateRating(soloRating, categories, comment); err != nil {
+			return errors.InvalidInput("rating", err.Error())
+		}
+
+		if err := ratingRepo.Update(ctx, existing); err != nil {
+			return err
+		}
+		return s.updateSpotStatistics(ctx, ratingRepo, spotRepo, spotID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// GetUserRating retrieves a user's solo-friendly rating for a spot
+func (s *RatingService) GetUserRating(ctx context.Context, spotID, userID string) (*rating.Rating, error) {
+	return s.ratingRepo.GetBySpotAndUser(ctx, spotID, userID)
+}
+
+// GetSpotRatings retrieves all solo-friendly ratings for a spot
+func (s *RatingService) GetSpotRatings(ctx context.Context, spotID string) ([]*rating.Rating, error) {
+	if _, err := s.spotRepo.GetByID(ctx, spotID); err != nil {
+		return nil, err
+	}
+	return s.ratingRepo.GetBySpot(ctx, spotID)
+}
+
+// CalculateCategoryCounts counts how many ratings mention each category
+func (s *RatingService) CalculateCategoryCounts(ratings []*rating.Rating) map[string]int {
+	counts := make(map[string]int)
+	for _, r := range ratings {
+		for _, category := range r.Categories {
+			counts[category]++
+		}
+	}
+	return counts
+}
+
 // CalculateSpotStatistics calculates average rating and total count
 func (s *RatingService) CalculateSpotStatistics(ratings []*rating.Rating) (float64, int) {
 	if len(ratings) == 0 {
@@ -51,27 +152,53 @@ func (s *RatingService) CalculateSpotStatistics(ratings []*rating.Rating) (float
 	return avgRating, len(ratings)
 }
 
-// updateSpotStatistics recalculates and updates spot statistics
-func (s *RatingService) updateSpotStatistics(ctx context.Context, spotID string) error {
-	ratings, err := s.ratingRepo.GetBySpot(ctx, spotID)
+// updateSpotStatistics recalculates and updates spot statistics. The caller must hold
+// the spot lock so concurrent writes cannot store statistics of a stale rescan.
+func (s *RatingService) updateSpotStatistics(ctx context.Context, ratingRepo RatingRepository, spotRepo SpotRepository, spotID string) error {
+	ratings, err := ratingRepo.GetBySpot(ctx, spotID)
 	if err != nil {
 		return fmt.Errorf("failed to get spot ratings: %w", err)
 	}
 
 	avgRating, totalRatings := s.CalculateSpotStatistics(ratings)
 
-	err = s.spotRepo.UpdateSoloFriendlyStats(ctx, spotID, avgRating, totalRatings)
+	err = spotRepo.UpdateSoloFriendlyStats(ctx, spotID, avgRating, totalRatings)
 	if err != nil {
 		return fmt.Errorf("failed to update spot statistics: %w", err)
 	}
 
+	err = spotRepo.UpdateSoloCategoryCounts(ctx, spotID, s.CalculateCategoryCounts(ratings))
+	if err != nil {
+		return fmt.Errorf("failed to update spot category counts: %w", err)
+	}
+
 	return nil
 }
 
+// RecomputeSpotStatistics recalculates a spot's solo-friendly statistics from its
+// ratings, e.g. after ratings were removed outside the service
+func (s *RatingService) RecomputeSpotStatistics(ctx context.Context, spotID string) error {
+	return s.runInTx(ctx, func(ratingRepo RatingRepository, spotRepo SpotRepository) error {
+		if err := spotRepo.LockForUpdate(ctx, spotID); err != nil {
+			return err
+		}
+		return s.updateSpotStatistics(ctx, ratingRepo, spotRepo, spotID)
+	})
+}
+
+// runInTx runs fn in a transaction of the service's TxRunner, or directly on its
+// repositories when it has none
+func (s *RatingService) runInTx(ctx context.Context, fn func(ratingRepo RatingRepository, spotRepo SpotRepository) error) error {
+	if s.tx == nil {
+		return fn(s.ratingRepo, s.spotRepo)
+	}
+	return s.tx.RunInTx(ctx, fn)
+}
+
 // isNotFoundError checks if an error represents a "not found" condition
-// This is a helper function that should be implemented based on your error handling strategy
 func isNotFoundError(err error) bool {
-	// This is a simplified implementation
-	// In a real application, you might check for specific error types
+	if errors.Is(err, errors.ErrTypeNotFound) {
+		return true
+	}
 	return err != nil && (err.Error() == "not found" || err.Error() == "record not found")
 }
\ No newline at end of file
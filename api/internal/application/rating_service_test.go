@@ -3,11 +3,11 @@ package application_test
 import (
 	"context"
 	"testing"
-	"time"
 
 	"bocchi/api/internal/application"
 	"bocchi/api/domain/entities"
 	"bocchi/api/internal/domain/rating"
+	"bocchi/api/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -55,15 +55,37 @@ func (m *MockSpotRepository) GetByID(ctx context.Context, id string) (*entities.
 	return args.Get(0).(*entities.Spot), args.Error(1)
 }
 
+func (m *MockSpotRepository) LockForUpdate(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *MockSpotRepository) UpdateSoloFriendlyStats(ctx context.Context, spotID string, avgRating float64, totalRatings int) error {
 	args := m.Called(ctx, spotID, avgRating, totalRatings)
 	return args.Error(0)
 }
 
+func (m *MockSpotRepository) UpdateSoloCategoryCounts(ctx context.Context, spotID string, counts map[string]int) error {
+	args := m.Called(ctx, spotID, counts)
+	return args.Error(0)
+}
+
+// recordingTxRunner runs fn on its own repositories and records whether it ran
+type recordingTxRunner struct {
+	ratingRepo application.RatingRepository
+	spotRepo   application.SpotRepository
+	calls      int
+}
+
+func (r *recordingTxRunner) RunInTx(ctx context.Context, fn func(ratingRepo application.RatingRepository, spotRepo application.SpotRepository) error) error {
+	r.calls++
+	return fn(r.ratingRepo, r.spotRepo)
+}
+
 // Test statistics calculation functionality
 func TestRatingService_CalculateSpotStatistics(t *testing.T) {
 	// Arrange
-	service := application.NewRatingService(nil, nil)
+	service := application.NewRatingService(nil, nil, nil)
 	
 	ratings := []*rating.Rating{
 		{SoloFriendlyRating: 5},
@@ -81,7 +103,7 @@ func TestRatingService_CalculateSpotStatistics(t *testing.T) {
 
 func TestRatingService_CalculateSpotStatistics_EmptyRatings(t *testing.T) {
 	// Arrange
-	service := application.NewRatingService(nil, nil)
+	service := application.NewRatingService(nil, nil, nil)
 	ratings := []*rating.Rating{}
 
 	// Act
@@ -90,4 +112,135 @@ func TestRatingService_CalculateSpotStatistics_EmptyRatings(t *testing.T) {
 	// Assert
 	assert.Equal(t, 0.0, avgRating)
 	assert.Equal(t, 0, totalCount)
-}
\ No newline at end of file
+}
+
+func TestRatingService_CreateRating(t *testing.T) {
+	// Arrange
+	ratingRepo := new(MockRatingRepository)
+	spotRepo := new(MockSpotRepository)
+	service := application.NewRatingService(ratingRepo, spotRepo, nil)
+	ctx := context.Background()
+
+	existing := &rating.Rating{SpotID: "spot-123", UserID: "user-789", SoloFriendlyRating: 3, Categories: []string{"wifi_available", "power_outlets"}}
+	spotRepo.On("LockForUpdate", ctx, "spot-123").Return(nil)
+	ratingRepo.On("GetBySpotAndUser", ctx, "spot-123", "user-456").Return(nil, errors.NotFound("rating", "spot-123"))
+	ratingRepo.On("Create", ctx, mock.AnythingOfType("*rating.Rating")).Return(nil)
+	ratingRepo.On("GetBySpot", ctx, "spot-123").Return([]*rating.Rating{existing, {SoloFriendlyRating: 5, Categories: []string{"wifi_available"}}}, nil)
+	spotRepo.On("UpdateSoloFriendlyStats", ctx, "spot-123", 4.0, 2).Return(nil)
+	spotRepo.On("UpdateSoloCategoryCounts", ctx, "spot-123", map[string]int{"wifi_available": 2, "power_outlets": 1}).Return(nil)
+
+	// Act
+	result, err := service.CreateRating(ctx, "spot-123", "user-456", 5, []string{"wifi_available"}, "Quiet corner seats")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.SoloFriendlyRating)
+	assert.Equal(t, []string{"wifi_available"}, result.Categories)
+	ratingRepo.AssertExpectations(t)
+	spotRepo.AssertExpectations(t)
+}
+
+func TestRatingService_CreateRating_AlreadyRated(t *testing.T) {
+	// Arrange
+	ratingRepo := new(MockRatingRepository)
+	spotRepo := new(MockSpotRepository)
+	service := application.NewRatingService(ratingRepo, spotRepo, nil)
+	ctx := context.Background()
+
+	spotRepo.On("LockForUpdate", ctx, "spot-123").Return(nil)
+	ratingRepo.On("GetBySpotAndUser", ctx, "spot-123", "user-456").Return(&rating.Rating{ID: "rating-1"}, nil)
+
+	// Act
+	_, err := service.CreateRating(ctx, "spot-123", "user-456", 5, nil, "")
+
+	// Assert
+	assert.True(t, errors.Is(err, errors.ErrTypeConflict))
+	ratingRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRatingService_UpdateRating_InvalidCategory(t *testing.T) {
+	// Arrange
+	ratingRepo := new(MockRatingRepository)
+	spotRepo := new(MockSpotRepository)
+	service := application.NewRatingService(ratingRepo, spotRepo, nil)
+	ctx := context.Background()
+
+	existing := &rating.Rating{ID: "rating-1", SpotID: "spot-123", UserID: "user-456", SoloFriendlyRating: 3}
+	spotRepo.On("LockForUpdate", ctx, "spot-123").Return(nil)
+	ratingRepo.On("GetBySpotAndUser", ctx, "spot-123", "user-456").Return(existing, nil)
+
+	// Act
+	_, err := service.UpdateRating(ctx, "spot-123", "user-456", 4, []string{"invalid_category"}, "")
+
+	// Assert
+	assert.True(t, errors.Is(err, errors.ErrTypeInvalidInput))
+	ratingRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestRatingService_CreateRating_ConcurrentDuplicate(t *testing.T) {
+	// Arrange
+	ratingRepo := new(MockRatingRepository)
+	spotRepo := new(MockSpotRepository)
+	service := application.NewRatingService(ratingRepo, spotRepo, nil)
+	ctx := context.Background()
+
+	// Another request inserted the rating after the check; the unique key rejects this one
+	spotRepo.On("LockForUpdate", ctx, "spot-123").Return(nil)
+	ratingRepo.On("GetBySpotAndUser", ctx, "spot-123", "user-456").Return(nil, errors.NotFound("rating", "spot-123"))
+	ratingRepo.On("Create", ctx, mock.AnythingOfType("*rating.Rating")).Return(errors.Conflict("rating", "user has already rated this spot"))
+
+	// Act
+	_, err := service.CreateRating(ctx, "spot-123", "user-456", 4, nil, "")
+
+	// Assert
+	assert.True(t, errors.Is(err, errors.ErrTypeConflict))
+	spotRepo.AssertNotCalled(t, "UpdateSoloFriendlyStats", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRatingService_CreateRating_SpotNotFound(t *testing.T) {
+	// Arrange
+	ratingRepo := new(MockRatingRepository)
+	spotRepo := new(MockSpotRepository)
+	service := application.NewRatingService(ratingRepo, spotRepo, nil)
+	ctx := context.Background()
+
+	spotRepo.On("LockForUpdate", ctx, "missing-spot").Return(errors.NotFound("spot", "missing-spot"))
+
+	// Act
+	_, err := service.CreateRating(ctx, "missing-spot", "user-456", 4, nil, "")
+
+	// Assert
+	assert.True(t, errors.Is(err, errors.ErrTypeNotFound))
+	ratingRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestRatingService_UpdateRating_RunsInTransaction(t *testing.T) {
+	// Arrange
+	ratingRepo := new(MockRatingRepository)
+	spotRepo := new(MockSpotRepository)
+	txRatingRepo := new(MockRatingRepository)
+	txSpotRepo := new(MockSpotRepository)
+	tx := &recordingTxRunner{ratingRepo: txRatingRepo, spotRepo: txSpotRepo}
+	service := application.NewRatingService(ratingRepo, spotRepo, tx)
+	ctx := context.Background()
+
+	existing := &rating.Rating{ID: "rating-1", SpotID: "spot-123", UserID: "user-456", SoloFriendlyRating: 3}
+	txSpotRepo.On("LockForUpdate", ctx, "spot-123").Return(nil)
+	txRatingRepo.On("GetBySpotAndUser", ctx, "spot-123", "user-456").Return(existing, nil)
+	txRatingRepo.On("Update", ctx, existing).Return(nil)
+	txRatingRepo.On("GetBySpot", ctx, "spot-123").Return([]*rating.Rating{existing}, nil)
+	txSpotRepo.On("UpdateSoloFriendlyStats", ctx, "spot-123", 5.0, 1).Return(nil)
+	txSpotRepo.On("UpdateSoloCategoryCounts", ctx, "spot-123", map[string]int{"quiet_atmosphere": 1}).Return(nil)
+
+	// Act
+	result, err := service.UpdateRating(ctx, "spot-123", "user-456", 5, []string{"quiet_atmosphere"}, "")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 5, result.SoloFriendlyRating)
+	assert.Equal(t, 1, tx.calls)
+	txRatingRepo.AssertExpectations(t)
+	txSpotRepo.AssertExpectations(t)
+	ratingRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+	spotRepo.AssertNotCalled(t, "UpdateSoloFriendlyStats", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,7 @@
+-- Reverse the changes from 000006_add_spot_fulltext_search.up.sql
+
+DROP INDEX `ft_spots_search` ON `spots`;
+
+ALTER TABLE `spots`
+DROP COLUMN `address_i18n_text`,
+DROP COLUMN `name_i18n_text`;
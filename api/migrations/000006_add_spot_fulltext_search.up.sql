@@ -0,0 +1,18 @@
+-- Full-text search across spot names and addresses, including localized values
+-- FULLTEXT indexes cannot cover JSON columns directly, so the i18n values are
+-- flattened into stored generated columns first. Each supported language is
+-- extracted separately: a '$.*' path yields a JSON array that JSON_UNQUOTE
+-- leaves escaped. The ngram parser tokenizes CJK text so Japanese names can be
+-- searched without word boundaries.
+
+ALTER TABLE `spots`
+ADD COLUMN `name_i18n_text` TEXT GENERATED ALWAYS AS (CONCAT_WS(' ',
+    JSON_UNQUOTE(JSON_EXTRACT(`name_i18n`, '$.ja')),
+    JSON_UNQUOTE(JSON_EXTRACT(`name_i18n`, '$.en')))) STORED,
+ADD COLUMN `address_i18n_text` TEXT GENERATED ALWAYS AS (CONCAT_WS(' ',
+    JSON_UNQUOTE(JSON_EXTRACT(`address_i18n`, '$.ja')),
+    JSON_UNQUOTE(JSON_EXTRACT(`address_i18n`, '$.en')))) STORED;
+
+CREATE FULLTEXT INDEX `ft_spots_search`
+ON `spots`(`name`, `address`, `name_i18n_text`, `address_i18n_text`)
+WITH PARSER ngram;
@@ -0,0 +1,6 @@
+-- Reverse the changes from 000007_add_spot_created_by.up.sql
+
+DROP INDEX `idx_spots_created_by` ON `spots`;
+
+ALTER TABLE `spots`
+DROP COLUMN `created_by`;
@@ -0,0 +1,6 @@
+-- Track which user created each spot so only the creator (or an admin) can modify it
+-- Existing spots have no recorded creator and can only be modified by admins
+ALTER TABLE `spots`
+ADD COLUMN `created_by` VARCHAR(255) NULL;
+
+CREATE INDEX `idx_spots_created_by` ON `spots`(`created_by`);
@@ -0,0 +1,4 @@
+-- Reverse the changes from 000008_add_spot_rating_sum.up.sql
+
+ALTER TABLE `spots`
+DROP COLUMN `rating_sum`;
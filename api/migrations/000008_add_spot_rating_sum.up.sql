@@ -0,0 +1,15 @@
+-- Keep a running sum of review ratings so spot statistics can be updated incrementally
+-- inside the same transaction as the review write instead of rescanning all reviews
+ALTER TABLE `spots`
+ADD COLUMN `rating_sum` INT NOT NULL DEFAULT 0;
+
+-- Backfill aggregates from existing reviews
+UPDATE `spots` s
+LEFT JOIN (
+    SELECT `spot_id`, COUNT(*) AS `review_count`, SUM(`rating`) AS `rating_sum`
+    FROM `reviews`
+    GROUP BY `spot_id`
+) r ON r.`spot_id` = s.`id`
+SET s.`review_count` = COALESCE(r.`review_count`, 0),
+    s.`rating_sum` = COALESCE(r.`rating_sum`, 0),
+    s.`average_rating` = COALESCE(ROUND(r.`rating_sum` / r.`review_count`, 1), 0.0);
@@ -0,0 +1,7 @@
+-- Reverse the changes from 000009_add_solo_ratings.up.sql
+
+ALTER TABLE `spots`
+DROP COLUMN `solo_rating_count`,
+DROP COLUMN `solo_friendly_rating`;
+
+DROP TABLE IF EXISTS `solo_ratings`;
@@ -0,0 +1,21 @@
+-- Solo-friendly ratings: how comfortable a spot is for someone visiting alone
+-- Each user can rate a spot once; the rating can be updated later
+CREATE TABLE `solo_ratings` (
+    `id` VARCHAR(36) PRIMARY KEY,
+    `spot_id` VARCHAR(36) NOT NULL,
+    `user_id` VARCHAR(255) NOT NULL,
+    `solo_friendly_rating` INT NOT NULL CHECK (`solo_friendly_rating` >= 1 AND `solo_friendly_rating` <= 5),
+    `categories` JSON NOT NULL,
+    `comment` TEXT,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY `idx_solo_ratings_spot_user` (`spot_id`, `user_id`),
+    INDEX `idx_solo_ratings_user` (`user_id`),
+    CONSTRAINT `fk_solo_ratings_spot_id` FOREIGN KEY (`spot_id`) REFERENCES `spots`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Per-spot solo-friendly statistics, maintained by the rating service
+ALTER TABLE `spots`
+ADD COLUMN `solo_friendly_rating` DECIMAL(3, 1) NOT NULL DEFAULT 0.0,
+ADD COLUMN `solo_rating_count` INT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Reverse the changes from 000010_add_spot_solo_categories.up.sql
+
+DROP TABLE IF EXISTS `spot_solo_categories`;
@@ -0,0 +1,31 @@
+-- Per-spot confirmation counts for solo-friendly categories such as power_outlets.
+-- A category is confirmed once for every solo rating of the spot that mentions it.
+-- The counts are derived from solo_ratings.categories and kept in their own table so
+-- spot listings can filter on categories through an index instead of scanning JSON.
+CREATE TABLE `spot_solo_categories` (
+    `spot_id` VARCHAR(36) NOT NULL,
+    `category` VARCHAR(50) NOT NULL,
+    `confirmations` INT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (`spot_id`, `category`),
+    INDEX `idx_spot_solo_categories_category` (`category`, `confirmations`),
+    CONSTRAINT `fk_spot_solo_categories_spot_id` FOREIGN KEY (`spot_id`) REFERENCES `spots`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Backfill from existing ratings
+INSERT INTO `spot_solo_categories` (`spot_id`, `category`, `confirmations`)
+SELECT r.`spot_id`, c.`category`, COUNT(*)
+FROM `solo_ratings` r
+JOIN (
+    SELECT 'quiet_atmosphere' AS `category`
+    UNION ALL SELECT 'wifi_available'
+    UNION ALL SELECT 'single_seating'
+    UNION ALL SELECT 'good_lighting'
+    UNION ALL SELECT 'power_outlets'
+    UNION ALL SELECT 'comfortable_seating'
+    UNION ALL SELECT 'minimal_noise'
+    UNION ALL SELECT 'study_friendly'
+    UNION ALL SELECT 'work_friendly'
+    UNION ALL SELECT 'reading_friendly'
+) c ON JSON_CONTAINS(r.`categories`, JSON_QUOTE(c.`category`))
+GROUP BY r.`spot_id`, c.`category`;
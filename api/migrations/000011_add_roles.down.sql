@@ -0,0 +1,5 @@
+-- Reverse the changes from 000011_add_roles.up.sql
+
+DROP TABLE IF EXISTS `user_roles`;
+DROP TABLE IF EXISTS `role_permissions`;
+DROP TABLE IF EXISTS `roles`;
@@ -0,0 +1,37 @@
+-- Roles grant permissions in addition to those issued in the Auth0 access token.
+-- Grant a role with: INSERT INTO user_roles (user_id, role_id) VALUES ('<user id>', 'admin');
+CREATE TABLE `roles` (
+    `id` VARCHAR(50) PRIMARY KEY,
+    `description` VARCHAR(255) NOT NULL DEFAULT '',
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `role_permissions` (
+    `role_id` VARCHAR(50) NOT NULL,
+    `permission` VARCHAR(100) NOT NULL,
+
+    PRIMARY KEY (`role_id`, `permission`),
+    CONSTRAINT `fk_role_permissions_role_id` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- user_id is the users.id of the user, or the token subject when it is not linked to a
+-- user, as stored in solo_ratings.user_id
+CREATE TABLE `user_roles` (
+    `user_id` VARCHAR(255) NOT NULL,
+    `role_id` VARCHAR(50) NOT NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (`user_id`, `role_id`),
+    INDEX `idx_user_roles_role` (`role_id`),
+    CONSTRAINT `fk_user_roles_role_id` FOREIGN KEY (`role_id`) REFERENCES `roles`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT INTO `roles` (`id`, `description`) VALUES
+    ('admin', 'Full administrative access'),
+    ('moderator', 'Moderates spots created by other users');
+
+INSERT INTO `role_permissions` (`role_id`, `permission`) VALUES
+    ('admin', 'admin:spots'),
+    ('admin', 'admin:users'),
+    ('admin', 'admin:auth'),
+    ('moderator', 'admin:spots');
@@ -0,0 +1,3 @@
+-- Reverse the changes from 000012_grant_admin_system.up.sql
+
+DELETE FROM `role_permissions` WHERE `role_id` = 'admin' AND `permission` = 'admin:system';
@@ -0,0 +1,3 @@
+-- Background job status is exposed to holders of admin:system
+INSERT INTO `role_permissions` (`role_id`, `permission`) VALUES
+    ('admin', 'admin:system');
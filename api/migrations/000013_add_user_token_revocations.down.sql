@@ -0,0 +1,3 @@
+-- Reverse the changes from 000013_add_user_token_revocations.up.sql
+
+DROP TABLE IF EXISTS `user_token_revocations`;
@@ -0,0 +1,8 @@
+-- Per-user revocation cutoff for logout-everywhere: access tokens of the user issued
+-- at or before revoked_before are rejected. The user_id is the token subject, which is
+-- not necessarily a row in users (e.g. local development subjects).
+CREATE TABLE `user_token_revocations` (
+    `user_id` VARCHAR(255) PRIMARY KEY,
+    `revoked_before` TIMESTAMP NOT NULL,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,4 @@
+-- Reverse the changes from 000014_add_api_keys.up.sql
+
+DELETE FROM `role_permissions` WHERE `role_id` = 'admin' AND `permission` = 'admin:api_keys';
+DROP TABLE IF EXISTS `api_keys`;
@@ -0,0 +1,22 @@
+-- API keys for machine-to-machine clients. Only the SHA-256 hash of a key is stored;
+-- the key itself is shown once when it is created or rotated.
+CREATE TABLE `api_keys` (
+    `id` VARCHAR(36) PRIMARY KEY,
+    `name` VARCHAR(100) NOT NULL,
+    `prefix` VARCHAR(32) NOT NULL,
+    `key_hash` CHAR(64) NOT NULL,
+    `permissions` JSON NOT NULL,
+    `created_by` VARCHAR(255) NOT NULL,
+    `usage_count` BIGINT NOT NULL DEFAULT 0,
+    `last_used_at` TIMESTAMP NULL,
+    `expires_at` TIMESTAMP NULL,
+    `revoked_at` TIMESTAMP NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `updated_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+
+    UNIQUE KEY `idx_api_keys_key_hash` (`key_hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- API keys are managed by holders of admin:api_keys
+INSERT INTO `role_permissions` (`role_id`, `permission`) VALUES
+    ('admin', 'admin:api_keys');
@@ -0,0 +1,5 @@
+-- Reverse the changes from 000015_add_user_identities.up.sql
+
+-- Rows re-keyed from token subjects to users.id are not reverted: the subject a row was
+-- written under and the superseded solo ratings are not recorded
+DROP TABLE IF EXISTS `user_identities`;
@@ -0,0 +1,119 @@
+-- Sign-in identities linked to a user account. A user can sign in with several
+-- providers (Google, X, Auth0 database connections); each (provider, provider_id)
+-- pair belongs to exactly one user. users.provider and users.provider_id keep the
+-- identity the account was created with.
+CREATE TABLE `user_identities` (
+    `provider` VARCHAR(50) NOT NULL,
+    `provider_id` VARCHAR(255) NOT NULL,
+    `user_id` VARCHAR(36) NOT NULL,
+    `email` VARCHAR(255),
+    `email_verified` BOOLEAN NOT NULL DEFAULT FALSE,
+    `last_used_at` TIMESTAMP NULL,
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    PRIMARY KEY (`provider`, `provider_id`),
+    INDEX `idx_user_identities_user_id` (`user_id`),
+    CONSTRAINT `fk_user_identities_user_id` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Every existing user starts with the identity it was created with
+INSERT INTO `user_identities` (`provider`, `provider_id`, `user_id`, `email`, `email_verified`, `created_at`)
+SELECT `provider`, `provider_id`, `id`, `email`, `email_verified`, `created_at` FROM `users`;
+
+-- Tokens are now resolved to the user their identity is linked to, so rows written under
+-- a token subject ("google-oauth2|123") are re-keyed to users.id. Subjects that are not
+-- linked identities (e.g. local development subjects) keep acting as user IDs.
+CREATE TEMPORARY TABLE `identity_subjects` (
+    `subject` VARCHAR(255) PRIMARY KEY,
+    `user_id` VARCHAR(36) NOT NULL
+);
+
+INSERT INTO `identity_subjects` (`subject`, `user_id`)
+SELECT CONCAT(IF(`provider` = 'google', 'google-oauth2', `provider`), '|', `provider_id`), `user_id`
+FROM `user_identities`;
+
+-- Roles granted to any identity of the user are granted to the user
+INSERT IGNORE INTO `user_roles` (`user_id`, `role_id`, `created_at`)
+SELECT s.`user_id`, r.`role_id`, r.`created_at`
+FROM `user_roles` r
+JOIN `identity_subjects` s ON s.`subject` = r.`user_id`;
+
+DELETE r FROM `user_roles` r
+JOIN `identity_subjects` s ON s.`subject` = r.`user_id`;
+
+-- The latest cutoff of the user's identities applies to the user
+INSERT INTO `user_token_revocations` (`user_id`, `revoked_before`)
+SELECT `user_id`, `revoked_before` FROM (
+    SELECT s.`user_id`, MAX(r.`revoked_before`) AS `revoked_before`
+    FROM `user_token_revocations` r
+    JOIN `identity_subjects` s ON s.`subject` = r.`user_id`
+    GROUP BY s.`user_id`
+) AS latest
+ON DUPLICATE KEY UPDATE `revoked_before` =
+    GREATEST(`user_token_revocations`.`revoked_before`, latest.`revoked_before`);
+
+DELETE r FROM `user_token_revocations` r
+JOIN `identity_subjects` s ON s.`subject` = r.`user_id`;
+
+UPDATE `spots` sp
+JOIN `identity_subjects` s ON s.`subject` = sp.`created_by`
+SET sp.`created_by` = s.`user_id`;
+
+UPDATE `api_keys` k
+JOIN `identity_subjects` s ON s.`subject` = k.`created_by`
+SET k.`created_by` = s.`user_id`;
+
+-- A user who rated a spot under more than one key keeps only the latest rating, since
+-- a user can rate a spot once
+CREATE TEMPORARY TABLE `superseded_solo_ratings` (
+    `id` VARCHAR(36) PRIMARY KEY,
+    `spot_id` VARCHAR(36) NOT NULL
+);
+
+INSERT INTO `superseded_solo_ratings` (`id`, `spot_id`)
+SELECT `id`, `spot_id` FROM (
+    SELECT r.`id`, r.`spot_id`, ROW_NUMBER() OVER (
+        PARTITION BY r.`spot_id`, COALESCE(s.`user_id`, r.`user_id`)
+        ORDER BY r.`updated_at` DESC, r.`id` DESC
+    ) AS `position`
+    FROM `solo_ratings` r
+    LEFT JOIN `identity_subjects` s ON s.`subject` = r.`user_id`
+) ranked
+WHERE `position` > 1;
+
+DELETE r FROM `solo_ratings` r
+JOIN `superseded_solo_ratings` d ON d.`id` = r.`id`;
+
+UPDATE `solo_ratings` r
+JOIN `identity_subjects` s ON s.`subject` = r.`user_id`
+SET r.`user_id` = s.`user_id`;
+
+-- Recompute the statistics of spots that lost a rating
+CREATE TEMPORARY TABLE `resolved_solo_rating_spots` (
+    `spot_id` VARCHAR(36) PRIMARY KEY
+);
+
+INSERT INTO `resolved_solo_rating_spots` (`spot_id`)
+SELECT DISTINCT `spot_id` FROM `superseded_solo_ratings`;
+
+UPDATE `spots` sp
+JOIN `resolved_solo_rating_spots` a ON a.`spot_id` = sp.`id`
+SET sp.`solo_friendly_rating` = (
+        SELECT COALESCE(ROUND(AVG(r.`solo_friendly_rating`), 1), 0)
+        FROM `solo_ratings` r WHERE r.`spot_id` = sp.`id`),
+    sp.`solo_rating_count` = (
+        SELECT COUNT(*) FROM `solo_ratings` r WHERE r.`spot_id` = sp.`id`);
+
+DELETE c FROM `spot_solo_categories` c
+JOIN `resolved_solo_rating_spots` a ON a.`spot_id` = c.`spot_id`;
+
+INSERT INTO `spot_solo_categories` (`spot_id`, `category`, `confirmations`)
+SELECT r.`spot_id`, c.`category`, COUNT(*)
+FROM `solo_ratings` r
+JOIN `resolved_solo_rating_spots` a ON a.`spot_id` = r.`spot_id`
+JOIN JSON_TABLE(r.`categories`, '$[*]' COLUMNS (`category` VARCHAR(50) PATH '$')) c
+GROUP BY r.`spot_id`, c.`category`;
+
+DROP TEMPORARY TABLE `resolved_solo_rating_spots`;
+DROP TEMPORARY TABLE `superseded_solo_ratings`;
+DROP TEMPORARY TABLE `identity_subjects`;
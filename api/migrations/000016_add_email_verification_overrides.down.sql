@@ -0,0 +1,3 @@
+-- Reverse the changes from 000016_add_email_verification_overrides.up.sql
+
+DROP TABLE IF EXISTS `email_verification_overrides`;
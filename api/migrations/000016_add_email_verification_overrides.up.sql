@@ -0,0 +1,10 @@
+-- Users an admin has exempted from the verified email requirement for creating spots,
+-- reviews and ratings (AUTH_REQUIRE_VERIFIED_EMAIL). A row is the exemption.
+CREATE TABLE `email_verification_overrides` (
+    `user_id` VARCHAR(36) PRIMARY KEY,
+    `granted_by` VARCHAR(255) NOT NULL,
+    `reason` VARCHAR(500),
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT `fk_email_verification_overrides_user_id` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
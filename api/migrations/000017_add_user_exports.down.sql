@@ -0,0 +1,3 @@
+-- Reverse the changes from 000017_add_user_exports.up.sql
+
+DROP TABLE IF EXISTS `user_exports`;
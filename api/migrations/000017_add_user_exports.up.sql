@@ -0,0 +1,21 @@
+-- Personal data exports requested by users. A background job builds the archive of
+-- pending exports; the archive can then be downloaded with a secret token, whose SHA-256
+-- hash is stored, until expires_at. Expired exports are deleted by the same job.
+CREATE TABLE `user_exports` (
+    `id` VARCHAR(36) PRIMARY KEY,
+    `user_id` VARCHAR(36) NOT NULL,
+    `format` VARCHAR(10) NOT NULL DEFAULT 'json',
+    `status` VARCHAR(20) NOT NULL DEFAULT 'pending',
+    `download_token_hash` CHAR(64) NOT NULL,
+    `archive` LONGBLOB,
+    `size_bytes` BIGINT NOT NULL DEFAULT 0,
+    `error` VARCHAR(500),
+    `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    `completed_at` TIMESTAMP NULL,
+    `expires_at` TIMESTAMP NULL,
+
+    INDEX `idx_user_exports_user_id` (`user_id`, `created_at`),
+    INDEX `idx_user_exports_status` (`status`, `created_at`),
+    INDEX `idx_user_exports_expires_at` (`expires_at`),
+    CONSTRAINT `fk_user_exports_user_id` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
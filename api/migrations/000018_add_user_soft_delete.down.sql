@@ -0,0 +1,10 @@
+-- Reverse the changes from 000018_add_user_soft_delete.up.sql
+ALTER TABLE `reviews`
+DROP FOREIGN KEY `fk_reviews_user_id`;
+
+ALTER TABLE `reviews`
+ADD CONSTRAINT `fk_reviews_user_id` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE;
+
+ALTER TABLE `users`
+DROP INDEX `idx_users_deleted_at`,
+DROP COLUMN `deleted_at`;
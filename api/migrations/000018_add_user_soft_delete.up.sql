@@ -0,0 +1,13 @@
+-- Deleting an account only sets deleted_at. The account can be restored until the purge
+-- job removes it once the grace period has passed.
+ALTER TABLE `users`
+ADD COLUMN `deleted_at` TIMESTAMP NULL,
+ADD INDEX `idx_users_deleted_at` (`deleted_at`);
+
+-- Reviews outlive their author: the purge job anonymizes them before deleting the user,
+-- and a user row deleted any other way detaches its reviews instead of deleting them
+ALTER TABLE `reviews`
+DROP FOREIGN KEY `fk_reviews_user_id`;
+
+ALTER TABLE `reviews`
+ADD CONSTRAINT `fk_reviews_user_id` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE SET NULL;
@@ -0,0 +1,7 @@
+-- Reverse the changes from 000019_version_user_preferences.up.sql. Releases before the
+-- preferences schema read dark_mode and ignore the other version 1 fields, so only the
+-- version marker is removed.
+UPDATE `users`
+SET `preferences` = JSON_REMOVE(`preferences`, '$.version')
+WHERE JSON_TYPE(`preferences`) = 'OBJECT'
+  AND JSON_EXTRACT(`preferences`, '$.version') = 1;
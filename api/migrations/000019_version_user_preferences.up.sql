@@ -0,0 +1,31 @@
+-- Upgrade stored preferences to version 1 of the preferences schema. The application
+-- migrates documents when it reads them as well, so rows written by an older release
+-- during the deploy are still handled. Fields added in version 1 are left out and take
+-- their defaults.
+UPDATE `users`
+SET `preferences` = JSON_SET(
+    JSON_REMOVE(`preferences`, '$.darkMode'),
+    '$.dark_mode', JSON_EXTRACT(`preferences`, '$.darkMode')
+)
+WHERE JSON_TYPE(`preferences`) = 'OBJECT'
+  AND JSON_CONTAINS_PATH(`preferences`, 'one', '$.darkMode')
+  AND NOT JSON_CONTAINS_PATH(`preferences`, 'one', '$.dark_mode');
+
+UPDATE `users`
+SET `preferences` = JSON_SET(
+    JSON_REMOVE(`preferences`, '$.theme'),
+    '$.dark_mode', IF(JSON_UNQUOTE(JSON_EXTRACT(`preferences`, '$.theme')) = 'dark', CAST('true' AS JSON), CAST('false' AS JSON))
+)
+WHERE JSON_TYPE(`preferences`) = 'OBJECT'
+  AND JSON_CONTAINS_PATH(`preferences`, 'one', '$.theme')
+  AND NOT JSON_CONTAINS_PATH(`preferences`, 'one', '$.dark_mode');
+
+UPDATE `users`
+SET `preferences` = JSON_REMOVE(`preferences`, '$.darkMode', '$.theme')
+WHERE JSON_TYPE(`preferences`) = 'OBJECT'
+  AND JSON_CONTAINS_PATH(`preferences`, 'one', '$.darkMode', '$.theme');
+
+UPDATE `users`
+SET `preferences` = JSON_SET(`preferences`, '$.version', 1)
+WHERE JSON_TYPE(`preferences`) = 'OBJECT'
+  AND NOT JSON_CONTAINS_PATH(`preferences`, 'one', '$.version');
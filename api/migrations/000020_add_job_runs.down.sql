@@ -0,0 +1,3 @@
+-- Reverse the changes from 000020_add_job_runs.up.sql
+
+DROP TABLE IF EXISTS `job_runs`;
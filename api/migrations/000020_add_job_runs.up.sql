@@ -0,0 +1,6 @@
+-- Last run of each background job across replicas. The scheduler only runs a job
+-- whose interval has elapsed since any replica last started it.
+CREATE TABLE `job_runs` (
+    `name` VARCHAR(64) PRIMARY KEY,
+    `last_run_at` TIMESTAMP(6) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,4 @@
+-- Reverse the changes from 000021_add_user_export_claims.up.sql
+
+ALTER TABLE `user_exports`
+DROP COLUMN `claimed_at`;
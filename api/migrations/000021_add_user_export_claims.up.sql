@@ -0,0 +1,5 @@
+-- The export job claims an export by setting claimed_at together with the processing
+-- status, so only one replica builds it. A claim older than the stale timeout belongs
+-- to a run that was interrupted and can be taken over.
+ALTER TABLE `user_exports`
+ADD COLUMN `claimed_at` TIMESTAMP NULL;
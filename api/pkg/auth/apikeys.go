@@ -0,0 +1,474 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	stdErrors "errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bocchi/api/infrastructure/database"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/logger"
+)
+
+const (
+	// APIKeyHeader carries an API key on HTTP requests
+	APIKeyHeader = "X-API-Key"
+	// APIKeySubjectPrefix prefixes the subject of requests authenticated with an API key,
+	// so machine clients never collide with user IDs
+	APIKeySubjectPrefix = "apikey|"
+	// DefaultAPIKeyUsageFlushInterval is how often usage counted in memory is written to the store
+	DefaultAPIKeyUsageFlushInterval = time.Minute
+
+	// apiKeyPrefix starts every key so leaked keys are easy to recognize in logs and scanners
+	apiKeyPrefix = "bocchi_"
+	// apiKeySecretBytes is the entropy of the secret part of a key
+	apiKeySecretBytes = 32
+)
+
+// APIKey is a credential for a machine-to-machine client. The key itself is never stored;
+// it is returned once by Create and Rotate.
+type APIKey struct {
+	ID          string
+	Name        string
+	Prefix      string // Identifies the key without revealing it, e.g. "bocchi_1a2b3c4d"
+	Permissions []string
+	CreatedBy   string
+	UsageCount  int64
+	LastUsedAt  time.Time
+	ExpiresAt   time.Time // Zero for keys that do not expire
+	RevokedAt   time.Time // Zero for active keys
+	CreatedAt   time.Time
+}
+
+// Active reports whether the key can authenticate at the given time
+func (k *APIKey) Active(now time.Time) bool {
+	if !k.RevokedAt.IsZero() {
+		return false
+	}
+	return k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt)
+}
+
+// APIKeyStore persists API keys by the SHA-256 hash of the key
+type APIKeyStore interface {
+	// Create stores a new key
+	Create(ctx context.Context, key APIKey, keyHash string) error
+	// GetByHash returns the key with the given hash, or nil when there is none
+	GetByHash(ctx context.Context, keyHash string) (*APIKey, error)
+	// Get returns the key with the given ID, or nil when there is none
+	Get(ctx context.Context, id string) (*APIKey, error)
+	// List returns every key, newest first
+	List(ctx context.Context) ([]APIKey, error)
+	// Rotate replaces the hash of an active key and reports whether it was found
+	Rotate(ctx context.Context, id, prefix, keyHash string) (bool, error)
+	// Revoke revokes an active key and reports whether it was found
+	Revoke(ctx context.Context, id string) (bool, error)
+	// AddUsage adds count requests to the key's usage counter
+	AddUsage(ctx context.Context, id string, count int64, lastUsedAt time.Time) error
+}
+
+// MySQLAPIKeyStore keeps API keys in the api_keys table
+type MySQLAPIKeyStore struct {
+	queries *database.Queries
+}
+
+// NewMySQLAPIKeyStore creates an API key store backed by the database
+func NewMySQLAPIKeyStore(queries *database.Queries) *MySQLAPIKeyStore {
+	return &MySQLAPIKeyStore{queries: queries}
+}
+
+// Create inserts the key into the api_keys table
+func (s *MySQLAPIKeyStore) Create(ctx context.Context, key APIKey, keyHash string) error {
+	permissions, err := json.Marshal(key.Permissions)
+	if err != nil {
+		return err
+	}
+	return s.queries.CreateAPIKey(ctx, database.CreateAPIKeyParams{
+		ID:          key.ID,
+		Name:        key.Name,
+		Prefix:      key.Prefix,
+		KeyHash:     keyHash,
+		Permissions: permissions,
+		CreatedBy:   key.CreatedBy,
+		ExpiresAt:   nullTime(key.ExpiresAt),
+	})
+}
+
+// GetByHash looks the key up by its hash
+func (s *MySQLAPIKeyStore) GetByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	row, err := s.queries.GetAPIKeyByHash(ctx, keyHash)
+	if stdErrors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return apiKeyFromRow(row)
+}
+
+// Get looks the key up by ID
+func (s *MySQLAPIKeyStore) Get(ctx context.Context, id string) (*APIKey, error) {
+	row, err := s.queries.GetAPIKeyByID(ctx, id)
+	if stdErrors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return apiKeyFromRow(row)
+}
+
+// List returns every key in the api_keys table
+func (s *MySQLAPIKeyStore) List(ctx context.Context) ([]APIKey, error) {
+	rows, err := s.queries.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]APIKey, 0, len(rows))
+	for _, row := range rows {
+		key, err := apiKeyFromRow(row)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+// Rotate updates the hash of an active key
+func (s *MySQLAPIKeyStore) Rotate(ctx context.Context, id, prefix, keyHash string) (bool, error) {
+	affected, err := s.queries.RotateAPIKey(ctx, database.RotateAPIKeyParams{
+		Prefix:  prefix,
+		KeyHash: keyHash,
+		ID:      id,
+	})
+	return affected > 0, err
+}
+
+// Revoke sets revoked_at on an active key
+func (s *MySQLAPIKeyStore) Revoke(ctx context.Context, id string) (bool, error) {
+	affected, err := s.queries.RevokeAPIKey(ctx, id)
+	return affected > 0, err
+}
+
+// AddUsage increments the key's usage_count and sets last_used_at
+func (s *MySQLAPIKeyStore) AddUsage(ctx context.Context, id string, count int64, lastUsedAt time.Time) error {
+	return s.queries.AddAPIKeyUsage(ctx, database.AddAPIKeyUsageParams{
+		UsageCount: count,
+		LastUsedAt: nullTime(lastUsedAt),
+		ID:         id,
+	})
+}
+
+func apiKeyFromRow(row database.ApiKey) (*APIKey, error) {
+	key := &APIKey{
+		ID:         row.ID,
+		Name:       row.Name,
+		Prefix:     row.Prefix,
+		CreatedBy:  row.CreatedBy,
+		UsageCount: row.UsageCount,
+		LastUsedAt: row.LastUsedAt.Time,
+		ExpiresAt:  row.ExpiresAt.Time,
+		RevokedAt:  row.RevokedAt.Time,
+		CreatedAt:  row.CreatedAt,
+	}
+	if err := json.Unmarshal(row.Permissions, &key.Permissions); err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeInternal, "invalid API key permissions")
+	}
+	return key, nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// APIKeyParams describes a key to create
+type APIKeyParams struct {
+	Name        string
+	Permissions []string
+	CreatedBy   string
+	ExpiresAt   time.Time // Zero for keys that do not expire
+}
+
+// apiKeyUsage is the usage of a key counted since the last flush
+type apiKeyUsage struct {
+	count      int64
+	lastUsedAt time.Time
+}
+
+// APIKeyManager creates, rotates, revokes and authenticates API keys.
+//
+// Usage is counted in memory and added to the store periodically, so authenticating a key
+// costs a single lookup. Counts not yet flushed are lost if the process crashes; Stop
+// flushes them on shutdown.
+type APIKeyManager struct {
+	store APIKeyStore
+	now   func() time.Time
+
+	mu    sync.Mutex
+	usage map[string]*apiKeyUsage
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewAPIKeyManager creates a manager that flushes usage every flushInterval.
+// A zero interval uses DefaultAPIKeyUsageFlushInterval.
+func NewAPIKeyManager(store APIKeyStore, flushInterval time.Duration) *APIKeyManager {
+	if flushInterval <= 0 {
+		flushInterval = DefaultAPIKeyUsageFlushInterval
+	}
+
+	m := &APIKeyManager{
+		store: store,
+		now:   time.Now,
+		usage: make(map[string]*apiKeyUsage),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go m.flushRoutine(flushInterval)
+	return m
+}
+
+// Create generates a new key and returns it along with the key itself, which cannot be
+// retrieved later. A key can only be granted permissions its creator holds, taken from ctx.
+func (m *APIKeyManager) Create(ctx context.Context, params APIKeyParams) (*APIKey, string, error) {
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return nil, "", errors.InvalidInput("name", "name is required")
+	}
+	if len(name) > 100 {
+		return nil, "", errors.InvalidInput("name", "name must be at most 100 characters")
+	}
+	if !params.ExpiresAt.IsZero() && !params.ExpiresAt.After(m.now()) {
+		return nil, "", errors.InvalidInput("expires_at", "expiry must be in the future")
+	}
+
+	permissions := mergePermissions(params.Permissions, nil)
+	for _, permission := range permissions {
+		if !HasPermission(ctx, permission) {
+			return nil, "", errors.Forbidden("permission", "grant "+permission)
+		}
+	}
+
+	secret, prefix, err := generateAPIKey()
+	if err != nil {
+		return nil, "", errors.Wrap(err, errors.ErrTypeInternal, "failed to generate API key")
+	}
+
+	key := &APIKey{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Prefix:      prefix,
+		Permissions: permissions,
+		CreatedBy:   params.CreatedBy,
+		ExpiresAt:   params.ExpiresAt,
+		CreatedAt:   m.now(),
+	}
+	if err := m.store.Create(ctx, *key, hashAPIKey(secret)); err != nil {
+		return nil, "", errors.Wrap(err, errors.ErrTypeInternal, "failed to store API key")
+	}
+
+	logger.InfoWithFields("API key created", map[string]interface{}{
+		"api_key_id":  key.ID,
+		"prefix":      key.Prefix,
+		"created_by":  key.CreatedBy,
+		"permissions": key.Permissions,
+	})
+
+	return key, secret, nil
+}
+
+// List returns every key, including revoked ones, with usage not yet flushed included
+func (m *APIKeyManager) List(ctx context.Context) ([]APIKey, error) {
+	keys, err := m.store.List(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeInternal, "failed to list API keys")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range keys {
+		if usage, ok := m.usage[keys[i].ID]; ok {
+			keys[i].UsageCount += usage.count
+			keys[i].LastUsedAt = usage.lastUsedAt
+		}
+	}
+	return keys, nil
+}
+
+// Rotate replaces the key's secret, keeping its ID, permissions and usage. The previous
+// secret stops working immediately.
+func (m *APIKeyManager) Rotate(ctx context.Context, id string) (*APIKey, string, error) {
+	secret, prefix, err := generateAPIKey()
+	if err != nil {
+		return nil, "", errors.Wrap(err, errors.ErrTypeInternal, "failed to generate API key")
+	}
+
+	rotated, err := m.store.Rotate(ctx, id, prefix, hashAPIKey(secret))
+	if err != nil {
+		return nil, "", errors.Wrap(err, errors.ErrTypeInternal, "failed to rotate API key")
+	}
+	if !rotated {
+		return nil, "", errors.NotFound("api_key", id)
+	}
+
+	key, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, "", errors.Wrap(err, errors.ErrTypeInternal, "failed to load rotated API key")
+	}
+	if key == nil {
+		return nil, "", errors.NotFound("api_key", id)
+	}
+
+	logger.InfoWithFields("API key rotated", map[string]interface{}{
+		"api_key_id": id,
+		"prefix":     prefix,
+	})
+
+	return key, secret, nil
+}
+
+// Revoke permanently disables the key
+func (m *APIKeyManager) Revoke(ctx context.Context, id string) error {
+	revoked, err := m.store.Revoke(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeInternal, "failed to revoke API key")
+	}
+	if !revoked {
+		return errors.NotFound("api_key", id)
+	}
+
+	logger.InfoWithFields("API key revoked", map[string]interface{}{
+		"api_key_id": id,
+	})
+
+	return nil
+}
+
+// Authenticate returns the active key matching the given key and counts its use
+func (m *APIKeyManager) Authenticate(ctx context.Context, secret string) (*APIKey, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, errors.Unauthorized("invalid API key")
+	}
+
+	keyHash := hashAPIKey(secret)
+	key, err := m.store.GetByHash(ctx, keyHash)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeInternal, "failed to look up API key")
+	}
+	if key == nil {
+		return nil, errors.Unauthorized("invalid API key")
+	}
+
+	now := m.now()
+	if !key.Active(now) {
+		return nil, errors.Unauthorized("API key has been revoked or has expired")
+	}
+
+	m.recordUsage(key.ID, now)
+	return key, nil
+}
+
+// Stop stops the flush routine and flushes the remaining usage
+func (m *APIKeyManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+		<-m.done
+	})
+}
+
+func (m *APIKeyManager) recordUsage(id string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	usage, ok := m.usage[id]
+	if !ok {
+		usage = &apiKeyUsage{}
+		m.usage[id] = usage
+	}
+	usage.count++
+	usage.lastUsedAt = at
+}
+
+func (m *APIKeyManager) flushRoutine(interval time.Duration) {
+	defer close(m.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.flush(context.Background())
+		case <-m.stop:
+			m.flush(context.Background())
+			return
+		}
+	}
+}
+
+// flush adds the counted usage to the store. Failed counts are kept for the next flush.
+func (m *APIKeyManager) flush(ctx context.Context) {
+	m.mu.Lock()
+	pending := m.usage
+	m.usage = make(map[string]*apiKeyUsage)
+	m.mu.Unlock()
+
+	for id, usage := range pending {
+		if err := m.store.AddUsage(ctx, id, usage.count, usage.lastUsedAt); err != nil {
+			logger.ErrorWithFields("Failed to record API key usage", err, map[string]interface{}{
+				"api_key_id": id,
+				"count":      usage.count,
+			})
+
+			m.mu.Lock()
+			if current, ok := m.usage[id]; ok {
+				current.count += usage.count
+			} else {
+				m.usage[id] = usage
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// generateAPIKey returns a new key of the form bocchi_<8 hex>_<secret> and its display prefix
+func generateAPIKey() (string, string, error) {
+	buf := make([]byte, 4+apiKeySecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(buf[:4]) + "_" + base64.RawURLEncoding.EncodeToString(buf[4:])
+	return key, apiKeyDisplayPrefix(key), nil
+}
+
+// apiKeyDisplayPrefix returns the non-secret part of a key, e.g. "bocchi_1a2b3c4d"
+func apiKeyDisplayPrefix(key string) string {
+	end := len(apiKeyPrefix) + 8
+	if len(key) <= end || key[end] != '_' {
+		return ""
+	}
+	return key[:end]
+}
+
+// hashAPIKey returns the hex SHA-256 of a key. Keys carry 256 bits of entropy, so a fast
+// unsalted hash is enough to make the stored hashes useless to an attacker.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetAPIKeyIDFromContext returns the ID of the API key that authenticated the request
+func GetAPIKeyIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value("api_key_id").(string)
+	return id, ok && id != ""
+}
@@ -0,0 +1,306 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"bocchi/api/pkg/config"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/ratelimit"
+)
+
+// memoryAPIKeyStore is an in-memory APIKeyStore
+type memoryAPIKeyStore struct {
+	mu     sync.Mutex
+	keys   map[string]*APIKey
+	hashes map[string]string // hash -> ID
+}
+
+func newMemoryAPIKeyStore() *memoryAPIKeyStore {
+	return &memoryAPIKeyStore{keys: make(map[string]*APIKey), hashes: make(map[string]string)}
+}
+
+func (s *memoryAPIKeyStore) Create(ctx context.Context, key APIKey, keyHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key.ID] = &key
+	s.hashes[keyHash] = key.ID
+	return nil
+}
+
+func (s *memoryAPIKeyStore) GetByHash(ctx context.Context, keyHash string) (*APIKey, error) {
+	s.mu.Lock()
+	id, ok := s.hashes[keyHash]
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	return s.Get(ctx, id)
+}
+
+func (s *memoryAPIKeyStore) Get(ctx context.Context, id string) (*APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (s *memoryAPIKeyStore) List(ctx context.Context) ([]APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]APIKey, 0, len(s.keys))
+	for _, key := range s.keys {
+		keys = append(keys, *key)
+	}
+	return keys, nil
+}
+
+func (s *memoryAPIKeyStore) Rotate(ctx context.Context, id, prefix, keyHash string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok || !key.RevokedAt.IsZero() {
+		return false, nil
+	}
+	for hash, keyID := range s.hashes {
+		if keyID == id {
+			delete(s.hashes, hash)
+		}
+	}
+	key.Prefix = prefix
+	s.hashes[keyHash] = id
+	return true, nil
+}
+
+func (s *memoryAPIKeyStore) Revoke(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key, ok := s.keys[id]
+	if !ok || !key.RevokedAt.IsZero() {
+		return false, nil
+	}
+	key.RevokedAt = time.Now()
+	return true, nil
+}
+
+func (s *memoryAPIKeyStore) AddUsage(ctx context.Context, id string, count int64, lastUsedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if key, ok := s.keys[id]; ok {
+		key.UsageCount += count
+		key.LastUsedAt = lastUsedAt
+	}
+	return nil
+}
+
+// adminContext returns a context for a user holding the given permissions
+func adminContext(t *testing.T, permissions ...string) context.Context {
+	return newTestLocalValidator(t).GetUserContext(context.Background(), &Claims{
+		Subject:     "local|admin",
+		Permissions: permissions,
+	})
+}
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	store := newMemoryAPIKeyStore()
+	manager := NewAPIKeyManager(store, time.Hour)
+	defer manager.Stop()
+	ctx := adminContext(t, PermissionAdminAPIKeys, PermissionAdminSpots)
+
+	key, secret, err := manager.Create(ctx, APIKeyParams{Name: "importer", Permissions: []string{PermissionAdminSpots}, CreatedBy: "local|admin"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if !strings.HasPrefix(secret, key.Prefix+"_") || !strings.HasPrefix(key.Prefix, "bocchi_") {
+		t.Errorf("Expected key %q to start with its prefix %q", secret, key.Prefix)
+	}
+	if _, ok := store.hashes[hashAPIKey(secret)]; !ok || len(store.hashes) != 1 {
+		t.Error("Expected only the hash of the key to be stored")
+	}
+
+	authenticated, err := manager.Authenticate(ctx, secret)
+	if err != nil || authenticated.ID != key.ID {
+		t.Fatalf("Expected the key to authenticate, got %v, %v", authenticated, err)
+	}
+	if _, err := manager.Authenticate(ctx, secret+"x"); !errors.Is(err, errors.ErrTypeUnauthorized) {
+		t.Errorf("Expected a wrong key to be unauthorized, got %v", err)
+	}
+
+	_, rotated, err := manager.Rotate(ctx, key.ID)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if _, err := manager.Authenticate(ctx, secret); err == nil {
+		t.Error("Expected the previous key to stop working after rotation")
+	}
+	if _, err := manager.Authenticate(ctx, rotated); err != nil {
+		t.Errorf("Expected the rotated key to authenticate, got %v", err)
+	}
+
+	if err := manager.Revoke(ctx, key.ID); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if _, err := manager.Authenticate(ctx, rotated); err == nil {
+		t.Error("Expected a revoked key to be rejected")
+	}
+	if _, _, err := manager.Rotate(ctx, key.ID); !errors.Is(err, errors.ErrTypeNotFound) {
+		t.Errorf("Expected rotating a revoked key to be not found, got %v", err)
+	}
+	if err := manager.Revoke(ctx, "missing"); !errors.Is(err, errors.ErrTypeNotFound) {
+		t.Errorf("Expected revoking an unknown key to be not found, got %v", err)
+	}
+}
+
+func TestAPIKeyCreateValidatesParams(t *testing.T) {
+	manager := NewAPIKeyManager(newMemoryAPIKeyStore(), time.Hour)
+	defer manager.Stop()
+	ctx := adminContext(t, PermissionAdminAPIKeys)
+
+	if _, _, err := manager.Create(ctx, APIKeyParams{Name: "escalate", Permissions: []string{PermissionAdminUsers}}); !errors.Is(err, errors.ErrTypeForbidden) {
+		t.Errorf("Expected granting a permission the creator lacks to be forbidden, got %v", err)
+	}
+	if _, _, err := manager.Create(ctx, APIKeyParams{Name: " "}); !errors.Is(err, errors.ErrTypeInvalidInput) {
+		t.Errorf("Expected a blank name to be rejected, got %v", err)
+	}
+	if _, _, err := manager.Create(ctx, APIKeyParams{Name: "stale", ExpiresAt: time.Now().Add(-time.Minute)}); !errors.Is(err, errors.ErrTypeInvalidInput) {
+		t.Errorf("Expected a past expiry to be rejected, got %v", err)
+	}
+
+	key, secret, err := manager.Create(ctx, APIKeyParams{Name: "short-lived", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	manager.now = func() time.Time { return key.ExpiresAt }
+	if _, err := manager.Authenticate(ctx, secret); err == nil {
+		t.Error("Expected an expired key to be rejected")
+	}
+}
+
+func TestAPIKeyUsageIsFlushed(t *testing.T) {
+	store := newMemoryAPIKeyStore()
+	manager := NewAPIKeyManager(store, time.Hour)
+	ctx := adminContext(t, PermissionAdminAPIKeys)
+
+	key, secret, _ := manager.Create(ctx, APIKeyParams{Name: "crawler"})
+	for i := 0; i < 3; i++ {
+		if _, err := manager.Authenticate(ctx, secret); err != nil {
+			t.Fatalf("Authenticate failed: %v", err)
+		}
+	}
+
+	keys, _ := manager.List(ctx)
+	if len(keys) != 1 || keys[0].UsageCount != 3 || keys[0].LastUsedAt.IsZero() {
+		t.Errorf("Expected List to include unflushed usage, got %+v", keys)
+	}
+	if stored, _ := store.Get(ctx, key.ID); stored.UsageCount != 0 {
+		t.Errorf("Expected usage to be batched in memory, got %d in the store", stored.UsageCount)
+	}
+
+	manager.Stop()
+	if stored, _ := store.Get(ctx, key.ID); stored.UsageCount != 3 {
+		t.Errorf("Expected Stop to flush usage, got %d in the store", stored.UsageCount)
+	}
+}
+
+func TestHumaMiddlewareAcceptsAPIKeys(t *testing.T) {
+	store := newMemoryAPIKeyStore()
+	middleware, err := NewAuthMiddlewareWithConfig(AuthConfig{
+		Mode:        config.AuthModeLocal,
+		JWTSecret:   testLocalSecret,
+		APIKeyStore: store,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+	defer middleware.Stop()
+
+	key, secret, err := middleware.GetAPIKeyManager().Create(adminContext(t, PermissionAdminSpots), APIKeyParams{
+		Name:        "importer",
+		Permissions: []string{PermissionAdminSpots},
+	})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	_, api := humatest.New(t)
+	api.UseMiddleware(middleware.HumaMiddleware())
+	var keyID string
+	huma.Register(api, middleware.RequirePermissions(api, huma.Operation{
+		OperationID: "admin-spots",
+		Method:      http.MethodGet,
+		Path:        "/admin/spots",
+	}, PermissionAdminSpots), func(ctx context.Context, input *struct{}) (*struct{}, error) {
+		keyID, _ = GetAPIKeyIDFromContext(ctx)
+		return nil, nil
+	})
+
+	if resp := api.Get("/admin/spots", APIKeyHeader+": "+secret); resp.Code != http.StatusNoContent {
+		t.Fatalf("Expected the API key to be accepted, got %d", resp.Code)
+	}
+	if keyID != key.ID {
+		t.Errorf("Expected the key ID %s in context, got %q", key.ID, keyID)
+	}
+	if resp := api.Get("/admin/spots", APIKeyHeader+": bocchi_00000000_invalid"); resp.Code != http.StatusUnauthorized {
+		t.Errorf("Expected an unknown API key to be unauthorized, got %d", resp.Code)
+	}
+
+	// A bearer token takes precedence over an API key
+	token, _ := newTestLocalIssuer(t).Mint(TokenOptions{Subject: "local|user-1"})
+	if resp := api.Get("/admin/spots", "Authorization: Bearer "+token, APIKeyHeader+": "+secret); resp.Code != http.StatusForbidden {
+		t.Errorf("Expected the user's own permissions to apply, got %d", resp.Code)
+	}
+}
+
+func TestRateLimitIdentityUsesOnlyVerifiedAPIKeys(t *testing.T) {
+	middleware, err := NewAuthMiddlewareWithConfig(AuthConfig{
+		Mode:        config.AuthModeLocal,
+		JWTSecret:   testLocalSecret,
+		APIKeyStore: newMemoryAPIKeyStore(),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+	defer middleware.Stop()
+
+	key, secret, err := middleware.GetAPIKeyManager().Create(adminContext(t, PermissionAdminAPIKeys), APIKeyParams{Name: "crawler"})
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	_, api := humatest.New(t)
+	api.UseMiddleware(middleware.HumaMiddleware())
+	var identity ratelimit.Identity
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		identity = RateLimitIdentity(ctx)
+		next(ctx)
+	})
+	huma.Register(api, huma.Operation{
+		OperationID: "list-spots",
+		Method:      http.MethodGet,
+		Path:        "/spots",
+	}, func(ctx context.Context, input *struct{}) (*struct{}, error) {
+		return nil, nil
+	})
+
+	api.Get("/spots", APIKeyHeader+": "+secret)
+	if identity.APIKey != key.ID {
+		t.Errorf("Expected the verified key ID %s as identity, got %q", key.ID, identity.APIKey)
+	}
+
+	// A forged key continues anonymously and must not get a quota of its own
+	api.Get("/spots", APIKeyHeader+": bocchi_00000000_forged")
+	if identity.APIKey != "" || identity.IP == "" {
+		t.Errorf("Expected an unverified key to be limited by IP, got %+v", identity)
+	}
+}
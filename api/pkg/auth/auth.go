@@ -5,8 +5,12 @@
 // compatible middleware functions.
 //
 // Features:
-// - Auth0 JWT token validation
-// - Rate limiting for authentication endpoints
+// - Auth0 JWT token validation with cached, rotation-aware JWKS keys
+// - Locally signed HS256 tokens for offline development (AUTH_MODE=local)
+// - Policy-based rate limiting for every route (package ratelimit)
+// - Hashed, permission-scoped API keys for machine clients (X-API-Key)
+// - Just-in-time provisioning of the user record of a first-seen sign-in identity
+// - An optional verified email requirement for writes, with per-user admin overrides
 // - Request context user information
 // - Permission-based authorization
 // - Integration with New Relic monitoring
@@ -49,18 +53,21 @@ import (
 	"bocchi/api/pkg/config"
 	"bocchi/api/pkg/errors"
 	"bocchi/api/pkg/logger"
+	"bocchi/api/pkg/ratelimit"
 )
 
 // Service provides a high-level interface for authentication operations
 type Service struct {
 	middleware   *AuthMiddleware
 	rateLimiter  *RateLimiter
+	limiter      *ratelimit.Limiter
 	validator    *JWTValidator
 	queries      *database.Queries
 }
 
 // ServiceConfig holds configuration for the authentication service
 type ServiceConfig struct {
+	Mode            string // config.AuthModeAuth0 (default) or config.AuthModeLocal
 	Auth0Domain     string
 	Auth0Audience   string
 	JWTSecret       string
@@ -68,54 +75,83 @@ type ServiceConfig struct {
 	RateLimit       int
 	RateLimitWindow time.Duration
 	SkipPaths       []string
+
+	// RateLimits are the route policies; RateLimitStore holds their counters and
+	// defaults to an in-memory store, which limits each replica separately
+	RateLimits     ratelimit.Config
+	RateLimitStore ratelimit.Store
+
+	// UserProvisioner creates the user of a sign-in identity on its first request;
+	// nil disables just-in-time provisioning
+	UserProvisioner UserProvisioner
+
+	// RequireVerifiedEmail rejects writes by users who have not verified their email
+	RequireVerifiedEmail bool
 }
 
 // NewService creates a new authentication service with all components
 func NewService(config ServiceConfig, queries *database.Queries) (*Service, error) {
-	// Create JWT validator
-	validator, err := NewJWTValidator(config.Auth0Domain, config.Auth0Audience)
+	// Create rate limiters
+	rateLimiter := NewRateLimiter(config.RateLimit, config.RateLimitWindow)
+
+	rateLimitStore := config.RateLimitStore
+	if rateLimitStore == nil {
+		rateLimitStore = ratelimit.NewMemoryStore()
+	}
+	limiter, err := ratelimit.NewLimiter(rateLimitStore, config.RateLimits)
 	if err != nil {
-		return nil, errors.Wrap(err, errors.ErrTypeInternal, "failed to create JWT validator")
+		rateLimiter.Stop()
+		return nil, errors.Wrap(err, errors.ErrTypeInternal, "failed to create rate limit policies")
 	}
 
-	// Create rate limiter
-	rateLimiter := NewRateLimiter(config.RateLimit, config.RateLimitWindow)
-
 	// Create auth middleware
 	authConfig := AuthConfig{
+		Mode:          config.Mode,
 		Auth0Domain:   config.Auth0Domain,
 		Auth0Audience: config.Auth0Audience,
 		JWTSecret:     config.JWTSecret,
 		Development:   config.Development,
 		SkipPaths:     config.SkipPaths,
+
+		UserProvisioner:      config.UserProvisioner,
+		RequireVerifiedEmail: config.RequireVerifiedEmail,
 	}
 
 	middleware, err := NewAuthMiddlewareWithConfig(authConfig, queries)
 	if err != nil {
+		rateLimiter.Stop()
 		return nil, errors.Wrap(err, errors.ErrTypeInternal, "failed to create auth middleware")
 	}
 
+	// Share the middleware's validator so OIDC discovery runs only once
 	service := &Service{
 		middleware:  middleware,
 		rateLimiter: rateLimiter,
-		validator:   validator,
+		limiter:     limiter,
+		validator:   middleware.GetValidator(),
 		queries:     queries,
 	}
 
 	logger.InfoWithFields("Authentication service initialized", map[string]interface{}{
+		"mode":              config.Mode,
 		"auth0_domain":      config.Auth0Domain,
 		"auth0_audience":    config.Auth0Audience,
 		"development":       config.Development,
 		"rate_limit":        config.RateLimit,
 		"rate_limit_window": config.RateLimitWindow.String(),
+		"rate_policies":     len(config.RateLimits.Policies),
+		"user_provisioning": config.UserProvisioner != nil,
+		"verified_email":    config.RequireVerifiedEmail,
 	})
 
 	return service, nil
 }
 
-// NewServiceFromConfig creates a new authentication service from application config
-func NewServiceFromConfig(cfg *config.Config, queries *database.Queries) (*Service, error) {
+// NewServiceFromConfig creates a new authentication service from application config.
+// provisioner is used for just-in-time user provisioning when the environment enables it.
+func NewServiceFromConfig(cfg *config.Config, queries *database.Queries, provisioner UserProvisioner) (*Service, error) {
 	serviceConfig := ServiceConfig{
+		Mode:            cfg.Auth.Mode,
 		Auth0Domain:     cfg.Auth.Auth0Domain,
 		Auth0Audience:   cfg.Auth.Auth0Audience,
 		JWTSecret:       cfg.Auth.JWTSecret,
@@ -128,6 +164,11 @@ func NewServiceFromConfig(cfg *config.Config, queries *database.Queries) (*Servi
 			"/swagger",
 			"/docs",
 		},
+		RateLimits:           ratelimit.DefaultConfig(),
+		RequireVerifiedEmail: cfg.Auth.RequireVerifiedEmail,
+	}
+	if cfg.Auth.ProvisionUsers {
+		serviceConfig.UserProvisioner = provisioner
 	}
 
 	return NewService(serviceConfig, queries)
@@ -143,6 +184,11 @@ func (s *Service) GetRateLimiter() *RateLimiter {
 	return s.rateLimiter
 }
 
+// GetLimiter returns the policy-based limiter applied to every route
+func (s *Service) GetLimiter() *ratelimit.Limiter {
+	return s.limiter
+}
+
 // GetValidator returns the JWT validator
 func (s *Service) GetValidator() *JWTValidator {
 	return s.validator
@@ -198,6 +244,9 @@ func (s *Service) Stop() {
 	if s.rateLimiter != nil {
 		s.rateLimiter.Stop()
 	}
+	if s.middleware != nil {
+		s.middleware.Stop()
+	}
 	
 	logger.Info("Authentication service stopped")
 }
@@ -209,6 +258,10 @@ func (s *Service) GetStats() map[string]interface{} {
 	if s.rateLimiter != nil {
 		stats["rate_limiter"] = s.rateLimiter.GetStats()
 	}
+
+	if blacklistStats := s.middleware.GetBlacklistStats(); blacklistStats != nil {
+		stats["token_blacklist_cache"] = blacklistStats
+	}
 	
 	return stats
 }
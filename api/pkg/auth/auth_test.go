@@ -22,7 +22,7 @@ func TestNewServiceFromConfig(t *testing.T) {
 
 	// This test will fail because we don't have a real database connection
 	// but it verifies the basic structure is correct
-	_, err := NewServiceFromConfig(cfg, nil)
+	_, err := NewServiceFromConfig(cfg, nil, nil)
 	
 	// We expect an error because Auth0 domain won't be reachable in tests
 	if err == nil {
@@ -0,0 +1,302 @@
+package auth
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	stdErrors "errors"
+	"sync"
+	"time"
+
+	"bocchi/api/infrastructure/database"
+)
+
+const (
+	// DefaultBlacklistCacheSize bounds the number of JWT IDs kept in the blacklist cache
+	DefaultBlacklistCacheSize = 10000
+	// DefaultBlacklistNegativeTTL caps how long a "not revoked" answer is trusted, so a
+	// logout handled by another instance is seen within this window
+	DefaultBlacklistNegativeTTL = 30 * time.Second
+)
+
+// BlacklistStore records revoked access tokens by JWT ID (jti) and per-user revocation
+// cutoffs, which revoke every token of a user issued at or before the cutoff
+type BlacklistStore interface {
+	// IsBlacklisted reports whether the token has been revoked.
+	// expiresAt is the token's expiry, which stores may use to bound caching.
+	IsBlacklisted(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+	// Blacklist revokes the token until it expires
+	Blacklist(ctx context.Context, jti string, expiresAt time.Time) error
+	// RevokedBefore returns the user's revocation cutoff, or the zero time without one
+	RevokedBefore(ctx context.Context, userID string) (time.Time, error)
+	// RevokeBefore revokes every token of the user issued at or before cutoff.
+	// A cutoff earlier than the stored one is ignored.
+	RevokeBefore(ctx context.Context, userID string, cutoff time.Time) error
+}
+
+// MySQLBlacklistStore keeps revoked tokens in the token_blacklist table
+type MySQLBlacklistStore struct {
+	queries *database.Queries
+}
+
+// NewMySQLBlacklistStore creates a blacklist store backed by the database
+func NewMySQLBlacklistStore(queries *database.Queries) *MySQLBlacklistStore {
+	return &MySQLBlacklistStore{queries: queries}
+}
+
+// IsBlacklisted checks the token_blacklist table
+func (s *MySQLBlacklistStore) IsBlacklisted(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	return s.queries.IsTokenBlacklisted(ctx, jti)
+}
+
+// Blacklist inserts the token into the token_blacklist table
+func (s *MySQLBlacklistStore) Blacklist(ctx context.Context, jti string, expiresAt time.Time) error {
+	return s.queries.BlacklistAccessToken(ctx, database.BlacklistAccessTokenParams{
+		Jti:       jti,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// RevokedBefore reads the user_token_revocations table
+func (s *MySQLBlacklistStore) RevokedBefore(ctx context.Context, userID string) (time.Time, error) {
+	cutoff, err := s.queries.GetUserTokensRevokedBefore(ctx, userID)
+	if stdErrors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	return cutoff, err
+}
+
+// RevokeBefore upserts the user's cutoff in the user_token_revocations table
+func (s *MySQLBlacklistStore) RevokeBefore(ctx context.Context, userID string, cutoff time.Time) error {
+	return s.queries.RevokeUserTokensBefore(ctx, database.RevokeUserTokensBeforeParams{
+		UserID:        userID,
+		RevokedBefore: cutoff,
+	})
+}
+
+// CachedBlacklistStore fronts a BlacklistStore with a bounded LRU cache keyed by jti and user.
+//
+// Revoked tokens are cached until they expire. "Not revoked" answers are cached too, until
+// the token expires or the negative TTL passes, whichever comes first. User cutoffs can be
+// moved by another instance at any time, so they are cached for the negative TTL only.
+// Blacklist and RevokeBefore update the cached entry immediately, so a logout takes effect
+// on this instance at once. An answer read from the store while a revocation was being
+// recorded is not cached, so it cannot replace the revocation.
+type CachedBlacklistStore struct {
+	store       BlacklistStore
+	capacity    int
+	negativeTTL time.Duration
+
+	mu         sync.Mutex
+	entries    map[blacklistKey]*list.Element
+	order      *list.List // front is most recently used
+	generation uint64     // incremented by every Blacklist and RevokeBefore call
+	hits       uint64
+	misses     uint64
+	evictions  uint64
+}
+
+// blacklistKey identifies a cached token (jti) or user cutoff (userID); exactly one is set
+type blacklistKey struct {
+	jti    string
+	userID string
+}
+
+type blacklistEntry struct {
+	key           blacklistKey
+	blacklisted   bool
+	revokedBefore time.Time
+	expiresAt     time.Time
+}
+
+// NewCachedBlacklistStore wraps store with an LRU cache. Zero values use the defaults.
+func NewCachedBlacklistStore(store BlacklistStore, capacity int, negativeTTL time.Duration) *CachedBlacklistStore {
+	if capacity <= 0 {
+		capacity = DefaultBlacklistCacheSize
+	}
+	if negativeTTL <= 0 {
+		negativeTTL = DefaultBlacklistNegativeTTL
+	}
+
+	return &CachedBlacklistStore{
+		store:       store,
+		capacity:    capacity,
+		negativeTTL: negativeTTL,
+		entries:     make(map[blacklistKey]*list.Element),
+		order:       list.New(),
+	}
+}
+
+// IsBlacklisted answers from the cache when possible and caches the store's answer otherwise
+func (c *CachedBlacklistStore) IsBlacklisted(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	key := blacklistKey{jti: jti}
+	if entry, ok := c.get(key); ok {
+		return entry.blacklisted, nil
+	}
+
+	generation := c.currentGeneration()
+	blacklisted, err := c.store.IsBlacklisted(ctx, jti, expiresAt)
+	if err != nil {
+		return false, err
+	}
+
+	c.setIfUnchanged(blacklistEntry{key: key, blacklisted: blacklisted, expiresAt: c.entryExpiry(blacklisted, expiresAt)}, generation)
+	return blacklisted, nil
+}
+
+// Blacklist revokes the token in the store and replaces any cached "not revoked" answer
+func (c *CachedBlacklistStore) Blacklist(ctx context.Context, jti string, expiresAt time.Time) error {
+	key := blacklistKey{jti: jti}
+	c.bumpGeneration()
+	if err := c.store.Blacklist(ctx, jti, expiresAt); err != nil {
+		c.remove(key)
+		return err
+	}
+
+	c.set(blacklistEntry{key: key, blacklisted: true, expiresAt: c.entryExpiry(true, expiresAt)})
+	return nil
+}
+
+// RevokedBefore answers from the cache when possible and caches the store's cutoff otherwise
+func (c *CachedBlacklistStore) RevokedBefore(ctx context.Context, userID string) (time.Time, error) {
+	key := blacklistKey{userID: userID}
+	if entry, ok := c.get(key); ok {
+		return entry.revokedBefore, nil
+	}
+
+	generation := c.currentGeneration()
+	cutoff, err := c.store.RevokedBefore(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	c.setIfUnchanged(blacklistEntry{key: key, revokedBefore: cutoff, expiresAt: time.Now().Add(c.negativeTTL)}, generation)
+	return cutoff, nil
+}
+
+// RevokeBefore moves the user's cutoff in the store and drops the cached one, so the next
+// check reads the cutoff the store actually kept
+func (c *CachedBlacklistStore) RevokeBefore(ctx context.Context, userID string, cutoff time.Time) error {
+	c.bumpGeneration()
+	err := c.store.RevokeBefore(ctx, userID, cutoff)
+	c.remove(blacklistKey{userID: userID})
+	return err
+}
+
+// GetStats returns cache hit/miss statistics
+func (c *CachedBlacklistStore) GetStats() map[string]interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return map[string]interface{}{
+		"size":      c.order.Len(),
+		"capacity":  c.capacity,
+		"hits":      c.hits,
+		"misses":    c.misses,
+		"evictions": c.evictions,
+	}
+}
+
+// entryExpiry derives how long an answer may be cached from the token's expiry
+func (c *CachedBlacklistStore) entryExpiry(blacklisted bool, tokenExpiresAt time.Time) time.Time {
+	limit := time.Now().Add(c.negativeTTL)
+	if blacklisted {
+		// A revoked token stays revoked; without a known expiry fall back to the negative TTL
+		if tokenExpiresAt.IsZero() {
+			return limit
+		}
+		return tokenExpiresAt
+	}
+	if !tokenExpiresAt.IsZero() && tokenExpiresAt.Before(limit) {
+		return tokenExpiresAt
+	}
+	return limit
+}
+
+func (c *CachedBlacklistStore) get(key blacklistKey) (blacklistEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return blacklistEntry{}, false
+	}
+
+	entry := element.Value.(*blacklistEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		c.misses++
+		return blacklistEntry{}, false
+	}
+
+	c.order.MoveToFront(element)
+	c.hits++
+	return *entry, true
+}
+
+func (c *CachedBlacklistStore) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func (c *CachedBlacklistStore) bumpGeneration() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+}
+
+// setIfUnchanged caches an answer read from the store unless a revocation started since
+// generation was taken, in which case the answer may predate it
+func (c *CachedBlacklistStore) setIfUnchanged(entry blacklistEntry, generation uint64) {
+	if !time.Now().Before(entry.expiresAt) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+	c.setLocked(entry)
+}
+
+func (c *CachedBlacklistStore) set(entry blacklistEntry) {
+	if !time.Now().Before(entry.expiresAt) {
+		c.remove(entry.key)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(entry)
+}
+
+func (c *CachedBlacklistStore) setLocked(entry blacklistEntry) {
+	if element, ok := c.entries[entry.key]; ok {
+		*element.Value.(*blacklistEntry) = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[entry.key] = c.order.PushFront(&entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*blacklistEntry).key)
+		c.evictions++
+	}
+}
+
+func (c *CachedBlacklistStore) remove(key blacklistKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"bocchi/api/pkg/config"
 )
 
 // Test helper functions for context manipulation
@@ -74,4 +76,253 @@ func TestTokenExpirationFromContext(t *testing.T) {
 	if !hasExp || !exp.Equal(testExpiration) {
 		t.Errorf("Expected expiration %v, got: %v (hasExp: %v)", testExpiration, exp, hasExp)
 	}
-}
\ No newline at end of file
+}
+// countingBlacklistStore is an in-memory BlacklistStore that counts lookups
+type countingBlacklistStore struct {
+	revoked map[string]bool
+	cutoffs map[string]time.Time
+	lookups int
+	// afterRead runs between reading a token's state and returning it
+	afterRead func()
+}
+
+func newCountingBlacklistStore() *countingBlacklistStore {
+	return &countingBlacklistStore{revoked: make(map[string]bool), cutoffs: make(map[string]time.Time)}
+}
+
+func (s *countingBlacklistStore) IsBlacklisted(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	s.lookups++
+	revoked := s.revoked[jti]
+	if s.afterRead != nil {
+		s.afterRead()
+	}
+	return revoked, nil
+}
+
+func (s *countingBlacklistStore) Blacklist(ctx context.Context, jti string, expiresAt time.Time) error {
+	s.revoked[jti] = true
+	return nil
+}
+
+func (s *countingBlacklistStore) RevokedBefore(ctx context.Context, userID string) (time.Time, error) {
+	s.lookups++
+	return s.cutoffs[userID], nil
+}
+
+func (s *countingBlacklistStore) RevokeBefore(ctx context.Context, userID string, cutoff time.Time) error {
+	if cutoff.After(s.cutoffs[userID]) {
+		s.cutoffs[userID] = cutoff
+	}
+	return nil
+}
+
+func TestCachedBlacklistStoreCachesNegativeResults(t *testing.T) {
+	store := newCountingBlacklistStore()
+	cache := NewCachedBlacklistStore(store, 10, time.Minute)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	for i := 0; i < 3; i++ {
+		revoked, err := cache.IsBlacklisted(ctx, "jti-1", expiresAt)
+		if err != nil || revoked {
+			t.Fatalf("Expected token not to be revoked, got %v, %v", revoked, err)
+		}
+	}
+
+	if store.lookups != 1 {
+		t.Errorf("Expected one store lookup, got %d", store.lookups)
+	}
+	stats := cache.GetStats()
+	if stats["hits"] != uint64(2) || stats["misses"] != uint64(1) {
+		t.Errorf("Expected 2 hits and 1 miss, got %v", stats)
+	}
+}
+
+func TestCachedBlacklistStoreLogoutInvalidatesEntry(t *testing.T) {
+	store := newCountingBlacklistStore()
+	cache := NewCachedBlacklistStore(store, 10, time.Minute)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	if revoked, _ := cache.IsBlacklisted(ctx, "jti-1", expiresAt); revoked {
+		t.Fatal("Expected token not to be revoked before logout")
+	}
+	if err := cache.Blacklist(ctx, "jti-1", expiresAt); err != nil {
+		t.Fatalf("Blacklist failed: %v", err)
+	}
+
+	if revoked, _ := cache.IsBlacklisted(ctx, "jti-1", expiresAt); !revoked {
+		t.Error("Expected cached answer to reflect the logout immediately")
+	}
+	if store.lookups != 1 {
+		t.Errorf("Expected the revoked answer to come from the cache, got %d store lookups", store.lookups)
+	}
+}
+
+func TestCachedBlacklistStoreKeepsLogoutDuringLookup(t *testing.T) {
+	store := newCountingBlacklistStore()
+	cache := NewCachedBlacklistStore(store, 10, time.Minute)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	// The logout lands after the store was read but before the answer is cached
+	store.afterRead = func() {
+		store.afterRead = nil
+		if err := cache.Blacklist(ctx, "jti-1", expiresAt); err != nil {
+			t.Fatalf("Blacklist failed: %v", err)
+		}
+	}
+	if revoked, err := cache.IsBlacklisted(ctx, "jti-1", expiresAt); err != nil || revoked {
+		t.Fatalf("Expected the racing lookup to report the state it read, got %v, %v", revoked, err)
+	}
+
+	if revoked, err := cache.IsBlacklisted(ctx, "jti-1", expiresAt); err != nil || !revoked {
+		t.Errorf("Expected the logout to survive the racing lookup, got %v, %v", revoked, err)
+	}
+}
+
+func TestCachedBlacklistStoreExpiresNegativeResults(t *testing.T) {
+	store := newCountingBlacklistStore()
+	cache := NewCachedBlacklistStore(store, 10, time.Minute)
+	ctx := context.Background()
+
+	// The token expires before the negative TTL, so its expiry bounds the cache entry
+	expiresAt := time.Now().Add(20 * time.Millisecond)
+	cache.IsBlacklisted(ctx, "jti-1", expiresAt)
+	time.Sleep(30 * time.Millisecond)
+	cache.IsBlacklisted(ctx, "jti-1", expiresAt)
+
+	if store.lookups != 2 {
+		t.Errorf("Expected the entry to expire with the token, got %d store lookups", store.lookups)
+	}
+}
+
+func TestCachedBlacklistStoreEvictsLeastRecentlyUsed(t *testing.T) {
+	store := newCountingBlacklistStore()
+	cache := NewCachedBlacklistStore(store, 2, time.Minute)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(time.Hour)
+
+	cache.IsBlacklisted(ctx, "jti-1", expiresAt)
+	cache.IsBlacklisted(ctx, "jti-2", expiresAt)
+	cache.IsBlacklisted(ctx, "jti-1", expiresAt) // jti-2 is now least recently used
+	cache.IsBlacklisted(ctx, "jti-3", expiresAt)
+
+	lookups := store.lookups
+	cache.IsBlacklisted(ctx, "jti-1", expiresAt)
+	if store.lookups != lookups {
+		t.Error("Expected recently used jti-1 to stay cached")
+	}
+	cache.IsBlacklisted(ctx, "jti-2", expiresAt)
+	if store.lookups != lookups+1 {
+		t.Error("Expected jti-2 to have been evicted")
+	}
+
+	stats := cache.GetStats()
+	if stats["size"] != 2 || stats["evictions"] != uint64(2) {
+		t.Errorf("Expected size 2 with 2 evictions, got %v", stats)
+	}
+}
+
+func TestAuthMiddlewareLogoutRevokesToken(t *testing.T) {
+	middleware, err := NewAuthMiddlewareWithConfig(AuthConfig{
+		Mode:           config.AuthModeLocal,
+		JWTSecret:      testLocalSecret,
+		BlacklistStore: NewCachedBlacklistStore(newCountingBlacklistStore(), 0, 0),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+
+	token, _ := newTestLocalIssuer(t).Mint(TokenOptions{Subject: "local|user-1", ID: "jti-logout"})
+	ctx := context.Background()
+
+	claims, err := middleware.ValidateToken(ctx, token)
+	if err != nil {
+		t.Fatalf("Expected token to validate before logout, got %v", err)
+	}
+	if err := middleware.Logout(ctx, claims.ID, claims.ExpiresAt.Time); err != nil {
+		t.Fatalf("Logout failed: %v", err)
+	}
+
+	if _, err := middleware.ValidateToken(ctx, token); err == nil {
+		t.Error("Expected token to be rejected after logout")
+	}
+	if stats := middleware.GetBlacklistStats(); stats == nil || stats["hits"] != uint64(1) {
+		t.Errorf("Expected the post-logout check to hit the cache, got %v", stats)
+	}
+}
+
+func TestCachedBlacklistStoreCachesUserCutoffs(t *testing.T) {
+	store := newCountingBlacklistStore()
+	cache := NewCachedBlacklistStore(store, 10, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if cutoff, err := cache.RevokedBefore(ctx, "user-1"); err != nil || !cutoff.IsZero() {
+			t.Fatalf("Expected no cutoff, got %v, %v", cutoff, err)
+		}
+	}
+	if store.lookups != 1 {
+		t.Errorf("Expected one store lookup, got %d", store.lookups)
+	}
+
+	// A token ID equal to the user ID must not share the user's cache entry
+	if revoked, _ := cache.IsBlacklisted(ctx, "user-1", time.Now().Add(time.Hour)); revoked {
+		t.Error("Expected token and user entries to be cached separately")
+	}
+
+	cutoff := time.Now().Truncate(time.Second)
+	if err := cache.RevokeBefore(ctx, "user-1", cutoff); err != nil {
+		t.Fatalf("RevokeBefore failed: %v", err)
+	}
+	if got, _ := cache.RevokedBefore(ctx, "user-1"); !got.Equal(cutoff) {
+		t.Errorf("Expected cutoff %v after revocation, got %v", cutoff, got)
+	}
+
+	// The store keeps the later cutoff, and the cache must not keep the earlier one
+	cache.RevokeBefore(ctx, "user-1", cutoff.Add(-time.Hour))
+	if got, _ := cache.RevokedBefore(ctx, "user-1"); !got.Equal(cutoff) {
+		t.Errorf("Expected the cutoff never to move backwards, got %v", got)
+	}
+}
+
+func TestAuthMiddlewareLogoutAllRevokesEarlierTokens(t *testing.T) {
+	middleware, err := NewAuthMiddlewareWithConfig(AuthConfig{
+		Mode:           config.AuthModeLocal,
+		JWTSecret:      testLocalSecret,
+		BlacklistStore: NewCachedBlacklistStore(newCountingBlacklistStore(), 0, 0),
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+
+	issuer := newTestLocalIssuer(t)
+	ctx := context.Background()
+	first, _ := issuer.Mint(TokenOptions{Subject: "local|user-1"})
+	second, _ := issuer.Mint(TokenOptions{Subject: "local|user-1", IssuedAt: time.Now().Add(-time.Minute)})
+	other, _ := issuer.Mint(TokenOptions{Subject: "local|user-2"})
+
+	cutoff, err := middleware.LogoutAll(ctx, "local|user-1")
+	if err != nil {
+		t.Fatalf("LogoutAll failed: %v", err)
+	}
+
+	for _, token := range []string{first, second} {
+		if _, err := middleware.ValidateToken(ctx, token); err == nil {
+			t.Error("Expected tokens issued before the cutoff to be rejected")
+		}
+	}
+	if _, err := middleware.ValidateToken(ctx, other); err != nil {
+		t.Errorf("Expected other users' tokens to stay valid, got %v", err)
+	}
+
+	fresh, _ := issuer.Mint(TokenOptions{Subject: "local|user-1", IssuedAt: cutoff.Add(time.Second)})
+	if _, err := middleware.ValidateToken(ctx, fresh); err != nil {
+		t.Errorf("Expected a token issued after the cutoff to be accepted, got %v", err)
+	}
+
+	if _, err := middleware.LogoutAll(ctx, ""); err == nil {
+		t.Error("Expected LogoutAll without a user ID to fail")
+	}
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	stdErrors "errors"
+	"net/http"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"bocchi/api/infrastructure/database"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/logger"
+)
+
+// EmailVerification is the state of a user under the verified email policy
+type EmailVerification struct {
+	// Verified reports whether the users table records the email as verified
+	Verified bool
+	// Exempt reports whether an admin has exempted the user from the policy
+	Exempt bool
+}
+
+// EmailVerificationStore reads the verification state of users and keeps the per-user
+// overrides granted by admins
+type EmailVerificationStore interface {
+	// Get returns the user's verification state, or nil for an unknown user
+	Get(ctx context.Context, userID string) (*EmailVerification, error)
+	// SetOverride exempts the user from the policy and reports whether the user exists
+	SetOverride(ctx context.Context, userID, grantedBy, reason string) (bool, error)
+	// DeleteOverride removes the user's exemption and reports whether there was one
+	DeleteOverride(ctx context.Context, userID string) (bool, error)
+}
+
+// MySQLEmailVerificationStore reads the users table and keeps overrides in the
+// email_verification_overrides table
+type MySQLEmailVerificationStore struct {
+	queries *database.Queries
+}
+
+// NewMySQLEmailVerificationStore creates an email verification store backed by the database
+func NewMySQLEmailVerificationStore(queries *database.Queries) *MySQLEmailVerificationStore {
+	return &MySQLEmailVerificationStore{queries: queries}
+}
+
+// Get reads the user's email_verified flag and whether an override exists
+func (s *MySQLEmailVerificationStore) Get(ctx context.Context, userID string) (*EmailVerification, error) {
+	row, err := s.queries.GetUserEmailVerification(ctx, userID)
+	if stdErrors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &EmailVerification{Verified: row.EmailVerified, Exempt: row.Exempt}, nil
+}
+
+// SetOverride upserts the user's row in email_verification_overrides
+func (s *MySQLEmailVerificationStore) SetOverride(ctx context.Context, userID, grantedBy, reason string) (bool, error) {
+	if state, err := s.Get(ctx, userID); err != nil || state == nil {
+		return false, err
+	}
+	return true, s.queries.UpsertEmailVerificationOverride(ctx, database.UpsertEmailVerificationOverrideParams{
+		UserID:    userID,
+		GrantedBy: grantedBy,
+		Reason:    sql.NullString{String: reason, Valid: reason != ""},
+	})
+}
+
+// DeleteOverride deletes the user's row from email_verification_overrides
+func (s *MySQLEmailVerificationStore) DeleteOverride(ctx context.Context, userID string) (bool, error) {
+	rows, err := s.queries.DeleteEmailVerificationOverride(ctx, userID)
+	return rows > 0, err
+}
+
+// newEmailVerificationStore returns the configured store or the MySQL store, or nil when
+// neither is available
+func newEmailVerificationStore(config AuthConfig, queries *database.Queries) EmailVerificationStore {
+	if config.EmailVerificationStore != nil {
+		return config.EmailVerificationStore
+	}
+	if queries == nil {
+		return nil
+	}
+	return NewMySQLEmailVerificationStore(queries)
+}
+
+// CheckEmailVerified enforces the verified email policy on a write by userID. The email
+// counts as verified when either the token's email_verified claim or the users table says
+// so. API keys, admin-exempted users and anonymous callers pass; protected operations
+// reject the latter themselves. It returns an errors.ErrTypeEmailNotVerified error for
+// action when the policy rejects the user.
+func (m *AuthMiddleware) CheckEmailVerified(ctx context.Context, userID string, tokenEmailVerified bool, action string) error {
+	if !m.requireVerifiedEmail || userID == "" || strings.HasPrefix(userID, APIKeySubjectPrefix) {
+		return nil
+	}
+
+	verified := tokenEmailVerified
+	if m.emailVerification != nil {
+		state, err := m.emailVerification.Get(ctx, userID)
+		if err != nil {
+			logger.ErrorWithFields("Failed to load email verification state", err, map[string]interface{}{
+				"user_id": userID,
+			})
+			// Don't block writes on database errors
+			return nil
+		}
+		if state != nil {
+			if state.Exempt {
+				return nil
+			}
+			verified = verified || state.Verified
+		}
+	}
+
+	if !verified {
+		logger.InfoWithFields("Write rejected for unverified email", map[string]interface{}{
+			"user_id": userID,
+			"action":  action,
+		})
+		return errors.EmailNotVerified(action)
+	}
+	return nil
+}
+
+// RequireVerifiedEmail creates a protected Huma operation that, when the verified email
+// policy is enabled, rejects users who have not verified their email with a 403 of type
+// EMAIL_NOT_VERIFIED. action describes the operation in the error message, e.g. "create spots".
+func (m *AuthMiddleware) RequireVerifiedEmail(api huma.API, operation huma.Operation, action string) huma.Operation {
+	operation = m.CreateProtectedOperation(operation)
+	operation.Middlewares = append(operation.Middlewares, func(ctx huma.Context, next func(huma.Context)) {
+		user, _ := GetUserFromContext(ctx.Context())
+		userID, _ := user["user_id"].(string)
+		emailVerified, _ := user["email_verified"].(bool)
+
+		err := m.CheckEmailVerified(ctx.Context(), userID, emailVerified, action)
+		var domainErr *errors.DomainError
+		if stdErrors.As(err, &domainErr) {
+			huma.WriteErr(api, ctx, http.StatusForbidden, domainErr.Message, &errors.HTTPErrorDetail{
+				Type:   string(domainErr.Type),
+				Fields: domainErr.Fields,
+			})
+			return
+		}
+		next(ctx)
+	})
+	return operation
+}
+
+// SetEmailVerificationOverride exempts a user from the verified email policy
+func (m *AuthMiddleware) SetEmailVerificationOverride(ctx context.Context, userID, grantedBy, reason string) error {
+	if m.emailVerification == nil {
+		return errors.Internal("email verification store not initialized")
+	}
+
+	found, err := m.emailVerification.SetOverride(ctx, userID, grantedBy, reason)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeDatabase, "failed to set email verification override")
+	}
+	if !found {
+		return errors.NotFound("user", userID)
+	}
+
+	logger.InfoWithFields("Email verification override granted", map[string]interface{}{
+		"user_id":    userID,
+		"granted_by": grantedBy,
+	})
+	return nil
+}
+
+// DeleteEmailVerificationOverride subjects a user to the verified email policy again
+func (m *AuthMiddleware) DeleteEmailVerificationOverride(ctx context.Context, userID string) error {
+	if m.emailVerification == nil {
+		return errors.Internal("email verification store not initialized")
+	}
+
+	found, err := m.emailVerification.DeleteOverride(ctx, userID)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeDatabase, "failed to delete email verification override")
+	}
+	if !found {
+		return errors.NotFound("email verification override", userID)
+	}
+
+	logger.InfoWithFields("Email verification override removed", map[string]interface{}{
+		"user_id": userID,
+	})
+	return nil
+}
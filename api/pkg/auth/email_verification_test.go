@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"bocchi/api/pkg/config"
+	"bocchi/api/pkg/errors"
+)
+
+// memoryEmailVerificationStore is an in-memory EmailVerificationStore
+type memoryEmailVerificationStore struct {
+	mu    sync.Mutex
+	users map[string]*EmailVerification
+}
+
+func newMemoryEmailVerificationStore() *memoryEmailVerificationStore {
+	return &memoryEmailVerificationStore{users: make(map[string]*EmailVerification)}
+}
+
+func (s *memoryEmailVerificationStore) Get(ctx context.Context, userID string) (*EmailVerification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	copied := *state
+	return &copied, nil
+}
+
+func (s *memoryEmailVerificationStore) SetOverride(ctx context.Context, userID, grantedBy, reason string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.users[userID]
+	if ok {
+		state.Exempt = true
+	}
+	return ok, nil
+}
+
+func (s *memoryEmailVerificationStore) DeleteOverride(ctx context.Context, userID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.users[userID]
+	if !ok || !state.Exempt {
+		return false, nil
+	}
+	state.Exempt = false
+	return true, nil
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	store := newMemoryEmailVerificationStore()
+	store.users["local|unverified"] = &EmailVerification{}
+	store.users["local|verified"] = &EmailVerification{Verified: true}
+	middleware, err := NewAuthMiddlewareWithConfig(AuthConfig{
+		Mode:                   config.AuthModeLocal,
+		JWTSecret:              testLocalSecret,
+		RequireVerifiedEmail:   true,
+		EmailVerificationStore: store,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+
+	_, api := humatest.New(t)
+	api.UseMiddleware(middleware.HumaMiddleware())
+	huma.Register(api, middleware.RequireVerifiedEmail(api, huma.Operation{
+		OperationID: "create-spot",
+		Method:      http.MethodPost,
+		Path:        "/spots",
+	}, "create spots"), func(ctx context.Context, input *struct{}) (*struct{}, error) {
+		return nil, nil
+	})
+
+	issuer := newTestLocalIssuer(t)
+	post := func(opts TokenOptions) int {
+		token, _ := issuer.Mint(opts)
+		return api.Post("/spots", "Authorization: Bearer "+token).Code
+	}
+
+	token, _ := issuer.Mint(TokenOptions{Subject: "local|unverified"})
+	resp := api.Post("/spots", "Authorization: Bearer "+token)
+	if resp.Code != http.StatusForbidden || !strings.Contains(resp.Body.String(), string(errors.ErrTypeEmailNotVerified)) {
+		t.Errorf("Expected an unverified user to get a 403 of type EMAIL_NOT_VERIFIED, got %d: %s", resp.Code, resp.Body.String())
+	}
+	if code := post(TokenOptions{Subject: "local|verified"}); code != http.StatusNoContent {
+		t.Errorf("Expected a user verified in the store to pass, got %d", code)
+	}
+	if code := post(TokenOptions{Subject: "local|unverified", EmailVerified: true}); code != http.StatusNoContent {
+		t.Errorf("Expected a verified email claim to pass, got %d", code)
+	}
+	if code := post(TokenOptions{Subject: "local|unknown"}); code != http.StatusForbidden {
+		t.Errorf("Expected an unknown user without a verified claim to be rejected, got %d", code)
+	}
+
+	if err := middleware.SetEmailVerificationOverride(context.Background(), "local|unverified", "local|admin", ""); err != nil {
+		t.Fatalf("SetEmailVerificationOverride failed: %v", err)
+	}
+	if code := post(TokenOptions{Subject: "local|unverified"}); code != http.StatusNoContent {
+		t.Errorf("Expected an exempted user to pass, got %d", code)
+	}
+	if err := middleware.DeleteEmailVerificationOverride(context.Background(), "local|unverified"); err != nil {
+		t.Fatalf("DeleteEmailVerificationOverride failed: %v", err)
+	}
+	if code := post(TokenOptions{Subject: "local|unverified"}); code != http.StatusForbidden {
+		t.Errorf("Expected the policy to apply again once the override is removed, got %d", code)
+	}
+
+	if err := middleware.SetEmailVerificationOverride(context.Background(), "local|missing", "local|admin", ""); !errors.Is(err, errors.ErrTypeNotFound) {
+		t.Errorf("Expected overriding an unknown user to be not found, got %v", err)
+	}
+	if err := middleware.DeleteEmailVerificationOverride(context.Background(), "local|verified"); !errors.Is(err, errors.ErrTypeNotFound) {
+		t.Errorf("Expected removing a missing override to be not found, got %v", err)
+	}
+}
+
+func TestCheckEmailVerifiedSkipsWhenNotRequired(t *testing.T) {
+	store := newMemoryEmailVerificationStore()
+	store.users["local|unverified"] = &EmailVerification{}
+
+	disabled, _ := NewAuthMiddlewareWithConfig(AuthConfig{
+		Mode:                   config.AuthModeLocal,
+		JWTSecret:              testLocalSecret,
+		EmailVerificationStore: store,
+	}, nil)
+	if err := disabled.CheckEmailVerified(context.Background(), "local|unverified", false, "create spots"); err != nil {
+		t.Errorf("Expected writes to pass while the policy is disabled, got %v", err)
+	}
+
+	enabled, _ := NewAuthMiddlewareWithConfig(AuthConfig{
+		Mode:                   config.AuthModeLocal,
+		JWTSecret:              testLocalSecret,
+		RequireVerifiedEmail:   true,
+		EmailVerificationStore: store,
+	}, nil)
+	if err := enabled.CheckEmailVerified(context.Background(), APIKeySubjectPrefix+"key-1", false, "create spots"); err != nil {
+		t.Errorf("Expected API keys to be exempt, got %v", err)
+	}
+	if err := enabled.CheckEmailVerified(context.Background(), "", false, "create spots"); err != nil {
+		t.Errorf("Expected anonymous callers to be left to the operation, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"bocchi/api/domain/entities"
+	"bocchi/api/infrastructure/database"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/logger"
+)
+
+// identityConnections maps the connection part of an Auth0 subject ("google-oauth2|123")
+// to the sign-in provider stored in user_identities
+var identityConnections = map[string]entities.AuthProvider{
+	"google-oauth2": entities.AuthProviderGoogle,
+	"twitter":       entities.AuthProviderTwitter,
+	"x":             entities.AuthProviderX,
+	"auth0":         entities.AuthProviderAuth0,
+}
+
+// IdentityFromSubject splits a token subject into the sign-in provider and the user's ID
+// at that provider. ok is false for subjects of other connections, local development
+// tokens and API keys, which are not linkable identities.
+func IdentityFromSubject(subject string) (provider entities.AuthProvider, providerID string, ok bool) {
+	connection, providerID, found := strings.Cut(subject, "|")
+	if !found || providerID == "" {
+		return "", "", false
+	}
+	provider, ok = identityConnections[connection]
+	if !ok {
+		return "", "", false
+	}
+	return provider, providerID, true
+}
+
+// resolveUserID returns the ID of the user the token subject is linked to through
+// user_identities, or the subject itself when it is not a linked identity. A database
+// error is returned as unavailable: roles and revocations are keyed on the user ID, so
+// the subject cannot stand in for it.
+func (m *AuthMiddleware) resolveUserID(ctx context.Context, subject string) (string, error) {
+	provider, providerID, ok := IdentityFromSubject(subject)
+	if !ok || m.queries == nil {
+		return subject, nil
+	}
+
+	identity, err := m.queries.GetUserIdentity(ctx, database.GetUserIdentityParams{
+		Provider:   string(provider),
+		ProviderID: providerID,
+	})
+	if err == sql.ErrNoRows {
+		// Unlinked subjects act as user IDs
+		return subject, nil
+	}
+	if err != nil {
+		logger.ErrorWithFields("Failed to resolve user identity", err, map[string]interface{}{
+			"subject": subject,
+		})
+		return "", errors.Wrap(err, errors.ErrTypeUnavailable, "user identity lookup is unavailable")
+	}
+	return identity.UserID, nil
+}
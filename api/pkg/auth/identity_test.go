@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+	_ "github.com/go-sql-driver/mysql"
+
+	"bocchi/api/domain/entities"
+	"bocchi/api/infrastructure/database"
+	"bocchi/api/pkg/errors"
+)
+
+func TestIdentityFromSubject(t *testing.T) {
+	tests := []struct {
+		subject    string
+		provider   entities.AuthProvider
+		providerID string
+		ok         bool
+	}{
+		{"google-oauth2|1234567890", entities.AuthProviderGoogle, "1234567890", true},
+		{"twitter|42", entities.AuthProviderTwitter, "42", true},
+		{"x|42", entities.AuthProviderX, "42", true},
+		{"auth0|64f0c0ffee", entities.AuthProviderAuth0, "64f0c0ffee", true},
+		{"local|user-1", "", "", false},
+		{APIKeySubjectPrefix + "key-1", "", "", false},
+		{"google-oauth2|", "", "", false},
+		{"test-user-123", "", "", false},
+	}
+	for _, tt := range tests {
+		provider, providerID, ok := IdentityFromSubject(tt.subject)
+		if provider != tt.provider || providerID != tt.providerID || ok != tt.ok {
+			t.Errorf("IdentityFromSubject(%q) = %q, %q, %v; want %q, %q, %v",
+				tt.subject, provider, providerID, ok, tt.provider, tt.providerID, tt.ok)
+		}
+	}
+}
+
+func TestGetUserContextUsesLinkedUserID(t *testing.T) {
+	ctx := newTestLocalValidator(t).GetUserContext(context.Background(), &Claims{
+		Subject: "google-oauth2|1234567890",
+		UserID:  "user-1",
+	})
+
+	if userID, _ := GetUserIDFromContext(ctx); userID != "user-1" {
+		t.Errorf("Expected the linked user ID, got %q", userID)
+	}
+	if userID, _ := ctx.Value("user_id").(string); userID != "user-1" {
+		t.Errorf("Expected the linked user ID under user_id, got %q", userID)
+	}
+	if subject, _ := GetSubjectFromContext(ctx); subject != "google-oauth2|1234567890" {
+		t.Errorf("Expected the token subject to be kept, got %q", subject)
+	}
+}
+
+func TestUnresolvableIdentityIsUnavailable(t *testing.T) {
+	// A closed database fails every identity lookup
+	db, err := sql.Open("mysql", "user:password@tcp(127.0.0.1:3306)/bocchi")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.Close()
+	middleware := &AuthMiddleware{validator: newTestLocalValidator(t), queries: database.New(db)}
+
+	token, err := newTestLocalIssuer(t).Mint(TokenOptions{Subject: "google-oauth2|1234567890"})
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+
+	if _, err := middleware.ValidateToken(context.Background(), token); !errors.Is(err, errors.ErrTypeUnavailable) {
+		t.Errorf("Expected the token to be rejected as unavailable, got %v", err)
+	}
+
+	_, api := humatest.New(t)
+	api.UseMiddleware(middleware.HumaMiddleware())
+	huma.Register(api, middleware.RequirePermissions(api, huma.Operation{
+		OperationID: "get-profile",
+		Method:      http.MethodGet,
+		Path:        "/profile",
+	}), func(ctx context.Context, input *struct{}) (*struct{}, error) {
+		return nil, nil
+	})
+
+	if resp := api.Get("/profile", "Authorization: Bearer "+token); resp.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 when the user cannot be resolved, got %d: %s", resp.Code, resp.Body.String())
+	}
+}
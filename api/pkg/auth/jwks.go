@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/logger"
+)
+
+const (
+	// DefaultJWKSCacheTTL is how long fetched signing keys are trusted before a refresh
+	DefaultJWKSCacheTTL = 15 * time.Minute
+	// DefaultJWKSMinRefreshInterval limits how often tokens with unknown key IDs can trigger a fetch
+	DefaultJWKSMinRefreshInterval = 30 * time.Second
+)
+
+// JWKSCache caches the RSA signing keys published at a JWKS endpoint.
+//
+// Keys are refreshed once the TTL expires, and immediately when a token names an unknown
+// key ID, so rotated keys are picked up without a restart. Refreshes are rate limited by
+// the minimum refresh interval. When a refresh fails, the previously fetched keys keep
+// being served (stale-while-error) so a JWKS outage does not log every user out.
+type JWKSCache struct {
+	url                string
+	client             *http.Client
+	ttl                time.Duration
+	minRefreshInterval time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+
+	refreshMu   sync.Mutex
+	lastAttempt time.Time
+}
+
+// NewJWKSCache creates a cache for the given JWKS URL. Zero durations use the defaults.
+func NewJWKSCache(url string, client *http.Client, ttl, minRefreshInterval time.Duration) *JWKSCache {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if ttl <= 0 {
+		ttl = DefaultJWKSCacheTTL
+	}
+	if minRefreshInterval <= 0 {
+		minRefreshInterval = DefaultJWKSMinRefreshInterval
+	}
+
+	return &JWKSCache{
+		url:                url,
+		client:             client,
+		ttl:                ttl,
+		minRefreshInterval: minRefreshInterval,
+		keys:               make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Key returns the public key with the given key ID, refreshing the cache when needed
+func (c *JWKSCache) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	fresh := time.Since(c.fetchedAt) < c.ttl
+	c.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		if ok {
+			logger.ErrorWithFields("JWKS refresh failed, serving cached key", err, map[string]interface{}{
+				"kid": kid,
+				"url": c.url,
+			})
+			return key, nil
+		}
+		return nil, err
+	}
+
+	c.mu.RLock()
+	key, ok = c.keys[kid]
+	c.mu.RUnlock()
+
+	if !ok {
+		return nil, errors.Unauthorized("token signing key is unknown")
+	}
+	return key, nil
+}
+
+// refresh fetches the key set unless another fetch was attempted within the minimum refresh interval
+func (c *JWKSCache) refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	if time.Since(c.lastAttempt) < c.minRefreshInterval {
+		return nil
+	}
+	c.lastAttempt = time.Now()
+
+	keys, err := c.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+
+	logger.InfoWithFields("JWKS refreshed", map[string]interface{}{
+		"url":  c.url,
+		"keys": len(keys),
+	})
+	return nil
+}
+
+// jsonWebKey is the subset of an RFC 7517 JSON Web Key needed for RSA signature verification
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// fetch downloads the key set and decodes its RSA signing keys
+func (c *JWKSCache) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeInternal, "failed to build JWKS request")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeExternalAPI, "failed to fetch JWKS")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrap(fmt.Errorf("unexpected status %d", resp.StatusCode), errors.ErrTypeExternalAPI, "failed to fetch JWKS")
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeExternalAPI, "failed to decode JWKS")
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || jwk.Kid == "" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		key, err := jwk.rsaPublicKey()
+		if err != nil {
+			logger.ErrorWithFields("Skipping malformed JWKS key", err, map[string]interface{}{
+				"kid": jwk.Kid,
+			})
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New(errors.ErrTypeExternalAPI, "JWKS contains no usable RSA signing keys")
+	}
+	return keys, nil
+}
+
+// rsaPublicKey decodes the base64url modulus and exponent
+func (k jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 3 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
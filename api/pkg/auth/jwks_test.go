@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"bocchi/api/pkg/auth/oidctest"
+)
+
+const testAudience = "bocchi-the-map-api"
+
+func newTestOIDCValidator(t *testing.T, provider *oidctest.Provider, opts ValidatorOptions) *JWTValidator {
+	t.Helper()
+	opts.HTTPClient = provider.Client()
+	validator, err := NewJWTValidatorWithOptions(provider.Domain(), testAudience, opts)
+	if err != nil {
+		t.Fatalf("Failed to create validator against fake provider: %v", err)
+	}
+	return validator
+}
+
+func TestOIDCValidatorAcceptsProviderToken(t *testing.T) {
+	provider := oidctest.NewProvider(t)
+	validator := newTestOIDCValidator(t, provider, ValidatorOptions{})
+
+	claims := provider.Claims("auth0|user-1", testAudience, time.Hour)
+	claims["email"] = "user1@example.com"
+	claims["permissions"] = []string{PermissionAdminSpots}
+
+	got, err := validator.ValidateToken(provider.Sign(t, claims))
+	if err != nil {
+		t.Fatalf("Expected provider token to validate, got %v", err)
+	}
+	if got.Subject != "auth0|user-1" || got.Email != "user1@example.com" {
+		t.Errorf("Unexpected claims: %+v", got)
+	}
+	if len(got.Permissions) != 1 || got.Permissions[0] != PermissionAdminSpots {
+		t.Errorf("Expected permissions to be decoded, got %v", got.Permissions)
+	}
+}
+
+func TestOIDCValidatorRejectsInvalidTokens(t *testing.T) {
+	provider := oidctest.NewProvider(t)
+	validator := newTestOIDCValidator(t, provider, ValidatorOptions{})
+
+	wrongIssuer := provider.Claims("auth0|user-1", testAudience, time.Hour)
+	wrongIssuer["iss"] = "https://someone-else.auth0.com/"
+
+	notYetValid := provider.Claims("auth0|user-1", testAudience, time.Hour)
+	notYetValid["nbf"] = time.Now().Add(time.Hour).Unix()
+
+	withoutExpiry := provider.Claims("auth0|user-1", testAudience, time.Hour)
+	delete(withoutExpiry, "exp")
+
+	hs256, err := jwt.NewWithClaims(jwt.SigningMethodHS256, provider.Claims("auth0|user-1", testAudience, time.Hour)).
+		SignedString([]byte("guessed-secret"))
+	if err != nil {
+		t.Fatalf("Failed to build HS256 token: %v", err)
+	}
+
+	otherProvider := oidctest.NewProvider(t)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", provider.Token(t, "auth0|user-1", testAudience, -time.Minute)},
+		{"wrong audience", provider.Token(t, "auth0|user-1", "another-api", time.Hour)},
+		{"wrong issuer", provider.Sign(t, wrongIssuer)},
+		{"not yet valid", provider.Sign(t, notYetValid)},
+		{"missing expiry", provider.Sign(t, withoutExpiry)},
+		{"HS256 algorithm", hs256},
+		{"unknown signing key", otherProvider.Token(t, "auth0|user-1", testAudience, time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := validator.ValidateToken(tt.token); err == nil {
+				t.Error("Expected token to be rejected")
+			}
+		})
+	}
+}
+
+func TestJWKSCacheServesCachedKeys(t *testing.T) {
+	provider := oidctest.NewProvider(t)
+	validator := newTestOIDCValidator(t, provider, ValidatorOptions{})
+
+	for i := 0; i < 3; i++ {
+		if _, err := validator.ValidateToken(provider.Token(t, "auth0|user-1", testAudience, time.Hour)); err != nil {
+			t.Fatalf("Expected token to validate, got %v", err)
+		}
+	}
+
+	if requests := provider.JWKSRequests(); requests != 1 {
+		t.Errorf("Expected a single JWKS fetch, got %d", requests)
+	}
+}
+
+func TestJWKSCacheRefreshesOnKeyRotation(t *testing.T) {
+	provider := oidctest.NewProvider(t)
+	validator := newTestOIDCValidator(t, provider, ValidatorOptions{JWKSMinRefreshInterval: time.Nanosecond})
+
+	oldToken := provider.Token(t, "auth0|user-1", testAudience, time.Hour)
+	if _, err := validator.ValidateToken(oldToken); err != nil {
+		t.Fatalf("Expected token to validate, got %v", err)
+	}
+
+	provider.RotateKey(t)
+	if _, err := validator.ValidateToken(provider.Token(t, "auth0|user-1", testAudience, time.Hour)); err != nil {
+		t.Fatalf("Expected token signed with the rotated key to validate, got %v", err)
+	}
+	if _, err := validator.ValidateToken(oldToken); err != nil {
+		t.Errorf("Expected token signed with the previous key to keep validating, got %v", err)
+	}
+
+	if requests := provider.JWKSRequests(); requests != 2 {
+		t.Errorf("Expected one refresh for the unknown key ID, got %d fetches", requests)
+	}
+}
+
+func TestJWKSCacheRateLimitsUnknownKeyRefreshes(t *testing.T) {
+	provider := oidctest.NewProvider(t)
+	validator := newTestOIDCValidator(t, provider, ValidatorOptions{JWKSMinRefreshInterval: time.Hour})
+	otherProvider := oidctest.NewProvider(t)
+
+	for i := 0; i < 3; i++ {
+		if _, err := validator.ValidateToken(otherProvider.Token(t, "auth0|user-1", testAudience, time.Hour)); err == nil {
+			t.Fatal("Expected token from another provider to be rejected")
+		}
+	}
+
+	if requests := provider.JWKSRequests(); requests != 1 {
+		t.Errorf("Expected unknown key IDs to trigger at most one fetch per interval, got %d", requests)
+	}
+}
+
+func TestJWKSCacheServesStaleKeysWhenRefreshFails(t *testing.T) {
+	provider := oidctest.NewProvider(t)
+	validator := newTestOIDCValidator(t, provider, ValidatorOptions{
+		JWKSCacheTTL:           time.Nanosecond,
+		JWKSMinRefreshInterval: time.Nanosecond,
+	})
+
+	token := provider.Token(t, "auth0|user-1", testAudience, time.Hour)
+	if _, err := validator.ValidateToken(token); err != nil {
+		t.Fatalf("Expected token to validate, got %v", err)
+	}
+
+	provider.SetJWKSFailing(true)
+	if _, err := validator.ValidateToken(token); err != nil {
+		t.Errorf("Expected cached key to be served while JWKS is failing, got %v", err)
+	}
+	if requests := provider.JWKSRequests(); requests != 2 {
+		t.Errorf("Expected the expired cache to attempt a refresh, got %d fetches", requests)
+	}
+}
+
+func TestJWKSCacheFailsWithoutKeys(t *testing.T) {
+	provider := oidctest.NewProvider(t)
+	validator := newTestOIDCValidator(t, provider, ValidatorOptions{})
+
+	provider.SetJWKSFailing(true)
+	if _, err := validator.ValidateToken(provider.Token(t, "auth0|user-1", testAudience, time.Hour)); err == nil {
+		t.Error("Expected validation to fail when no keys were ever fetched")
+	}
+}
@@ -2,7 +2,6 @@ package auth
 
 import (
 	"context"
-	"crypto/rsa"
 	"net/http"
 	"strings"
 	"time"
@@ -14,10 +13,10 @@ import (
 	"bocchi/api/pkg/logger"
 )
 
-// Claims represents the JWT claims structure for Auth0 tokens
+// Claims represents the JWT claims structure for Auth0 tokens.
+// The expiry is read from the embedded RegisteredClaims so the JWT parser validates it.
 type Claims struct {
 	Audience  []string `json:"aud,omitempty"`
-	ExpiresAt int64    `json:"exp,omitempty"`
 	ID        string   `json:"jti,omitempty"`
 	IssuedAt  int64    `json:"iat,omitempty"`
 	Issuer    string   `json:"iss,omitempty"`
@@ -32,21 +31,49 @@ type Claims struct {
 	Picture       string   `json:"picture,omitempty"`
 	Scope         string   `json:"scope,omitempty"`
 	Permissions   []string `json:"permissions,omitempty"`
+
+	// UserID is the user the subject is linked to through user_identities; it is set by
+	// AuthMiddleware.ValidateToken and is empty when the subject is not a linked identity
+	UserID string `json:"-"`
 	
 	jwt.RegisteredClaims
 }
 
-// JWTValidator handles Auth0 JWT token validation
+// GetUserID returns the ID of the authenticated user: the linked user when the subject
+// resolved to one, otherwise the subject itself
+func (c *Claims) GetUserID() string {
+	if c.UserID != "" {
+		return c.UserID
+	}
+	return c.Subject
+}
+
+// JWTValidator handles Auth0 JWT token validation.
+// A validator created by NewLocalJWTValidator verifies HS256 tokens signed with a shared secret instead.
 type JWTValidator struct {
 	provider   *oidc.Provider
-	verifier   *oidc.IDTokenVerifier
+	keys       *JWKSCache
 	audience   string
 	domain     string
-	publicKeys map[string]*rsa.PublicKey
+	issuer     string
+	hmacSecret []byte
+}
+
+// ValidatorOptions tunes how an Auth0 validator reaches the tenant and caches its signing keys.
+// Zero values use the defaults.
+type ValidatorOptions struct {
+	HTTPClient             *http.Client
+	JWKSCacheTTL           time.Duration
+	JWKSMinRefreshInterval time.Duration
 }
 
 // NewJWTValidator creates a new JWT validator for Auth0 tokens
 func NewJWTValidator(domain, audience string) (*JWTValidator, error) {
+	return NewJWTValidatorWithOptions(domain, audience, ValidatorOptions{})
+}
+
+// NewJWTValidatorWithOptions creates a new JWT validator for Auth0 tokens with custom key fetching options
+func NewJWTValidatorWithOptions(domain, audience string, opts ValidatorOptions) (*JWTValidator, error) {
 	if domain == "" {
 		return nil, errors.InvalidInput("domain", "Auth0 domain is required")
 	}
@@ -54,16 +81,13 @@ func NewJWTValidator(domain, audience string) (*JWTValidator, error) {
 		return nil, errors.InvalidInput("audience", "Auth0 audience is required")
 	}
 
-	// Ensure domain has https:// prefix
-	issuerURL := domain
-	if !strings.HasPrefix(domain, "https://") {
-		issuerURL = "https://" + domain + "/"
-	} else if !strings.HasSuffix(domain, "/") {
-		issuerURL = domain + "/"
-	}
+	issuerURL := auth0IssuerURL(domain)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
+	if opts.HTTPClient != nil {
+		ctx = oidc.ClientContext(ctx, opts.HTTPClient)
+	}
 
 	// Initialize OIDC provider
 	provider, err := oidc.NewProvider(ctx, issuerURL)
@@ -71,29 +95,70 @@ func NewJWTValidator(domain, audience string) (*JWTValidator, error) {
 		return nil, errors.Wrap(err, errors.ErrTypeExternalAPI, "failed to initialize Auth0 OIDC provider")
 	}
 
-	// Configure the ID token verifier
-	oidcConfig := &oidc.Config{
-		ClientID: audience,
+	// Signing keys come from the JWKS endpoint advertised by discovery
+	var discovery struct {
+		JWKSURL string `json:"jwks_uri"`
+	}
+	if err := provider.Claims(&discovery); err != nil || discovery.JWKSURL == "" {
+		return nil, errors.New(errors.ErrTypeExternalAPI, "Auth0 discovery document has no jwks_uri")
 	}
-	verifier := provider.Verifier(oidcConfig)
 
 	validator := &JWTValidator{
-		provider:   provider,
-		verifier:   verifier,
-		audience:   audience,
-		domain:     domain,
-		publicKeys: make(map[string]*rsa.PublicKey),
+		provider: provider,
+		keys:     NewJWKSCache(discovery.JWKSURL, opts.HTTPClient, opts.JWKSCacheTTL, opts.JWKSMinRefreshInterval),
+		audience: audience,
+		domain:   domain,
+		issuer:   issuerURL,
 	}
 
 	logger.InfoWithFields("Auth0 JWT validator initialized successfully", map[string]interface{}{
 		"domain":   domain,
 		"audience": audience,
 		"issuer":   issuerURL,
+		"jwks_url": discovery.JWKSURL,
 	})
 
 	return validator, nil
 }
 
+// NewLocalJWTValidator creates a validator for HS256 tokens minted by LocalTokenIssuer.
+// It needs no network access and is meant for local development and tests only.
+func NewLocalJWTValidator(secret, audience string) (*JWTValidator, error) {
+	if secret == "" {
+		return nil, errors.InvalidInput("secret", "JWT secret is required for local authentication")
+	}
+	if audience == "" {
+		audience = DefaultLocalAudience
+	}
+
+	logger.InfoWithFields("Local JWT validator initialized", map[string]interface{}{
+		"audience": audience,
+		"issuer":   LocalIssuer,
+	})
+
+	return &JWTValidator{
+		audience:   audience,
+		issuer:     LocalIssuer,
+		hmacSecret: []byte(secret),
+	}, nil
+}
+
+// IsLocal reports whether the validator verifies locally signed HS256 tokens
+func (v *JWTValidator) IsLocal() bool {
+	return v.hmacSecret != nil
+}
+
+// auth0IssuerURL returns the issuer URL for an Auth0 domain, with https:// prefix and trailing slash
+func auth0IssuerURL(domain string) string {
+	if !strings.HasPrefix(domain, "https://") {
+		return "https://" + domain + "/"
+	}
+	if !strings.HasSuffix(domain, "/") {
+		return domain + "/"
+	}
+	return domain
+}
+
 // ValidateToken validates an Auth0 JWT token and returns the claims
 func (v *JWTValidator) ValidateToken(tokenString string) (*Claims, error) {
 	if tokenString == "" {
@@ -108,23 +173,17 @@ func (v *JWTValidator) ValidateToken(tokenString string) (*Claims, error) {
 		return nil, errors.Unauthorized("token is empty after processing")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
-	// Parse and verify the token
-	token, err := v.verifier.Verify(ctx, tokenString)
-	if err != nil {
-		logger.InfoWithFields("Token verification failed", map[string]interface{}{
-			"error": err.Error(),
-		})
-		return nil, errors.Wrap(err, errors.ErrTypeUnauthorized, "invalid or expired token")
+	var (
+		claims Claims
+		err    error
+	)
+	if v.IsLocal() {
+		err = v.verifyLocal(tokenString, &claims)
+	} else {
+		err = v.verifyOIDC(tokenString, &claims)
 	}
-
-	// Extract claims
-	var claims Claims
-	if err := token.Claims(&claims); err != nil {
-		logger.Error("Failed to extract claims from token", err)
-		return nil, errors.Wrap(err, errors.ErrTypeInternal, "failed to extract token claims")
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate audience
@@ -145,26 +204,14 @@ func (v *JWTValidator) ValidateToken(tokenString string) (*Claims, error) {
 	}
 
 	// Validate issuer
-	expectedIssuer := v.domain
-	if !strings.HasPrefix(v.domain, "https://") {
-		expectedIssuer = "https://" + v.domain + "/"
-	} else if !strings.HasSuffix(v.domain, "/") {
-		expectedIssuer = v.domain + "/"
-	}
-
-	if claims.Issuer != expectedIssuer {
+	if claims.Issuer != v.issuer {
 		logger.InfoWithFields("Token issuer validation failed", map[string]interface{}{
-			"expected": expectedIssuer,
+			"expected": v.issuer,
 			"actual":   claims.Issuer,
 		})
 		return nil, errors.Unauthorized("token issuer is invalid")
 	}
 
-	// Validate expiration
-	if claims.ExpiresAt > 0 && time.Unix(claims.ExpiresAt, 0).Before(time.Now()) {
-		return nil, errors.Unauthorized("token has expired")
-	}
-
 	// Validate not before
 	if claims.NotBefore > 0 && time.Unix(claims.NotBefore, 0).After(time.Now()) {
 		return nil, errors.Unauthorized("token is not yet valid")
@@ -178,16 +225,49 @@ func (v *JWTValidator) ValidateToken(tokenString string) (*Claims, error) {
 	return &claims, nil
 }
 
+// verifyOIDC verifies an RS256 signature against the cached Auth0 JWKS and decodes the claims
+func (v *JWTValidator) verifyOIDC(tokenString string, claims *Claims) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Parse and verify the token
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.Unauthorized("token has no key ID")
+		}
+		return v.keys.Key(ctx, kid)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		logger.InfoWithFields("Token verification failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return errors.Wrap(err, errors.ErrTypeUnauthorized, "invalid or expired token")
+	}
+	return nil
+}
+
+// verifyLocal verifies an HS256 signature with the shared secret and decodes the claims.
+// Any other algorithm is rejected so RS256 or unsigned tokens cannot slip through.
+func (v *JWTValidator) verifyLocal(tokenString string, claims *Claims) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return v.hmacSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		logger.InfoWithFields("Local token verification failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return errors.Wrap(err, errors.ErrTypeUnauthorized, "invalid or expired token")
+	}
+	return nil
+}
+
 // ExtractTokenFromRequest extracts the JWT token from HTTP request headers
 func (v *JWTValidator) ExtractTokenFromRequest(r *http.Request) (string, error) {
 	// Check Authorization header
 	authHeader := r.Header.Get("Authorization")
 	if authHeader != "" {
-		parts := strings.Split(authHeader, " ")
-		if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
-			return parts[1], nil
-		}
-		return "", errors.Unauthorized("authorization header format must be 'Bearer {token}'")
+		return parseBearerToken(authHeader)
 	}
 
 	// Check query parameter as fallback
@@ -199,6 +279,15 @@ func (v *JWTValidator) ExtractTokenFromRequest(r *http.Request) (string, error)
 	return "", errors.Unauthorized("no authorization token found")
 }
 
+// parseBearerToken extracts the token from an "Authorization: Bearer {token}" header value
+func parseBearerToken(authHeader string) (string, error) {
+	parts := strings.Split(authHeader, " ")
+	if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
+		return parts[1], nil
+	}
+	return "", errors.Unauthorized("authorization header format must be 'Bearer {token}'")
+}
+
 // ValidateTokenFromRequest validates a JWT token from HTTP request
 func (v *JWTValidator) ValidateTokenFromRequest(r *http.Request) (*Claims, error) {
 	token, err := v.ExtractTokenFromRequest(r)
@@ -212,7 +301,8 @@ func (v *JWTValidator) ValidateTokenFromRequest(r *http.Request) (*Claims, error
 // GetUserContext creates a context with user information from claims
 func (v *JWTValidator) GetUserContext(ctx context.Context, claims *Claims) context.Context {
 	userInfo := map[string]interface{}{
-		"user_id":        claims.Subject,
+		"user_id":        claims.GetUserID(),
+		"subject":        claims.Subject,
 		"email":          claims.Email,
 		"email_verified": claims.EmailVerified,
 		"name":           claims.Name,
@@ -223,7 +313,7 @@ func (v *JWTValidator) GetUserContext(ctx context.Context, claims *Claims) conte
 
 	// Set both "user" object and individual context keys for compatibility
 	ctx = context.WithValue(ctx, "user", userInfo)
-	ctx = context.WithValue(ctx, "user_id", claims.Subject)
+	ctx = context.WithValue(ctx, "user_id", claims.GetUserID())
 	ctx = context.WithValue(ctx, "email", claims.Email)
 	
 	return ctx
@@ -246,6 +336,18 @@ func GetUserIDFromContext(ctx context.Context) (string, bool) {
 	return userID, ok
 }
 
+// GetSubjectFromContext extracts the token subject from context. It differs from the user ID
+// when the subject is a sign-in identity linked to the user.
+func GetSubjectFromContext(ctx context.Context) (string, bool) {
+	user, ok := GetUserFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	subject, ok := user["subject"].(string)
+	return subject, ok
+}
+
 // GetUserEmailFromContext extracts user email from context
 func GetUserEmailFromContext(ctx context.Context) (string, bool) {
 	user, ok := GetUserFromContext(ctx)
@@ -257,6 +359,17 @@ func GetUserEmailFromContext(ctx context.Context) (string, bool) {
 	return email, ok
 }
 
+// GetPermissionsFromContext extracts the user's permissions from context
+func GetPermissionsFromContext(ctx context.Context) []string {
+	user, ok := GetUserFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	permissions, _ := user["permissions"].([]string)
+	return permissions
+}
+
 // HasPermission checks if the user has a specific permission
 func HasPermission(ctx context.Context, permission string) bool {
 	user, ok := GetUserFromContext(ctx)
@@ -288,4 +401,26 @@ func GetJTIFromContext(ctx context.Context) (string, bool) {
 func GetTokenExpirationFromContext(ctx context.Context) (time.Time, bool) {
 	expiresAt, ok := ctx.Value("token_expires_at").(time.Time)
 	return expiresAt, ok
-}
\ No newline at end of file
+}
+
+// WithAccessToken stores the raw bearer token in context for forwarding to remote services
+func WithAccessToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, "access_token", token)
+}
+
+// GetAccessTokenFromContext extracts the raw bearer token from context
+func GetAccessTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value("access_token").(string)
+	return token, ok && token != ""
+}
+
+// WithAPIKey stores the raw API key in context for forwarding to remote services
+func WithAPIKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, "api_key", key)
+}
+
+// GetAPIKeyFromContext extracts the raw API key from context
+func GetAPIKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value("api_key").(string)
+	return key, ok && key != ""
+}
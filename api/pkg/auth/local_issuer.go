@@ -0,0 +1,100 @@
+package auth
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"bocchi/api/pkg/errors"
+)
+
+const (
+	// LocalIssuer is the issuer of tokens minted by LocalTokenIssuer
+	LocalIssuer = "bocchi-the-map-local"
+	// DefaultLocalAudience is used when no audience is configured in local auth mode
+	DefaultLocalAudience = "bocchi-the-map-api"
+	// DefaultLocalTokenTTL is the lifetime of a minted token when neither TTL nor ExpiresAt is given
+	DefaultLocalTokenTTL = time.Hour
+)
+
+// TokenOptions describes the claims of a locally minted token
+type TokenOptions struct {
+	Subject       string
+	Email         string
+	Name          string
+	EmailVerified bool
+	Permissions   []string
+	ID            string        // JWT ID; a random UUID when empty
+	TTL           time.Duration // lifetime from IssuedAt; DefaultLocalTokenTTL when zero
+	ExpiresAt     time.Time     // absolute expiry; overrides TTL, may be in the past for expiry tests
+	IssuedAt      time.Time     // iat; now when zero
+}
+
+// LocalTokenIssuer mints HS256 tokens accepted by a validator from NewLocalJWTValidator.
+// It replaces Auth0 for local development and tests and must never be used in production.
+type LocalTokenIssuer struct {
+	secret   []byte
+	audience string
+}
+
+// NewLocalTokenIssuer creates an issuer signing with the given secret
+func NewLocalTokenIssuer(secret, audience string) (*LocalTokenIssuer, error) {
+	if secret == "" {
+		return nil, errors.InvalidInput("secret", "JWT secret is required for local authentication")
+	}
+	if audience == "" {
+		audience = DefaultLocalAudience
+	}
+
+	return &LocalTokenIssuer{
+		secret:   []byte(secret),
+		audience: audience,
+	}, nil
+}
+
+// Mint signs a token carrying the given claims
+func (i *LocalTokenIssuer) Mint(opts TokenOptions) (string, error) {
+	if opts.Subject == "" {
+		return "", errors.InvalidInput("sub", "token subject is required")
+	}
+
+	now := opts.IssuedAt
+	if now.IsZero() {
+		now = time.Now()
+	}
+	expiresAt := opts.ExpiresAt
+	if expiresAt.IsZero() {
+		ttl := opts.TTL
+		if ttl <= 0 {
+			ttl = DefaultLocalTokenTTL
+		}
+		expiresAt = now.Add(ttl)
+	}
+
+	jti := opts.ID
+	if jti == "" {
+		jti = uuid.New().String()
+	}
+
+	claims := &Claims{
+		Audience:      []string{i.audience},
+		ID:            jti,
+		IssuedAt:      now.Unix(),
+		Issuer:        LocalIssuer,
+		Subject:       opts.Subject,
+		Email:         opts.Email,
+		EmailVerified: opts.EmailVerified,
+		Name:          opts.Name,
+		Permissions:   opts.Permissions,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrTypeInternal, "failed to sign local token")
+	}
+	return signed, nil
+}
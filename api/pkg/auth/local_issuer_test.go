@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"bocchi/api/pkg/config"
+)
+
+const testLocalSecret = "local-test-secret-that-is-at-least-32-chars"
+
+func newTestLocalIssuer(t *testing.T) *LocalTokenIssuer {
+	t.Helper()
+	issuer, err := NewLocalTokenIssuer(testLocalSecret, "")
+	if err != nil {
+		t.Fatalf("Failed to create local issuer: %v", err)
+	}
+	return issuer
+}
+
+func newTestLocalValidator(t *testing.T) *JWTValidator {
+	t.Helper()
+	validator, err := NewLocalJWTValidator(testLocalSecret, "")
+	if err != nil {
+		t.Fatalf("Failed to create local validator: %v", err)
+	}
+	return validator
+}
+
+func TestLocalTokenRoundTrip(t *testing.T) {
+	token, err := newTestLocalIssuer(t).Mint(TokenOptions{
+		Subject:     "local|user-1",
+		Email:       "user1@example.com",
+		Permissions: []string{PermissionAdminSpots},
+		ID:          "jti-123",
+	})
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+
+	claims, err := newTestLocalValidator(t).ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Expected minted token to validate, got %v", err)
+	}
+
+	if claims.Subject != "local|user-1" || claims.Email != "user1@example.com" || claims.ID != "jti-123" {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+	if !reflect.DeepEqual(claims.Permissions, []string{PermissionAdminSpots}) {
+		t.Errorf("Expected permissions to round trip, got %v", claims.Permissions)
+	}
+	if claims.Issuer != LocalIssuer {
+		t.Errorf("Expected issuer %q, got %q", LocalIssuer, claims.Issuer)
+	}
+}
+
+func TestLocalTokenDefaults(t *testing.T) {
+	token, err := newTestLocalIssuer(t).Mint(TokenOptions{Subject: "local|user-1"})
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+
+	claims, err := newTestLocalValidator(t).ValidateToken(token)
+	if err != nil {
+		t.Fatalf("Expected minted token to validate, got %v", err)
+	}
+
+	if claims.ID == "" {
+		t.Error("Expected a generated JTI")
+	}
+	ttl := claims.ExpiresAt.Sub(time.Unix(claims.IssuedAt, 0))
+	if ttl != DefaultLocalTokenTTL {
+		t.Errorf("Expected default TTL %v, got %v", DefaultLocalTokenTTL, ttl)
+	}
+}
+
+func TestLocalTokenRejected(t *testing.T) {
+	issuer := newTestLocalIssuer(t)
+
+	expired, err := issuer.Mint(TokenOptions{Subject: "local|user-1", ExpiresAt: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+
+	otherIssuer, _ := NewLocalTokenIssuer("another-secret-that-is-at-least-32-chars", "")
+	wrongSecret, err := otherIssuer.Mint(TokenOptions{Subject: "local|user-1"})
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+
+	otherAudience, _ := NewLocalTokenIssuer(testLocalSecret, "another-api")
+	wrongAudience, err := otherAudience.Mint(TokenOptions{Subject: "local|user-1"})
+	if err != nil {
+		t.Fatalf("Mint failed: %v", err)
+	}
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, &Claims{
+		Subject:  "local|user-1",
+		Issuer:   LocalIssuer,
+		Audience: []string{DefaultLocalAudience},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("Failed to build unsigned token: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", expired},
+		{"wrong secret", wrongSecret},
+		{"wrong audience", wrongAudience},
+		{"unsigned", unsigned},
+		{"malformed", "invalid.jwt.token"},
+	}
+	validator := newTestLocalValidator(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := validator.ValidateToken(tt.token); err == nil {
+				t.Error("Expected token to be rejected")
+			}
+		})
+	}
+}
+
+func TestNewAuthMiddlewareWithConfigLocalMode(t *testing.T) {
+	middleware, err := NewAuthMiddlewareWithConfig(AuthConfig{
+		Mode:      config.AuthModeLocal,
+		JWTSecret: testLocalSecret,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Expected local mode to start without Auth0, got %v", err)
+	}
+	if !middleware.GetValidator().IsLocal() {
+		t.Error("Expected a local validator")
+	}
+}
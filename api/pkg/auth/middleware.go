@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	stdErrors "errors"
 	"net/http"
 	"strings"
@@ -11,6 +12,8 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"bocchi/api/infrastructure/database"
+	"bocchi/api/pkg/clientip"
+	appConfig "bocchi/api/pkg/config"
 	"bocchi/api/pkg/errors"
 	"bocchi/api/pkg/logger"
 	"bocchi/api/pkg/monitoring"
@@ -18,37 +21,106 @@ import (
 
 // AuthMiddleware provides JWT authentication middleware for the API
 type AuthMiddleware struct {
-	validator    *JWTValidator
-	queries      *database.Queries
-	jwtSecret    string
-	skipPaths    map[string]bool
-	development  bool
+	validator            *JWTValidator
+	queries              *database.Queries
+	blacklist            BlacklistStore
+	apiKeys              *APIKeyManager
+	provisioning         *userProvisioning
+	emailVerification    EmailVerificationStore
+	requireVerifiedEmail bool
+	jwtSecret            string
+	skipPaths            map[string]bool
+	development          bool
 }
 
 // AuthConfig holds configuration for the authentication middleware
 type AuthConfig struct {
+	Mode          string // config.AuthModeAuth0 (default) or config.AuthModeLocal
 	Auth0Domain   string
 	Auth0Audience string
 	JWTSecret     string
 	Development   bool
 	SkipPaths     []string
+
+	// BlacklistStore overrides the cached MySQL store used to check revoked tokens
+	BlacklistStore BlacklistStore
+	// BlacklistCacheSize and BlacklistCacheTTL tune the default store's cache; zero uses the defaults
+	BlacklistCacheSize int
+	BlacklistCacheTTL  time.Duration
+
+	// APIKeyStore overrides the MySQL store used to authenticate X-API-Key requests
+	APIKeyStore APIKeyStore
+	// APIKeyUsageFlushInterval sets how often key usage is written to the store; zero uses the default
+	APIKeyUsageFlushInterval time.Duration
+
+	// UserProvisioner creates or refreshes the user of a sign-in identity on its first
+	// authenticated request; nil disables just-in-time provisioning
+	UserProvisioner UserProvisioner
+	// UserProvisionRefreshInterval sets how often a known user is refreshed from its claims;
+	// zero uses the default
+	UserProvisionRefreshInterval time.Duration
+
+	// RequireVerifiedEmail rejects spot, review and rating creation by users who have not
+	// verified their email, unless an admin has exempted them
+	RequireVerifiedEmail bool
+	// EmailVerificationStore overrides the MySQL store holding verification state and overrides
+	EmailVerificationStore EmailVerificationStore
 }
 
-// NewAuthMiddleware creates a new authentication middleware instance
+// NewAuthMiddleware creates an authentication middleware that accepts HS256 tokens signed
+// with jwtSecret by LocalTokenIssuer, using the default local audience. It needs no Auth0
+// access and is meant for tests; an empty secret leaves every request unauthenticated.
+// With queries it flushes API key usage in the background until Stop is called.
 func NewAuthMiddleware(jwtSecret string, queries *database.Queries) *AuthMiddleware {
-	// For now, we'll create a placeholder that will be properly configured
-	// when the full Auth0 configuration is available
+	validator, _ := NewLocalJWTValidator(jwtSecret, "")
 	return &AuthMiddleware{
-		queries:   queries,
-		jwtSecret: jwtSecret,
-		skipPaths: make(map[string]bool),
+		validator:         validator,
+		queries:           queries,
+		blacklist:         newBlacklistStore(AuthConfig{}, queries),
+		apiKeys:           newAPIKeyManager(AuthConfig{}, queries),
+		emailVerification: newEmailVerificationStore(AuthConfig{}, queries),
+		jwtSecret:         jwtSecret,
+		skipPaths:         make(map[string]bool),
+	}
+}
+
+// newBlacklistStore returns the configured blacklist store, or the MySQL store behind an LRU cache
+func newBlacklistStore(config AuthConfig, queries *database.Queries) BlacklistStore {
+	if config.BlacklistStore != nil {
+		return config.BlacklistStore
+	}
+	if queries == nil {
+		return nil
+	}
+	return NewCachedBlacklistStore(NewMySQLBlacklistStore(queries), config.BlacklistCacheSize, config.BlacklistCacheTTL)
+}
+
+// newAPIKeyManager returns a manager for the configured API key store or the MySQL store,
+// or nil when neither is available
+func newAPIKeyManager(config AuthConfig, queries *database.Queries) *APIKeyManager {
+	store := config.APIKeyStore
+	if store == nil {
+		if queries == nil {
+			return nil
+		}
+		store = NewMySQLAPIKeyStore(queries)
 	}
+	return NewAPIKeyManager(store, config.APIKeyUsageFlushInterval)
 }
 
-// NewAuthMiddlewareWithConfig creates a new authentication middleware with full configuration
+// newValidator creates the JWT validator for the configured authentication mode
+func newValidator(config AuthConfig) (*JWTValidator, error) {
+	if config.Mode == appConfig.AuthModeLocal {
+		return NewLocalJWTValidator(config.JWTSecret, config.Auth0Audience)
+	}
+	return NewJWTValidator(config.Auth0Domain, config.Auth0Audience)
+}
+
+// NewAuthMiddlewareWithConfig creates a new authentication middleware with full configuration.
+// With an API key store it flushes key usage in the background until Stop is called.
 func NewAuthMiddlewareWithConfig(config AuthConfig, queries *database.Queries) (*AuthMiddleware, error) {
-	// Initialize JWT validator with Auth0 configuration
-	validator, err := NewJWTValidator(config.Auth0Domain, config.Auth0Audience)
+	// Initialize JWT validator for the configured mode
+	validator, err := newValidator(config)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrTypeInternal, "failed to create JWT validator")
 	}
@@ -73,18 +145,27 @@ func NewAuthMiddlewareWithConfig(config AuthConfig, queries *database.Queries) (
 	}
 
 	middleware := &AuthMiddleware{
-		validator:   validator,
-		queries:     queries,
-		jwtSecret:   config.JWTSecret,
-		skipPaths:   skipPaths,
-		development: config.Development,
+		validator:            validator,
+		queries:              queries,
+		blacklist:            newBlacklistStore(config, queries),
+		apiKeys:              newAPIKeyManager(config, queries),
+		provisioning:         newUserProvisioning(config.UserProvisioner, config.UserProvisionRefreshInterval),
+		emailVerification:    newEmailVerificationStore(config, queries),
+		requireVerifiedEmail: config.RequireVerifiedEmail,
+		jwtSecret:            config.JWTSecret,
+		skipPaths:            skipPaths,
+		development:          config.Development,
 	}
 
 	logger.InfoWithFields("Auth middleware initialized", map[string]interface{}{
+		"mode":           config.Mode,
 		"auth0_domain":   config.Auth0Domain,
 		"auth0_audience": config.Auth0Audience,
 		"development":    config.Development,
 		"skip_paths":     len(skipPaths),
+		"api_keys":       middleware.apiKeys != nil,
+		"provisioning":   middleware.provisioning != nil,
+		"verified_email": config.RequireVerifiedEmail,
 	})
 
 	return middleware, nil
@@ -104,28 +185,15 @@ func (m *AuthMiddleware) RequireAuth() func(http.Handler) http.Handler {
 			ctx := monitoring.StartTrace(r.Context(), "auth.validate_token")
 			defer monitoring.EndTrace(ctx)
 
-			// Validate token
-			claims, err := m.validateRequest(r)
+			// Validate the bearer token or API key
+			claims, userCtx, err := m.authenticateRequest(r)
 			if err != nil {
 				m.handleAuthError(w, r, err)
 				return
 			}
-
-			// Add user context to request
-			userCtx := m.validator.GetUserContext(r.Context(), claims)
-			
-			// Add JWT ID (JTI) to context for logout functionality
-			if claims.ID != "" {
-				userCtx = context.WithValue(userCtx, "jti", claims.ID)
-			}
-			
-			// Add token expiration to context
-			if claims.ExpiresAt > 0 {
-				userCtx = context.WithValue(userCtx, "token_expires_at", time.Unix(claims.ExpiresAt, 0))
-			}
 			
 			// Add user info for monitoring
-			monitoring.AddUserContext(userCtx, claims.Subject, claims.Email)
+			monitoring.AddUserContext(userCtx, claims.GetUserID(), claims.Email)
 
 			// Continue with authenticated request
 			next.ServeHTTP(w, r.WithContext(userCtx))
@@ -133,16 +201,69 @@ func (m *AuthMiddleware) RequireAuth() func(http.Handler) http.Handler {
 	}
 }
 
-// HumaMiddleware returns a Huma v2 compatible middleware function
-// This creates a wrapper that integrates Auth0 authentication with Huma v2 operations
+// HumaMiddleware returns a Huma v2 compatible middleware function.
+// Requests carrying a valid bearer token or API key get the caller and their permissions
+// added to the context; other requests continue anonymously and protected operations
+// reject them. A bearer token takes precedence over an API key.
 func (m *AuthMiddleware) HumaMiddleware() func(ctx huma.Context, next func(huma.Context)) {
 	return func(ctx huma.Context, next func(huma.Context)) {
-		// This is a placeholder for Huma v2 specific middleware
-		// In practice, it's better to use the Chi middleware with humachi adapter
+		authHeader := ctx.Header("Authorization")
+		apiKey := ctx.Header(APIKeyHeader)
+		if (authHeader == "" && apiKey == "") || m.validator == nil {
+			next(ctx)
+			return
+		}
+
+		var claims *Claims
+		var userCtx context.Context
+		var err error
+		if authHeader != "" {
+			var token string
+			if token, err = parseBearerToken(authHeader); err == nil {
+				claims, userCtx, err = m.authenticateBearer(ctx.Context(), token)
+			}
+		} else {
+			claims, userCtx, err = m.authenticateAPIKey(ctx.Context(), apiKey)
+		}
+		if err == nil {
+			monitoring.AddUserContext(userCtx, claims.GetUserID(), claims.Email)
+			next(huma.WithContext(ctx, userCtx))
+			return
+		}
+		// The user could not be resolved; continuing anonymously would answer 401
+		if errors.Is(err, errors.ErrTypeUnavailable) {
+			m.writeHumaError(ctx, err)
+			return
+		}
+
+		logger.InfoWithFields("Huma authentication failed", map[string]interface{}{
+			"error": err.Error(),
+			"path":  ctx.URL().Path,
+		})
 		next(ctx)
 	}
 }
 
+// RequirePermissions creates a protected Huma operation that also requires every given
+// permission. It answers 401 without an authenticated user and 403 without the permissions.
+func (m *AuthMiddleware) RequirePermissions(api huma.API, operation huma.Operation, permissions ...string) huma.Operation {
+	operation = m.CreateProtectedOperation(operation)
+	operation.Middlewares = append(operation.Middlewares, func(ctx huma.Context, next func(huma.Context)) {
+		if userID, ok := GetUserIDFromContext(ctx.Context()); !ok || userID == "" {
+			huma.WriteErr(api, ctx, http.StatusUnauthorized, "authentication required")
+			return
+		}
+		for _, permission := range permissions {
+			if !HasPermission(ctx.Context(), permission) {
+				huma.WriteErr(api, ctx, http.StatusForbidden, "insufficient permissions")
+				return
+			}
+		}
+		next(ctx)
+	})
+	return operation
+}
+
 // CreateProtectedOperation creates a Huma operation that requires authentication
 // This is a helper function to easily create protected endpoints
 func (m *AuthMiddleware) CreateProtectedOperation(operation huma.Operation) huma.Operation {
@@ -151,10 +272,11 @@ func (m *AuthMiddleware) CreateProtectedOperation(operation huma.Operation) huma
 		operation.Security = []map[string][]string{}
 	}
 	
-	// Add bearer token security requirement
-	operation.Security = append(operation.Security, map[string][]string{
-		"bearerAuth": {},
-	})
+	// Accept either a bearer token or an API key
+	operation.Security = append(operation.Security,
+		map[string][]string{"bearerAuth": {}},
+		map[string][]string{"apiKeyAuth": {}},
+	)
 	
 	return operation
 }
@@ -164,7 +286,11 @@ func (m *AuthMiddleware) OptionalAuth() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Always allow the request to continue, but add user context if token is valid
-			claims, err := m.validateRequest(r)
+			claims, userCtx, err := m.authenticateRequest(r)
+			if errors.Is(err, errors.ErrTypeUnavailable) {
+				m.handleAuthError(w, r, err)
+				return
+			}
 			if err != nil {
 				// Log the error but don't block the request
 				logger.InfoWithFields("Optional auth failed", map[string]interface{}{
@@ -176,47 +302,150 @@ func (m *AuthMiddleware) OptionalAuth() func(http.Handler) http.Handler {
 			}
 
 			// Add user context if validation succeeded
-			userCtx := m.validator.GetUserContext(r.Context(), claims)
-			
-			// Add JWT ID (JTI) to context for logout functionality
-			if claims.ID != "" {
-				userCtx = context.WithValue(userCtx, "jti", claims.ID)
-			}
-			
-			// Add token expiration to context
-			if claims.ExpiresAt > 0 {
-				userCtx = context.WithValue(userCtx, "token_expires_at", time.Unix(claims.ExpiresAt, 0))
-			}
-			
-			monitoring.AddUserContext(userCtx, claims.Subject, claims.Email)
+			monitoring.AddUserContext(userCtx, claims.GetUserID(), claims.Email)
 			
 			next.ServeHTTP(w, r.WithContext(userCtx))
 		})
 	}
 }
 
-// validateRequest validates the JWT token from the request
-func (m *AuthMiddleware) validateRequest(r *http.Request) (*Claims, error) {
+// authenticateRequest validates the bearer token, or the API key when the request has no
+// Authorization header, and returns the claims with the authenticated context
+func (m *AuthMiddleware) authenticateRequest(r *http.Request) (*Claims, context.Context, error) {
+	if m.validator == nil {
+		return nil, nil, errors.Internal("JWT validator not initialized")
+	}
+
+	if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" && r.Header.Get("Authorization") == "" {
+		return m.authenticateAPIKey(r.Context(), apiKey)
+	}
+
+	token, err := m.validator.ExtractTokenFromRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return m.authenticateBearer(r.Context(), token)
+}
+
+// authenticateBearer validates a bearer token, provisions the user of a first-seen sign-in
+// identity and returns the claims with the authenticated context
+func (m *AuthMiddleware) authenticateBearer(ctx context.Context, token string) (*Claims, context.Context, error) {
+	claims, err := m.ValidateToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	m.provisionUser(ctx, claims)
+	return claims, m.userContext(ctx, claims, token), nil
+}
+
+// AuthenticateAPIKey validates an API key and returns claims for the key, whose subject is
+// APIKeySubjectPrefix followed by the key ID and whose permissions are the key's scope.
+// It is shared by the HTTP middleware and the gRPC auth interceptor.
+func (m *AuthMiddleware) AuthenticateAPIKey(ctx context.Context, key string) (*Claims, error) {
+	if m.apiKeys == nil {
+		return nil, errors.Unauthorized("API keys are not enabled")
+	}
+
+	apiKey, err := m.apiKeys.Authenticate(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Claims{
+		Subject:     APIKeySubjectPrefix + apiKey.ID,
+		Name:        apiKey.Name,
+		Permissions: apiKey.Permissions,
+	}, nil
+}
+
+// authenticateAPIKey validates an API key and returns the claims with the authenticated context
+func (m *AuthMiddleware) authenticateAPIKey(ctx context.Context, key string) (*Claims, context.Context, error) {
+	claims, err := m.AuthenticateAPIKey(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyCtx := m.validator.GetUserContext(ctx, claims)
+	keyCtx = context.WithValue(keyCtx, "api_key_id", strings.TrimPrefix(claims.Subject, APIKeySubjectPrefix))
+	// Keep the raw key so remote gRPC clients can forward it
+	keyCtx = WithAPIKey(keyCtx, key)
+	return claims, keyCtx, nil
+}
+
+// ValidateToken validates a raw JWT token, including the blacklist check.
+// It is shared by the HTTP middleware and the gRPC auth interceptor.
+func (m *AuthMiddleware) ValidateToken(ctx context.Context, token string) (*Claims, error) {
 	if m.validator == nil {
 		return nil, errors.Internal("JWT validator not initialized")
 	}
 
-	// Extract and validate token
-	claims, err := m.validator.ValidateTokenFromRequest(r)
+	claims, err := m.validator.ValidateToken(token)
 	if err != nil {
 		return nil, err
 	}
 
-	// Additional validation can be added here (e.g., token blacklist check)
-	if m.queries != nil {
-		if err := m.checkTokenBlacklist(r.Context(), claims); err != nil {
+	userID, err := m.resolveUserID(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if userID != claims.Subject {
+		claims.UserID = userID
+	}
+
+	if m.blacklist != nil {
+		if err := m.checkTokenBlacklist(ctx, claims); err != nil {
+			return nil, err
+		}
+		if err := m.checkUserRevocation(ctx, claims); err != nil {
 			return nil, err
 		}
 	}
 
+	claims.Permissions = m.resolvePermissions(ctx, claims)
 	return claims, nil
 }
 
+// resolvePermissions adds the permissions granted through the user's roles to those in the token
+func (m *AuthMiddleware) resolvePermissions(ctx context.Context, claims *Claims) []string {
+	if m.queries == nil || claims.GetUserID() == "" {
+		return claims.Permissions
+	}
+
+	rolePermissions, err := m.queries.ListPermissionsByUserID(ctx, claims.GetUserID())
+	if err != nil {
+		logger.ErrorWithFields("Failed to load role permissions", err, map[string]interface{}{
+			"subject": claims.Subject,
+		})
+		// Don't block authentication on database errors; fall back to token permissions
+		return claims.Permissions
+	}
+
+	return mergePermissions(claims.Permissions, rolePermissions)
+}
+
+// userContext adds the authenticated user, token ID, expiration and raw token to context
+func (m *AuthMiddleware) userContext(ctx context.Context, claims *Claims, token string) context.Context {
+	userCtx := m.validator.GetUserContext(ctx, claims)
+
+	// Keep the raw token so remote gRPC clients can forward it
+	if token != "" {
+		userCtx = WithAccessToken(userCtx, token)
+	}
+
+	// Add JWT ID (JTI) to context for logout functionality
+	if claims.ID != "" {
+		userCtx = context.WithValue(userCtx, "jti", claims.ID)
+	}
+
+	// Add token expiration to context
+	if claims.ExpiresAt != nil {
+		userCtx = context.WithValue(userCtx, "token_expires_at", claims.ExpiresAt.Time)
+	}
+
+	return userCtx
+}
+
 // checkTokenBlacklist checks if the token is blacklisted
 func (m *AuthMiddleware) checkTokenBlacklist(ctx context.Context, claims *Claims) error {
 	// Check if JWT ID (JTI) is available
@@ -229,7 +458,11 @@ func (m *AuthMiddleware) checkTokenBlacklist(ctx context.Context, claims *Claims
 	}
 
 	// Check if token is blacklisted
-	isBlacklisted, err := m.queries.IsTokenBlacklisted(ctx, claims.ID)
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	isBlacklisted, err := m.blacklist.IsBlacklisted(ctx, claims.ID, expiresAt)
 	if err != nil {
 		logger.ErrorWithFields("Failed to check token blacklist", err, map[string]interface{}{
 			"jti": claims.ID,
@@ -249,6 +482,35 @@ func (m *AuthMiddleware) checkTokenBlacklist(ctx context.Context, claims *Claims
 	return nil
 }
 
+// checkUserRevocation rejects tokens issued at or before the user's revocation cutoff.
+// iat has one-second resolution, so a token issued in the same second as a logout-all is
+// revoked too; a token without iat is revoked whenever the user has a cutoff.
+func (m *AuthMiddleware) checkUserRevocation(ctx context.Context, claims *Claims) error {
+	if claims.GetUserID() == "" {
+		return nil
+	}
+
+	cutoff, err := m.blacklist.RevokedBefore(ctx, claims.GetUserID())
+	if err != nil {
+		logger.ErrorWithFields("Failed to check user token revocation", err, map[string]interface{}{
+			"subject": claims.Subject,
+		})
+		// Don't block authentication on database errors
+		return nil
+	}
+
+	if !cutoff.IsZero() && claims.IssuedAt <= cutoff.Unix() {
+		logger.InfoWithFields("Token revoked by user logout-all", map[string]interface{}{
+			"subject":        claims.Subject,
+			"issued_at":      claims.IssuedAt,
+			"revoked_before": cutoff,
+		})
+		return errors.Unauthorized("token has been revoked")
+	}
+
+	return nil
+}
+
 // shouldSkipPath checks if the given path should skip authentication
 func (m *AuthMiddleware) shouldSkipPath(path string) bool {
 	// Check exact match
@@ -287,7 +549,7 @@ func (m *AuthMiddleware) handleAuthError(w http.ResponseWriter, r *http.Request,
 		"error":  err.Error(),
 		"path":   r.URL.Path,
 		"method": r.Method,
-		"ip":     r.RemoteAddr,
+		"ip":     clientip.FromRequest(r),
 	})
 
 	// Add monitoring metrics
@@ -326,6 +588,17 @@ func (m *AuthMiddleware) convertToHumaError(err error) *huma.ErrorModel {
 	}
 }
 
+// writeHumaError writes an authentication error from the Huma middleware, which has no
+// API to negotiate the response format with
+func (m *AuthMiddleware) writeHumaError(ctx huma.Context, err error) {
+	model := m.convertToHumaError(err)
+	ctx.SetHeader("Content-Type", "application/problem+json")
+	ctx.SetStatus(model.Status)
+	if encodeErr := json.NewEncoder(ctx.BodyWriter()).Encode(model); encodeErr != nil {
+		logger.Error("Failed to write authentication error", encodeErr)
+	}
+}
+
 // RegisterAuthRoutes registers authentication-related routes (placeholder)
 func (m *AuthMiddleware) RegisterAuthRoutes(router chi.Router) {
 	// TODO: Implement authentication routes like login, logout, refresh token
@@ -338,12 +611,12 @@ func (m *AuthMiddleware) Logout(ctx context.Context, tokenJTI string, expiresAt
 		return errors.InvalidInput("tokenJTI", "JWT ID is required for logout")
 	}
 
+	if m.blacklist == nil {
+		return errors.Internal("token blacklist is not configured")
+	}
+
 	// Add token to blacklist
-	err := m.queries.BlacklistAccessToken(ctx, database.BlacklistAccessTokenParams{
-		Jti:       tokenJTI,
-		ExpiresAt: expiresAt,
-	})
-	if err != nil {
+	if err := m.blacklist.Blacklist(ctx, tokenJTI, expiresAt); err != nil {
 		logger.ErrorWithFields("Failed to blacklist token", err, map[string]interface{}{
 			"jti": tokenJTI,
 		})
@@ -358,6 +631,35 @@ func (m *AuthMiddleware) Logout(ctx context.Context, tokenJTI string, expiresAt
 	return nil
 }
 
+// LogoutAll revokes every token of the user issued up to now and returns the cutoff.
+// userID is the user's ID as returned by Claims.GetUserID, so the cutoff covers every
+// identity linked to the user. Tokens issued afterwards, e.g. after signing in again, remain valid.
+func (m *AuthMiddleware) LogoutAll(ctx context.Context, userID string) (time.Time, error) {
+	if userID == "" {
+		return time.Time{}, errors.InvalidInput("userID", "user ID is required to revoke tokens")
+	}
+
+	if m.blacklist == nil {
+		return time.Time{}, errors.Internal("token blacklist is not configured")
+	}
+
+	// The cutoff is compared with iat, which has one-second resolution
+	cutoff := time.Now().Truncate(time.Second)
+	if err := m.blacklist.RevokeBefore(ctx, userID, cutoff); err != nil {
+		logger.ErrorWithFields("Failed to revoke user tokens", err, map[string]interface{}{
+			"user_id": userID,
+		})
+		return time.Time{}, errors.Wrap(err, errors.ErrTypeInternal, "failed to revoke user tokens")
+	}
+
+	logger.InfoWithFields("User tokens revoked", map[string]interface{}{
+		"user_id":        userID,
+		"revoked_before": cutoff,
+	})
+
+	return cutoff, nil
+}
+
 // RefreshToken handles token refresh (placeholder)
 func (m *AuthMiddleware) RefreshToken(ctx context.Context, refreshToken string) (*Claims, error) {
 	// TODO: Implement token refresh logic
@@ -368,4 +670,29 @@ func (m *AuthMiddleware) RefreshToken(ctx context.Context, refreshToken string)
 // GetValidator returns the JWT validator instance
 func (m *AuthMiddleware) GetValidator() *JWTValidator {
 	return m.validator
+}
+
+// GetAPIKeyManager returns the manager for API keys, or nil without a database
+func (m *AuthMiddleware) GetAPIKeyManager() *APIKeyManager {
+	return m.apiKeys
+}
+
+// Stop flushes API key usage and stops its background flushing
+func (m *AuthMiddleware) Stop() {
+	if m.apiKeys != nil {
+		m.apiKeys.Stop()
+	}
+}
+
+// GetBlacklistStore returns the store used to check revoked tokens, or nil without a database
+func (m *AuthMiddleware) GetBlacklistStore() BlacklistStore {
+	return m.blacklist
+}
+
+// GetBlacklistStats returns the blacklist cache statistics, or nil when the store is not cached
+func (m *AuthMiddleware) GetBlacklistStats() map[string]interface{} {
+	if cached, ok := m.blacklist.(*CachedBlacklistStore); ok {
+		return cached.GetStats()
+	}
+	return nil
 }
\ No newline at end of file
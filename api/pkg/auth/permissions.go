@@ -0,0 +1,32 @@
+package auth
+
+// Permission names as issued in the Auth0 access token permissions claim
+// or granted through roles stored in the database
+const (
+	// PermissionAdminSpots allows modifying and deleting spots created by other users
+	PermissionAdminSpots = "admin:spots"
+	// PermissionAdminUsers allows updating and deleting other users' accounts
+	PermissionAdminUsers = "admin:users"
+	// PermissionAdminAuth allows reading authentication service statistics
+	PermissionAdminAuth = "admin:auth"
+	// PermissionAdminSystem allows inspecting background jobs and other operational state
+	PermissionAdminSystem = "admin:system"
+	// PermissionAdminAPIKeys allows creating, rotating and revoking API keys
+	PermissionAdminAPIKeys = "admin:api_keys"
+)
+
+// mergePermissions returns the union of token and role permissions without duplicates,
+// keeping the token permissions first
+func mergePermissions(tokenPermissions, rolePermissions []string) []string {
+	merged := make([]string, 0, len(tokenPermissions)+len(rolePermissions))
+	seen := make(map[string]bool, cap(merged))
+	for _, permissions := range [][]string{tokenPermissions, rolePermissions} {
+		for _, permission := range permissions {
+			if !seen[permission] {
+				seen[permission] = true
+				merged = append(merged, permission)
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+func TestMergePermissions(t *testing.T) {
+	merged := mergePermissions(
+		[]string{"read:spots", PermissionAdminSpots},
+		[]string{PermissionAdminSpots, PermissionAdminUsers},
+	)
+
+	expected := []string{"read:spots", PermissionAdminSpots, PermissionAdminUsers}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected %v, got %v", expected, merged)
+	}
+}
+
+func TestMergePermissionsWithoutRoles(t *testing.T) {
+	merged := mergePermissions([]string{"read:spots"}, nil)
+
+	if !reflect.DeepEqual(merged, []string{"read:spots"}) {
+		t.Errorf("Expected token permissions only, got %v", merged)
+	}
+}
+
+func TestRequirePermissions(t *testing.T) {
+	_, api := humatest.New(t)
+	middleware := &AuthMiddleware{validator: &JWTValidator{}}
+
+	// Stand-in for HumaMiddleware: the X-Test-Permissions header describes the caller
+	api.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		header := ctx.Header("X-Test-Permissions")
+		if header == "" {
+			next(ctx)
+			return
+		}
+		claims := &Claims{Subject: "auth0|admin-test", Permissions: strings.Split(header, ",")}
+		userCtx := middleware.userContext(ctx.Context(), claims, "")
+		next(huma.WithContext(ctx, userCtx))
+	})
+
+	huma.Register(api, middleware.RequirePermissions(api, huma.Operation{
+		OperationID: "admin-only",
+		Method:      http.MethodGet,
+		Path:        "/admin",
+	}, PermissionAdminAuth), func(ctx context.Context, input *struct{}) (*struct{}, error) {
+		return &struct{}{}, nil
+	})
+
+	tests := []struct {
+		name     string
+		headers  []interface{}
+		expected int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"missing permission", []interface{}{"X-Test-Permissions: " + PermissionAdminSpots}, http.StatusForbidden},
+		{"granted", []interface{}{"X-Test-Permissions: " + PermissionAdminSpots + "," + PermissionAdminAuth}, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := api.Get("/admin", tt.headers...)
+			if resp.Code != tt.expected {
+				t.Errorf("Expected status %d, got %d: %s", tt.expected, resp.Code, resp.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"bocchi/api/pkg/logger"
+)
+
+const (
+	// DefaultUserProvisionRefreshInterval is how long a provisioned user is trusted before
+	// the next request of the identity refreshes the user record from its claims again
+	DefaultUserProvisionRefreshInterval = 15 * time.Minute
+	// userProvisionCacheSize bounds the number of recently provisioned subjects kept in memory
+	userProvisionCacheSize = 10000
+)
+
+// UserProvisioner creates or refreshes the user record of an authenticated sign-in identity
+// from the token's claims and returns the ID of the user
+type UserProvisioner interface {
+	ProvisionUser(ctx context.Context, claims *Claims) (string, error)
+}
+
+// userProvisioning calls the provisioner at most once per refresh interval for each
+// subject. Concurrent first requests of a subject share a single call.
+type userProvisioning struct {
+	provisioner UserProvisioner
+	refresh     time.Duration
+	now         func() time.Time
+
+	mu       sync.Mutex
+	recent   map[string]provisionedUser
+	inflight map[string]*provisionCall
+}
+
+type provisionedUser struct {
+	userID        string
+	provisionedAt time.Time
+}
+
+type provisionCall struct {
+	done   chan struct{}
+	userID string
+	err    error
+}
+
+// newUserProvisioning returns nil without a provisioner; a zero refresh uses the default
+func newUserProvisioning(provisioner UserProvisioner, refresh time.Duration) *userProvisioning {
+	if provisioner == nil {
+		return nil
+	}
+	if refresh <= 0 {
+		refresh = DefaultUserProvisionRefreshInterval
+	}
+	return &userProvisioning{
+		provisioner: provisioner,
+		refresh:     refresh,
+		now:         time.Now,
+		recent:      make(map[string]provisionedUser),
+		inflight:    make(map[string]*provisionCall),
+	}
+}
+
+// provision returns the ID of the user of the claims' subject, provisioning it when it
+// has not been provisioned within the refresh interval
+func (p *userProvisioning) provision(ctx context.Context, claims *Claims) (string, error) {
+	subject := claims.Subject
+
+	p.mu.Lock()
+	if recent, ok := p.recent[subject]; ok && p.now().Sub(recent.provisionedAt) < p.refresh {
+		p.mu.Unlock()
+		return recent.userID, nil
+	}
+	if call, ok := p.inflight[subject]; ok {
+		p.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.userID, call.err
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	call := &provisionCall{done: make(chan struct{})}
+	p.inflight[subject] = call
+	p.mu.Unlock()
+
+	// The call is shared, so the first request going away must not cancel it for the others
+	call.userID, call.err = p.provisioner.ProvisionUser(context.WithoutCancel(ctx), claims)
+
+	p.mu.Lock()
+	delete(p.inflight, subject)
+	if call.err == nil {
+		p.remember(subject, call.userID)
+	}
+	p.mu.Unlock()
+	close(call.done)
+
+	return call.userID, call.err
+}
+
+// remember records a provisioned subject, dropping expired entries when the cache is full.
+// The caller must hold p.mu.
+func (p *userProvisioning) remember(subject, userID string) {
+	now := p.now()
+	if _, ok := p.recent[subject]; !ok && len(p.recent) >= userProvisionCacheSize {
+		for cached, recent := range p.recent {
+			if now.Sub(recent.provisionedAt) >= p.refresh {
+				delete(p.recent, cached)
+			}
+		}
+		// Still full of fresh entries: forget an arbitrary one, which is merely refreshed early
+		for cached := range p.recent {
+			if len(p.recent) < userProvisionCacheSize {
+				break
+			}
+			delete(p.recent, cached)
+		}
+	}
+	p.recent[subject] = provisionedUser{userID: userID, provisionedAt: now}
+}
+
+// provisionUser creates or refreshes the user of a sign-in identity on its first request
+// and links the claims to that user. Subjects that are not sign-in identities, such as
+// local development tokens and API keys, and tokens without an email are left alone.
+// Failures are logged and do not block authentication.
+func (m *AuthMiddleware) provisionUser(ctx context.Context, claims *Claims) {
+	if m.provisioning == nil || claims.Email == "" {
+		return
+	}
+	if _, _, ok := IdentityFromSubject(claims.Subject); !ok {
+		return
+	}
+
+	userID, err := m.provisioning.provision(ctx, claims)
+	if err != nil {
+		logger.ErrorWithFields("Failed to provision user", err, map[string]interface{}{
+			"subject": claims.Subject,
+		})
+		return
+	}
+
+	if userID != "" && userID != claims.GetUserID() {
+		claims.UserID = userID
+		// The subject was not linked when the token was validated; pick up the user's roles
+		claims.Permissions = m.resolvePermissions(ctx, claims)
+	}
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+
+	"bocchi/api/pkg/config"
+)
+
+// fakeProvisioner maps every subject to a user ID derived from it and counts calls
+type fakeProvisioner struct {
+	calls   atomic.Int32
+	release chan struct{} // when set, calls block until it is closed
+}
+
+func (p *fakeProvisioner) ProvisionUser(ctx context.Context, claims *Claims) (string, error) {
+	p.calls.Add(1)
+	if p.release != nil {
+		<-p.release
+	}
+	return "user-of-" + claims.Subject, nil
+}
+
+func TestUserProvisioningDeduplicatesConcurrentRequests(t *testing.T) {
+	provisioner := &fakeProvisioner{release: make(chan struct{})}
+	provisioning := newUserProvisioning(provisioner, time.Hour)
+	claims := &Claims{Subject: "google-oauth2|123", Email: "user@example.com"}
+
+	var wg sync.WaitGroup
+	userIDs := make([]string, 10)
+	for i := range userIDs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			userIDs[i], _ = provisioning.provision(context.Background(), claims)
+		}(i)
+	}
+
+	// Let every request reach the provisioning call before it completes
+	for provisioner.calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(provisioner.release)
+	wg.Wait()
+
+	if calls := provisioner.calls.Load(); calls != 1 {
+		t.Errorf("Expected concurrent first requests to share one call, got %d", calls)
+	}
+	for _, userID := range userIDs {
+		if userID != "user-of-google-oauth2|123" {
+			t.Errorf("Expected every request to get the provisioned user, got %q", userID)
+		}
+	}
+}
+
+func TestUserProvisioningRefreshesAfterInterval(t *testing.T) {
+	provisioner := &fakeProvisioner{}
+	provisioning := newUserProvisioning(provisioner, time.Minute)
+	now := time.Now()
+	provisioning.now = func() time.Time { return now }
+	claims := &Claims{Subject: "auth0|abc", Email: "user@example.com"}
+
+	provisioning.provision(context.Background(), claims)
+	provisioning.provision(context.Background(), claims)
+	if calls := provisioner.calls.Load(); calls != 1 {
+		t.Fatalf("Expected a recently provisioned user not to be provisioned again, got %d calls", calls)
+	}
+
+	now = now.Add(time.Minute)
+	provisioning.provision(context.Background(), claims)
+	if calls := provisioner.calls.Load(); calls != 2 {
+		t.Errorf("Expected the user to be refreshed after the interval, got %d calls", calls)
+	}
+}
+
+func TestHumaMiddlewareProvisionsFirstSeenUsers(t *testing.T) {
+	provisioner := &fakeProvisioner{}
+	middleware, err := NewAuthMiddlewareWithConfig(AuthConfig{
+		Mode:            config.AuthModeLocal,
+		JWTSecret:       testLocalSecret,
+		UserProvisioner: provisioner,
+	}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create middleware: %v", err)
+	}
+
+	_, api := humatest.New(t)
+	api.UseMiddleware(middleware.HumaMiddleware())
+	var userID string
+	huma.Register(api, huma.Operation{
+		OperationID: "me",
+		Method:      http.MethodGet,
+		Path:        "/me",
+	}, func(ctx context.Context, input *struct{}) (*struct{}, error) {
+		userID, _ = GetUserIDFromContext(ctx)
+		return nil, nil
+	})
+
+	issuer := newTestLocalIssuer(t)
+	get := func(opts TokenOptions) string {
+		token, _ := issuer.Mint(opts)
+		userID = ""
+		if resp := api.Get("/me", "Authorization: Bearer "+token); resp.Code != http.StatusNoContent {
+			t.Fatalf("Expected the request to succeed, got %d", resp.Code)
+		}
+		return userID
+	}
+
+	if got := get(TokenOptions{Subject: "google-oauth2|123", Email: "user@example.com"}); got != "user-of-google-oauth2|123" {
+		t.Errorf("Expected the provisioned user in context, got %q", got)
+	}
+	if got := get(TokenOptions{Subject: "google-oauth2|123", Email: "user@example.com"}); got != "user-of-google-oauth2|123" {
+		t.Errorf("Expected the provisioned user on later requests, got %q", got)
+	}
+	if calls := provisioner.calls.Load(); calls != 1 {
+		t.Errorf("Expected the user to be provisioned once, got %d calls", calls)
+	}
+
+	// Local subjects and tokens without an email are not provisioned
+	if got := get(TokenOptions{Subject: "local|dev", Email: "dev@example.com"}); got != "local|dev" {
+		t.Errorf("Expected the subject as user ID, got %q", got)
+	}
+	get(TokenOptions{Subject: "auth0|no-email"})
+	if calls := provisioner.calls.Load(); calls != 1 {
+		t.Errorf("Expected no further provisioning, got %d calls", calls)
+	}
+}
@@ -2,18 +2,35 @@ package auth
 
 import (
 	"fmt"
-	"net"
 	"net/http"
-	"strings"
 	"sync"
 	"time"
 
+	"github.com/danielgtaylor/huma/v2"
+
+	"bocchi/api/pkg/clientip"
 	"bocchi/api/pkg/errors"
 	"bocchi/api/pkg/logger"
 	"bocchi/api/pkg/monitoring"
+	"bocchi/api/pkg/ratelimit"
 )
 
-// RateLimiter provides rate limiting functionality for authentication endpoints
+// RateLimitIdentity identifies the caller of a Huma request for ratelimit policies.
+// It must run after HumaMiddleware so the authenticated user or API key is in the context;
+// only verified API keys get a quota of their own.
+func RateLimitIdentity(ctx huma.Context) ratelimit.Identity {
+	id := ratelimit.Identity{IP: ratelimit.ClientIP(ctx)}
+	if userID, ok := GetUserIDFromContext(ctx.Context()); ok {
+		id.UserID = userID
+	}
+	if keyID, ok := GetAPIKeyIDFromContext(ctx.Context()); ok {
+		id.APIKey = keyID
+	}
+	return id
+}
+
+// RateLimiter is a fixed-window, per-IP, in-process limiter. It predates the
+// policy-based limiter in package ratelimit, which the API applies to every route.
 type RateLimiter struct {
 	requests     map[string]*requestCounter
 	mutex        sync.RWMutex
@@ -161,49 +178,9 @@ func (rl *RateLimiter) Middleware() func(http.Handler) http.Handler {
 	}
 }
 
-// getClientIP extracts the real client IP from the request
+// getClientIP returns the client IP resolved behind trusted proxies
 func (rl *RateLimiter) getClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header (most common for proxies)
-	xff := r.Header.Get("X-Forwarded-For")
-	if xff != "" {
-		// X-Forwarded-For can contain multiple IPs, get the first one
-		ips := strings.Split(xff, ",")
-		if len(ips) > 0 {
-			ip := strings.TrimSpace(ips[0])
-			if net.ParseIP(ip) != nil {
-				return ip
-			}
-		}
-	}
-
-	// Check X-Real-IP header (used by nginx)
-	xri := r.Header.Get("X-Real-IP")
-	if xri != "" {
-		if net.ParseIP(xri) != nil {
-			return xri
-		}
-	}
-
-	// Check CF-Connecting-IP header (used by Cloudflare)
-	cfip := r.Header.Get("CF-Connecting-IP")
-	if cfip != "" {
-		if net.ParseIP(cfip) != nil {
-			return cfip
-		}
-	}
-
-	// Fall back to RemoteAddr
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		// If there's no port, the entire string might be an IP
-		if net.ParseIP(r.RemoteAddr) != nil {
-			return r.RemoteAddr
-		}
-		// Return a default IP if parsing fails
-		return "unknown"
-	}
-
-	return ip
+	return clientip.FromRequest(r)
 }
 
 // handleRateLimitExceeded handles rate limit exceeded responses
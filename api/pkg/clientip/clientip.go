@@ -0,0 +1,171 @@
+// Package clientip resolves the client IP of a request behind trusted proxies and applies
+// the IP allow and deny lists from the network configuration.
+//
+// Forwarded headers are honored only when the connection comes from a trusted proxy, so
+// clients cannot spoof their address. The resolved IP is shared with everything downstream
+// (rate limiting, auth logs, monitoring) through the request context and RemoteAddr.
+package clientip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"bocchi/api/pkg/config"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/logger"
+)
+
+type contextKey string
+
+const (
+	clientIPKey    contextKey = "client_ip"
+	allowlistedKey contextKey = "client_ip_allowlisted"
+)
+
+// Resolver resolves client IPs and classifies them against the allow and deny lists
+type Resolver struct {
+	trusted []*net.IPNet
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+}
+
+// NewResolver creates a resolver from the network configuration
+func NewResolver(cfg config.NetworkConfig) (*Resolver, error) {
+	trusted, err := config.ParseCIDRs(cfg.TrustedProxies)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeValidation, "invalid trusted proxies")
+	}
+	allow, err := config.ParseCIDRs(cfg.AllowList)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeValidation, "invalid IP allowlist")
+	}
+	deny, err := config.ParseCIDRs(cfg.DenyList)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeValidation, "invalid IP denylist")
+	}
+
+	return &Resolver{trusted: trusted, allow: allow, deny: deny}, nil
+}
+
+// Resolve returns the client IP of the request. Starting from the connection's address, it
+// walks X-Forwarded-For from the right while the hop is a trusted proxy; X-Real-IP is used
+// only when a trusted proxy sent no X-Forwarded-For.
+func (r *Resolver) Resolve(req *http.Request) string {
+	client := remoteIP(req.RemoteAddr)
+	if !r.IsTrustedProxy(client) {
+		return client
+	}
+
+	if forwarded := req.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				// A malformed hop cannot be trusted; stop at the last valid address
+				break
+			}
+			client = hop
+			if !r.IsTrustedProxy(hop) {
+				break
+			}
+		}
+		return client
+	}
+
+	if realIP := strings.TrimSpace(req.Header.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return client
+}
+
+// IsTrustedProxy reports whether ip is a trusted proxy
+func (r *Resolver) IsTrustedProxy(ip string) bool {
+	return contains(r.trusted, ip)
+}
+
+// IsAllowed reports whether ip is on the allowlist
+func (r *Resolver) IsAllowed(ip string) bool {
+	return contains(r.allow, ip)
+}
+
+// IsDenied reports whether ip is on the denylist
+func (r *Resolver) IsDenied(ip string) bool {
+	return contains(r.deny, ip)
+}
+
+// Middleware resolves the client IP once per request, rejects denylisted clients with 403
+// and stores the IP in the context and in RemoteAddr. It replaces chi's RealIP middleware,
+// which trusts forwarded headers from anyone.
+func (r *Resolver) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			ip := r.Resolve(req)
+
+			if r.IsDenied(ip) {
+				logger.InfoWithFields("Request from denylisted IP rejected", map[string]interface{}{
+					"ip":     ip,
+					"path":   req.URL.Path,
+					"method": req.Method,
+				})
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error":{"type":"FORBIDDEN","message":"access denied"}}`))
+				return
+			}
+
+			ctx := context.WithValue(req.Context(), clientIPKey, ip)
+			if r.IsAllowed(ip) {
+				ctx = context.WithValue(ctx, allowlistedKey, true)
+			}
+			req = req.WithContext(ctx)
+			req.RemoteAddr = ip
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// FromContext returns the client IP resolved by the middleware
+func FromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(clientIPKey).(string)
+	return ip, ok && ip != ""
+}
+
+// FromRequest returns the resolved client IP, falling back to the connection's address
+// when the middleware did not run
+func FromRequest(req *http.Request) string {
+	if ip, ok := FromContext(req.Context()); ok {
+		return ip
+	}
+	return remoteIP(req.RemoteAddr)
+}
+
+// IsAllowlisted reports whether the middleware found the client on the allowlist
+func IsAllowlisted(ctx context.Context) bool {
+	allowed, _ := ctx.Value(allowlistedKey).(bool)
+	return allowed
+}
+
+// remoteIP strips the port from a connection address
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+func contains(networks []*net.IPNet, value string) bool {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
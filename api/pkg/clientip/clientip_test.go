@@ -0,0 +1,111 @@
+package clientip
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"bocchi/api/pkg/config"
+)
+
+func newTestResolver(t *testing.T, cfg config.NetworkConfig) *Resolver {
+	t.Helper()
+	resolver, err := NewResolver(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+	return resolver
+}
+
+func TestResolveHonorsForwardedHeadersOnlyFromTrustedProxies(t *testing.T) {
+	resolver := newTestResolver(t, config.NetworkConfig{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"direct client ignores spoofed header", "203.0.113.7:5000", "198.51.100.1", "", "203.0.113.7"},
+		{"trusted proxy forwards client", "10.0.0.2:5000", "198.51.100.1", "", "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.2:5000", "198.51.100.1, 192.168.1.1, 10.1.1.1", "", "198.51.100.1"},
+		{"spoofed left-most hop is skipped", "10.0.0.2:5000", "1.2.3.4, 198.51.100.1", "", "198.51.100.1"},
+		{"all hops trusted uses left-most", "10.0.0.2:5000", "10.3.3.3, 10.1.1.1", "", "10.3.3.3"},
+		{"malformed hop stops the walk", "10.0.0.2:5000", "198.51.100.1, garbage", "", "10.0.0.2"},
+		{"real IP from trusted proxy", "10.0.0.2:5000", "", "198.51.100.9", "198.51.100.9"},
+		{"real IP from untrusted client", "203.0.113.7:5000", "", "198.51.100.9", "203.0.113.7"},
+		{"IPv6 client", "[2001:db8::1]:5000", "", "", "2001:db8::1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			if got := resolver.Resolve(req); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMiddlewareAppliesAllowAndDenyLists(t *testing.T) {
+	resolver := newTestResolver(t, config.NetworkConfig{
+		TrustedProxies: []string{"10.0.0.1"},
+		AllowList:      []string{"172.16.0.0/12"},
+		DenyList:       []string{"198.51.100.0/24"},
+	})
+
+	var gotIP, gotRemoteAddr string
+	var allowlisted bool
+	handler := resolver.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotIP, _ = FromContext(r.Context())
+		gotRemoteAddr = r.RemoteAddr
+		allowlisted = IsAllowlisted(r.Context())
+	}))
+
+	serve := func(remoteAddr, forwarded string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if forwarded != "" {
+			req.Header.Set("X-Forwarded-For", forwarded)
+		}
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		return resp
+	}
+
+	if resp := serve("10.0.0.1:443", "198.51.100.20"); resp.Code != http.StatusForbidden {
+		t.Errorf("Expected a denylisted client behind a trusted proxy to get 403, got %d", resp.Code)
+	}
+
+	if resp := serve("10.0.0.1:443", "172.16.5.5"); resp.Code != http.StatusOK || !allowlisted {
+		t.Errorf("Expected the allowlisted client to pass and be marked, got %d (allowlisted=%v)", resp.Code, allowlisted)
+	}
+	if gotIP != "172.16.5.5" || gotRemoteAddr != "172.16.5.5" {
+		t.Errorf("Expected the resolved IP in context and RemoteAddr, got %s and %s", gotIP, gotRemoteAddr)
+	}
+
+	// An untrusted client cannot claim an allowlisted address
+	if resp := serve("203.0.113.7:5000", "172.16.5.5"); resp.Code != http.StatusOK || allowlisted {
+		t.Errorf("Expected a spoofed allowlisted address to be ignored, got %d (allowlisted=%v)", resp.Code, allowlisted)
+	}
+}
+
+func TestNewResolverRejectsInvalidNetworks(t *testing.T) {
+	for _, cfg := range []config.NetworkConfig{
+		{TrustedProxies: []string{"not-an-ip"}},
+		{AllowList: []string{"10.0.0.0/33"}},
+		{DenyList: []string{"300.1.1.1"}},
+	} {
+		if _, err := NewResolver(cfg); err == nil {
+			t.Errorf("Expected %+v to be rejected", cfg)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 // Config holds the application configuration
@@ -16,6 +18,9 @@ type Config struct {
 	Monitoring MonitoringConfig
 	App        AppConfig
 	Auth       AuthConfig
+	Services   ServicesConfig
+	Network    NetworkConfig
+	Users      UsersConfig
 }
 
 // ServerConfig holds server-related configuration
@@ -46,13 +51,54 @@ type AppConfig struct {
 	Version     string
 }
 
+// Authentication modes selected with AUTH_MODE
+const (
+	// AuthModeAuth0 validates Auth0-issued tokens via OIDC discovery
+	AuthModeAuth0 = "auth0"
+	// AuthModeLocal validates HS256 tokens signed with JWT_SECRET, for offline development and tests
+	AuthModeLocal = "local"
+)
+
 // AuthConfig holds authentication-related configuration
 type AuthConfig struct {
+	Mode            string
 	JWTSecret       string
 	Auth0Domain     string
 	Auth0Audience   string
 	Auth0ClientID   string
 	Auth0ClientSecret string
+	// ProvisionUsers creates or refreshes the user record of a sign-in identity on its
+	// first authenticated request (AUTH_PROVISION_USERS, enabled by default)
+	ProvisionUsers bool
+	// RequireVerifiedEmail stops users with an unverified email from creating spots, reviews
+	// and ratings (AUTH_REQUIRE_VERIFIED_EMAIL, disabled by default)
+	RequireVerifiedEmail bool
+}
+
+// ServicesConfig holds the gRPC address of each service.
+// "internal" calls the service in-process instead of over the network.
+type ServicesConfig struct {
+	SpotAddr   string
+	UserAddr   string
+	ReviewAddr string
+	RatingAddr string
+}
+
+// NetworkConfig holds client IP resolution settings as CIDRs; bare IPs are single-host CIDRs
+type NetworkConfig struct {
+	// TrustedProxies are the hops whose X-Forwarded-For and X-Real-IP headers are honored
+	TrustedProxies []string
+	// AllowList clients, e.g. internal monitoring, are exempt from rate limits
+	AllowList []string
+	// DenyList clients are rejected with 403 before any other processing
+	DenyList []string
+}
+
+// UsersConfig holds user account settings
+type UsersConfig struct {
+	// DeletionGraceDays is how long a deleted account can be restored before it is
+	// purged (USER_DELETION_GRACE_DAYS, 30 by default)
+	DeletionGraceDays int
 }
 
 // Load loads configuration from environment variables
@@ -79,11 +125,28 @@ func Load() (*Config, error) {
 			Version:     getEnvWithDefault("APP_VERSION", "1.0.0"),
 		},
 		Auth: AuthConfig{
+			Mode:            getEnvWithDefault("AUTH_MODE", AuthModeAuth0),
 			JWTSecret:       os.Getenv("JWT_SECRET"),
 			Auth0Domain:     os.Getenv("AUTH0_DOMAIN"),
 			Auth0Audience:   os.Getenv("AUTH0_AUDIENCE"),
 			Auth0ClientID:   os.Getenv("AUTH0_CLIENT_ID"),
 			Auth0ClientSecret: os.Getenv("AUTH0_CLIENT_SECRET"),
+			ProvisionUsers:    getBoolEnvWithDefault("AUTH_PROVISION_USERS", true),
+			RequireVerifiedEmail: getBoolEnvWithDefault("AUTH_REQUIRE_VERIFIED_EMAIL", false),
+		},
+		Services: ServicesConfig{
+			SpotAddr:   getEnvWithDefault("SPOT_SERVICE_ADDR", "internal"),
+			UserAddr:   getEnvWithDefault("USER_SERVICE_ADDR", "internal"),
+			ReviewAddr: getEnvWithDefault("REVIEW_SERVICE_ADDR", "internal"),
+			RatingAddr: getEnvWithDefault("RATING_SERVICE_ADDR", "internal"),
+		},
+		Network: NetworkConfig{
+			TrustedProxies: getListEnv("TRUSTED_PROXIES"),
+			AllowList:      getListEnv("IP_ALLOWLIST"),
+			DenyList:       getListEnv("IP_DENYLIST"),
+		},
+		Users: UsersConfig{
+			DeletionGraceDays: getIntEnvWithDefault("USER_DELETION_GRACE_DAYS", 30),
 		},
 	}
 
@@ -103,12 +166,54 @@ func (c *Config) Validate() error {
 	if err := c.validateJWTSecret(); err != nil {
 		return err
 	}
+	if err := c.validateAuthMode(); err != nil {
+		return err
+	}
+	if err := c.validateNetwork(); err != nil {
+		return err
+	}
+	if c.Users.DeletionGraceDays < 0 {
+		return errors.New("USER_DELETION_GRACE_DAYS must not be negative")
+	}
+	if c.Auth.IsLocalMode() {
+		return nil
+	}
 	if err := c.validateAuth0Config(); err != nil {
 		return err
 	}
 	return nil
 }
 
+// validateAuthMode validates AUTH_MODE and keeps local tokens out of production
+func (c *Config) validateAuthMode() error {
+	switch c.Auth.Mode {
+	case "", AuthModeAuth0:
+		return nil
+	case AuthModeLocal:
+		if c.App.Environment == "production" || c.App.Environment == "prod" {
+			return errors.New("AUTH_MODE=local is not allowed in production environment")
+		}
+		return nil
+	default:
+		return fmt.Errorf("AUTH_MODE must be %q or %q", AuthModeAuth0, AuthModeLocal)
+	}
+}
+
+// validateNetwork validates the CIDR lists
+func (c *Config) validateNetwork() error {
+	lists := map[string][]string{
+		"TRUSTED_PROXIES": c.Network.TrustedProxies,
+		"IP_ALLOWLIST":    c.Network.AllowList,
+		"IP_DENYLIST":     c.Network.DenyList,
+	}
+	for name, values := range lists {
+		if _, err := ParseCIDRs(values); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
 // validateJWTSecret validates the JWT secret with environment-specific requirements
 func (c *Config) validateJWTSecret() error {
 	secret := c.Auth.JWTSecret
@@ -197,11 +302,42 @@ func (c *AuthConfig) GetAuth0JWKSURL() string {
 	return fmt.Sprintf("https://%s/.well-known/jwks.json", c.Auth0Domain)
 }
 
+// IsLocalMode returns true if tokens are signed locally with JWT_SECRET instead of by Auth0
+func (c *AuthConfig) IsLocalMode() bool {
+	return c.Mode == AuthModeLocal
+}
+
 // IsAuth0Configured returns true if Auth0 configuration is present
 func (c *AuthConfig) IsAuth0Configured() bool {
 	return c.Auth0Domain != "" && c.Auth0ClientID != "" && c.Auth0Audience != ""
 }
 
+// ParseCIDRs parses CIDRs, treating a bare IP as a single-host network
+func ParseCIDRs(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %q", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
 // GetDSN returns the database connection string
 func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true&loc=Local",
@@ -227,4 +363,26 @@ func getIntEnvWithDefault(key string, defaultValue int) int {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+// getBoolEnvWithDefault gets a boolean environment variable with a default value
+func getBoolEnvWithDefault(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+		fmt.Fprintf(os.Stderr, "Warning: Invalid boolean value for %s: %s, using default %t\n", key, value, defaultValue)
+	}
+	return defaultValue
+}
+
+// getListEnv gets a comma-separated environment variable, dropping empty items
+func getListEnv(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
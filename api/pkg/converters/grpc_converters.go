@@ -9,13 +9,14 @@ import (
 	"bocchi/api/gen/user/v1"
 	"bocchi/api/infrastructure/database"
 	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/preferences"
 )
 
 // Default user preference values
 const (
-	DefaultLanguage = "ja"
-	DefaultTimezone = "Asia/Tokyo"
-	DefaultDarkMode = false
+	DefaultLanguage = preferences.DefaultLanguage
+	DefaultTimezone = preferences.DefaultTimezone
+	DefaultDarkMode = preferences.DefaultDarkMode
 )
 
 // Use Protocol Buffers generated types
@@ -72,20 +73,8 @@ func (c *GRPCConverter) ConvertGRPCToEntity(grpcUser *GRPCUser) (*entities.User,
 
 // ConvertDatabaseToGRPC converts database type directly to gRPC type
 func (c *GRPCConverter) ConvertDatabaseToGRPC(dbUser database.User) (*GRPCUser, error) {
-	// Convert preferences to JSON string for protobuf
-	var preferencesJSON string
-	if len(dbUser.Preferences) > 0 {
-		preferencesJSON = string(dbUser.Preferences)
-	} else {
-		// Default preferences
-		defaultPrefs := map[string]interface{}{
-			"language": DefaultLanguage,
-			"darkMode": DefaultDarkMode,
-			"timezone": DefaultTimezone,
-		}
-		prefsBytes, _ := json.Marshal(defaultPrefs)
-		preferencesJSON = string(prefsBytes)
-	}
+	// Convert preferences to JSON string for protobuf, migrated to the current schema
+	preferencesJSON := string(preferences.Normalize(dbUser.Preferences))
 
 	// Get display name
 	var displayName string
@@ -137,27 +126,11 @@ func (c *GRPCConverter) ConvertEntityAuthProviderToGRPC(provider entities.AuthPr
 	}
 }
 
-// ConvertGRPCPreferencesToEntity converts gRPC preferences JSON string to domain entity
+// ConvertGRPCPreferencesToEntity converts gRPC preferences JSON string to domain entity.
+// Documents of earlier versions are migrated, and missing or invalid values take their
+// defaults.
 func (c *GRPCConverter) ConvertGRPCPreferencesToEntity(prefsJSON string) entities.UserPreferences {
-	if prefsJSON == "" {
-		return entities.UserPreferences{
-			Language: DefaultLanguage,
-			DarkMode: DefaultDarkMode,
-			Timezone: DefaultTimezone,
-		}
-	}
-
-	var prefs entities.UserPreferences
-	if err := json.Unmarshal([]byte(prefsJSON), &prefs); err != nil {
-		// Return defaults if parsing fails
-		return entities.UserPreferences{
-			Language: DefaultLanguage,
-			DarkMode: DefaultDarkMode,
-			Timezone: DefaultTimezone,
-		}
-	}
-
-	return prefs
+	return preferences.Decode([]byte(prefsJSON))
 }
 
 // ConvertEntityPreferencesToGRPC converts domain entity preferences to gRPC JSON string
@@ -22,6 +22,9 @@ const (
 	ErrTypeUnauthorized ErrorType = "UNAUTHORIZED"
 	ErrTypeForbidden    ErrorType = "FORBIDDEN"
 	ErrTypeConflict     ErrorType = "CONFLICT"
+	// ErrTypeEmailNotVerified rejects writes by users who have not verified their email,
+	// so clients can prompt for verification instead of showing a generic 403
+	ErrTypeEmailNotVerified ErrorType = "EMAIL_NOT_VERIFIED"
 
 	// Server errors
 	ErrTypeInternal      ErrorType = "INTERNAL"
@@ -132,6 +135,11 @@ func Conflict(resource, reason string) *DomainError {
 		WithField("reason", reason)
 }
 
+func EmailNotVerified(action string) *DomainError {
+	return New(ErrTypeEmailNotVerified, fmt.Sprintf("a verified email address is required to %s", action)).
+		WithField("action", action)
+}
+
 func Internal(message string) *DomainError {
 	return New(ErrTypeInternal, message)
 }
@@ -155,7 +163,7 @@ func (e *DomainError) ToHTTPStatus() int {
 		return http.StatusBadRequest
 	case ErrTypeUnauthorized:
 		return http.StatusUnauthorized
-	case ErrTypeForbidden:
+	case ErrTypeForbidden, ErrTypeEmailNotVerified:
 		return http.StatusForbidden
 	case ErrTypeConflict:
 		return http.StatusConflict
@@ -180,7 +188,7 @@ func (e *DomainError) ToGRPCStatus() *status.Status {
 		code = codes.InvalidArgument
 	case ErrTypeUnauthorized:
 		code = codes.Unauthenticated
-	case ErrTypeForbidden:
+	case ErrTypeForbidden, ErrTypeEmailNotVerified:
 		code = codes.PermissionDenied
 	case ErrTypeConflict:
 		code = codes.AlreadyExists
@@ -98,6 +98,7 @@ func HandleHTTPError(ctx context.Context, err error, operation, fallbackMessage
 		Is(err, ErrTypeInvalidInput) ||
 		Is(err, ErrTypeUnauthorized) ||
 		Is(err, ErrTypeForbidden) ||
+		Is(err, ErrTypeEmailNotVerified) ||
 		Is(err, ErrTypeConflict) {
 		return ToHumaError(ctx, err, operation)
 	}
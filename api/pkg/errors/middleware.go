@@ -18,6 +18,8 @@ const (
 	UserIDKey ContextKey = "user_id"
 	// OperationKey is the context key for operation name
 	OperationKey ContextKey = "operation"
+	// PermissionsKey is the context key for the authenticated user's permissions
+	PermissionsKey ContextKey = "permissions"
 )
 
 // isSensitiveField checks if a field contains sensitive information
@@ -126,6 +128,11 @@ func WithUserID(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, UserIDKey, userID)
 }
 
+// WithPermissions adds the authenticated user's permissions to context
+func WithPermissions(ctx context.Context, permissions []string) context.Context {
+	return context.WithValue(ctx, PermissionsKey, permissions)
+}
+
 // WithOperation adds operation name to context
 func WithOperation(ctx context.Context, operation string) context.Context {
 	return context.WithValue(ctx, OperationKey, operation)
@@ -151,6 +158,24 @@ func GetUserID(ctx context.Context) string {
 	return ""
 }
 
+// GetPermissions extracts the authenticated user's permissions from context
+func GetPermissions(ctx context.Context) []string {
+	if permissions, ok := ctx.Value(PermissionsKey).([]string); ok {
+		return permissions
+	}
+	return nil
+}
+
+// HasPermission checks if the permissions in context include the given permission
+func HasPermission(ctx context.Context, permission string) bool {
+	for _, p := range GetPermissions(ctx) {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
 // GetOperation extracts operation name from context
 func GetOperation(ctx context.Context) string {
 	if operation := ctx.Value(OperationKey); operation != nil {
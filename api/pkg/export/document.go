@@ -0,0 +1,183 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"bocchi/api/infrastructure/database"
+)
+
+// documentFilename is the name of the JSON document inside ZIP archives
+const documentFilename = "export.json"
+
+// Document is the JSON layout of an export archive
+type Document struct {
+	FormatVersion int              `json:"format_version"`
+	GeneratedAt   time.Time        `json:"generated_at"`
+	User          UserData         `json:"user"`
+	Preferences   json.RawMessage  `json:"preferences"`
+	Identities    []IdentityData   `json:"identities"`
+	Reviews       []ReviewData     `json:"reviews"`
+	SoloRatings   []SoloRatingData `json:"solo_ratings"`
+}
+
+// UserData is the users row of the exported user
+type UserData struct {
+	ID            string    `json:"id"`
+	Email         string    `json:"email"`
+	Name          string    `json:"name,omitempty"`
+	Nickname      string    `json:"nickname,omitempty"`
+	Picture       string    `json:"picture,omitempty"`
+	Provider      string    `json:"provider"`
+	ProviderID    string    `json:"provider_id"`
+	EmailVerified bool      `json:"email_verified"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// IdentityData is a sign-in identity linked to the user
+type IdentityData struct {
+	Provider      string     `json:"provider"`
+	ProviderID    string     `json:"provider_id"`
+	Email         string     `json:"email,omitempty"`
+	EmailVerified bool       `json:"email_verified"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ReviewData is a review written by the user
+type ReviewData struct {
+	ID            string          `json:"id"`
+	SpotID        string          `json:"spot_id"`
+	SpotName      string          `json:"spot_name"`
+	ReviewerName  string          `json:"reviewer_name"`
+	Rating        int32           `json:"rating"`
+	Comment       string          `json:"comment,omitempty"`
+	RatingAspects json.RawMessage `json:"rating_aspects,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// SoloRatingData is a solo-friendliness rating given by the user
+type SoloRatingData struct {
+	ID                 string          `json:"id"`
+	SpotID             string          `json:"spot_id"`
+	SoloFriendlyRating int32           `json:"solo_friendly_rating"`
+	Categories         json.RawMessage `json:"categories,omitempty"`
+	Comment            string          `json:"comment,omitempty"`
+	CreatedAt          time.Time       `json:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at"`
+}
+
+// newDocument assembles the export document from database rows
+func newDocument(now time.Time, user database.User, identities []database.UserIdentity, reviews []database.ListReviewsByUserRow, ratings []database.SoloRating) *Document {
+	doc := &Document{
+		FormatVersion: FormatVersion,
+		GeneratedAt:   now.UTC(),
+		User: UserData{
+			ID:            user.ID,
+			Email:         user.Email,
+			Name:          user.Name.String,
+			Nickname:      user.Nickname.String,
+			Picture:       user.Picture.String,
+			Provider:      user.Provider,
+			ProviderID:    user.ProviderID,
+			EmailVerified: user.EmailVerified,
+			CreatedAt:     user.CreatedAt,
+			UpdatedAt:     user.UpdatedAt,
+		},
+		Preferences: decodePreferences(user.Preferences),
+		Identities:  make([]IdentityData, 0, len(identities)),
+		Reviews:     make([]ReviewData, 0, len(reviews)),
+		SoloRatings: make([]SoloRatingData, 0, len(ratings)),
+	}
+
+	for _, identity := range identities {
+		data := IdentityData{
+			Provider:      identity.Provider,
+			ProviderID:    identity.ProviderID,
+			Email:         identity.Email.String,
+			EmailVerified: identity.EmailVerified,
+			CreatedAt:     identity.CreatedAt,
+		}
+		if identity.LastUsedAt.Valid {
+			lastUsedAt := identity.LastUsedAt.Time
+			data.LastUsedAt = &lastUsedAt
+		}
+		doc.Identities = append(doc.Identities, data)
+	}
+	for _, review := range reviews {
+		doc.Reviews = append(doc.Reviews, ReviewData{
+			ID:            review.ID,
+			SpotID:        review.SpotID,
+			SpotName:      review.SpotName,
+			ReviewerName:  review.ReviewerName,
+			Rating:        review.Rating,
+			Comment:       review.Comment.String,
+			RatingAspects: validJSON(review.RatingAspects),
+			CreatedAt:     review.CreatedAt,
+			UpdatedAt:     review.UpdatedAt,
+		})
+	}
+	for _, rating := range ratings {
+		doc.SoloRatings = append(doc.SoloRatings, SoloRatingData{
+			ID:                 rating.ID,
+			SpotID:             rating.SpotID,
+			SoloFriendlyRating: rating.SoloFriendlyRating,
+			Categories:         validJSON(rating.Categories),
+			Comment:            rating.Comment.String,
+			CreatedAt:          rating.CreatedAt,
+			UpdatedAt:          rating.UpdatedAt,
+		})
+	}
+	return doc
+}
+
+// decodePreferences returns the stored preferences as a JSON object. Preferences that
+// are missing or not valid JSON are exported as an empty object.
+func decodePreferences(raw json.RawMessage) json.RawMessage {
+	var preferences map[string]interface{}
+	if len(raw) == 0 || json.Unmarshal(raw, &preferences) != nil || preferences == nil {
+		return json.RawMessage("{}")
+	}
+	return raw
+}
+
+// validJSON drops values that would make the document invalid JSON
+func validJSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 || !json.Valid(raw) {
+		return nil
+	}
+	return raw
+}
+
+// encodeDocument encodes doc as indented JSON, wrapped in a ZIP file for FormatZIP
+func encodeDocument(doc *Document, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if format != FormatZIP {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	file, err := writer.CreateHeader(&zip.FileHeader{
+		Name:     documentFilename,
+		Method:   zip.Deflate,
+		Modified: doc.GeneratedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
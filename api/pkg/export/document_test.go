@@ -0,0 +1,107 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"bocchi/api/infrastructure/database"
+)
+
+func testDocument() *Document {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	user := database.User{
+		ID:          "local|user-1",
+		Email:       "user@example.com",
+		Name:        sql.NullString{String: "Hitori", Valid: true},
+		Provider:    "local",
+		ProviderID:  "user-1",
+		Preferences: json.RawMessage(`{"language":"ja","dark_mode":true}`),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	reviews := []database.ListReviewsByUserRow{{
+		ID:            "review-1",
+		SpotID:        "spot-1",
+		SpotName:      "Solo Cafe",
+		Rating:        4,
+		Comment:       sql.NullString{String: "Quiet", Valid: true},
+		RatingAspects: json.RawMessage(`{"quietness":5}`),
+	}}
+	ratings := []database.SoloRating{{
+		ID:                 "rating-1",
+		SpotID:             "spot-1",
+		SoloFriendlyRating: 5,
+		Categories:         json.RawMessage(`not json`),
+	}}
+	return newDocument(now, user, nil, reviews, ratings)
+}
+
+func TestEncodeDocumentJSON(t *testing.T) {
+	data, err := encodeDocument(testDocument(), FormatJSON)
+	if err != nil {
+		t.Fatalf("encodeDocument failed: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Expected a JSON document, got %v", err)
+	}
+	if decoded["format_version"] != float64(FormatVersion) {
+		t.Errorf("Expected format_version %d, got %v", FormatVersion, decoded["format_version"])
+	}
+	if preferences, _ := decoded["preferences"].(map[string]interface{}); preferences["language"] != "ja" {
+		t.Errorf("Expected the decoded preferences, got %v", decoded["preferences"])
+	}
+	if identities, ok := decoded["identities"].([]interface{}); !ok || len(identities) != 0 {
+		t.Errorf("Expected an empty identities list, got %v", decoded["identities"])
+	}
+	ratings, _ := decoded["solo_ratings"].([]interface{})
+	if len(ratings) != 1 {
+		t.Fatalf("Expected one solo rating, got %v", decoded["solo_ratings"])
+	}
+	if _, ok := ratings[0].(map[string]interface{})["categories"]; ok {
+		t.Error("Expected invalid stored JSON to be dropped")
+	}
+}
+
+func TestEncodeDocumentZIP(t *testing.T) {
+	data, err := encodeDocument(testDocument(), FormatZIP)
+	if err != nil {
+		t.Fatalf("encodeDocument failed: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Expected a ZIP archive, got %v", err)
+	}
+	if len(reader.File) != 1 || reader.File[0].Name != documentFilename {
+		t.Fatalf("Expected a single %s, got %d files", documentFilename, len(reader.File))
+	}
+	file, err := reader.File[0].Open()
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", documentFilename, err)
+	}
+	defer file.Close()
+	contents, _ := io.ReadAll(file)
+
+	var doc Document
+	if err := json.Unmarshal(contents, &doc); err != nil {
+		t.Fatalf("Expected the ZIP to contain the JSON document, got %v", err)
+	}
+	if doc.User.Name != "Hitori" || len(doc.Reviews) != 1 || doc.Reviews[0].SpotName != "Solo Cafe" {
+		t.Errorf("Unexpected document contents: %+v", doc)
+	}
+}
+
+func TestDecodePreferencesFallsBackToEmptyObject(t *testing.T) {
+	for _, raw := range []string{"", "null", "not json", `["list"]`} {
+		if got := string(decodePreferences(json.RawMessage(raw))); got != "{}" {
+			t.Errorf("decodePreferences(%q) = %s, want {}", raw, got)
+		}
+	}
+}
@@ -0,0 +1,405 @@
+// Package export assembles personal data exports for users.
+//
+// A user requests an export, which is stored as pending. A background job builds the
+// archive (a versioned JSON document, optionally wrapped in a ZIP file) and stores it
+// with the export. The archive is then fetched with a download token that is returned
+// once, when the export is requested, and stops working when the export expires.
+package export
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	stdErrors "errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bocchi/api/infrastructure/database"
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/logger"
+)
+
+// Archive formats
+const (
+	FormatJSON = "json"
+	FormatZIP  = "zip"
+)
+
+// Export statuses
+const (
+	StatusPending    = "pending"
+	StatusProcessing = "processing"
+	StatusReady      = "ready"
+	StatusFailed     = "failed"
+)
+
+// FormatVersion is the version of the archive layout; bump it when fields change meaning
+const FormatVersion = 1
+
+// DefaultDownloadTTL is how long a finished export can be downloaded
+const DefaultDownloadTTL = 24 * time.Hour
+
+const (
+	// batchSize is the number of pending exports built per run
+	batchSize = 10
+	// reviewPageSize is the page size used to read a user's reviews
+	reviewPageSize = 100
+	// tokenBytes is the entropy of a download token
+	tokenBytes = 32
+	// maxErrorLength matches the user_exports.error column
+	maxErrorLength = 500
+	// claimTimeout is how long a claimed export is left to the run that claimed it before
+	// another run takes it over; it is well above the export job's timeout
+	claimTimeout = 15 * time.Minute
+)
+
+// Export is a requested personal data export, without its archive
+type Export struct {
+	ID          string
+	UserID      string
+	Format      string
+	Status      string
+	SizeBytes   int64
+	Error       string
+	CreatedAt   time.Time
+	CompletedAt time.Time
+	ExpiresAt   time.Time
+}
+
+// Archive is a downloadable export archive
+type Archive struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Service requests, builds and serves personal data exports
+type Service struct {
+	db          *sql.DB
+	queries     *database.Queries
+	downloadTTL time.Duration
+	now         func() time.Time
+}
+
+// NewService creates an export service; finished exports can be downloaded for
+// downloadTTL, or DefaultDownloadTTL when it is zero
+func NewService(db *sql.DB, downloadTTL time.Duration) *Service {
+	if downloadTTL <= 0 {
+		downloadTTL = DefaultDownloadTTL
+	}
+	return &Service{
+		db:          db,
+		queries:     database.New(db),
+		downloadTTL: downloadTTL,
+		now:         time.Now,
+	}
+}
+
+// Request records a pending export of the user's data in format and returns it with
+// its download token. The token is not stored and cannot be retrieved again. A user
+// can only have one export in progress at a time.
+func (s *Service) Request(ctx context.Context, userID, format string) (*Export, string, error) {
+	if format == "" {
+		format = FormatJSON
+	}
+	if format != FormatJSON && format != FormatZIP {
+		return nil, "", errors.InvalidInput("format", "format must be json or zip")
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, "", errors.Wrap(err, errors.ErrTypeInternal, "failed to generate download token")
+	}
+
+	// The user's row lock serializes concurrent requests, so the active export check
+	// cannot pass twice before either export is inserted
+	id := uuid.New().String()
+	err = s.withTx(ctx, func(q *database.Queries) error {
+		if _, err := q.LockUserForUpdate(ctx, userID); err != nil {
+			if stdErrors.Is(err, sql.ErrNoRows) {
+				return errors.NotFound("user", userID)
+			}
+			return errors.Database("lock user", err)
+		}
+		// A deleted account waiting to be purged cannot request exports
+		deleted, err := q.IsUserDeleted(ctx, userID)
+		if err != nil {
+			return errors.Database("check deleted user", err)
+		}
+		if deleted {
+			return errors.New(errors.ErrTypeForbidden, "the account is deleted; restore it to request exports")
+		}
+
+		active, err := q.CountActiveUserExports(ctx, userID)
+		if err != nil {
+			return errors.Database("count active exports", err)
+		}
+		if active > 0 {
+			return errors.Conflict("export", "an export is already in progress")
+		}
+
+		if err := q.CreateUserExport(ctx, database.CreateUserExportParams{
+			ID:                id,
+			UserID:            userID,
+			Format:            format,
+			DownloadTokenHash: hashToken(token),
+		}); err != nil {
+			return errors.Database("create export", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	logger.InfoWithFields("Personal data export requested", map[string]interface{}{
+		"export_id": id,
+		"user_id":   userID,
+		"format":    format,
+	})
+
+	export, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, "", err
+	}
+	return export, token, nil
+}
+
+// Get returns one of the user's exports
+func (s *Service) Get(ctx context.Context, userID, id string) (*Export, error) {
+	row, err := s.queries.GetUserExport(ctx, id)
+	if stdErrors.Is(err, sql.ErrNoRows) || (err == nil && row.UserID != userID) {
+		return nil, errors.NotFound("export", id)
+	}
+	if err != nil {
+		return nil, errors.Database("get export", err)
+	}
+	return toExport(row), nil
+}
+
+// Download returns the archive of a finished export. token must be the download token
+// returned when the export was requested.
+func (s *Service) Download(ctx context.Context, id, token string) (*Archive, error) {
+	row, err := s.queries.GetUserExport(ctx, id)
+	if stdErrors.Is(err, sql.ErrNoRows) {
+		return nil, errors.NotFound("export", id)
+	}
+	if err != nil {
+		return nil, errors.Database("get export", err)
+	}
+	// Unknown, expired and mismatched exports look the same to the caller
+	if subtle.ConstantTimeCompare([]byte(hashToken(token)), []byte(row.DownloadTokenHash)) != 1 ||
+		(row.ExpiresAt.Valid && !s.now().Before(row.ExpiresAt.Time)) {
+		return nil, errors.NotFound("export", id)
+	}
+	if row.Status != StatusReady {
+		return nil, errors.Conflict("export", "the export is "+row.Status)
+	}
+
+	archive := &Archive{
+		Filename:    "bocchi-export-" + row.ID + ".json",
+		ContentType: "application/json",
+		Data:        row.Archive,
+	}
+	if row.Format == FormatZIP {
+		archive.Filename = "bocchi-export-" + row.ID + ".zip"
+		archive.ContentType = "application/zip"
+	}
+	return archive, nil
+}
+
+// ProcessPending deletes expired exports and builds the archives of pending ones. Each
+// export is claimed before it is built, so concurrent runs never build the same export;
+// an export whose claim is older than claimTimeout is taken over.
+func (s *Service) ProcessPending(ctx context.Context) error {
+	if _, err := s.queries.DeleteExpiredUserExports(ctx, sql.NullTime{Time: s.now(), Valid: true}); err != nil {
+		return errors.Database("delete expired exports", err)
+	}
+
+	staleBefore := sql.NullTime{Time: s.now().Add(-claimTimeout), Valid: true}
+	pending, err := s.queries.ListPendingUserExports(ctx, database.ListPendingUserExportsParams{
+		StaleBefore: staleBefore,
+		Limit:       batchSize,
+	})
+	if err != nil {
+		return errors.Database("list pending exports", err)
+	}
+
+	for _, export := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// claimed_at has one-second resolution and identifies the claim when the export is
+		// stored, so it is truncated to match the stored value
+		claimedAt := sql.NullTime{Time: s.now().Truncate(time.Second), Valid: true}
+		claimed, err := s.queries.ClaimUserExport(ctx, database.ClaimUserExportParams{
+			ClaimedAt:   claimedAt,
+			ID:          export.ID,
+			StaleBefore: staleBefore,
+		})
+		if err != nil {
+			return errors.Database("claim export", err)
+		}
+		if claimed == 0 {
+			// Another run claimed the export after it was listed
+			continue
+		}
+		if err := s.process(ctx, export, claimedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// process builds the archive of one export and stores it, or records why it failed. The
+// outcome is only stored while the export is still held by the claim made at claimedAt.
+func (s *Service) process(ctx context.Context, export database.ListPendingUserExportsRow, claimedAt sql.NullTime) error {
+
+	data, err := s.buildArchive(ctx, export.UserID, export.Format)
+	if err != nil {
+		logger.ErrorWithFields("Failed to build personal data export", err, map[string]interface{}{
+			"export_id": export.ID,
+			"user_id":   export.UserID,
+		})
+		message := err.Error()
+		if len(message) > maxErrorLength {
+			message = message[:maxErrorLength]
+		}
+		completedAt := s.now()
+		failed, err := s.queries.FailUserExport(ctx, database.FailUserExportParams{
+			Error:       sql.NullString{String: message, Valid: true},
+			CompletedAt: sql.NullTime{Time: completedAt, Valid: true},
+			ExpiresAt:   sql.NullTime{Time: completedAt.Add(s.downloadTTL), Valid: true},
+			ID:          export.ID,
+			ClaimedAt:   claimedAt,
+		})
+		if err != nil {
+			return errors.Database("fail export", err)
+		}
+		if failed == 0 {
+			logClaimLost(export)
+		}
+		return nil
+	}
+
+	completedAt := s.now()
+	completed, err := s.queries.CompleteUserExport(ctx, database.CompleteUserExportParams{
+		Archive:     data,
+		SizeBytes:   int64(len(data)),
+		CompletedAt: sql.NullTime{Time: completedAt, Valid: true},
+		ExpiresAt:   sql.NullTime{Time: completedAt.Add(s.downloadTTL), Valid: true},
+		ID:          export.ID,
+		ClaimedAt:   claimedAt,
+	})
+	if err != nil {
+		return errors.Database("complete export", err)
+	}
+	if completed == 0 {
+		logClaimLost(export)
+		return nil
+	}
+
+	logger.InfoWithFields("Personal data export ready", map[string]interface{}{
+		"export_id":  export.ID,
+		"user_id":    export.UserID,
+		"size_bytes": len(data),
+	})
+	return nil
+}
+
+// logClaimLost records that an export was taken over by another run while it was built,
+// so the outcome of this run was discarded
+func logClaimLost(export database.ListPendingUserExportsRow) {
+	logger.InfoWithFields("Personal data export was claimed by another run", map[string]interface{}{
+		"export_id": export.ID,
+		"user_id":   export.UserID,
+	})
+}
+
+// buildArchive collects the user's data and encodes it in format
+func (s *Service) buildArchive(ctx context.Context, userID, format string) ([]byte, error) {
+	user, err := s.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errors.Database("get user", err)
+	}
+	identities, err := s.queries.ListUserIdentities(ctx, userID)
+	if err != nil {
+		return nil, errors.Database("list identities", err)
+	}
+	ratings, err := s.queries.ListSoloRatingsByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.Database("list solo ratings", err)
+	}
+
+	var reviews []database.ListReviewsByUserRow
+	for offset := int32(0); ; offset += reviewPageSize {
+		page, err := s.queries.ListReviewsByUser(ctx, database.ListReviewsByUserParams{
+			UserID: sql.NullString{String: userID, Valid: true},
+			Limit:  reviewPageSize,
+			Offset: offset,
+		})
+		if err != nil {
+			return nil, errors.Database("list reviews", err)
+		}
+		reviews = append(reviews, page...)
+		if len(page) < reviewPageSize {
+			break
+		}
+	}
+
+	return encodeDocument(newDocument(s.now(), user, identities, reviews, ratings), format)
+}
+
+// withTx runs fn in a transaction, committing if it returns nil and rolling back otherwise
+func (s *Service) withTx(ctx context.Context, fn func(q *database.Queries) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Database("begin transaction", err)
+	}
+
+	if err := fn(s.queries.WithTx(tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			logger.ErrorWithContext(ctx, "Failed to roll back export transaction", rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Database("commit transaction", err)
+	}
+	return nil
+}
+
+// generateToken returns a random URL-safe download token
+func generateToken() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the SHA-256 hex digest stored for a download token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func toExport(row database.UserExport) *Export {
+	return &Export{
+		ID:          row.ID,
+		UserID:      row.UserID,
+		Format:      row.Format,
+		Status:      row.Status,
+		SizeBytes:   row.SizeBytes,
+		Error:       row.Error.String,
+		CreatedAt:   row.CreatedAt,
+		CompletedAt: row.CompletedAt.Time,
+		ExpiresAt:   row.ExpiresAt.Time,
+	}
+}
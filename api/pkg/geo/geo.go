@@ -0,0 +1,137 @@
+// Package geo provides geographic helpers used by the spot discovery queries
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	// EarthRadiusKm is the mean Earth radius used for great-circle distances
+	EarthRadiusKm = 6371.0
+	// MaxZoom is the deepest web map zoom level accepted for clustering
+	MaxZoom = 22
+	// cellsPerTile splits each 256px map tile into a 4x4 clustering grid
+	cellsPerTile = 4
+)
+
+// BoundingBox represents a latitude/longitude rectangle in degrees
+type BoundingBox struct {
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+// ParseBoundingBox parses a "minLng,minLat,maxLng,maxLat" string as used by web map clients
+func ParseBoundingBox(value string) (BoundingBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return BoundingBox{}, fmt.Errorf("bbox must have the form minLng,minLat,maxLng,maxLat")
+	}
+
+	values := make([]float64, 4)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BoundingBox{}, fmt.Errorf("bbox contains an invalid number: %q", part)
+		}
+		values[i] = v
+	}
+
+	box := BoundingBox{MinLng: values[0], MinLat: values[1], MaxLng: values[2], MaxLat: values[3]}
+	return box, box.Validate()
+}
+
+// Validate checks that the box corners are valid coordinates and correctly ordered.
+// A minimum longitude greater than the maximum is allowed and means the box crosses the antimeridian.
+func (b BoundingBox) Validate() error {
+	if err := ValidateCoordinates(b.MinLat, b.MinLng); err != nil {
+		return err
+	}
+	if err := ValidateCoordinates(b.MaxLat, b.MaxLng); err != nil {
+		return err
+	}
+	if b.MinLat > b.MaxLat {
+		return fmt.Errorf("bbox minimum latitude must not exceed maximum latitude")
+	}
+	return nil
+}
+
+// CrossesAntimeridian reports whether the box wraps from +180 to -180 longitude
+func (b BoundingBox) CrossesAntimeridian() bool {
+	return b.MinLng > b.MaxLng
+}
+
+// LongitudeRanges splits the box into longitude ranges that do not wrap.
+// A box crossing the antimeridian yields its eastern and western parts; any other box yields one range.
+func (b BoundingBox) LongitudeRanges() [][2]float64 {
+	if b.CrossesAntimeridian() {
+		return [][2]float64{{b.MinLng, 180}, {-180, b.MaxLng}}
+	}
+	return [][2]float64{{b.MinLng, b.MaxLng}}
+}
+
+// GridCellSize returns the clustering grid cell size in degrees for a web map zoom level
+func GridCellSize(zoom int) float64 {
+	return 360 / math.Pow(2, float64(zoom)) / cellsPerTile
+}
+
+// ValidateCoordinates checks that a latitude/longitude pair is within range
+func ValidateCoordinates(lat, lng float64) error {
+	if math.IsNaN(lat) || lat < -90 || lat > 90 {
+		return fmt.Errorf("latitude must be between -90 and 90")
+	}
+	if math.IsNaN(lng) || lng < -180 || lng > 180 {
+		return fmt.Errorf("longitude must be between -180 and 180")
+	}
+	return nil
+}
+
+// DistanceKm returns the great-circle distance between two points using the haversine formula
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// BoundingBoxAround returns the smallest box that contains every point within radiusKm of the center.
+// The box is used as an index-friendly pre-filter before the exact distance check, so when it would
+// wrap a pole or the antimeridian the longitude range is widened to the whole globe instead.
+func BoundingBoxAround(lat, lng, radiusKm float64) BoundingBox {
+	angular := radiusKm / EarthRadiusKm
+	dLat := toDegrees(angular)
+
+	box := BoundingBox{
+		MinLat: lat - dLat,
+		MaxLat: lat + dLat,
+		MinLng: -180,
+		MaxLng: 180,
+	}
+
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat = math.Max(box.MinLat, -90)
+		box.MaxLat = math.Min(box.MaxLat, 90)
+		return box
+	}
+
+	dLng := toDegrees(math.Asin(math.Sin(angular) / math.Cos(toRadians(lat))))
+	if lng-dLng >= -180 && lng+dLng <= 180 {
+		box.MinLng = lng - dLng
+		box.MaxLng = lng + dLng
+	}
+	return box
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func toDegrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
@@ -0,0 +1,113 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	// Tokyo Station to Osaka Station is roughly 403 km
+	d := DistanceKm(35.6812, 139.7671, 34.7025, 135.4959)
+	if math.Abs(d-403) > 5 {
+		t.Errorf("Expected distance around 403km, got %f", d)
+	}
+
+	if d := DistanceKm(35.6812, 139.7671, 35.6812, 139.7671); d != 0 {
+		t.Errorf("Expected zero distance for identical points, got %f", d)
+	}
+}
+
+func TestBoundingBoxAround(t *testing.T) {
+	lat, lng, radius := 35.6812, 139.7671, 5.0
+	box := BoundingBoxAround(lat, lng, radius)
+
+	if box.MinLat >= lat || box.MaxLat <= lat || box.MinLng >= lng || box.MaxLng <= lng {
+		t.Fatalf("Box does not contain its center: %+v", box)
+	}
+
+	// Points just inside the radius in each cardinal direction must be inside the box
+	kmPerDegree := 2 * math.Pi * EarthRadiusKm / 360
+	inner := radius * 0.999
+	for _, p := range [][2]float64{
+		{lat + inner/kmPerDegree, lng},
+		{lat - inner/kmPerDegree, lng},
+		{lat, lng + inner/(kmPerDegree*math.Cos(lat*math.Pi/180))},
+		{lat, lng - inner/(kmPerDegree*math.Cos(lat*math.Pi/180))},
+	} {
+		if DistanceKm(lat, lng, p[0], p[1]) > radius {
+			t.Fatalf("Test point %v is outside the radius", p)
+		}
+		if p[0] < box.MinLat || p[0] > box.MaxLat || p[1] < box.MinLng || p[1] > box.MaxLng {
+			t.Errorf("Point %v outside box %+v", p, box)
+		}
+	}
+}
+
+func TestBoundingBoxAround_WidensNearAntimeridianAndPoles(t *testing.T) {
+	box := BoundingBoxAround(0, 179.99, 10)
+	if box.MinLng != -180 || box.MaxLng != 180 {
+		t.Errorf("Expected full longitude range near antimeridian, got %+v", box)
+	}
+
+	box = BoundingBoxAround(89.99, 0, 10)
+	if box.MaxLat != 90 || box.MinLng != -180 || box.MaxLng != 180 {
+		t.Errorf("Expected polar box to be clamped and widened, got %+v", box)
+	}
+}
+
+func TestValidateCoordinates(t *testing.T) {
+	if err := ValidateCoordinates(35.6, 139.7); err != nil {
+		t.Errorf("Expected valid coordinates, got %v", err)
+	}
+	if err := ValidateCoordinates(91, 0); err == nil {
+		t.Error("Expected error for latitude out of range")
+	}
+	if err := ValidateCoordinates(0, -181); err == nil {
+		t.Error("Expected error for longitude out of range")
+	}
+}
+
+func TestParseBoundingBox(t *testing.T) {
+	box, err := ParseBoundingBox("139.5, 35.5,140.0,35.9")
+	if err != nil {
+		t.Fatalf("Expected valid bbox, got %v", err)
+	}
+	if box.MinLng != 139.5 || box.MinLat != 35.5 || box.MaxLng != 140.0 || box.MaxLat != 35.9 {
+		t.Errorf("Unexpected bbox: %+v", box)
+	}
+
+	for _, invalid := range []string{"", "1,2,3", "a,2,3,4", "0,10,1,5", "0,-91,1,0"} {
+		if _, err := ParseBoundingBox(invalid); err == nil {
+			t.Errorf("Expected error for bbox %q", invalid)
+		}
+	}
+}
+
+func TestGridCellSize(t *testing.T) {
+	if size := GridCellSize(0); size != 90 {
+		t.Errorf("Expected 90 degree cells at zoom 0, got %f", size)
+	}
+	if GridCellSize(10) >= GridCellSize(9) {
+		t.Error("Expected cells to shrink as zoom increases")
+	}
+}
+
+func TestBoundingBox_LongitudeRanges(t *testing.T) {
+	box := BoundingBox{MinLat: -10, MinLng: 170, MaxLat: 10, MaxLng: -170}
+	if err := box.Validate(); err != nil {
+		t.Fatalf("Expected antimeridian box to be valid, got %v", err)
+	}
+	if !box.CrossesAntimeridian() {
+		t.Fatal("Expected box to cross the antimeridian")
+	}
+
+	ranges := box.LongitudeRanges()
+	if len(ranges) != 2 || ranges[0] != [2]float64{170, 180} || ranges[1] != [2]float64{-180, -170} {
+		t.Errorf("Unexpected ranges: %v", ranges)
+	}
+
+	box = BoundingBox{MinLat: 35, MinLng: 139, MaxLat: 36, MaxLng: 140}
+	if ranges := box.LongitudeRanges(); len(ranges) != 1 || ranges[0] != [2]float64{139, 140} {
+		t.Errorf("Unexpected ranges: %v", ranges)
+	}
+}
@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"bocchi/api/infrastructure/database"
+)
+
+// TokenBlacklistCleanupInterval is how often expired blacklist entries are purged
+const TokenBlacklistCleanupInterval = time.Hour
+
+// NewTokenBlacklistCleanupJob deletes token_blacklist rows whose tokens have expired.
+// An expired token is rejected on its own, so its blacklist entry is no longer needed.
+func NewTokenBlacklistCleanupJob(queries *database.Queries) Job {
+	return Job{
+		Name:     "token-blacklist-cleanup",
+		Interval: TokenBlacklistCleanupInterval,
+		Timeout:  5 * time.Minute,
+		Run: func(ctx context.Context) error {
+			return queries.CleanupExpiredTokens(ctx)
+		},
+	}
+}
@@ -0,0 +1,21 @@
+package jobs
+
+import (
+	"time"
+
+	"bocchi/api/pkg/export"
+)
+
+// UserExportInterval is how often pending personal data exports are built
+const UserExportInterval = 30 * time.Second
+
+// NewUserExportJob builds the archives of pending personal data exports and deletes
+// exports whose download link has expired
+func NewUserExportJob(exports *export.Service) Job {
+	return Job{
+		Name:     "user-export",
+		Interval: UserExportInterval,
+		Timeout:  5 * time.Minute,
+		Run:      exports.ProcessPending,
+	}
+}
@@ -0,0 +1,63 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	stdErrors "errors"
+	"time"
+
+	"bocchi/api/infrastructure/database"
+)
+
+// RunLedger records when each job last ran across replicas. ClaimRun is called while
+// holding the job's lock: it returns due=false when the job started less than interval
+// ago, and otherwise records a run starting now.
+type RunLedger interface {
+	ClaimRun(ctx context.Context, name string, interval time.Duration) (due bool, err error)
+	// LastRuns returns when each job last started on any replica
+	LastRuns(ctx context.Context) (map[string]time.Time, error)
+}
+
+// MySQLRunLedger keeps the last run of each job in the job_runs table. Run times use
+// the database clock so replicas with skewed clocks agree on whether a run is due.
+type MySQLRunLedger struct {
+	queries *database.Queries
+}
+
+// NewMySQLRunLedger creates a run ledger using the given queries
+func NewMySQLRunLedger(queries *database.Queries) *MySQLRunLedger {
+	return &MySQLRunLedger{queries: queries}
+}
+
+// ClaimRun records a run of the job unless it last started within interval
+func (l *MySQLRunLedger) ClaimRun(ctx context.Context, name string, interval time.Duration) (bool, error) {
+	recent, err := l.queries.IsJobRunRecent(ctx, database.IsJobRunRecentParams{
+		IntervalMicroseconds: interval.Microseconds(),
+		Name:                 name,
+	})
+	if err != nil && !stdErrors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+	if recent {
+		return false, nil
+	}
+
+	if err := l.queries.RecordJobRun(ctx, name); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LastRuns reads the job_runs table
+func (l *MySQLRunLedger) LastRuns(ctx context.Context) (map[string]time.Time, error) {
+	runs, err := l.queries.ListJobRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lastRuns := make(map[string]time.Time, len(runs))
+	for _, run := range runs {
+		lastRuns[run.Name] = run.LastRunAt
+	}
+	return lastRuns, nil
+}
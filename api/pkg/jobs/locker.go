@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"bocchi/api/pkg/logger"
+)
+
+// Locker elects the replica that runs a job. TryLock must not block: it returns
+// acquired=false when another holder owns the lock, and release frees an acquired lock.
+type Locker interface {
+	TryLock(ctx context.Context, name string) (release func(), acquired bool, err error)
+}
+
+// MySQLLocker elects a leader with MySQL named locks (GET_LOCK), which TiDB also supports.
+// A named lock belongs to a session, so the connection is pinned until the lock is released;
+// if the replica dies, the session ends and the lock is freed automatically.
+type MySQLLocker struct {
+	db *sql.DB
+}
+
+// NewMySQLLocker creates a locker using the given database
+func NewMySQLLocker(db *sql.DB) *MySQLLocker {
+	return &MySQLLocker{db: db}
+}
+
+// TryLock attempts GET_LOCK with a zero timeout
+func (l *MySQLLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", name).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		// The job's context may already be cancelled, so release with a fresh one
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if _, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", name); err != nil {
+			logger.ErrorWithFields("Failed to release job lock", err, map[string]interface{}{
+				"lock": name,
+			})
+		}
+		conn.Close()
+	}
+	return release, true, nil
+}
@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+// UserPurgeInterval is how often deleted accounts past their grace period are purged
+const UserPurgeInterval = time.Hour
+
+// userPurgeBatchSize is the number of accounts purged per run
+const userPurgeBatchSize = 100
+
+// UserPurger permanently removes accounts that were deleted before a cutoff
+type UserPurger interface {
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time, limit int) (int, error)
+}
+
+// NewUserPurgeJob purges accounts that were deleted more than gracePeriod ago
+func NewUserPurgeJob(purger UserPurger, gracePeriod time.Duration) Job {
+	return Job{
+		Name:     "user-purge",
+		Interval: UserPurgeInterval,
+		Timeout:  10 * time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := purger.PurgeDeletedUsers(ctx, time.Now().Add(-gracePeriod), userPurgeBatchSize)
+			return err
+		},
+	}
+}
@@ -0,0 +1,284 @@
+// Package jobs runs interval-based background jobs inside the API process.
+//
+// Each job runs on its own jittered interval. Before every run the scheduler takes a
+// named lock through a Locker, so when several replicas run the same schedule only the
+// one holding the lock (the leader for that run) does the work. The lock only keeps runs
+// from overlapping: replicas fire at different times, so the leader then checks a
+// RunLedger and runs the job only if its interval has elapsed since any replica last
+// ran it. Attempts that lose the lock or are not yet due are recorded as skipped.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"bocchi/api/pkg/errors"
+	"bocchi/api/pkg/logger"
+)
+
+// Run results recorded in Status.LastResult
+const (
+	ResultSucceeded = "succeeded"
+	ResultFailed    = "failed"
+	ResultSkipped   = "skipped" // another replica held the job's lock or ran it within the interval
+)
+
+// lockPrefix namespaces job locks; MySQL lock names are limited to 64 characters
+const lockPrefix = "bocchi:job:"
+
+// Job describes a background job
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Jitter is the maximum random delay added to each interval so replicas do not
+	// fire in lockstep; zero uses a tenth of the interval
+	Jitter time.Duration
+	// Timeout bounds a single run; zero uses the interval
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// Status reports the last run attempt of a job on this replica
+type Status struct {
+	Name          string
+	Interval      time.Duration
+	Runs          int64
+	LastRunAt     time.Time // start of the last run this replica performed
+	LastAttemptAt time.Time // start of the last attempt, including skipped ones
+	LastDuration  time.Duration
+	LastResult    string
+	LastError     string
+	NextRunAt     time.Time
+}
+
+// Scheduler runs registered jobs until stopped
+type Scheduler struct {
+	locker Locker
+	ledger RunLedger
+
+	mu       sync.RWMutex
+	jobs     []*Job
+	statuses map[string]*Status
+	random   *rand.Rand
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewScheduler creates a scheduler that elects a leader per run through locker and
+// skips runs that ledger reports as not yet due. A nil ledger runs a job whenever its
+// lock is acquired, which is only correct for a single replica.
+func NewScheduler(locker Locker, ledger RunLedger) *Scheduler {
+	return &Scheduler{
+		locker:   locker,
+		ledger:   ledger,
+		statuses: make(map[string]*Status),
+		random:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Register adds a job. Jobs must be registered before Start.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || len(lockPrefix+job.Name) > 64 {
+		return errors.InvalidInput("name", "job name is required and must be at most 53 characters")
+	}
+	if job.Interval <= 0 {
+		return errors.InvalidInput("interval", "job interval must be positive")
+	}
+	if job.Run == nil {
+		return errors.InvalidInput("run", "job function is required")
+	}
+	if job.Jitter <= 0 {
+		job.Jitter = job.Interval / 10
+	}
+	if job.Timeout <= 0 {
+		job.Timeout = job.Interval
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return errors.Conflict("job", "jobs cannot be registered after the scheduler has started")
+	}
+	if _, exists := s.statuses[job.Name]; exists {
+		return errors.Conflict("job", fmt.Sprintf("%s is already registered", job.Name))
+	}
+
+	s.jobs = append(s.jobs, &job)
+	s.statuses[job.Name] = &Status{Name: job.Name, Interval: job.Interval}
+	return nil
+}
+
+// Start runs every registered job in the background until Stop is called
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+
+	logger.InfoWithFields("Job scheduler started", map[string]interface{}{
+		"jobs": len(s.jobs),
+	})
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	s.mu.RLock()
+	cancel := s.cancel
+	s.mu.RUnlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	s.wg.Wait()
+
+	logger.Info("Job scheduler stopped")
+}
+
+// Statuses returns the status of every registered job, sorted by name
+func (s *Scheduler) Statuses() []Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]Status, 0, len(s.statuses))
+	for _, status := range s.statuses {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// LastRuns returns when each job last started on any replica, as recorded by the ledger.
+// Without a ledger only the runs of this replica are known.
+func (s *Scheduler) LastRuns(ctx context.Context) (map[string]time.Time, error) {
+	if s.ledger != nil {
+		return s.ledger.LastRuns(ctx)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lastRuns := make(map[string]time.Time, len(s.statuses))
+	for _, status := range s.statuses {
+		if !status.LastRunAt.IsZero() {
+			lastRuns[status.Name] = status.LastRunAt
+		}
+	}
+	return lastRuns, nil
+}
+
+// loop runs the job on its jittered interval until ctx is cancelled
+func (s *Scheduler) loop(ctx context.Context, job *Job) {
+	defer s.wg.Done()
+
+	timer := time.NewTimer(s.nextDelay(job))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			s.runOnce(ctx, job)
+			timer.Reset(s.nextDelay(job))
+		}
+	}
+}
+
+// nextDelay returns the interval plus random jitter and records the next run time
+func (s *Scheduler) nextDelay(job *Job) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delay := job.Interval + time.Duration(s.random.Int63n(int64(job.Jitter)+1))
+	s.statuses[job.Name].NextRunAt = time.Now().Add(delay)
+	return delay
+}
+
+// runOnce runs the job if this replica wins its lock and the job is due, and records the outcome
+func (s *Scheduler) runOnce(ctx context.Context, job *Job) {
+	runCtx, cancel := context.WithTimeout(ctx, job.Timeout)
+	defer cancel()
+
+	started := time.Now()
+	release, acquired, err := s.locker.TryLock(runCtx, lockPrefix+job.Name)
+	if err != nil {
+		s.record(job.Name, started, ResultFailed, errors.Database("acquire job lock", err))
+		return
+	}
+	if !acquired {
+		s.record(job.Name, started, ResultSkipped, nil)
+		return
+	}
+	defer release()
+
+	if s.ledger != nil {
+		due, err := s.ledger.ClaimRun(runCtx, job.Name, job.Interval)
+		if err != nil {
+			s.record(job.Name, started, ResultFailed, errors.Database("claim job run", err))
+			return
+		}
+		if !due {
+			s.record(job.Name, started, ResultSkipped, nil)
+			return
+		}
+	}
+
+	if err := safeRun(runCtx, job); err != nil {
+		s.record(job.Name, started, ResultFailed, err)
+		return
+	}
+	s.record(job.Name, started, ResultSucceeded, nil)
+}
+
+// safeRun runs the job, converting a panic into an error so one job cannot stop the scheduler
+func safeRun(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
+
+func (s *Scheduler) record(name string, started time.Time, result string, err error) {
+	s.mu.Lock()
+	status := s.statuses[name]
+	status.LastAttemptAt = started
+	status.LastDuration = time.Since(started)
+	status.LastResult = result
+	status.LastError = ""
+	if result != ResultSkipped {
+		status.Runs++
+		status.LastRunAt = started
+	}
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	fields := map[string]interface{}{
+		"job":         name,
+		"result":      result,
+		"duration_ms": time.Since(started).Milliseconds(),
+	}
+	if err != nil {
+		logger.ErrorWithFields("Background job failed", err, fields)
+		return
+	}
+	logger.InfoWithFields("Background job finished", fields)
+}
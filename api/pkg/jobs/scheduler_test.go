@@ -0,0 +1,313 @@
+package jobs
+
+import (
+	"context"
+	stdErrors "errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memoryLocker is an in-process Locker shared by schedulers standing in for replicas
+type memoryLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func newMemoryLocker() *memoryLocker {
+	return &memoryLocker{held: make(map[string]bool)}
+}
+
+func (l *memoryLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, name)
+	}, true, nil
+}
+
+// memoryLedger is an in-process RunLedger shared by schedulers standing in for replicas
+type memoryLedger struct {
+	mu      sync.Mutex
+	lastRun map[string]time.Time
+}
+
+func newMemoryLedger() *memoryLedger {
+	return &memoryLedger{lastRun: make(map[string]time.Time)}
+}
+
+func (l *memoryLedger) ClaimRun(ctx context.Context, name string, interval time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if last, ok := l.lastRun[name]; ok && time.Since(last) < interval {
+		return false, nil
+	}
+	l.lastRun[name] = time.Now()
+	return true, nil
+}
+
+func (l *memoryLedger) LastRuns(ctx context.Context) (map[string]time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lastRuns := make(map[string]time.Time, len(l.lastRun))
+	for name, last := range l.lastRun {
+		lastRuns[name] = last
+	}
+	return lastRuns, nil
+}
+
+// notDueLedger reports every run as not yet due while keeping the recorded last runs
+type notDueLedger struct {
+	*memoryLedger
+}
+
+func (l notDueLedger) ClaimRun(ctx context.Context, name string, interval time.Duration) (bool, error) {
+	return false, nil
+}
+
+// ledgerFunc adapts a function to RunLedger
+type ledgerFunc func(ctx context.Context, name string, interval time.Duration) (bool, error)
+
+func (f ledgerFunc) ClaimRun(ctx context.Context, name string, interval time.Duration) (bool, error) {
+	return f(ctx, name, interval)
+}
+
+func (f ledgerFunc) LastRuns(ctx context.Context) (map[string]time.Time, error) {
+	return nil, nil
+}
+
+// waitFor polls until condition holds or fails the test after a second
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSchedulerRunsJobsRepeatedly(t *testing.T) {
+	scheduler := NewScheduler(newMemoryLocker(), nil)
+	var runs int32
+	err := scheduler.Register(Job{Name: "counter", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	scheduler.Start()
+	waitFor(t, func() bool { return atomic.LoadInt32(&runs) >= 3 })
+	scheduler.Stop()
+
+	status := scheduler.Statuses()[0]
+	if status.LastResult != ResultSucceeded || status.Runs < 3 || status.LastRunAt.IsZero() {
+		t.Errorf("Unexpected status after successful runs: %+v", status)
+	}
+
+	stoppedAt := atomic.LoadInt32(&runs)
+	time.Sleep(30 * time.Millisecond)
+	if atomic.LoadInt32(&runs) != stoppedAt {
+		t.Error("Expected no runs after Stop")
+	}
+}
+
+func TestSchedulerRecordsFailuresAndPanics(t *testing.T) {
+	scheduler := NewScheduler(newMemoryLocker(), nil)
+	scheduler.Register(Job{Name: "failing", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		return stdErrors.New("boom")
+	}})
+	scheduler.Register(Job{Name: "panicking", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		panic("kaboom")
+	}})
+
+	scheduler.Start()
+	defer scheduler.Stop()
+	waitFor(t, func() bool {
+		for _, status := range scheduler.Statuses() {
+			if status.Runs == 0 {
+				return false
+			}
+		}
+		return true
+	})
+
+	for _, status := range scheduler.Statuses() {
+		if status.LastResult != ResultFailed || status.LastError == "" {
+			t.Errorf("Expected %s to record a failure, got %+v", status.Name, status)
+		}
+	}
+}
+
+func TestSchedulerSkipsRunsWithoutLock(t *testing.T) {
+	locker := newMemoryLocker()
+	release, _, _ := locker.TryLock(context.Background(), lockPrefix+"cleanup")
+	defer release()
+
+	scheduler := NewScheduler(locker, nil)
+	var runs int32
+	scheduler.Register(Job{Name: "cleanup", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}})
+
+	scheduler.Start()
+	defer scheduler.Stop()
+	waitFor(t, func() bool { return scheduler.Statuses()[0].LastResult == ResultSkipped })
+
+	if atomic.LoadInt32(&runs) != 0 {
+		t.Error("Expected the job not to run while another replica holds its lock")
+	}
+	if status := scheduler.Statuses()[0]; status.Runs != 0 {
+		t.Errorf("Expected skipped attempts not to count as runs, got %d", status.Runs)
+	}
+}
+
+func TestSchedulerElectsOneReplicaPerRun(t *testing.T) {
+	locker := newMemoryLocker()
+	var running, overlaps int32
+	job := Job{Name: "exclusive", Interval: 5 * time.Millisecond, Run: func(ctx context.Context) error {
+		if atomic.AddInt32(&running, 1) > 1 {
+			atomic.AddInt32(&overlaps, 1)
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}}
+
+	replicas := []*Scheduler{NewScheduler(locker, nil), NewScheduler(locker, nil)}
+	for _, replica := range replicas {
+		replica.Register(job)
+		replica.Start()
+	}
+	time.Sleep(100 * time.Millisecond)
+	for _, replica := range replicas {
+		replica.Stop()
+	}
+
+	if atomic.LoadInt32(&overlaps) != 0 {
+		t.Errorf("Expected runs never to overlap across replicas, got %d overlaps", overlaps)
+	}
+}
+
+func TestSchedulerRegisterValidation(t *testing.T) {
+	run := func(ctx context.Context) error { return nil }
+	scheduler := NewScheduler(newMemoryLocker(), nil)
+
+	if err := scheduler.Register(Job{Name: "ok", Interval: time.Minute, Run: run}); err != nil {
+		t.Fatalf("Expected valid job to register, got %v", err)
+	}
+
+	tests := []struct {
+		name string
+		job  Job
+	}{
+		{"missing name", Job{Interval: time.Minute, Run: run}},
+		{"zero interval", Job{Name: "zero", Run: run}},
+		{"missing function", Job{Name: "nil", Interval: time.Minute}},
+		{"duplicate name", Job{Name: "ok", Interval: time.Minute, Run: run}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := scheduler.Register(tt.job); err == nil {
+				t.Error("Expected registration to fail")
+			}
+		})
+	}
+
+	scheduler.Start()
+	defer scheduler.Stop()
+	if err := scheduler.Register(Job{Name: "late", Interval: time.Minute, Run: run}); err == nil {
+		t.Error("Expected registration after Start to fail")
+	}
+}
+
+func TestSchedulerSkipsRunsNotYetDue(t *testing.T) {
+	// Another replica ran the job within its interval
+	notDue := ledgerFunc(func(ctx context.Context, name string, interval time.Duration) (bool, error) {
+		return false, nil
+	})
+
+	scheduler := NewScheduler(newMemoryLocker(), notDue)
+	var runs int32
+	scheduler.Register(Job{Name: "cleanup", Interval: 10 * time.Millisecond, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}})
+
+	scheduler.Start()
+	defer scheduler.Stop()
+	waitFor(t, func() bool { return scheduler.Statuses()[0].LastResult == ResultSkipped })
+
+	if atomic.LoadInt32(&runs) != 0 {
+		t.Error("Expected the job not to run before its interval has elapsed")
+	}
+	if status := scheduler.Statuses()[0]; status.Runs != 0 {
+		t.Errorf("Expected runs that are not due not to count as runs, got %d", status.Runs)
+	}
+}
+
+func TestSchedulerRunsOncePerIntervalAcrossReplicas(t *testing.T) {
+	locker := newMemoryLocker()
+	ledger := newMemoryLedger()
+	var runs int32
+	job := Job{Name: "periodic", Interval: 40 * time.Millisecond, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}}
+
+	replicas := []*Scheduler{NewScheduler(locker, ledger), NewScheduler(locker, ledger), NewScheduler(locker, ledger)}
+	for _, replica := range replicas {
+		replica.Register(job)
+		replica.Start()
+	}
+	time.Sleep(200 * time.Millisecond)
+	for _, replica := range replicas {
+		replica.Stop()
+	}
+
+	// At most one run per elapsed interval, however many replicas fire
+	if got := atomic.LoadInt32(&runs); got < 1 || got > 5 {
+		t.Errorf("Expected between 1 and 5 runs in 5 intervals, got %d", got)
+	}
+}
+
+func TestSchedulerLastRunsComeFromLedger(t *testing.T) {
+	// Another replica ran the job within its interval
+	ranAt := time.Now().Add(-time.Minute)
+	ledger := notDueLedger{newMemoryLedger()}
+	ledger.lastRun["cleanup"] = ranAt
+
+	scheduler := NewScheduler(newMemoryLocker(), ledger)
+	scheduler.Register(Job{Name: "cleanup", Interval: 50 * time.Millisecond, Run: func(ctx context.Context) error {
+		return nil
+	}})
+
+	scheduler.Start()
+	defer scheduler.Stop()
+	waitFor(t, func() bool { return scheduler.Statuses()[0].LastResult == ResultSkipped })
+
+	lastRuns, err := scheduler.LastRuns(context.Background())
+	if err != nil {
+		t.Fatalf("LastRuns failed: %v", err)
+	}
+	if !lastRuns["cleanup"].Equal(ranAt) {
+		t.Errorf("Expected the last run recorded by the other replica, got %v", lastRuns["cleanup"])
+	}
+	if status := scheduler.Statuses()[0]; !status.LastRunAt.IsZero() {
+		t.Errorf("Expected this replica to have no run of its own, got %v", status.LastRunAt)
+	}
+}
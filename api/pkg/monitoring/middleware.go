@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/getsentry/sentry-go"
+	"bocchi/api/pkg/clientip"
 	"bocchi/api/pkg/logger"
 )
 
@@ -66,6 +67,7 @@ func RequestIDMiddleware() func(http.Handler) http.Handler {
 					"request_id": requestID,
 					"method":     r.Method,
 					"url":        r.URL.String(),
+					"client_ip":  clientip.FromRequest(r),
 				},
 			})
 
@@ -106,6 +108,7 @@ func PerformanceMiddleware() func(http.Handler) http.Handler {
 			logger.InfoWithFields("HTTP request completed", map[string]interface{}{
 				"method":      r.Method,
 				"url":         r.URL.String(),
+				"client_ip":   clientip.FromRequest(r),
 				"status_code": wrappedWriter.statusCode,
 				"duration_ms": duration.Milliseconds(),
 				"duration":    duration.String(),
@@ -0,0 +1,352 @@
+// Package preferences defines the versioned schema of the users.preferences document.
+//
+// Stored documents are migrated to the current Version whenever they are read, so rows
+// written by earlier releases never need a rewrite to be usable. Keys this release does
+// not know, e.g. ones written by a newer release during a rolling deploy, are kept when
+// a document is updated.
+package preferences
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+	// Embed the time zone database so IANA names validate on hosts without zoneinfo
+	_ "time/tzdata"
+
+	"golang.org/x/text/language"
+
+	"bocchi/api/domain/entities"
+	"bocchi/api/internal/domain/rating"
+	"bocchi/api/pkg/errors"
+)
+
+// Version is the current version of the preferences document; bump it together with a
+// new entry in migrations
+const Version = 1
+
+// Distance units
+const (
+	UnitMetric   = "metric"
+	UnitImperial = "imperial"
+)
+
+// Default preference values
+const (
+	DefaultLanguage       = "ja"
+	DefaultTimezone       = "Asia/Tokyo"
+	DefaultDarkMode       = false
+	DefaultSearchRadiusKm = 1.0
+	DefaultDistanceUnit   = UnitMetric
+)
+
+// Search radius bounds, matching the spot search API
+const (
+	MinSearchRadiusKm = 0.1
+	MaxSearchRadiusKm = 50.0
+)
+
+// versionKey is the document key holding the schema version
+const versionKey = "version"
+
+// document is a preferences document with its values left encoded, so that unknown
+// keys survive a round trip
+type document map[string]json.RawMessage
+
+// Defaults returns the preferences of a user who has not changed any
+func Defaults() entities.UserPreferences {
+	return entities.UserPreferences{
+		Version:        Version,
+		Language:       DefaultLanguage,
+		DarkMode:       DefaultDarkMode,
+		Timezone:       DefaultTimezone,
+		SearchRadiusKm: DefaultSearchRadiusKm,
+		SoloCategories: []string{},
+		DistanceUnit:   DefaultDistanceUnit,
+		Notifications: entities.NotificationPreferences{
+			Email: true,
+		},
+	}
+}
+
+// migrations[v] upgrades a version v document to version v+1 in place
+var migrations = []func(doc document){
+	migrateV0,
+}
+
+// migrateV0 upgrades unversioned documents. Early clients wrote darkMode instead of
+// dark_mode, and provisioned users were given a theme instead of dark_mode.
+func migrateV0(doc document) {
+	if raw, ok := doc["darkMode"]; ok {
+		if _, exists := doc["dark_mode"]; !exists {
+			doc["dark_mode"] = raw
+		}
+		delete(doc, "darkMode")
+	}
+	if raw, ok := doc["theme"]; ok {
+		var theme string
+		if _, exists := doc["dark_mode"]; !exists && json.Unmarshal(raw, &theme) == nil && theme == "dark" {
+			doc["dark_mode"] = json.RawMessage("true")
+		}
+		delete(doc, "theme")
+	}
+}
+
+// fields decodes, validates and normalizes each known key into the preferences
+var fields = map[string]func(prefs *entities.UserPreferences, key string, raw json.RawMessage) error{
+	"language": func(prefs *entities.UserPreferences, key string, raw json.RawMessage) error {
+		return decodeValue(key, raw, &prefs.Language, checkLanguage)
+	},
+	"dark_mode": func(prefs *entities.UserPreferences, key string, raw json.RawMessage) error {
+		return decodeValue(key, raw, &prefs.DarkMode, nil)
+	},
+	"timezone": func(prefs *entities.UserPreferences, key string, raw json.RawMessage) error {
+		return decodeValue(key, raw, &prefs.Timezone, checkTimezone)
+	},
+	"search_radius_km": func(prefs *entities.UserPreferences, key string, raw json.RawMessage) error {
+		return decodeValue(key, raw, &prefs.SearchRadiusKm, checkSearchRadius)
+	},
+	"solo_categories": func(prefs *entities.UserPreferences, key string, raw json.RawMessage) error {
+		return decodeValue(key, raw, &prefs.SoloCategories, checkSoloCategories)
+	},
+	"distance_unit": func(prefs *entities.UserPreferences, key string, raw json.RawMessage) error {
+		return decodeValue(key, raw, &prefs.DistanceUnit, checkDistanceUnit)
+	},
+	"notifications": func(prefs *entities.UserPreferences, key string, raw json.RawMessage) error {
+		// Notification settings merge field by field into the current ones
+		return decodeValue(key, raw, &prefs.Notifications, nil)
+	},
+}
+
+// Decode returns the stored document raw as current preferences. Missing, malformed
+// and invalid values take their defaults, so reading preferences never fails.
+func Decode(raw []byte) entities.UserPreferences {
+	prefs := Defaults()
+	doc, version, err := load(raw)
+	if err != nil {
+		return prefs
+	}
+	for key, value := range doc {
+		if set, ok := fields[key]; ok {
+			_ = set(&prefs, key, value)
+		}
+	}
+	prefs.Version = version
+	return prefs
+}
+
+// Parse validates a complete document, as sent when a user is created or their
+// preferences are replaced. Documents of earlier versions are migrated first, and
+// missing fields take their defaults.
+func Parse(raw []byte) (entities.UserPreferences, error) {
+	prefs := Defaults()
+	doc, version, err := load(raw)
+	if err != nil {
+		return prefs, err
+	}
+	for _, key := range sortedKeys(doc) {
+		if key == versionKey {
+			continue
+		}
+		set, ok := fields[key]
+		if !ok {
+			// A newer release may have added the key; Encode keeps it
+			if version > Version {
+				continue
+			}
+			return prefs, errors.InvalidInput(key, "unknown preference")
+		}
+		if err := set(&prefs, key, doc[key]); err != nil {
+			return prefs, err
+		}
+	}
+	prefs.Version = version
+	return prefs, nil
+}
+
+// Merge applies patch, a JSON object with the fields to change, to the stored document
+// and returns the merged preferences along with the document to store. Fields missing
+// from the patch keep their values, notification settings are merged field by field
+// and solo categories are replaced as a whole.
+func Merge(stored, patch []byte) (entities.UserPreferences, []byte, error) {
+	prefs := Decode(stored)
+
+	changes, err := parseDocument(patch)
+	if err != nil {
+		return prefs, nil, err
+	}
+	for _, key := range sortedKeys(changes) {
+		if key == versionKey {
+			return prefs, nil, errors.InvalidInput(key, "cannot be changed")
+		}
+		set, ok := fields[key]
+		if !ok {
+			return prefs, nil, errors.InvalidInput(key, "unknown preference")
+		}
+		if err := set(&prefs, key, changes[key]); err != nil {
+			return prefs, nil, err
+		}
+	}
+
+	encoded, err := Encode(stored, prefs)
+	if err != nil {
+		return prefs, nil, err
+	}
+	return prefs, encoded, nil
+}
+
+// Encode returns the document to store for prefs. Keys of the stored document that
+// prefs does not cover, such as ones added by a newer release, are kept.
+func Encode(stored []byte, prefs entities.UserPreferences) ([]byte, error) {
+	doc, version, err := load(stored)
+	if err != nil {
+		doc, version = document{}, Version
+	}
+	if prefs.SoloCategories == nil {
+		prefs.SoloCategories = []string{}
+	}
+	prefs.Version = version
+
+	data, err := json.Marshal(prefs)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeInternal, "failed to encode preferences")
+	}
+	var current document
+	if err := json.Unmarshal(data, &current); err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeInternal, "failed to encode preferences")
+	}
+	for key, value := range current {
+		doc[key] = value
+	}
+	return json.Marshal(doc)
+}
+
+// Normalize returns the stored document raw migrated to the current version with
+// invalid values replaced by their defaults, as served to clients
+func Normalize(raw []byte) []byte {
+	normalized, err := Encode(raw, Decode(raw))
+	if err != nil {
+		return raw
+	}
+	return normalized
+}
+
+// load parses a stored document and migrates it to the current version. It returns
+// the document's version, which is newer than Version for documents written by a
+// newer release.
+func load(raw []byte) (document, int, error) {
+	doc, err := parseDocument(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	version := 0
+	if value, ok := doc[versionKey]; ok {
+		if err := json.Unmarshal(value, &version); err != nil || version < 0 {
+			return nil, 0, errors.InvalidInput(versionKey, "must be a non-negative integer")
+		}
+	}
+	for ; version < len(migrations); version++ {
+		migrations[version](doc)
+	}
+	doc[versionKey] = json.RawMessage(strconv.Itoa(version))
+	return doc, version, nil
+}
+
+// parseDocument parses a JSON object; empty input and null are an empty document
+func parseDocument(raw []byte) (document, error) {
+	doc := document{}
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return doc, nil
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, errors.InvalidInput("preferences", "must be a JSON object")
+	}
+	if doc == nil {
+		doc = document{}
+	}
+	return doc, nil
+}
+
+// decodeValue decodes raw over the current value of dst, validates it with check and
+// stores it. Unknown keys in objects are rejected; null leaves dst unchanged.
+func decodeValue[T any](key string, raw json.RawMessage, dst *T, check func(key string, value *T) error) error {
+	value := *dst
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&value); err != nil {
+		return errors.InvalidInput(key, "has the wrong type or unknown fields")
+	}
+	if check != nil {
+		if err := check(key, &value); err != nil {
+			return err
+		}
+	}
+	*dst = value
+	return nil
+}
+
+// checkLanguage accepts BCP 47 language tags and stores them in canonical form
+func checkLanguage(key string, tag *string) error {
+	parsed, err := language.Parse(*tag)
+	if *tag == "" || err != nil {
+		return errors.InvalidInput(key, "must be a BCP 47 language tag, e.g. ja or en-US")
+	}
+	*tag = parsed.String()
+	return nil
+}
+
+// checkTimezone accepts IANA time zone names
+func checkTimezone(key string, name *string) error {
+	// LoadLocation also accepts "" and "Local", which name no zone
+	if *name == "" || *name == "Local" {
+		return errors.InvalidInput(key, "must be an IANA time zone, e.g. Asia/Tokyo")
+	}
+	if _, err := time.LoadLocation(*name); err != nil {
+		return errors.InvalidInput(key, "must be an IANA time zone, e.g. Asia/Tokyo")
+	}
+	return nil
+}
+
+// checkSearchRadius accepts radii the spot search API accepts
+func checkSearchRadius(key string, radius *float64) error {
+	if !(*radius >= MinSearchRadiusKm && *radius <= MaxSearchRadiusKm) {
+		return errors.InvalidInput(key, "must be between 0.1 and 50")
+	}
+	return nil
+}
+
+// checkSoloCategories accepts solo-friendly rating categories and removes duplicates
+func checkSoloCategories(key string, categories *[]string) error {
+	unique := make([]string, 0, len(*categories))
+	seen := make(map[string]bool, len(*categories))
+	for _, category := range *categories {
+		if !rating.ValidCategories[category] {
+			return errors.InvalidInput(key, "unknown category: "+category)
+		}
+		if !seen[category] {
+			seen[category] = true
+			unique = append(unique, category)
+		}
+	}
+	*categories = unique
+	return nil
+}
+
+// checkDistanceUnit accepts the supported distance units
+func checkDistanceUnit(key string, unit *string) error {
+	if *unit != UnitMetric && *unit != UnitImperial {
+		return errors.InvalidInput(key, "must be metric or imperial")
+	}
+	return nil
+}
+
+func sortedKeys(doc document) []string {
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
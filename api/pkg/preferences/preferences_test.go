@@ -0,0 +1,162 @@
+package preferences
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"bocchi/api/pkg/errors"
+)
+
+func TestDecodeMigratesLegacyDocuments(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		darkMode bool
+		language string
+	}{
+		{name: "empty", raw: "", language: DefaultLanguage},
+		{name: "camel case dark mode", raw: `{"language":"en","darkMode":true,"timezone":"UTC"}`, darkMode: true, language: "en"},
+		{name: "dark theme", raw: `{"language":"en","theme":"dark"}`, darkMode: true, language: "en"},
+		{name: "auto theme", raw: `{"language":"en","theme":"auto"}`, language: "en"},
+		{name: "not an object", raw: `[1,2]`, language: DefaultLanguage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefs := Decode([]byte(tt.raw))
+			if prefs.Version != Version {
+				t.Errorf("Expected version %d, got %d", Version, prefs.Version)
+			}
+			if prefs.DarkMode != tt.darkMode || prefs.Language != tt.language {
+				t.Errorf("Expected dark mode %v and language %q, got %v and %q", tt.darkMode, tt.language, prefs.DarkMode, prefs.Language)
+			}
+			if prefs.SearchRadiusKm != DefaultSearchRadiusKm || prefs.DistanceUnit != DefaultDistanceUnit {
+				t.Errorf("Expected new fields to take their defaults, got %+v", prefs)
+			}
+		})
+	}
+}
+
+func TestDecodeReplacesInvalidValuesWithDefaults(t *testing.T) {
+	prefs := Decode([]byte(`{"version":0,"language":"en","timezone":"Mars/Olympus","search_radius_km":0,"distance_unit":"","dark_mode":"yes"}`))
+
+	if prefs.Language != "en" {
+		t.Errorf("Expected valid values to be kept, got language %q", prefs.Language)
+	}
+	if prefs.Timezone != DefaultTimezone || prefs.SearchRadiusKm != DefaultSearchRadiusKm ||
+		prefs.DistanceUnit != DefaultDistanceUnit || prefs.DarkMode != DefaultDarkMode {
+		t.Errorf("Expected invalid values to take their defaults, got %+v", prefs)
+	}
+}
+
+func TestParseValidatesFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		field string
+	}{
+		{name: "unknown time zone", raw: `{"timezone":"Asia/Nowhere"}`, field: "timezone"},
+		{name: "local time zone", raw: `{"timezone":"Local"}`, field: "timezone"},
+		{name: "malformed language", raw: `{"language":"not a tag"}`, field: "language"},
+		{name: "radius too small", raw: `{"search_radius_km":0.01}`, field: "search_radius_km"},
+		{name: "radius too large", raw: `{"search_radius_km":51}`, field: "search_radius_km"},
+		{name: "unknown category", raw: `{"solo_categories":["karaoke"]}`, field: "solo_categories"},
+		{name: "unknown unit", raw: `{"distance_unit":"furlongs"}`, field: "distance_unit"},
+		{name: "unknown notification", raw: `{"notifications":{"sms":true}}`, field: "notifications"},
+		{name: "wrong type", raw: `{"dark_mode":"yes"}`, field: "dark_mode"},
+		{name: "unknown preference", raw: `{"favorite_color":"blue"}`, field: "favorite_color"},
+		{name: "not an object", raw: `"ja"`, field: "preferences"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.raw))
+			if !errors.Is(err, errors.ErrTypeInvalidInput) {
+				t.Fatalf("Expected an invalid input error, got %v", err)
+			}
+			if field := errors.GetFields(err)["field"]; field != tt.field {
+				t.Errorf("Expected the error to name %q, got %v", tt.field, field)
+			}
+		})
+	}
+}
+
+func TestParseNormalizesValues(t *testing.T) {
+	prefs, err := Parse([]byte(`{"language":"en-us","timezone":"America/New_York","solo_categories":["wifi_available","quiet_atmosphere","wifi_available"],"distance_unit":"imperial"}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if prefs.Language != "en-US" {
+		t.Errorf("Expected the canonical language tag en-US, got %q", prefs.Language)
+	}
+	if want := []string{"wifi_available", "quiet_atmosphere"}; !reflect.DeepEqual(prefs.SoloCategories, want) {
+		t.Errorf("Expected categories %v, got %v", want, prefs.SoloCategories)
+	}
+	if prefs.Notifications != Defaults().Notifications {
+		t.Errorf("Expected missing notification settings to take their defaults, got %+v", prefs.Notifications)
+	}
+}
+
+func TestParseAcceptsUnknownKeysOfNewerVersions(t *testing.T) {
+	prefs, err := Parse([]byte(`{"version":2,"language":"fr","map_style":"satellite"}`))
+	if err != nil {
+		t.Fatalf("Expected keys of a newer version to be accepted, got %v", err)
+	}
+	if prefs.Version != 2 || prefs.Language != "fr" {
+		t.Errorf("Expected version 2 and language fr, got %+v", prefs)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	stored := []byte(`{"version":1,"language":"ja","dark_mode":true,"solo_categories":["wifi_available"],"notifications":{"email":true,"push":true,"nearby_spots":false},"map_style":"satellite"}`)
+
+	prefs, encoded, err := Merge(stored, []byte(`{"timezone":"Europe/London","solo_categories":["quiet_atmosphere"],"notifications":{"push":false}}`))
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if !prefs.DarkMode || prefs.Language != "ja" {
+		t.Errorf("Expected fields missing from the patch to keep their values, got %+v", prefs)
+	}
+	if prefs.Timezone != "Europe/London" {
+		t.Errorf("Expected the timezone to change, got %q", prefs.Timezone)
+	}
+	if want := []string{"quiet_atmosphere"}; !reflect.DeepEqual(prefs.SoloCategories, want) {
+		t.Errorf("Expected categories to be replaced with %v, got %v", want, prefs.SoloCategories)
+	}
+	if !prefs.Notifications.Email || prefs.Notifications.Push {
+		t.Errorf("Expected notification settings to merge field by field, got %+v", prefs.Notifications)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		t.Fatalf("Expected the stored document to be JSON: %v", err)
+	}
+	if doc["map_style"] != "satellite" {
+		t.Errorf("Expected unknown keys of the stored document to be kept, got %v", doc)
+	}
+	if doc["version"] != float64(Version) || doc["timezone"] != "Europe/London" {
+		t.Errorf("Expected the stored document to be current, got %v", doc)
+	}
+}
+
+func TestMergeRejectsInvalidPatches(t *testing.T) {
+	stored := []byte(`{"language":"ja"}`)
+	for _, patch := range []string{`{"version":2}`, `{"theme":"dark"}`, `{"timezone":"Nowhere"}`, `[]`} {
+		if _, _, err := Merge(stored, []byte(patch)); !errors.Is(err, errors.ErrTypeInvalidInput) {
+			t.Errorf("Expected patch %s to be rejected, got %v", patch, err)
+		}
+	}
+}
+
+func TestNormalizeUpgradesLegacyDocuments(t *testing.T) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(Normalize([]byte(`{"language":"en","darkMode":true}`)), &doc); err != nil {
+		t.Fatalf("Expected JSON: %v", err)
+	}
+	if _, ok := doc["darkMode"]; ok || doc["dark_mode"] != true || doc["version"] != float64(Version) {
+		t.Errorf("Expected a current document, got %v", doc)
+	}
+}
@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// take spends one request of the policy's quota from state
+func take(policy Policy, state State, now time.Time) (State, Decision) {
+	if policy.Algorithm == AlgorithmTokenBucket {
+		return takeToken(policy, state, now)
+	}
+	return takeSlidingWindow(policy, state, now)
+}
+
+// stateTTL is how long a key's state matters after its last request
+func stateTTL(policy Policy) time.Duration {
+	if policy.Algorithm == AlgorithmTokenBucket {
+		// Time for an empty bucket to refill completely
+		return seconds(float64(policy.Burst) / refillRate(policy))
+	}
+	// The previous window still weighs on the current one
+	return 2 * policy.Period
+}
+
+// refillRate returns the token bucket refill rate in tokens per second
+func refillRate(policy Policy) float64 {
+	return float64(policy.Limit) / policy.Period.Seconds()
+}
+
+func takeToken(policy Policy, state State, now time.Time) (State, Decision) {
+	rate := refillRate(policy)
+	capacity := float64(policy.Burst)
+
+	tokens := capacity
+	if !state.UpdatedAt.IsZero() {
+		tokens = math.Min(capacity, state.Tokens+now.Sub(state.UpdatedAt).Seconds()*rate)
+	}
+
+	decision := Decision{Allowed: tokens >= 1}
+	if decision.Allowed {
+		tokens--
+	} else {
+		decision.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	decision.Remaining = int(math.Floor(tokens))
+	decision.Reset = seconds((capacity - tokens) / rate)
+
+	return State{Tokens: tokens, UpdatedAt: now}, decision
+}
+
+func takeSlidingWindow(policy Policy, state State, now time.Time) (State, Decision) {
+	period := policy.Period
+	limit := float64(policy.Limit)
+	windowStart := now.Truncate(period)
+
+	if !state.WindowStart.Equal(windowStart) {
+		var previous int64
+		if state.WindowStart.Equal(windowStart.Add(-period)) {
+			previous = state.Count
+		}
+		state = State{WindowStart: windowStart, PreviousCount: previous}
+	}
+	state.UpdatedAt = now
+
+	elapsed := now.Sub(windowStart)
+	weight := 1 - elapsed.Seconds()/period.Seconds()
+	estimate := float64(state.PreviousCount)*weight + float64(state.Count)
+
+	decision := Decision{Allowed: estimate+1 <= limit}
+	if decision.Allowed {
+		state.Count++
+		estimate++
+	} else {
+		decision.RetryAfter = slidingRetryAfter(policy, state, elapsed)
+	}
+	decision.Remaining = int(math.Max(0, limit-math.Ceil(estimate)))
+
+	// Requests of the current window stop counting once the next window has passed
+	decision.Reset = period - elapsed
+	if state.Count > 0 {
+		decision.Reset += period
+	}
+
+	return state, decision
+}
+
+// slidingRetryAfter returns how long until the weighted estimate leaves room for one request
+func slidingRetryAfter(policy Policy, state State, elapsed time.Duration) time.Duration {
+	period := policy.Period.Seconds()
+	limit := float64(policy.Limit)
+
+	// Room appears in this window as the previous window's weight decays
+	if state.PreviousCount > 0 && float64(state.Count)+1 <= limit {
+		at := period * (1 - (limit-float64(state.Count)-1)/float64(state.PreviousCount))
+		return seconds(at - elapsed.Seconds())
+	}
+
+	// Otherwise wait for the next window, where this window's count decays instead
+	at := 0.0
+	if state.Count > 0 {
+		at = period * math.Max(0, 1-(limit-1)/float64(state.Count))
+	}
+	return seconds(period - elapsed.Seconds() + at)
+}
+
+// seconds converts fractional seconds to a duration rounded up to whole milliseconds
+func seconds(value float64) time.Duration {
+	if value <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(value*1000)) * time.Millisecond
+}
@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+
+	"bocchi/api/pkg/clientip"
+	"bocchi/api/pkg/logger"
+	"bocchi/api/pkg/monitoring"
+)
+
+// HumaMiddleware enforces the policy of each operation and sets the RateLimit-* headers.
+// identify resolves who made the request, so the middleware must be added after the
+// authentication middleware for per-user policies. Allowlisted clients and store failures
+// let requests through.
+func (l *Limiter) HumaMiddleware(api huma.API, identify func(huma.Context) Identity) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		operation := ctx.Operation()
+		if operation == nil || clientip.IsAllowlisted(ctx.Context()) {
+			next(ctx)
+			return
+		}
+		policy, ok := l.PolicyFor(operation.OperationID)
+		if !ok {
+			next(ctx)
+			return
+		}
+
+		id := identify(ctx)
+		decision, err := l.allow(ctx.Context(), policy, id)
+		if err != nil {
+			logger.ErrorWithFields("Rate limit check failed", err, map[string]interface{}{
+				"policy":    policy.Name,
+				"operation": operation.OperationID,
+			})
+			next(ctx)
+			return
+		}
+
+		WriteHeaders(ctx.SetHeader, decision)
+		if !decision.Allowed {
+			logger.InfoWithFields("Rate limit exceeded", map[string]interface{}{
+				"policy":    policy.Name,
+				"operation": operation.OperationID,
+				"ip":        id.IP,
+				"user_id":   id.UserID,
+			})
+			monitoring.RecordRateLimitExceeded(ctx.Context(), id.IP)
+
+			ctx.SetHeader("Retry-After", strconv.Itoa(ceilSeconds(decision.RetryAfter)))
+			huma.WriteErr(api, ctx, http.StatusTooManyRequests, "rate limit exceeded, please try again later")
+			return
+		}
+
+		next(ctx)
+	}
+}
+
+// WriteHeaders sets the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and
+// RateLimit-Policy headers of the IETF rate limit headers draft
+func WriteHeaders(setHeader func(name, value string), decision Decision) {
+	policy := decision.Policy
+	quota := policy.Limit
+	description := fmt.Sprintf("%d;w=%d", policy.Limit, ceilSeconds(policy.Period))
+	if policy.Algorithm == AlgorithmTokenBucket {
+		quota = policy.Burst
+		description += fmt.Sprintf(";burst=%d", policy.Burst)
+	}
+
+	setHeader("RateLimit-Limit", strconv.Itoa(quota))
+	setHeader("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	setHeader("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+	setHeader("RateLimit-Policy", description)
+}
+
+// ClientIP returns the client IP resolved by the clientip middleware, falling back to the
+// host part of the request's remote address
+func ClientIP(ctx huma.Context) string {
+	if ip, ok := clientip.FromContext(ctx.Context()); ok {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(ctx.RemoteAddr())
+	if err != nil {
+		return ctx.RemoteAddr()
+	}
+	return host
+}
+
+// ceilSeconds rounds a duration up to whole seconds for headers
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import "time"
+
+// Policy names used by DefaultConfig
+const (
+	PolicyDefault      = "default"
+	PolicyAuth         = "auth"
+	PolicySearch       = "search"
+	PolicyWrite        = "write"
+	PolicyReviewCreate = "review-create"
+)
+
+// DefaultConfig returns the API's policies keyed by Huma operation ID. Reads fall under
+// the per-IP default policy; writes are limited per user.
+func DefaultConfig() Config {
+	return Config{
+		Policies: []Policy{
+			{Name: PolicyDefault, Limit: 300, Period: time.Minute, KeyBy: KeyByAPIKey},
+			{Name: PolicyAuth, Limit: 20, Period: time.Minute, KeyBy: KeyByIP},
+			{Name: PolicySearch, Limit: 60, Period: time.Minute, KeyBy: KeyByAPIKey},
+			{Name: PolicyWrite, Limit: 60, Period: time.Hour, Algorithm: AlgorithmTokenBucket, Burst: 20, KeyBy: KeyByAPIKey},
+			{Name: PolicyReviewCreate, Limit: 10, Period: time.Hour, Algorithm: AlgorithmTokenBucket, KeyBy: KeyByUser},
+		},
+		Operations: map[string]string{
+			"validate-token": PolicyAuth,
+			"auth-status":    PolicyAuth,
+			"logout":         PolicyAuth,
+			"logout-all":     PolicyAuth,
+
+			"search-spots":  PolicySearch,
+			"list-spots":    PolicySearch,
+			"cluster-spots": PolicySearch,
+
+			"create-review": PolicyReviewCreate,
+			"update-review": PolicyWrite,
+			"delete-review": PolicyWrite,
+
+			"create-spot": PolicyWrite,
+			"update-spot": PolicyWrite,
+			"delete-spot": PolicyWrite,
+
+			"create-solo-rating": PolicyWrite,
+			"update-solo-rating": PolicyWrite,
+
+			"update-user":                     PolicyWrite,
+			"update-current-user":             PolicyWrite,
+			"update-current-user-preferences": PolicyWrite,
+			"delete-current-user":             PolicyWrite,
+			"restore-current-user":            PolicyWrite,
+			"request-user-export":             PolicyWrite,
+		},
+		Default: PolicyDefault,
+	}
+}
@@ -0,0 +1,202 @@
+// Package ratelimit applies named rate limit policies to API operations.
+//
+// A policy limits requests per period for one kind of key (client IP, user or API key)
+// using a token bucket or sliding window algorithm. Operations are mapped to policies by
+// their Huma operation ID, with an optional default policy for the rest. Counters live in
+// a Store, so replicas sharing a store share their limits.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"bocchi/api/pkg/errors"
+)
+
+// Algorithm selects how a policy counts requests
+type Algorithm string
+
+const (
+	// AlgorithmSlidingWindow weights the previous fixed window by its overlap with the
+	// sliding window, which smooths the bursts a fixed window allows at its boundaries
+	AlgorithmSlidingWindow Algorithm = "sliding_window"
+	// AlgorithmTokenBucket refills Limit tokens per Period up to Burst and spends one per request
+	AlgorithmTokenBucket Algorithm = "token_bucket"
+)
+
+// KeyBy selects what a policy counts requests by
+type KeyBy string
+
+const (
+	// KeyByIP counts requests per client IP
+	KeyByIP KeyBy = "ip"
+	// KeyByUser counts requests per authenticated user, falling back to the client IP
+	KeyByUser KeyBy = "user"
+	// KeyByAPIKey counts requests per API key, falling back to the user and then the client IP
+	KeyByAPIKey KeyBy = "api_key"
+)
+
+// Policy is a named rate limit, e.g. "review-create: 10 per hour per user"
+type Policy struct {
+	Name      string
+	Limit     int
+	Period    time.Duration
+	Algorithm Algorithm // AlgorithmSlidingWindow when empty
+	KeyBy     KeyBy     // KeyByIP when empty
+	// Burst is the token bucket capacity; zero uses Limit. Ignored by the sliding window.
+	Burst int
+}
+
+// Identity describes who made a request
+type Identity struct {
+	IP     string
+	UserID string
+	APIKey string // an API key identifier; never the raw secret
+}
+
+// Decision is the outcome of a rate limit check
+type Decision struct {
+	Policy     Policy
+	Allowed    bool
+	Remaining  int
+	Reset      time.Duration // until the quota is fully available again
+	RetryAfter time.Duration // until the next request may be allowed; zero when allowed
+}
+
+// Config maps operations to policies
+type Config struct {
+	Policies []Policy
+	// Operations maps Huma operation IDs to policy names
+	Operations map[string]string
+	// Default is the policy for operations not in Operations; empty leaves them unlimited
+	Default string
+}
+
+// Limiter checks requests against policies
+type Limiter struct {
+	store      Store
+	policies   map[string]Policy
+	operations map[string]string
+	fallback   string
+}
+
+// NewLimiter creates a limiter keeping its counters in store
+func NewLimiter(store Store, config Config) (*Limiter, error) {
+	if store == nil {
+		return nil, errors.InvalidInput("store", "rate limit store is required")
+	}
+
+	policies := make(map[string]Policy, len(config.Policies))
+	for _, policy := range config.Policies {
+		policy, err := normalizePolicy(policy)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := policies[policy.Name]; exists {
+			return nil, errors.Conflict("policy", fmt.Sprintf("%s is defined more than once", policy.Name))
+		}
+		policies[policy.Name] = policy
+	}
+
+	operations := make(map[string]string, len(config.Operations))
+	for operationID, name := range config.Operations {
+		if _, ok := policies[name]; !ok {
+			return nil, errors.InvalidInput("operations", fmt.Sprintf("operation %s uses unknown policy %s", operationID, name))
+		}
+		operations[operationID] = name
+	}
+	if config.Default != "" {
+		if _, ok := policies[config.Default]; !ok {
+			return nil, errors.InvalidInput("default", fmt.Sprintf("unknown default policy %s", config.Default))
+		}
+	}
+
+	return &Limiter{
+		store:      store,
+		policies:   policies,
+		operations: operations,
+		fallback:   config.Default,
+	}, nil
+}
+
+// normalizePolicy validates a policy and fills in its defaults
+func normalizePolicy(policy Policy) (Policy, error) {
+	if policy.Name == "" {
+		return policy, errors.InvalidInput("name", "policy name is required")
+	}
+	if policy.Limit <= 0 || policy.Period <= 0 {
+		return policy, errors.InvalidInput("limit", fmt.Sprintf("policy %s needs a positive limit and period", policy.Name))
+	}
+	switch policy.Algorithm {
+	case "":
+		policy.Algorithm = AlgorithmSlidingWindow
+	case AlgorithmSlidingWindow, AlgorithmTokenBucket:
+	default:
+		return policy, errors.InvalidInput("algorithm", fmt.Sprintf("policy %s has unknown algorithm %s", policy.Name, policy.Algorithm))
+	}
+	switch policy.KeyBy {
+	case "":
+		policy.KeyBy = KeyByIP
+	case KeyByIP, KeyByUser, KeyByAPIKey:
+	default:
+		return policy, errors.InvalidInput("key_by", fmt.Sprintf("policy %s has unknown key %s", policy.Name, policy.KeyBy))
+	}
+	if policy.Burst <= 0 {
+		policy.Burst = policy.Limit
+	}
+	return policy, nil
+}
+
+// Policy returns the named policy
+func (l *Limiter) Policy(name string) (Policy, bool) {
+	policy, ok := l.policies[name]
+	return policy, ok
+}
+
+// PolicyFor returns the policy applied to an operation, falling back to the default policy
+func (l *Limiter) PolicyFor(operationID string) (Policy, bool) {
+	name, ok := l.operations[operationID]
+	if !ok {
+		name = l.fallback
+	}
+	return l.Policy(name)
+}
+
+// Allow records a request by id against the named policy
+func (l *Limiter) Allow(ctx context.Context, name string, id Identity) (Decision, error) {
+	policy, ok := l.Policy(name)
+	if !ok {
+		return Decision{}, errors.NotFound("policy", name)
+	}
+	return l.allow(ctx, policy, id)
+}
+
+func (l *Limiter) allow(ctx context.Context, policy Policy, id Identity) (Decision, error) {
+	now := time.Now()
+	var decision Decision
+	_, err := l.store.Update(ctx, counterKey(policy, id), stateTTL(policy), func(state State) State {
+		state, decision = take(policy, state, now)
+		return state
+	})
+	if err != nil {
+		return Decision{}, errors.Wrap(err, errors.ErrTypeInternal, "failed to update rate limit counter")
+	}
+	decision.Policy = policy
+	return decision, nil
+}
+
+// counterKey names the counter of a policy for the identity the policy keys by
+func counterKey(policy Policy, id Identity) string {
+	kind, value := KeyByIP, id.IP
+	switch {
+	case policy.KeyBy == KeyByAPIKey && id.APIKey != "":
+		kind, value = KeyByAPIKey, id.APIKey
+	case (policy.KeyBy == KeyByAPIKey || policy.KeyBy == KeyByUser) && id.UserID != "":
+		kind, value = KeyByUser, id.UserID
+	}
+	if value == "" {
+		value = "unknown"
+	}
+	return "ratelimit:" + policy.Name + ":" + string(kind) + ":" + value
+}
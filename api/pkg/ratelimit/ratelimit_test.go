@@ -0,0 +1,233 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/humatest"
+)
+
+func newTestLimiter(t *testing.T, store Store, policies ...Policy) *Limiter {
+	t.Helper()
+	limiter, err := NewLimiter(store, Config{Policies: policies})
+	if err != nil {
+		t.Fatalf("Failed to create limiter: %v", err)
+	}
+	return limiter
+}
+
+func TestSlidingWindowLimitsRequests(t *testing.T) {
+	policy, _ := normalizePolicy(Policy{Name: "search", Limit: 3, Period: time.Minute})
+	windowStart := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var state State
+	var decision Decision
+	for i := 0; i < 3; i++ {
+		state, decision = takeSlidingWindow(policy, state, windowStart.Add(time.Duration(i)*time.Second))
+		if !decision.Allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+	if decision.Remaining != 0 {
+		t.Errorf("Expected no remaining requests, got %d", decision.Remaining)
+	}
+
+	state, decision = takeSlidingWindow(policy, state, windowStart.Add(10*time.Second))
+	if decision.Allowed || decision.RetryAfter <= 0 {
+		t.Fatalf("Expected the fourth request to be denied with a retry delay, got %+v", decision)
+	}
+
+	// Half-way through the next window, the previous window's three requests weigh 1.5
+	state, decision = takeSlidingWindow(policy, state, windowStart.Add(90*time.Second))
+	if !decision.Allowed {
+		t.Error("Expected a request to be allowed once the previous window has decayed")
+	}
+	if _, decision = takeSlidingWindow(policy, state, windowStart.Add(91*time.Second)); decision.Allowed {
+		t.Error("Expected the weighted previous window to still count")
+	}
+}
+
+func TestSlidingWindowRetryAfterIsAccurate(t *testing.T) {
+	policy, _ := normalizePolicy(Policy{Name: "search", Limit: 2, Period: time.Minute})
+	windowStart := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var state State
+	state, _ = takeSlidingWindow(policy, state, windowStart)
+	state, _ = takeSlidingWindow(policy, state, windowStart)
+	denied, decision := takeSlidingWindow(policy, state, windowStart.Add(time.Second))
+	if decision.Allowed {
+		t.Fatal("Expected the third request to be denied")
+	}
+
+	at := windowStart.Add(time.Second + decision.RetryAfter)
+	if _, retried := takeSlidingWindow(policy, denied, at); !retried.Allowed {
+		t.Errorf("Expected a request after RetryAfter (%v) to be allowed", decision.RetryAfter)
+	}
+	if _, early := takeSlidingWindow(policy, denied, at.Add(-time.Second)); early.Allowed {
+		t.Errorf("Expected a request a second before RetryAfter to be denied")
+	}
+}
+
+func TestTokenBucketRefillsOverTime(t *testing.T) {
+	policy, _ := normalizePolicy(Policy{Name: "write", Limit: 60, Period: time.Minute, Algorithm: AlgorithmTokenBucket, Burst: 2})
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	var state State
+	var decision Decision
+	for i := 0; i < 2; i++ {
+		if state, decision = takeToken(policy, state, now); !decision.Allowed {
+			t.Fatalf("Expected burst request %d to be allowed", i+1)
+		}
+	}
+	if state, decision = takeToken(policy, state, now); decision.Allowed {
+		t.Fatal("Expected the bucket to be empty after the burst")
+	}
+	if decision.RetryAfter != time.Second {
+		t.Errorf("Expected a one second retry delay at one token per second, got %v", decision.RetryAfter)
+	}
+
+	if _, decision = takeToken(policy, state, now.Add(time.Second)); !decision.Allowed {
+		t.Error("Expected a refilled token after one second")
+	}
+	if decision.Reset != 2*time.Second {
+		t.Errorf("Expected the bucket to be full again in two seconds, got %v", decision.Reset)
+	}
+}
+
+func TestLimiterKeysByIdentity(t *testing.T) {
+	limiter := newTestLimiter(t, NewMemoryStore(),
+		Policy{Name: "per-user", Limit: 1, Period: time.Minute, KeyBy: KeyByUser},
+		Policy{Name: "per-key", Limit: 1, Period: time.Minute, KeyBy: KeyByAPIKey},
+	)
+	ctx := context.Background()
+
+	allow := func(policy string, id Identity) bool {
+		t.Helper()
+		decision, err := limiter.Allow(ctx, policy, id)
+		if err != nil {
+			t.Fatalf("Allow failed: %v", err)
+		}
+		return decision.Allowed
+	}
+
+	// Users behind the same IP have separate quotas
+	if !allow("per-user", Identity{IP: "10.0.0.1", UserID: "alice"}) || !allow("per-user", Identity{IP: "10.0.0.1", UserID: "bob"}) {
+		t.Error("Expected each user to have a quota of their own")
+	}
+	if allow("per-user", Identity{IP: "10.0.0.2", UserID: "alice"}) {
+		t.Error("Expected a user's quota to follow them across IPs")
+	}
+
+	// Anonymous requests fall back to the client IP
+	if !allow("per-user", Identity{IP: "10.0.0.3"}) || allow("per-user", Identity{IP: "10.0.0.3"}) {
+		t.Error("Expected anonymous requests to be limited per IP")
+	}
+
+	if !allow("per-key", Identity{IP: "10.0.0.4", UserID: "alice", APIKey: "key-1"}) {
+		t.Error("Expected the API key to have a quota separate from its user")
+	}
+	if allow("per-key", Identity{IP: "10.0.0.5", APIKey: "key-1"}) {
+		t.Error("Expected the API key quota to be shared across IPs")
+	}
+
+	if _, err := limiter.Allow(ctx, "missing", Identity{}); err == nil {
+		t.Error("Expected an unknown policy to fail")
+	}
+}
+
+func TestLimiterSharesCountersThroughStore(t *testing.T) {
+	store := NewMemoryStore()
+	policy := Policy{Name: "shared", Limit: 2, Period: time.Minute}
+	replicas := []*Limiter{newTestLimiter(t, store, policy), newTestLimiter(t, store, policy)}
+	id := Identity{IP: "10.0.0.1"}
+
+	allowed := 0
+	for i := 0; i < 4; i++ {
+		decision, _ := replicas[i%2].Allow(context.Background(), "shared", id)
+		if decision.Allowed {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("Expected replicas sharing a store to share the limit, got %d allowed", allowed)
+	}
+}
+
+func TestNewLimiterValidatesConfig(t *testing.T) {
+	valid := Policy{Name: "ok", Limit: 1, Period: time.Minute}
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{"missing name", Config{Policies: []Policy{{Limit: 1, Period: time.Minute}}}},
+		{"zero limit", Config{Policies: []Policy{{Name: "zero", Period: time.Minute}}}},
+		{"unknown algorithm", Config{Policies: []Policy{{Name: "algo", Limit: 1, Period: time.Minute, Algorithm: "leaky"}}}},
+		{"duplicate policy", Config{Policies: []Policy{valid, valid}}},
+		{"unknown operation policy", Config{Policies: []Policy{valid}, Operations: map[string]string{"op": "missing"}}},
+		{"unknown default", Config{Policies: []Policy{valid}, Default: "missing"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLimiter(NewMemoryStore(), tt.config); err == nil {
+				t.Error("Expected the config to be rejected")
+			}
+		})
+	}
+
+	if _, err := NewLimiter(NewMemoryStore(), DefaultConfig()); err != nil {
+		t.Errorf("Expected the default config to be valid, got %v", err)
+	}
+}
+
+func TestHumaMiddlewareSetsHeadersAndRejects(t *testing.T) {
+	limiter, err := NewLimiter(NewMemoryStore(), Config{
+		Policies:   []Policy{{Name: "search", Limit: 2, Period: time.Minute}},
+		Operations: map[string]string{"search": "search"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create limiter: %v", err)
+	}
+
+	_, api := humatest.New(t)
+	api.UseMiddleware(limiter.HumaMiddleware(api, func(ctx huma.Context) Identity {
+		return Identity{IP: ClientIP(ctx)}
+	}))
+	for _, operationID := range []string{"search", "unlimited"} {
+		huma.Register(api, huma.Operation{
+			OperationID: operationID,
+			Method:      http.MethodGet,
+			Path:        "/" + operationID,
+		}, func(ctx context.Context, input *struct{}) (*struct{}, error) {
+			return nil, nil
+		})
+	}
+
+	first := api.Get("/search")
+	if first.Code != http.StatusNoContent {
+		t.Fatalf("Expected the first request to pass, got %d", first.Code)
+	}
+	if first.Header().Get("RateLimit-Limit") != "2" || first.Header().Get("RateLimit-Remaining") != "1" {
+		t.Errorf("Unexpected rate limit headers: %v", first.Header())
+	}
+	if first.Header().Get("RateLimit-Policy") != "2;w=60" {
+		t.Errorf("Unexpected RateLimit-Policy header %q", first.Header().Get("RateLimit-Policy"))
+	}
+
+	api.Get("/search")
+	denied := api.Get("/search")
+	if denied.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the third request to be rejected, got %d", denied.Code)
+	}
+	if denied.Header().Get("Retry-After") == "" || denied.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("Expected Retry-After and an exhausted quota, got %v", denied.Header())
+	}
+
+	for i := 0; i < 3; i++ {
+		if resp := api.Get("/unlimited"); resp.Code != http.StatusNoContent || resp.Header().Get("RateLimit-Limit") != "" {
+			t.Errorf("Expected operations without a policy to be unlimited, got %d %v", resp.Code, resp.Header())
+		}
+	}
+}
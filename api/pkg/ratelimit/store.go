@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// State is the counter state of one key. The token bucket uses Tokens; the sliding window
+// uses WindowStart, Count and PreviousCount.
+type State struct {
+	Tokens        float64
+	WindowStart   time.Time
+	Count         int64
+	PreviousCount int64
+	UpdatedAt     time.Time
+}
+
+// Store keeps counter state, possibly shared between replicas
+type Store interface {
+	// Update atomically replaces the state under key with fn's result, which is kept for
+	// at least ttl. fn receives the zero State for unknown or expired keys. Stores using
+	// optimistic concurrency may call fn more than once.
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(State) State) (State, error)
+}
+
+// memorySweepInterval is how many updates pass between sweeps of expired entries
+const memorySweepInterval = 1024
+
+// MemoryStore is an in-process Store for a single replica and for tests
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	updates int
+}
+
+type memoryEntry struct {
+	state     State
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry)}
+}
+
+// Update applies fn under the store's lock
+func (s *MemoryStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(State) State) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.updates++
+	if s.updates%memorySweepInterval == 0 {
+		s.sweep(now)
+	}
+
+	var state State
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		state = entry.state
+	}
+
+	state = fn(state)
+	s.entries[key] = memoryEntry{state: state, expiresAt: now.Add(ttl)}
+	return state, nil
+}
+
+// Len returns the number of keys held, including expired ones not swept yet
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
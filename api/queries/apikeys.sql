@@ -0,0 +1,26 @@
+-- name: CreateAPIKey :exec
+INSERT INTO api_keys (
+    id, name, prefix, key_hash, permissions, created_by, expires_at
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: GetAPIKeyByHash :one
+SELECT * FROM api_keys WHERE key_hash = ? LIMIT 1;
+
+-- name: GetAPIKeyByID :one
+SELECT * FROM api_keys WHERE id = ? LIMIT 1;
+
+-- name: ListAPIKeys :many
+SELECT * FROM api_keys ORDER BY created_at DESC, id;
+
+-- Replaces the secret of an active key; the old secret stops working immediately
+-- name: RotateAPIKey :execrows
+UPDATE api_keys SET prefix = ?, key_hash = ? WHERE id = ? AND revoked_at IS NULL;
+
+-- name: RevokeAPIKey :execrows
+UPDATE api_keys SET revoked_at = NOW() WHERE id = ? AND revoked_at IS NULL;
+
+-- Adds usage counted in memory since the last flush
+-- name: AddAPIKeyUsage :exec
+UPDATE api_keys SET usage_count = usage_count + ?, last_used_at = ? WHERE id = ?;
@@ -0,0 +1,18 @@
+-- Verification state of a user for the verified email policy
+-- name: GetUserEmailVerification :one
+SELECT u.email_verified,
+    EXISTS(
+        SELECT 1 FROM email_verification_overrides o WHERE o.user_id = u.id
+    ) AS exempt
+FROM users u WHERE u.id = ? LIMIT 1;
+
+-- name: UpsertEmailVerificationOverride :exec
+INSERT INTO email_verification_overrides (user_id, granted_by, reason, created_at)
+VALUES (?, ?, ?, NOW())
+ON DUPLICATE KEY UPDATE
+    granted_by = VALUES(granted_by),
+    reason = VALUES(reason),
+    created_at = NOW();
+
+-- name: DeleteEmailVerificationOverride :execrows
+DELETE FROM email_verification_overrides WHERE user_id = ?;
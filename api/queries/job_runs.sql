@@ -0,0 +1,15 @@
+-- Reports whether any replica started the job within the interval, by the database clock
+-- name: IsJobRunRecent :one
+SELECT last_run_at > NOW(6) - INTERVAL sqlc.arg(interval_microseconds) MICROSECOND AS recent
+FROM job_runs
+WHERE name = sqlc.arg(name);
+
+-- Records a run of the job starting now
+-- name: RecordJobRun :exec
+INSERT INTO job_runs (name, last_run_at) VALUES (?, NOW(6))
+ON DUPLICATE KEY UPDATE last_run_at = VALUES(last_run_at);
+
+-- Last run of every job that any replica has run
+-- name: ListJobRuns :many
+SELECT name, last_run_at FROM job_runs
+ORDER BY name;
@@ -9,6 +9,11 @@ INSERT INTO reviews (
 SELECT * FROM reviews 
 WHERE id = ?;
 
+-- name: GetReviewByIDForUpdate :one
+SELECT * FROM reviews 
+WHERE id = ?
+FOR UPDATE;
+
 -- name: GetReviewByUserAndSpot :one
 SELECT * FROM reviews 
 WHERE user_id = ? AND spot_id = ?;
@@ -22,6 +27,7 @@ WHERE id = ?;
 DELETE FROM reviews 
 WHERE id = ?;
 
+-- Reviews by deleted accounts are listed without their author's name and avatar
 -- name: ListReviewsBySpot :many
 SELECT
   r.id,
@@ -35,7 +41,7 @@ SELECT
   u.name          AS user_name,
   u.picture       AS user_avatar
 FROM reviews r
-JOIN users u ON r.user_id = u.id
+LEFT JOIN users u ON r.user_id = u.id AND u.deleted_at IS NULL
 WHERE r.spot_id = ?
 ORDER BY r.created_at DESC
 LIMIT ? OFFSET ?;
@@ -56,6 +62,15 @@ LIMIT ? OFFSET ?;
 SELECT COUNT(*) FROM reviews 
 WHERE user_id = ?;
 
+-- name: ListReviewedSpotIDsByUser :many
+SELECT DISTINCT spot_id FROM reviews
+WHERE user_id = ?;
+
+-- Detaches a purged user's reviews; the rating and comment stay with the spot
+-- name: AnonymizeUserReviews :execrows
+UPDATE reviews SET user_id = NULL, reviewer_name = ''
+WHERE user_id = ?;
+
 -- name: GetSpotRatingStats :one
 SELECT 
     AVG(rating) as average_rating,
@@ -0,0 +1,16 @@
+-- Role-based permission queries
+
+-- Permissions granted to a user through their roles
+-- name: ListPermissionsByUserID :many
+SELECT DISTINCT rp.permission
+FROM user_roles ur
+JOIN role_permissions rp ON rp.role_id = ur.role_id
+WHERE ur.user_id = ?
+ORDER BY rp.permission;
+
+-- name: ListRolesByUserID :many
+SELECT role_id FROM user_roles WHERE user_id = ? ORDER BY role_id;
+
+-- Removes the roles of a purged user
+-- name: DeleteUserRoles :exec
+DELETE FROM user_roles WHERE user_id = ?;
@@ -0,0 +1,42 @@
+-- name: CreateSoloRating :exec
+INSERT INTO solo_ratings (
+    id, spot_id, user_id, solo_friendly_rating, categories, comment
+) VALUES (
+    ?, ?, ?, ?, ?, ?
+);
+
+-- name: UpdateSoloRating :exec
+UPDATE solo_ratings
+SET solo_friendly_rating = ?, categories = ?, comment = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: GetSoloRatingBySpotAndUser :one
+SELECT * FROM solo_ratings
+WHERE spot_id = ? AND user_id = ?;
+
+-- name: ListSoloRatingsBySpot :many
+SELECT * FROM solo_ratings
+WHERE spot_id = ?
+ORDER BY updated_at DESC, id;
+
+-- name: DeleteSpotSoloCategories :exec
+DELETE FROM spot_solo_categories
+WHERE spot_id = ?;
+
+-- name: CreateSpotSoloCategory :exec
+INSERT INTO spot_solo_categories (spot_id, category, confirmations)
+VALUES (?, ?, ?);
+
+-- name: ListSpotSoloCategories :many
+SELECT * FROM spot_solo_categories
+WHERE spot_id IN (sqlc.slice(spot_ids))
+ORDER BY spot_id, confirmations DESC, category;
+
+-- name: ListSoloRatingsByUser :many
+SELECT * FROM solo_ratings
+WHERE user_id = ?
+ORDER BY created_at, id;
+
+-- name: DeleteSoloRatingsByUser :execrows
+DELETE FROM solo_ratings
+WHERE user_id = ?;
@@ -0,0 +1,246 @@
+-- name: CreateSpot :exec
+INSERT INTO spots (
+    id, name, name_i18n, latitude, longitude, category, address, address_i18n, country_code, created_by
+) VALUES (
+    ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+);
+
+-- name: GetSpotByID :one
+SELECT * FROM spots 
+WHERE id = ?;
+
+-- name: UpdateSpot :exec
+UPDATE spots 
+SET name = ?, name_i18n = ?, latitude = ?, longitude = ?, category = ?, 
+    address = ?, address_i18n = ?, country_code = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- name: UpdateSpotSoloStats :exec
+UPDATE spots
+SET solo_friendly_rating = ?, solo_rating_count = ?, updated_at = CURRENT_TIMESTAMP
+WHERE id = ?;
+
+-- LockSpotForUpdate takes the spot row lock that serializes concurrent review writes for a spot
+-- name: LockSpotForUpdate :one
+SELECT id FROM spots
+WHERE id = ?
+FOR UPDATE;
+
+-- ApplySpotRatingDelta adjusts the rating aggregates incrementally. MySQL evaluates
+-- single-table SET assignments left to right, so average_rating is computed first
+-- from the sum and count as they were before this update.
+-- name: ApplySpotRatingDelta :exec
+UPDATE spots
+SET average_rating = IF(review_count + sqlc.arg(count_delta) > 0,
+        ROUND((rating_sum + sqlc.arg(sum_delta)) / (review_count + sqlc.arg(count_delta)), 1),
+        0.0),
+    rating_sum = rating_sum + sqlc.arg(sum_delta),
+    review_count = review_count + sqlc.arg(count_delta),
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = sqlc.arg(id);
+
+-- ReconcileSpotRatings recomputes every spot's rating aggregates from reviews and
+-- only touches spots whose stored values have drifted.
+-- name: ReconcileSpotRatings :execrows
+UPDATE spots s
+LEFT JOIN (
+    SELECT spot_id, COUNT(*) AS review_count, SUM(rating) AS rating_sum
+    FROM reviews
+    GROUP BY spot_id
+) r ON r.spot_id = s.id
+SET s.review_count = COALESCE(r.review_count, 0),
+    s.rating_sum = COALESCE(r.rating_sum, 0),
+    s.average_rating = COALESCE(ROUND(r.rating_sum / r.review_count, 1), 0.0)
+WHERE s.review_count <> COALESCE(r.review_count, 0)
+   OR s.rating_sum <> COALESCE(r.rating_sum, 0)
+   OR s.average_rating <> COALESCE(ROUND(r.rating_sum / r.review_count, 1), 0.0);
+
+-- RecomputeSpotRating recomputes one spot's rating aggregates from its reviews
+-- name: RecomputeSpotRating :exec
+UPDATE spots s
+LEFT JOIN (
+    SELECT spot_id, COUNT(*) AS review_count, SUM(rating) AS rating_sum
+    FROM reviews
+    WHERE spot_id = sqlc.arg(id)
+    GROUP BY spot_id
+) r ON r.spot_id = s.id
+SET s.review_count = COALESCE(r.review_count, 0),
+    s.rating_sum = COALESCE(r.rating_sum, 0),
+    s.average_rating = COALESCE(ROUND(r.rating_sum / r.review_count, 1), 0.0),
+    s.updated_at = CURRENT_TIMESTAMP
+WHERE s.id = sqlc.arg(id);
+
+-- name: ListSpots :many
+SELECT * FROM spots
+WHERE (sqlc.arg(category) = '' OR category = sqlc.arg(category))
+  AND (sqlc.arg(country_code) = '' OR country_code = sqlc.arg(country_code))
+  AND solo_friendly_rating >= sqlc.arg(min_solo_score)
+  AND (sqlc.arg(solo_category_count) = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (sqlc.slice(solo_categories))
+        AND confirmations >= sqlc.arg(min_confirmations)
+      GROUP BY spot_id
+      HAVING COUNT(*) = sqlc.arg(solo_category_count)))
+ORDER BY created_at DESC, id
+LIMIT ? OFFSET ?;
+
+-- name: CountSpots :one
+SELECT COUNT(*) FROM spots
+WHERE (sqlc.arg(category) = '' OR category = sqlc.arg(category))
+  AND (sqlc.arg(country_code) = '' OR country_code = sqlc.arg(country_code))
+  AND solo_friendly_rating >= sqlc.arg(min_solo_score)
+  AND (sqlc.arg(solo_category_count) = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (sqlc.slice(solo_categories))
+        AND confirmations >= sqlc.arg(min_confirmations)
+      GROUP BY spot_id
+      HAVING COUNT(*) = sqlc.arg(solo_category_count)));
+
+-- ListSpotsByLocation pre-filters on a bounding box so idx_location can be used,
+-- then applies the exact great-circle distance. LEAST guards acos against
+-- floating point values slightly above 1 for points at the center.
+-- name: ListSpotsByLocation :many
+SELECT sqlc.embed(spots),
+       (6371 * acos(LEAST(1.0,
+           cos(radians(sqlc.arg(center_lat))) * cos(radians(latitude)) *
+           cos(radians(longitude) - radians(sqlc.arg(center_lng))) +
+           sin(radians(sqlc.arg(center_lat))) * sin(radians(latitude))
+       ))) AS distance_km
+FROM spots
+WHERE latitude BETWEEN sqlc.arg(min_lat) AND sqlc.arg(max_lat)
+  AND longitude BETWEEN sqlc.arg(min_lng) AND sqlc.arg(max_lng)
+  AND (sqlc.arg(category) = '' OR category = sqlc.arg(category))
+  AND (sqlc.arg(country_code) = '' OR country_code = sqlc.arg(country_code))
+  AND solo_friendly_rating >= sqlc.arg(min_solo_score)
+  AND (sqlc.arg(solo_category_count) = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (sqlc.slice(solo_categories))
+        AND confirmations >= sqlc.arg(min_confirmations)
+      GROUP BY spot_id
+      HAVING COUNT(*) = sqlc.arg(solo_category_count)))
+HAVING distance_km <= sqlc.arg(radius_km)
+ORDER BY distance_km, id
+LIMIT ? OFFSET ?;
+
+-- name: CountSpotsByLocation :one
+SELECT COUNT(*) FROM spots
+WHERE latitude BETWEEN sqlc.arg(min_lat) AND sqlc.arg(max_lat)
+  AND longitude BETWEEN sqlc.arg(min_lng) AND sqlc.arg(max_lng)
+  AND (sqlc.arg(category) = '' OR category = sqlc.arg(category))
+  AND (sqlc.arg(country_code) = '' OR country_code = sqlc.arg(country_code))
+  AND solo_friendly_rating >= sqlc.arg(min_solo_score)
+  AND (sqlc.arg(solo_category_count) = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (sqlc.slice(solo_categories))
+        AND confirmations >= sqlc.arg(min_confirmations)
+      GROUP BY spot_id
+      HAVING COUNT(*) = sqlc.arg(solo_category_count)))
+  AND (6371 * acos(LEAST(1.0,
+      cos(radians(sqlc.arg(center_lat))) * cos(radians(latitude)) *
+      cos(radians(longitude) - radians(sqlc.arg(center_lng))) +
+      sin(radians(sqlc.arg(center_lat))) * sin(radians(latitude))
+  ))) <= sqlc.arg(radius_km);
+
+-- SearchSpots ranks spots by full-text relevance over the ft_spots_search index.
+-- Spots whose name in the preferred language contains the query are boosted.
+-- Sorting by solo_friendly_rating ranks the best solo-friendly spots first, then by relevance.
+-- name: SearchSpots :many
+SELECT sqlc.embed(spots),
+       (MATCH(name, address, name_i18n_text, address_i18n_text) AGAINST (sqlc.arg(query) IN NATURAL LANGUAGE MODE)
+        * IF(sqlc.arg(lang) <> ''
+             AND JSON_UNQUOTE(JSON_EXTRACT(name_i18n, CONCAT('$."', sqlc.arg(lang), '"'))) LIKE sqlc.arg(name_pattern),
+             1.5, 1.0)) AS relevance
+FROM spots
+WHERE MATCH(name, address, name_i18n_text, address_i18n_text) AGAINST (sqlc.arg(query) IN NATURAL LANGUAGE MODE)
+  AND latitude BETWEEN sqlc.arg(min_lat) AND sqlc.arg(max_lat)
+  AND longitude BETWEEN sqlc.arg(min_lng) AND sqlc.arg(max_lng)
+  AND (sqlc.arg(radius_km) = 0 OR (6371 * acos(LEAST(1.0,
+      cos(radians(sqlc.arg(center_lat))) * cos(radians(latitude)) *
+      cos(radians(longitude) - radians(sqlc.arg(center_lng))) +
+      sin(radians(sqlc.arg(center_lat))) * sin(radians(latitude))
+  ))) <= sqlc.arg(radius_km))
+  AND (sqlc.arg(category) = '' OR category = sqlc.arg(category))
+  AND (sqlc.arg(country_code) = '' OR country_code = sqlc.arg(country_code))
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by) = 'solo_friendly_rating' THEN solo_friendly_rating ELSE 0 END DESC,
+  relevance DESC,
+  average_rating DESC,
+  review_count DESC,
+  id
+LIMIT ? OFFSET ?;
+
+-- name: CountSearchSpots :one
+SELECT COUNT(*) FROM spots
+WHERE MATCH(name, address, name_i18n_text, address_i18n_text) AGAINST (sqlc.arg(query) IN NATURAL LANGUAGE MODE)
+  AND latitude BETWEEN sqlc.arg(min_lat) AND sqlc.arg(max_lat)
+  AND longitude BETWEEN sqlc.arg(min_lng) AND sqlc.arg(max_lng)
+  AND (sqlc.arg(radius_km) = 0 OR (6371 * acos(LEAST(1.0,
+      cos(radians(sqlc.arg(center_lat))) * cos(radians(latitude)) *
+      cos(radians(longitude) - radians(sqlc.arg(center_lng))) +
+      sin(radians(sqlc.arg(center_lat))) * sin(radians(latitude))
+  ))) <= sqlc.arg(radius_km))
+  AND (sqlc.arg(category) = '' OR category = sqlc.arg(category))
+  AND (sqlc.arg(country_code) = '' OR country_code = sqlc.arg(country_code));
+
+-- ClusterSpotsInBounds groups spots into a fixed degree grid. The bounding box
+-- filter is served by idx_location; cells are numbered from the south-west corner.
+-- A viewport crossing the antimeridian is passed as two longitude ranges.
+-- name: ClusterSpotsInBounds :many
+SELECT CAST(FLOOR((latitude + 90) / sqlc.arg(cell_size)) AS SIGNED) AS cell_row,
+       CAST(FLOOR((longitude + 180) / sqlc.arg(cell_size)) AS SIGNED) AS cell_col,
+       COUNT(*) AS spot_count,
+       AVG(latitude) AS centroid_lat,
+       AVG(longitude) AS centroid_lng,
+       AVG(CASE WHEN review_count > 0 THEN average_rating END) AS average_rating
+FROM spots
+WHERE latitude BETWEEN sqlc.arg(min_lat) AND sqlc.arg(max_lat)
+  AND (longitude BETWEEN sqlc.arg(min_lng) AND sqlc.arg(max_lng)
+       OR longitude BETWEEN sqlc.arg(wrap_min_lng) AND sqlc.arg(wrap_max_lng))
+  AND (sqlc.arg(category) = '' OR category = sqlc.arg(category))
+  AND (sqlc.arg(country_code) = '' OR country_code = sqlc.arg(country_code))
+GROUP BY cell_row, cell_col
+ORDER BY spot_count DESC, cell_row, cell_col
+LIMIT ?;
+
+-- ListSpotsInViewport returns spots inside a map viewport, compared directly against
+-- the DECIMAL coordinate columns so idx_location applies. A viewport crossing the
+-- antimeridian is passed as two longitude ranges; otherwise both ranges are the same.
+-- name: ListSpotsInViewport :many
+SELECT * FROM spots
+WHERE latitude BETWEEN sqlc.arg(min_lat) AND sqlc.arg(max_lat)
+  AND (longitude BETWEEN sqlc.arg(min_lng) AND sqlc.arg(max_lng)
+       OR longitude BETWEEN sqlc.arg(wrap_min_lng) AND sqlc.arg(wrap_max_lng))
+  AND (sqlc.arg(category) = '' OR category = sqlc.arg(category))
+  AND (sqlc.arg(country_code) = '' OR country_code = sqlc.arg(country_code))
+  AND solo_friendly_rating >= sqlc.arg(min_solo_score)
+  AND (sqlc.arg(solo_category_count) = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (sqlc.slice(solo_categories))
+        AND confirmations >= sqlc.arg(min_confirmations)
+      GROUP BY spot_id
+      HAVING COUNT(*) = sqlc.arg(solo_category_count)))
+ORDER BY
+  CASE WHEN sqlc.arg(sort_by) = 'review_count' THEN review_count ELSE 0 END DESC,
+  average_rating DESC,
+  review_count DESC,
+  id
+LIMIT ? OFFSET ?;
+
+-- name: CountSpotsInViewport :one
+SELECT COUNT(*) FROM spots
+WHERE latitude BETWEEN sqlc.arg(min_lat) AND sqlc.arg(max_lat)
+  AND (longitude BETWEEN sqlc.arg(min_lng) AND sqlc.arg(max_lng)
+       OR longitude BETWEEN sqlc.arg(wrap_min_lng) AND sqlc.arg(wrap_max_lng))
+  AND (sqlc.arg(category) = '' OR category = sqlc.arg(category))
+  AND (sqlc.arg(country_code) = '' OR country_code = sqlc.arg(country_code))
+  AND solo_friendly_rating >= sqlc.arg(min_solo_score)
+  AND (sqlc.arg(solo_category_count) = 0 OR id IN (
+      SELECT spot_id FROM spot_solo_categories
+      WHERE category IN (sqlc.slice(solo_categories))
+        AND confirmations >= sqlc.arg(min_confirmations)
+      GROUP BY spot_id
+      HAVING COUNT(*) = sqlc.arg(solo_category_count)));
+
+-- name: DeleteSpot :exec
+DELETE FROM spots 
+WHERE id = ?;
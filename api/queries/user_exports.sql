@@ -0,0 +1,45 @@
+-- name: CreateUserExport :exec
+INSERT INTO user_exports (id, user_id, format, download_token_hash)
+VALUES (?, ?, ?, ?);
+
+-- name: CountActiveUserExports :one
+SELECT COUNT(*) FROM user_exports
+WHERE user_id = ? AND status IN ('pending', 'processing');
+
+-- name: GetUserExport :one
+SELECT * FROM user_exports WHERE id = ? LIMIT 1;
+
+-- Exports waiting for their archive, including exports whose claim went stale because
+-- the run building them was interrupted
+-- name: ListPendingUserExports :many
+SELECT id, user_id, format FROM user_exports
+WHERE status = 'pending'
+   OR (status = 'processing' AND (claimed_at IS NULL OR claimed_at < sqlc.arg(stale_before)))
+ORDER BY created_at, id
+LIMIT ?;
+
+-- Claims a pending or stale export for one run; no row is affected when another run
+-- claimed it first
+-- name: ClaimUserExport :execrows
+UPDATE user_exports SET status = 'processing', claimed_at = sqlc.arg(claimed_at)
+WHERE id = sqlc.arg(id)
+  AND (status = 'pending'
+   OR (status = 'processing' AND (claimed_at IS NULL OR claimed_at < sqlc.arg(stale_before))));
+
+-- Stores the archive of an export; no row is affected when the claim was taken over
+-- by another run
+-- name: CompleteUserExport :execrows
+UPDATE user_exports
+SET status = 'ready', archive = ?, size_bytes = ?, completed_at = ?, expires_at = ?
+WHERE id = ? AND status = 'processing' AND claimed_at = ?;
+
+-- Records why an export failed; no row is affected when the claim was taken over by
+-- another run
+-- name: FailUserExport :execrows
+UPDATE user_exports
+SET status = 'failed', error = ?, completed_at = ?, expires_at = ?
+WHERE id = ? AND status = 'processing' AND claimed_at = ?;
+
+-- Expiry is compared against the service clock that set expires_at
+-- name: DeleteExpiredUserExports :execrows
+DELETE FROM user_exports WHERE expires_at <= ?;
@@ -0,0 +1,35 @@
+-- Sign-in identities linked to user accounts
+
+-- name: GetUserIdentity :one
+SELECT * FROM user_identities WHERE provider = ? AND provider_id = ? LIMIT 1;
+
+-- name: ListUserIdentities :many
+SELECT * FROM user_identities WHERE user_id = ? ORDER BY created_at, provider;
+
+-- Locks the user's identities so concurrent unlinks cannot remove the last one
+-- name: ListUserIdentitiesForUpdate :many
+SELECT * FROM user_identities WHERE user_id = ? ORDER BY created_at, provider FOR UPDATE;
+
+-- name: CreateUserIdentity :exec
+INSERT INTO user_identities (
+    provider, provider_id, user_id, email, email_verified, last_used_at, created_at
+) VALUES (
+    ?, ?, ?, ?, ?, NOW(), NOW()
+);
+
+-- Records a sign-in with the identity and the email the provider reported for it
+-- name: TouchUserIdentity :exec
+UPDATE user_identities SET email = ?, email_verified = ?, last_used_at = NOW()
+WHERE provider = ? AND provider_id = ?;
+
+-- name: DeleteUserIdentity :execrows
+DELETE FROM user_identities WHERE user_id = ? AND provider = ? AND provider_id = ?;
+
+-- Profile fields refreshed from the identity provider on sign-in
+-- name: RefreshUserProfile :exec
+UPDATE users SET name = ?, nickname = ?, picture = ?, email_verified = ?, updated_at = NOW()
+WHERE id = ?;
+
+-- Moves the identity the account was created with to another linked identity
+-- name: SetUserPrimaryIdentity :exec
+UPDATE users SET provider = ?, provider_id = ?, updated_at = NOW() WHERE id = ?;
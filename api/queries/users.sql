@@ -4,8 +4,9 @@
 -- name: GetUserByID :one
 SELECT * FROM users WHERE id = ? LIMIT 1;
 
+-- Deleted accounts waiting to be purged are not found by email
 -- name: GetUserByEmail :one
-SELECT * FROM users WHERE email = ? LIMIT 1;
+SELECT * FROM users WHERE email = ? AND deleted_at IS NULL LIMIT 1;
 
 -- name: GetUserByProviderID :one
 SELECT * FROM users WHERE provider = ? AND provider_id = ? LIMIT 1;
@@ -40,6 +41,14 @@ UPDATE users SET picture = ?, updated_at = NOW() WHERE id = ?;
 -- name: UpdateUserPreferences :exec
 UPDATE users SET preferences = ?, updated_at = NOW() WHERE id = ?;
 
+-- Locks the user's row so concurrent preference patches are merged one after the other
+-- name: GetUserPreferencesForUpdate :one
+SELECT preferences FROM users WHERE id = ? FOR UPDATE;
+
+-- Locks the user's row so concurrent requests for the user are applied one after the other
+-- name: LockUserForUpdate :one
+SELECT id FROM users WHERE id = ? FOR UPDATE;
+
 -- Token blacklist queries for logout and security
 -- name: AddToBlacklist :exec
 INSERT INTO token_blacklist (jti, token_type, expires_at) 
@@ -62,5 +71,37 @@ SELECT EXISTS(
 -- name: CleanupExpiredTokens :exec
 DELETE FROM token_blacklist WHERE expires_at <= NOW();
 
+-- Per-user revocation cutoff for logout-everywhere; the cutoff never moves backwards
+-- name: RevokeUserTokensBefore :exec
+INSERT INTO user_token_revocations (user_id, revoked_before)
+VALUES (?, ?)
+ON DUPLICATE KEY UPDATE revoked_before = GREATEST(revoked_before, VALUES(revoked_before));
+
+-- name: GetUserTokensRevokedBefore :one
+SELECT revoked_before FROM user_token_revocations WHERE user_id = ? LIMIT 1;
+
+-- Removes the revocation cutoff of a purged user
+-- name: DeleteUserTokenRevocation :exec
+DELETE FROM user_token_revocations WHERE user_id = ?;
+
 -- name: DeleteUser :exec
-DELETE FROM users WHERE id = ?;
\ No newline at end of file
+DELETE FROM users WHERE id = ?;
+
+-- Soft delete: the account is purged once the grace period has passed
+-- name: SoftDeleteUser :execrows
+UPDATE users SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL;
+
+-- name: IsUserDeleted :one
+SELECT EXISTS(SELECT 1 FROM users WHERE id = ? AND deleted_at IS NOT NULL);
+
+-- name: IsEmailOfDeletedUser :one
+SELECT EXISTS(SELECT 1 FROM users WHERE email = ? AND deleted_at IS NOT NULL);
+
+-- name: RestoreUser :execrows
+UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL;
+
+-- name: ListUsersDeletedBefore :many
+SELECT id FROM users
+WHERE deleted_at IS NOT NULL AND deleted_at <= ?
+ORDER BY deleted_at, id
+LIMIT ?;
@@ -16,6 +16,7 @@ import (
 	"bocchi/api/application/clients"
 	"bocchi/api/interfaces/http/handlers"
 	"bocchi/api/pkg/auth"
+	"bocchi/api/tests/helpers"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -57,7 +58,7 @@ var _ = Describe("End-to-End Integration Scenarios", func() {
 		Expect(err).NotTo(HaveOccurred())
 		
 		// Create middleware and rate limiter
-		authMiddleware = auth.NewAuthMiddleware("test-secret-key", testSuite.TestDB.Queries)
+		authMiddleware = auth.NewAuthMiddleware(helpers.TestJWTSecret, testSuite.TestDB.Queries)
 		rateLimiter = auth.NewRateLimiter(10, 300) // More lenient for integration tests
 		
 		// Setup complete API with all handlers
@@ -52,18 +52,34 @@ func (td *TestDatabase) CleanDatabase() error {
 	
 	// Define allowed tables for cleanup to prevent SQL injection
 	allowedTables := map[string]bool{
+		"user_roles":      true,
+		"user_identities": true,
+		"email_verification_overrides": true,
+		"user_exports":    true,
+		"spot_solo_categories": true,
+		"solo_ratings":    true,
 		"reviews":         true,
 		"spots":           true,
 		"users":           true,
 		"token_blacklist": true,
+		"user_token_revocations": true,
+		"api_keys":               true,
 	}
 	
 	// Clean up in reverse order of dependencies
 	tables := []string{
+		"user_roles",
+		"user_identities",
+		"email_verification_overrides",
+		"user_exports",
+		"spot_solo_categories",
+		"solo_ratings",
 		"reviews",
 		"spots", 
 		"users",
 		"token_blacklist",
+		"user_token_revocations",
+		"api_keys",
 	}
 	
 	var errors []error
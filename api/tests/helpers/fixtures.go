@@ -40,6 +40,7 @@ type SpotFixture struct {
 	Address     string
 	AddressI18n map[string]string
 	CountryCode string
+	CreatedBy   string // ID of the creating user; empty for spots without a recorded creator
 }
 
 // UserFixture represents a test user fixture
@@ -93,6 +94,7 @@ func (fm *FixtureManager) CreateSpotFixture(ctx context.Context, fixture SpotFix
 		Address:     fixture.Address,
 		AddressI18n: addressI18nJSON,
 		CountryCode: fixture.CountryCode,
+		CreatedBy:   sql.NullString{String: fixture.CreatedBy, Valid: fixture.CreatedBy != ""},
 	}
 	
 	err := fm.db.Queries.CreateSpot(ctx, params)
@@ -130,6 +132,16 @@ func (fm *FixtureManager) CreateUserFixture(ctx context.Context, fixture UserFix
 	
 	err := fm.db.Queries.CreateUser(ctx, params)
 	gomega.Expect(err).NotTo(gomega.HaveOccurred(), "Failed to create user fixture: %v", err)
+
+	// Like a real account, the user can sign in with the identity it was created with
+	err = fm.db.Queries.CreateUserIdentity(ctx, database.CreateUserIdentityParams{
+		Provider:      fixture.AuthProvider,
+		ProviderID:    fixture.AuthProviderID,
+		UserID:        fixture.ID,
+		Email:         sql.NullString{String: fixture.Email, Valid: true},
+		EmailVerified: true,
+	})
+	gomega.Expect(err).NotTo(gomega.HaveOccurred(), "Failed to create user identity fixture: %v", err)
 	
 	return &entities.User{
 		ID:             fixture.ID,